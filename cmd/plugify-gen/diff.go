@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/untrustedmodders/plugify-gen/pkg/manifest"
+)
+
+// diffReport is what diff prints with -json, for CI to gate a release on.
+type diffReport struct {
+	OldVersion       string           `json:"oldVersion"`
+	NewVersion       string           `json:"newVersion"`
+	Bump             string           `json:"bump"`             // "major", "minor", "patch", or "" when nothing changed
+	SuggestedVersion string           `json:"suggestedVersion"` // the old version bumped that way
+	Sufficient       bool             `json:"sufficient"`       // whether the new version is at least the suggested one
+	Breaking         int              `json:"breaking"`
+	Compatible       int              `json:"compatible"`
+	DocOnly          int              `json:"docOnly"`
+	Changes          manifest.Changes `json:"changes"`
+}
+
+// runDiff compares two versions of a manifest and reports what changed
+// between them, by how much it affects plugins built against the older one
+func runDiff(args []string) int {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "Print the report as JSON")
+	check := fs.Bool("check", false, "Exit with status 1 when the new manifest's version is lower than the changes call for")
+	var searchPath pathList
+	fs.Var(&searchPath, "I", "Directory to search for the manifests of dependencies (repeatable)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: plugify-gen diff [flags] old.pplugin new.pplugin\n\n")
+		fmt.Fprintf(fs.Output(), "Classifies every change as breaking, compatible or doc-only and suggests the version bump.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return 1
+	}
+
+	opts := &manifest.ParseOptions{SearchPath: searchPath}
+	older, err := manifest.ParseFile(fs.Arg(0), opts)
+	if err != nil {
+		printParseError(err, false)
+		return 1
+	}
+	newer, err := manifest.ParseFile(fs.Arg(1), opts)
+	if err != nil {
+		printParseError(err, false)
+		return 1
+	}
+
+	changes := manifest.Diff(older, newer)
+	report := diffReport{
+		OldVersion: older.Version,
+		NewVersion: newer.Version,
+		Bump:       changes.Bump(),
+		Breaking:   changes.Count(manifest.ImpactBreaking),
+		Compatible: changes.Count(manifest.ImpactCompatible),
+		DocOnly:    changes.Count(manifest.ImpactDoc),
+		Changes:    changes,
+	}
+	suggested, err := changes.NextVersion(older.Version)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s: version: %v\n", fs.Arg(0), err)
+		return 1
+	}
+	report.SuggestedVersion = suggested.String()
+	current, err := manifest.ParseVersion(newer.Version)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s: version: %v\n", fs.Arg(1), err)
+		return 1
+	}
+	report.Sufficient = current.Compare(suggested) >= 0
+
+	if *asJSON {
+		if report.Changes == nil {
+			report.Changes = manifest.Changes{}
+		}
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error encoding report: %v\n", err)
+			return 1
+		}
+		fmt.Println(string(data))
+	} else {
+		for i := range changes {
+			fmt.Println(changes[i].String())
+		}
+		if len(changes) == 0 {
+			fmt.Println("No changes.")
+		} else {
+			fmt.Printf("%d breaking, %d compatible, %d doc-only: %s bump, %s -> %s\n",
+				report.Breaking, report.Compatible, report.DocOnly, report.Bump, report.OldVersion, report.SuggestedVersion)
+		}
+		if !report.Sufficient {
+			fmt.Printf("The new manifest's version %s is lower than %s.\n", report.NewVersion, report.SuggestedVersion)
+		}
+	}
+
+	if *check && !report.Sufficient {
+		return 1
+	}
+	return 0
+}
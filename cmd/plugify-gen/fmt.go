@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/untrustedmodders/plugify-gen/pkg/manifest"
+)
+
+// runFmt rewrites manifests in canonical form
+func runFmt(args []string) int {
+	fs := flag.NewFlagSet("fmt", flag.ExitOnError)
+	check := fs.Bool("check", false, "List the manifests that are not formatted and exit with status 1 if there are any, changing nothing")
+	write := fs.Bool("w", false, "Rewrite the manifests in place instead of printing them")
+	var searchPath pathList
+	fs.Var(&searchPath, "I", "Directory to search for the manifests of dependencies (repeatable)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: plugify-gen fmt [-check | -w] [-I dir] manifest.pplugin...\n\n")
+		fmt.Fprintf(fs.Output(), "Formats manifests canonically: keys in a fixed order, defaults left out, and\n")
+		fmt.Fprintf(fs.Output(), "enums, prototypes and structs defined inline more than once moved to the tables.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() == 0 || (*check && *write) || (!*check && !*write && fs.NArg() > 1) {
+		fs.Usage()
+		return 1
+	}
+
+	status := 0
+	for _, path := range fs.Args() {
+		formatted, err := manifest.FormatFile(path, &manifest.ParseOptions{SearchPath: searchPath})
+		if err != nil {
+			printParseError(err, false)
+			status = 1
+			continue
+		}
+
+		switch {
+		case *check || *write:
+			original, err := os.ReadFile(path)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", path, err)
+				status = 1
+				continue
+			}
+			if bytes.Equal(original, formatted) {
+				continue
+			}
+			if *check {
+				fmt.Println(path)
+				status = 1
+				continue
+			}
+			if err := os.WriteFile(path, formatted, 0644); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing %s: %v\n", path, err)
+				status = 1
+			}
+		default:
+			os.Stdout.Write(formatted)
+		}
+	}
+	return status
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/untrustedmodders/plugify-gen/pkg/generator"
+	"github.com/untrustedmodders/plugify-gen/pkg/manifest"
+)
+
+// project is a plugify-gen.json or plugify-gen.toml file: the manifests a repository generates
+// bindings for and the languages it generates them in, so that one run
+// regenerates everything. Paths are relative to the project file.
+type project struct {
+	Manifests      []string `json:"manifests"`
+	Targets        []target `json:"targets"`
+	SearchPath     []string `json:"searchPath,omitempty"`    // as -I
+	Platform       string   `json:"platform,omitempty"`      // as -platform
+	TargetVersion  string   `json:"targetVersion,omitempty"` // as -target-version
+	Strict         bool     `json:"strict,omitempty"`        // as -strict
+	ValidateSchema bool     `json:"validateSchema,omitempty"`
+	Overwrite      bool     `json:"overwrite,omitempty"`
+}
+
+// target is one language generated for every manifest in a project.
+type target struct {
+	Lang    string                     `json:"lang"`
+	Output  string                     `json:"output"` // "{plugin}" stands for the manifest's name
+	Options generator.GeneratorOptions `json:"options"`
+}
+
+// loadProject reads a project file, making its paths relative to the working
+// directory. A TOML project uses the same keys as a JSON one.
+func loadProject(path string) (*project, error) {
+	ext := filepath.Ext(path)
+	if ext != ".json" && ext != ".toml" {
+		return nil, fmt.Errorf("%s: project files are JSON or TOML, not %s", path, ext)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read project file: %w", err)
+	}
+	if ext == ".toml" {
+		// Decoded through JSON, so that both are checked for unknown keys
+		// and mistyped values alike
+		table, err := decodeTOML(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if data, err = json.Marshal(table); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	var p project
+	if err := decoder.Decode(&p); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(p.Manifests) == 0 {
+		return nil, fmt.Errorf("%s: no manifests listed", path)
+	}
+	if len(p.Targets) == 0 {
+		return nil, fmt.Errorf("%s: no targets listed", path)
+	}
+	for i, t := range p.Targets {
+		if _, err := generator.GetGenerator(t.Lang); err != nil {
+			return nil, fmt.Errorf("%s: targets[%d]: %w (supported: %s)", path, i, err, generator.SupportedLanguages())
+		}
+		if t.Output == "" {
+			return nil, fmt.Errorf("%s: targets[%d]: output is required", path, i)
+		}
+	}
+
+	dir := filepath.Dir(path)
+	relative := func(path *string) {
+		if !filepath.IsAbs(*path) {
+			*path = filepath.Join(dir, *path)
+		}
+	}
+	for i := range p.Manifests {
+		relative(&p.Manifests[i])
+	}
+	for i := range p.SearchPath {
+		relative(&p.SearchPath[i])
+	}
+	for i := range p.Targets {
+		relative(&p.Targets[i].Output)
+	}
+	return &p, nil
+}
+
+// runGenerate generates every target of a project file for every manifest in
+// it, reporting on each
+func runGenerate(args []string) int {
+	fs := flag.NewFlagSet("generate", flag.ExitOnError)
+	projectPath := fs.String("project", "", "Project file listing the manifests and targets (default plugify-gen.json, or plugify-gen.toml if there is no JSON one)")
+	allErrors := fs.Bool("all-errors", false, "Report every manifest error instead of stopping at the first")
+	verbose := fs.Bool("verbose", false, "List every file written")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: plugify-gen generate [flags]\n\n")
+		fmt.Fprintf(fs.Output(), "Generates bindings in every language of a project file for every manifest in it.\n")
+		fmt.Fprintf(fs.Output(), "A failed target is reported and the rest still run.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return 1
+	}
+
+	start := time.Now()
+	if *projectPath == "" {
+		*projectPath = defaultProject()
+	}
+	p, err := loadProject(*projectPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	opts := &manifest.ParseOptions{
+		AllErrors:      *allErrors,
+		ValidateSchema: p.ValidateSchema,
+		Strict:         p.Strict,
+		SearchPath:     p.SearchPath,
+	}
+	written := map[string]string{} // file to the target that wrote it, to catch two writing the same one
+	failed, total := 0, 0
+	for _, path := range p.Manifests {
+		total += len(p.Targets)
+		m, err := loadForGenerate(path, p, opts)
+		if err != nil {
+			fmt.Printf("✗ %s: %d targets skipped\n", path, len(p.Targets))
+			printParseError(err, *allErrors)
+			failed += len(p.Targets)
+			continue
+		}
+		if len(m.Warnings) > 0 {
+			fmt.Fprintln(os.Stderr, m.Warnings)
+		}
+
+		for i, t := range p.Targets {
+			name := fmt.Sprintf("%s %s", m.Name, t.Lang)
+			outputDir := strings.ReplaceAll(t.Output, "{plugin}", m.Name)
+			own := m
+			if i > 0 {
+				// Generators sanitize names in the manifest they are given for
+				// their language, so each needs one of its own.
+				if own, err = loadForGenerate(path, p, opts); err != nil {
+					fmt.Printf("✗ %s: %v\n", name, err)
+					failed++
+					continue
+				}
+			}
+			files, err := generateTarget(own, t, outputDir, p.Overwrite, *verbose, name, written)
+			if err != nil {
+				fmt.Printf("✗ %s: %v\n", name, err)
+				failed++
+				continue
+			}
+			fmt.Printf("✓ %s: %d files in %s\n", name, files, outputDir)
+		}
+	}
+
+	fmt.Printf("Generated %d of %d targets in %s\n", total-failed, total, time.Since(start).Round(time.Millisecond))
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// defaultProject returns the project file in the current directory,
+// plugify-gen.json unless only a plugify-gen.toml is there.
+func defaultProject() string {
+	if _, err := os.Stat("plugify-gen.json"); errors.Is(err, os.ErrNotExist) {
+		if _, err := os.Stat("plugify-gen.toml"); err == nil {
+			return "plugify-gen.toml"
+		}
+	}
+	return "plugify-gen.json"
+}
+
+// loadForGenerate parses a project's manifest and narrows it to the project's
+// platform and version.
+func loadForGenerate(path string, p *project, opts *manifest.ParseOptions) (*manifest.Manifest, error) {
+	m, err := manifest.ParseFile(path, opts)
+	if err != nil {
+		return nil, err
+	}
+	if p.Platform != "" {
+		if err := m.FilterPlatform(p.Platform); err != nil {
+			return nil, err
+		}
+	}
+	if p.TargetVersion != "" {
+		if err := m.FilterVersion(p.TargetVersion); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// generateTarget generates one language for one manifest into outputDir and
+// returns how many files it wrote. written records every file this run has
+// written so far, so that a target writing over another's output fails
+// instead.
+func generateTarget(m *manifest.Manifest, t target, outputDir string, overwrite, verbose bool, name string, written map[string]string) (int, error) {
+	gen, err := generator.GetGenerator(t.Lang)
+	if err != nil {
+		return 0, err
+	}
+	options := t.Options
+	result, err := gen.Generate(m, &options)
+	if err != nil {
+		return 0, fmt.Errorf("generating code: %w", err)
+	}
+
+	filenames := make([]string, 0, len(result.Files))
+	for filename := range result.Files {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+	for _, filename := range filenames {
+		outputPath := filepath.Join(outputDir, filename)
+		if other, taken := written[outputPath]; taken {
+			return 0, fmt.Errorf("%s is generated by %s as well; give the targets different outputs, or use {plugin} in them", outputPath, other)
+		}
+	}
+
+	if err := writeFiles(result, outputDir, overwrite, verbose); err != nil {
+		if errors.Is(err, errFileExists) {
+			return 0, fmt.Errorf("%w (set \"overwrite\": true in the project to replace)", err)
+		}
+		return 0, err
+	}
+	for _, filename := range filenames {
+		written[filepath.Join(outputDir, filename)] = name
+	}
+	return len(filenames), nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/untrustedmodders/plugify-gen/pkg/manifest"
+)
+
+// runLint reports style and quality problems in manifests that parse
+func runLint(args []string) int {
+	fs := flag.NewFlagSet("lint", flag.ExitOnError)
+	configPath := fs.String("config", "", "JSON file setting the severity of rules, e.g. {\"rules\": {\"missing-description\": \"off\"}}")
+	asJSON := fs.Bool("json", false, "Print the findings as JSON")
+	listRules := fs.Bool("rules", false, "List the rules and their default severities, then exit")
+	var searchPath pathList
+	fs.Var(&searchPath, "I", "Directory to search for the manifests of dependencies (repeatable)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: plugify-gen lint [flags] manifest.pplugin...\n\n")
+		fmt.Fprintf(fs.Output(), "Exits with status 1 when a manifest does not parse or a rule set to \"error\" reports.\n")
+		fmt.Fprintf(fs.Output(), "List rule IDs under \"nolint\" in a manifest item to silence them there.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if *listRules {
+		for _, rule := range manifest.LintRules() {
+			fmt.Printf("%-32s %-8s %s\n", rule.ID, rule.Severity, rule.Description)
+		}
+		return 0
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 1
+	}
+
+	var config *manifest.LintConfig
+	if *configPath != "" {
+		var err error
+		if config, err = manifest.LoadLintConfig(*configPath); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+	}
+
+	status := 0
+	all := manifest.Diagnostics{}
+	for _, path := range fs.Args() {
+		found, err := manifest.LintFile(path, config, &manifest.ParseOptions{SearchPath: searchPath})
+		if err != nil {
+			printParseError(err, false)
+			status = 1
+			continue
+		}
+		if found.Count(manifest.SeverityError) > 0 {
+			status = 1
+		}
+		all = append(all, found...)
+	}
+
+	if *asJSON {
+		data, err := json.MarshalIndent(all, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error encoding findings: %v\n", err)
+			return 1
+		}
+		fmt.Println(string(data))
+	} else if len(all) > 0 {
+		fmt.Println(all)
+	}
+	return status
+}
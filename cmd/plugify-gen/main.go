@@ -1,10 +1,13 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/untrustedmodders/plugify-gen/pkg/generator"
@@ -14,7 +17,24 @@ import (
 // version is set via ldflags during build
 var version = "dev"
 
+// commands maps each subcommand to its entry point, which returns the process
+// exit code. Without a subcommand the tool generates bindings.
+var commands = map[string]func(args []string) int{
+	"schema":   runSchema,
+	"diff":     runDiff,
+	"fmt":      runFmt,
+	"migrate":  runMigrate,
+	"lint":     runLint,
+	"generate": runGenerate,
+}
+
 func main() {
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			os.Exit(command(os.Args[2:]))
+		}
+	}
+
 	start := time.Now()
 
 	var (
@@ -25,9 +45,17 @@ func main() {
 		verbose         = flag.Bool("verbose", false, "Enable verbose output")
 		generateClasses = flag.Bool("classes", false, "Generate class wrappers")
 		generateScopes  = flag.Bool("scopes", false, "Generate call scopes")
+		allErrors       = flag.Bool("all-errors", false, "Report every manifest error instead of stopping at the first")
+		validateSchema  = flag.Bool("validate-schema", false, "Validate the manifest against the JSON Schema (see 'plugify-gen schema')")
+		strict          = flag.Bool("strict", false, "Reject manifest keys that are unknown or spelled with the wrong case")
+		platform        = flag.String("platform", "", "Generate for one platform only, leaving out what it lacks: "+strings.Join(manifest.KnownPlatforms, ", "))
+		targetVersion   = flag.String("target-version", "", "Generate for an older plugin version, leaving out what came later or was removed by then")
 		showVersion     = flag.Bool("version", false, "Show version")
 	)
 
+	var searchPath pathList
+	flag.Var(&searchPath, "I", "Directory to search for the manifests of dependencies (repeatable)")
+
 	flag.Parse()
 
 	if *showVersion {
@@ -46,11 +74,32 @@ func main() {
 		fmt.Printf("Parsing manifest: %s\n", *manifestPath)
 	}
 
-	m, err := manifest.ParseFile(*manifestPath)
+	m, err := manifest.ParseFile(*manifestPath, &manifest.ParseOptions{
+		AllErrors:      *allErrors,
+		ValidateSchema: *validateSchema,
+		Strict:         *strict,
+		SearchPath:     searchPath,
+	})
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error parsing manifest: %v\n", err)
+		printParseError(err, *allErrors)
 		os.Exit(1)
 	}
+	if len(m.Warnings) > 0 {
+		fmt.Fprintln(os.Stderr, m.Warnings)
+	}
+
+	if *platform != "" {
+		if err := m.FilterPlatform(*platform); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if *targetVersion != "" {
+		if err := m.FilterVersion(*targetVersion); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
 
 	if *verbose {
 		fmt.Printf("Loaded plugin: %s (version %s)\n", m.Name, m.Version)
@@ -88,34 +137,90 @@ func main() {
 	}
 
 	// Write output files
-	for filename, content := range result.Files {
-		outputPath := filepath.Join(*outputDir, filename)
+	if err := writeFiles(result, *outputDir, *overwrite, *verbose); err != nil {
+		if errors.Is(err, errFileExists) {
+			fmt.Fprintf(os.Stderr, "Error: %v (use -overwrite to replace)\n", err)
+		} else {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+		os.Exit(1)
+	}
+
+	elapsed := time.Since(start)
+	fmt.Printf("✓ Successfully generated %s bindings in %s\n", *language, *outputDir)
+	if *verbose {
+		fmt.Printf("Execution time: %s\n", elapsed)
+	}
+}
+
+// errFileExists is returned by writeFiles for a file it may not overwrite.
+var errFileExists = errors.New("already exists")
+
+// writeFiles writes the generated files into outputDir, in name order, making
+// whatever directories they need.
+func writeFiles(result *generator.GeneratorResult, outputDir string, overwrite, verbose bool) error {
+	filenames := make([]string, 0, len(result.Files))
+	for filename := range result.Files {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+
+	for _, filename := range filenames {
+		content := result.Files[filename]
+		outputPath := filepath.Join(outputDir, filename)
 
 		// Check if file exists and overwrite flag
-		if _, err := os.Stat(outputPath); err == nil && !*overwrite {
-			fmt.Fprintf(os.Stderr, "Error: file %s already exists (use -overwrite to replace)\n", outputPath)
-			os.Exit(1)
+		if _, err := os.Stat(outputPath); err == nil && !overwrite {
+			return fmt.Errorf("file %s %w", outputPath, errFileExists)
 		}
 
 		// Create parent directories if they don't exist
 		if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
-			fmt.Fprintf(os.Stderr, "Error creating directory for %s: %v\n", outputPath, err)
-			os.Exit(1)
+			return fmt.Errorf("creating directory for %s: %w", outputPath, err)
 		}
 
 		if err := os.WriteFile(outputPath, []byte(content), 0644); err != nil {
-			fmt.Fprintf(os.Stderr, "Error writing file %s: %v\n", outputPath, err)
-			os.Exit(1)
+			return fmt.Errorf("writing file %s: %w", outputPath, err)
 		}
 
-		if *verbose {
+		if verbose {
 			fmt.Printf("Generated: %s (%d bytes)\n", outputPath, len(content))
 		}
 	}
+	return nil
+}
 
-	elapsed := time.Since(start)
-	fmt.Printf("✓ Successfully generated %s bindings in %s\n", *language, *outputDir)
-	if *verbose {
-		fmt.Printf("Execution time: %s\n", elapsed)
+// printParseError reports a manifest that failed to parse. Diagnostics are
+// already in file:line:col form, which editors and CI logs pick up only when
+// nothing is prefixed to them.
+func printParseError(err error, allErrors bool) {
+	var diags manifest.Diagnostics
+	if errors.As(err, &diags) {
+		fmt.Fprintln(os.Stderr, diags)
+		if allErrors {
+			fmt.Fprintf(os.Stderr, "%s\n", countErrors(diags.Count(manifest.SeverityError)))
+		}
+	} else {
+		fmt.Fprintf(os.Stderr, "Error parsing manifest: %v\n", err)
+	}
+}
+
+// countErrors renders an error count for the summary line after diagnostics.
+func countErrors(n int) string {
+	if n == 1 {
+		return "1 error generated."
 	}
+	return fmt.Sprintf("%d errors generated.", n)
+}
+
+// pathList collects a flag that may be given more than once, in order.
+type pathList []string
+
+func (l *pathList) String() string {
+	return strings.Join(*l, string(os.PathListSeparator))
+}
+
+func (l *pathList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
 }
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/untrustedmodders/plugify-gen/pkg/manifest"
+)
+
+// runMigrate rewrites manifests written for an older schema version
+func runMigrate(args []string) int {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	check := fs.Bool("check", false, "List the manifests that need migrating and exit with status 1 if there are any, changing nothing")
+	write := fs.Bool("w", false, "Rewrite the manifests in place instead of printing them")
+	var searchPath pathList
+	fs.Var(&searchPath, "I", "Directory to search for the manifests of dependencies (repeatable)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: plugify-gen migrate [-check | -w] [-I dir] manifest.pplugin...\n\n")
+		fmt.Fprintf(fs.Output(), "Brings manifests written for an older schema version up to version %d, reporting\n", manifest.SchemaVersion)
+		fmt.Fprintf(fs.Output(), "each change on stderr.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() == 0 || (*check && *write) || (!*check && !*write && fs.NArg() > 1) {
+		fs.Usage()
+		return 1
+	}
+
+	status := 0
+	for _, path := range fs.Args() {
+		migration, err := manifest.MigrateFile(path, &manifest.ParseOptions{SearchPath: searchPath})
+		if err != nil {
+			printParseError(err, false)
+			status = 1
+			continue
+		}
+		for _, change := range migration.Changes {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", path, change)
+		}
+
+		switch {
+		case *check:
+			if migration.From < migration.To {
+				fmt.Println(path)
+				status = 1
+			}
+		case *write:
+			if migration.From == migration.To {
+				continue
+			}
+			if err := os.WriteFile(path, migration.Data, 0644); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing %s: %v\n", path, err)
+				status = 1
+			}
+		default:
+			os.Stdout.Write(migration.Data)
+		}
+	}
+	return status
+}
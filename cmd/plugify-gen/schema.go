@@ -0,0 +1,38 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/untrustedmodders/plugify-gen/pkg/manifest"
+)
+
+// runSchema prints the JSON Schema for .pplugin manifests
+func runSchema(args []string) int {
+	fs := flag.NewFlagSet("schema", flag.ExitOnError)
+	output := fs.String("output", "", "Write the schema to this file instead of stdout")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: plugify-gen schema [-output file]\n\n")
+		fmt.Fprintf(fs.Output(), "Prints the JSON Schema (v%d) for .pplugin manifests.\n\n", manifest.SchemaVersion)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	schema, err := manifest.SchemaJSON()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error generating schema: %v\n", err)
+		return 1
+	}
+	schema = append(schema, '\n')
+
+	if *output == "" {
+		os.Stdout.Write(schema)
+		return 0
+	}
+	if err := os.WriteFile(*output, schema, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing schema: %v\n", err)
+		return 1
+	}
+	return 0
+}
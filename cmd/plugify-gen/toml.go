@@ -0,0 +1,390 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// decodeTOML reads the part of TOML a project file needs into the maps,
+// slices and scalars encoding/json would decode the same project into: tables,
+// arrays of tables, dotted keys, strings, booleans, integers, floats, arrays
+// and inline tables. Dates and multi-line strings are not supported.
+func decodeTOML(data []byte) (map[string]any, error) {
+	p := &tomlParser{src: string(data), line: 1}
+	root := map[string]any{}
+	current := root
+	defined := map[string]bool{} // [table] headers seen, which may not repeat
+	for {
+		p.skipBlank()
+		if p.done() {
+			return root, nil
+		}
+
+		var err error
+		if p.peek() == '[' {
+			current, err = p.header(root, defined)
+		} else {
+			err = p.keyValue(current)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", p.line, err)
+		}
+		if err := p.endOfLine(); err != nil {
+			return nil, fmt.Errorf("line %d: %w", p.line, err)
+		}
+	}
+}
+
+type tomlParser struct {
+	src  string
+	pos  int
+	line int
+}
+
+func (p *tomlParser) done() bool { return p.pos >= len(p.src) }
+
+func (p *tomlParser) peek() byte {
+	if p.done() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+// skipSpace skips spaces and tabs on the current line.
+func (p *tomlParser) skipSpace() {
+	for !p.done() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.pos++
+	}
+}
+
+// skipComment skips a comment running to the end of the line.
+func (p *tomlParser) skipComment() {
+	if p.peek() == '#' {
+		for !p.done() && p.peek() != '\n' {
+			p.pos++
+		}
+	}
+}
+
+// skipBlank skips whitespace, newlines and comments, as allowed between
+// statements and between the values of an array.
+func (p *tomlParser) skipBlank() {
+	for !p.done() {
+		switch p.peek() {
+		case ' ', '\t', '\r':
+			p.pos++
+		case '\n':
+			p.pos++
+			p.line++
+		case '#':
+			p.skipComment()
+		default:
+			return
+		}
+	}
+}
+
+// endOfLine checks that nothing but a comment follows a statement.
+func (p *tomlParser) endOfLine() error {
+	p.skipSpace()
+	p.skipComment()
+	if p.peek() == '\r' {
+		p.pos++
+	}
+	if !p.done() && p.peek() != '\n' {
+		return fmt.Errorf("unexpected %q after value", p.peek())
+	}
+	return nil
+}
+
+// header reads a [table] or [[array of tables]] header and returns the table
+// the key/value pairs below it go into.
+func (p *tomlParser) header(root map[string]any, defined map[string]bool) (map[string]any, error) {
+	p.pos++ // [
+	array := p.peek() == '['
+	if array {
+		p.pos++
+	}
+	p.skipSpace()
+	keys, err := p.key()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	closing := "]"
+	if array {
+		closing = "]]"
+	}
+	if !strings.HasPrefix(p.src[p.pos:], closing) {
+		return nil, fmt.Errorf("expected %s after table name", closing)
+	}
+	p.pos += len(closing)
+
+	parent, err := tomlTable(root, keys[:len(keys)-1])
+	if err != nil {
+		return nil, err
+	}
+	last := keys[len(keys)-1]
+	if array {
+		tables, _ := parent[last].([]any)
+		if _, exists := parent[last]; exists && tables == nil {
+			return nil, fmt.Errorf("%s is not an array of tables", strings.Join(keys, "."))
+		}
+		table := map[string]any{}
+		parent[last] = append(tables, table)
+		return table, nil
+	}
+
+	name := strings.Join(keys, ".")
+	if defined[name] {
+		return nil, fmt.Errorf("table %s is defined twice", name)
+	}
+	defined[name] = true
+	return tomlTable(parent, []string{last})
+}
+
+// tomlTable returns the table at keys below table, creating any missing. A key
+// holding an array of tables leads into its last one, as TOML has it.
+func tomlTable(table map[string]any, keys []string) (map[string]any, error) {
+	for _, key := range keys {
+		switch next := table[key].(type) {
+		case nil:
+			child := map[string]any{}
+			table[key] = child
+			table = child
+		case map[string]any:
+			table = next
+		case []any:
+			last, ok := next[len(next)-1].(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("%s is not a table", key)
+			}
+			table = last
+		default:
+			return nil, fmt.Errorf("%s is not a table", key)
+		}
+	}
+	return table, nil
+}
+
+// keyValue reads key = value into table.
+func (p *tomlParser) keyValue(table map[string]any) error {
+	keys, err := p.key()
+	if err != nil {
+		return err
+	}
+	p.skipSpace()
+	if p.peek() != '=' {
+		return fmt.Errorf("expected = after %s", strings.Join(keys, "."))
+	}
+	p.pos++
+	p.skipSpace()
+	value, err := p.value()
+	if err != nil {
+		return err
+	}
+
+	parent, err := tomlTable(table, keys[:len(keys)-1])
+	if err != nil {
+		return err
+	}
+	last := keys[len(keys)-1]
+	if _, exists := parent[last]; exists {
+		return fmt.Errorf("%s is set twice", strings.Join(keys, "."))
+	}
+	parent[last] = value
+	return nil
+}
+
+// key reads a key of bare or quoted parts joined by dots.
+func (p *tomlParser) key() ([]string, error) {
+	var keys []string
+	for {
+		p.skipSpace()
+		var part string
+		switch c := p.peek(); {
+		case c == '"' || c == '\'':
+			s, err := p.str()
+			if err != nil {
+				return nil, err
+			}
+			part = s
+		default:
+			start := p.pos
+			for !p.done() && isBareKeyChar(p.peek()) {
+				p.pos++
+			}
+			if start == p.pos {
+				if p.done() {
+					return nil, fmt.Errorf("expected a key")
+				}
+				return nil, fmt.Errorf("unexpected %q where a key belongs", p.peek())
+			}
+			part = p.src[start:p.pos]
+		}
+		keys = append(keys, part)
+
+		p.skipSpace()
+		if p.peek() != '.' {
+			return keys, nil
+		}
+		p.pos++
+	}
+}
+
+func isBareKeyChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+// value reads a string, boolean, number, array or inline table.
+func (p *tomlParser) value() (any, error) {
+	switch c := p.peek(); {
+	case c == '"' || c == '\'':
+		return p.str()
+	case c == '[':
+		return p.array()
+	case c == '{':
+		return p.inlineTable()
+	case strings.HasPrefix(p.src[p.pos:], "true"):
+		p.pos += len("true")
+		return true, nil
+	case strings.HasPrefix(p.src[p.pos:], "false"):
+		p.pos += len("false")
+		return false, nil
+	case c == '+' || c == '-' || c >= '0' && c <= '9':
+		return p.number()
+	case p.done() || c == '\n' || c == '\r':
+		return nil, fmt.Errorf("expected a value")
+	}
+	return nil, fmt.Errorf("unexpected %q where a value belongs", p.peek())
+}
+
+// number reads an integer or a float, with _ between digits allowed.
+func (p *tomlParser) number() (any, error) {
+	start := p.pos
+	for !p.done() && strings.IndexByte("+-0123456789_.eE", p.peek()) >= 0 {
+		p.pos++
+	}
+	text := strings.ReplaceAll(p.src[start:p.pos], "_", "")
+	if n, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return n, nil
+	}
+	f, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return nil, fmt.Errorf("malformed number %q", p.src[start:p.pos])
+	}
+	return f, nil
+}
+
+// array reads [a, b, ...], which may span lines and end in a comma.
+func (p *tomlParser) array() ([]any, error) {
+	p.pos++ // [
+	values := []any{}
+	for {
+		p.skipBlank()
+		if p.peek() == ']' {
+			p.pos++
+			return values, nil
+		}
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+
+		p.skipBlank()
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ']':
+		default:
+			return nil, fmt.Errorf("expected , or ] in array")
+		}
+	}
+}
+
+// inlineTable reads { key = value, ... } on a single line.
+func (p *tomlParser) inlineTable() (map[string]any, error) {
+	p.pos++ // {
+	table := map[string]any{}
+	p.skipSpace()
+	if p.peek() == '}' {
+		p.pos++
+		return table, nil
+	}
+	for {
+		if err := p.keyValue(table); err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case '}':
+			p.pos++
+			return table, nil
+		default:
+			return nil, fmt.Errorf("expected , or } in inline table")
+		}
+	}
+}
+
+// str reads a basic "string", with escapes, or a literal 'string'.
+func (p *tomlParser) str() (string, error) {
+	quote := p.peek()
+	p.pos++
+	var sb strings.Builder
+	for {
+		if p.done() || p.peek() == '\n' {
+			return "", fmt.Errorf("unterminated string")
+		}
+		c := p.peek()
+		p.pos++
+		switch {
+		case c == quote:
+			return sb.String(), nil
+		case c == '\\' && quote == '"':
+			if err := p.escape(&sb); err != nil {
+				return "", err
+			}
+		default:
+			sb.WriteByte(c)
+		}
+	}
+}
+
+// escape reads the escape sequence after a backslash into sb.
+func (p *tomlParser) escape(sb *strings.Builder) error {
+	if p.done() {
+		return fmt.Errorf("unterminated string")
+	}
+	c := p.peek()
+	p.pos++
+	simple := map[byte]byte{'b': '\b', 't': '\t', 'n': '\n', 'f': '\f', 'r': '\r', '"': '"', '\\': '\\'}
+	if r, ok := simple[c]; ok {
+		sb.WriteByte(r)
+		return nil
+	}
+
+	digits := 0
+	switch c {
+	case 'u':
+		digits = 4
+	case 'U':
+		digits = 8
+	default:
+		return fmt.Errorf("unknown escape \\%c", c)
+	}
+	if p.pos+digits > len(p.src) {
+		return fmt.Errorf("short \\%c escape", c)
+	}
+	code, err := strconv.ParseUint(p.src[p.pos:p.pos+digits], 16, 32)
+	if err != nil || !utf8.ValidRune(rune(code)) {
+		return fmt.Errorf("malformed \\%c escape %q", c, p.src[p.pos:p.pos+digits])
+	}
+	p.pos += digits
+	sb.WriteRune(rune(code))
+	return nil
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// checkTOML decodes data and compares the result against want.
+func checkTOML(t *testing.T, data string, want map[string]any) {
+	t.Helper()
+	table, err := decodeTOML([]byte(data))
+	if err != nil {
+		t.Fatalf("decodeTOML: %v", err)
+	}
+	if !reflect.DeepEqual(table, want) {
+		t.Errorf("decodeTOML gives\n\t%#v\nwant\n\t%#v", table, want)
+	}
+}
+
+// tomlError checks that data does not decode, with an error mentioning want.
+func tomlError(t *testing.T, data, want string) {
+	t.Helper()
+	table, err := decodeTOML([]byte(data))
+	if err == nil {
+		t.Errorf("%q decodes to %v", data, table)
+	} else if !strings.Contains(err.Error(), want) {
+		t.Errorf("error %q does not mention %q", err, want)
+	}
+}
+
+func TestDecodeTOMLValues(t *testing.T) {
+	checkTOML(t, `
+# a comment
+name = "demo" # and another
+literal = 'C:\path'
+escaped = "tab\tquote\" \u00e9"
+count = 1_000
+negative = -3
+ratio = 0.5
+big = 1e3
+on = true
+off = false
+list = [1, "two", [3],
+  4,   # a trailing comma is allowed
+]
+inline = { a = 1, b.c = "d" }
+`, map[string]any{
+		"name":     "demo",
+		"literal":  `C:\path`,
+		"escaped":  "tab\tquote\" é",
+		"count":    int64(1000),
+		"negative": int64(-3),
+		"ratio":    0.5,
+		"big":      1000.0,
+		"on":       true,
+		"off":      false,
+		"list":     []any{int64(1), "two", []any{int64(3)}, int64(4)},
+		"inline":   map[string]any{"a": int64(1), "b": map[string]any{"c": "d"}},
+	})
+}
+
+func TestDecodeTOMLTables(t *testing.T) {
+	checkTOML(t, `
+top = 1
+a.b = 2
+"quoted key".x = 3
+
+[table]
+key = "value"
+
+[table.nested]
+key = 4
+
+[[targets]]
+lang = "cpp"
+options.classes = true
+
+[[targets]]
+lang = "rust"
+
+[targets.options]
+scopes = false
+`, map[string]any{
+		"top":        int64(1),
+		"a":          map[string]any{"b": int64(2)},
+		"quoted key": map[string]any{"x": int64(3)},
+		"table":      map[string]any{"key": "value", "nested": map[string]any{"key": int64(4)}},
+		"targets": []any{
+			map[string]any{"lang": "cpp", "options": map[string]any{"classes": true}},
+			map[string]any{"lang": "rust", "options": map[string]any{"scopes": false}},
+		},
+	})
+}
+
+func TestDecodeTOMLRejects(t *testing.T) {
+	tomlError(t, "a = 1\na = 2", "line 2: a is set twice")
+	tomlError(t, "[t]\n[t]", "table t is defined twice")
+	tomlError(t, "t = 1\n[[t]]", "t is not an array of tables")
+	tomlError(t, "a = 1\na.b = 2", "a is not a table")
+	tomlError(t, "a = ", "expected a value")
+	tomlError(t, "a = 1 2", "line 1")
+	tomlError(t, `a = "open`, "unterminated string")
+	tomlError(t, `a = "\q"`, `unknown escape \q`)
+	tomlError(t, `a = "\uD800"`, `malformed \u escape`)
+	tomlError(t, "a = 1.2.3", `malformed number "1.2.3"`)
+	tomlError(t, "a = [1 2]", "expected , or ] in array")
+	tomlError(t, "a = { b = 1\n}", "expected , or } in inline table")
+	tomlError(t, "[t", "expected ] after table name")
+	tomlError(t, "= 1", `unexpected '=' where a key belongs`)
+	tomlError(t, "a = 1979-05-27", "malformed number")
+}
+
+// writeProject writes a project file called name holding content, and returns
+// its path.
+func writeProject(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadProjectTOML(t *testing.T) {
+	path := writeProject(t, "plugify-gen.toml", `
+manifests = ["plugins/demo.pplugin"]
+searchPath = ["/abs/plugins"]
+strict = true
+
+[[targets]]
+lang = "cpp"
+output = "out/{plugin}/cpp"
+options = { classes = true, scopes = false }
+`)
+	p, err := loadProject(path)
+	if err != nil {
+		t.Fatalf("loadProject: %v", err)
+	}
+	dir := filepath.Dir(path)
+	if p.Manifests[0] != filepath.Join(dir, "plugins/demo.pplugin") || p.SearchPath[0] != "/abs/plugins" || !p.Strict {
+		t.Errorf("the project is %+v", p)
+	}
+	if len(p.Targets) != 1 || p.Targets[0].Lang != "cpp" || p.Targets[0].Output != filepath.Join(dir, "out/{plugin}/cpp") ||
+		!p.Targets[0].Options.GenerateClasses || p.Targets[0].Options.GenerateScopes {
+		t.Errorf("the targets are %+v", p.Targets)
+	}
+
+	// The same project in JSON loads the same
+	jsonPath := writeProject(t, "plugify-gen.json", `{"manifests": ["plugins/demo.pplugin"], "searchPath": ["/abs/plugins"], "strict": true,
+		"targets": [{"lang": "cpp", "output": "out/{plugin}/cpp", "options": {"classes": true, "scopes": false}}]}`)
+	fromJSON, err := loadProject(jsonPath)
+	if err != nil {
+		t.Fatalf("loadProject: %v", err)
+	}
+	fromJSON.Manifests[0] = filepath.Join(dir, "plugins/demo.pplugin")
+	fromJSON.Targets[0].Output = filepath.Join(dir, "out/{plugin}/cpp")
+	if !reflect.DeepEqual(fromJSON, p) {
+		t.Errorf("the JSON project is %+v, want %+v", fromJSON, p)
+	}
+}
+
+func TestLoadProjectRejects(t *testing.T) {
+	reject := func(name, content, want string) {
+		t.Helper()
+		_, err := loadProject(writeProject(t, name, content))
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("loadProject error %v, want one mentioning %q", err, want)
+		}
+	}
+	const target = "\n[[targets]]\nlang = \"cpp\"\noutput = \"out\"\n"
+
+	reject("plugify-gen.yaml", "", "project files are JSON or TOML, not .yaml")
+	reject("plugify-gen.toml", `manifests = ["a.pplugin"]`+"\nbogus = 1"+target, `unknown field "bogus"`)
+	reject("plugify-gen.toml", `manifests = "a.pplugin"`+target, "cannot unmarshal string")
+	reject("plugify-gen.toml", `manifests = ["a.pplugin"`+target, "expected , or ] in array")
+	reject("plugify-gen.toml", target, "no manifests listed")
+	reject("plugify-gen.toml", `manifests = ["a.pplugin"]`, "no targets listed")
+	reject("plugify-gen.toml", `manifests = ["a.pplugin"]`+"\n[[targets]]\nlang = \"cobol\"\noutput = \"out\"\n", "targets[0]")
+	reject("plugify-gen.toml", `manifests = ["a.pplugin"]`+"\n[[targets]]\nlang = \"cpp\"\n", "targets[0]: output is required")
+}
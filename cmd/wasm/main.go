@@ -1,7 +1,11 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
 	"syscall/js"
 
 	"github.com/untrustedmodders/plugify-gen/pkg/generator"
@@ -12,9 +16,44 @@ var version = "dev" // Version is set via -ldflags during build
 
 // ConvertResult represents the result of a conversion
 type ConvertResult struct {
-	Success bool              `json:"success"`
-	Files   map[string]string `json:"files,omitempty"`
-	Error   string            `json:"error,omitempty"`
+	Success     bool                   `json:"success"`
+	Files       map[string]string      `json:"files,omitempty"`
+	Error       string                 `json:"error,omitempty"`
+	Diagnostics []*manifest.Diagnostic `json:"diagnostics,omitempty"`
+}
+
+// diagnosticsToJS converts a parse error, or a manifest's warnings, into the
+// plain objects js.ValueOf accepts, so callers can highlight the offending
+// lines themselves.
+func diagnosticsToJS(err error) []interface{} {
+	var diags manifest.Diagnostics
+	if !errors.As(err, &diags) {
+		return nil
+	}
+	out := make([]interface{}, 0, len(diags))
+	for _, diag := range diags {
+		out = append(out, map[string]interface{}{
+			"file":     diag.File,
+			"line":     diag.Line,
+			"column":   diag.Column,
+			"pointer":  diag.Pointer,
+			"severity": string(diag.Severity),
+			"message":  diag.Message,
+		})
+	}
+	return out
+}
+
+// readFromObject reads files from a JavaScript object of path to content,
+// which stands in for the file system a browser does not have.
+func readFromObject(files js.Value) func(name string) ([]byte, error) {
+	return func(name string) ([]byte, error) {
+		content := files.Get(path.Clean(filepath.ToSlash(name)))
+		if content.Type() != js.TypeString {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+		}
+		return []byte(content.String()), nil
+	}
 }
 
 // convertManifest converts a manifest file content to language bindings
@@ -34,21 +73,67 @@ func convertManifest(this js.Value, args []js.Value) interface{} {
 		GenerateClasses: true, // Default to true
 		GenerateScopes:  true, // Default to true
 	}
+	parseOpts := &manifest.ParseOptions{
+		AllErrors: true, // Default to true: the page shows every problem at once
+	}
+	platform := ""
+	targetVersion := ""
 	if len(args) >= 3 && args[2].Type() == js.TypeObject {
+		if allErrors := args[2].Get("allErrors"); allErrors.Type() == js.TypeBoolean {
+			parseOpts.AllErrors = allErrors.Bool()
+		}
+		if validateSchema := args[2].Get("validateSchema"); validateSchema.Type() == js.TypeBoolean {
+			parseOpts.ValidateSchema = validateSchema.Bool()
+		}
+		if strict := args[2].Get("strict"); strict.Type() == js.TypeBoolean {
+			parseOpts.Strict = strict.Bool()
+		}
+		if files := args[2].Get("files"); files.Type() == js.TypeObject {
+			parseOpts.ReadFile = readFromObject(files)
+		}
+		if searchPath := args[2].Get("searchPath"); searchPath.Type() == js.TypeObject {
+			for i := 0; i < searchPath.Length(); i++ {
+				parseOpts.SearchPath = append(parseOpts.SearchPath, searchPath.Index(i).String())
+			}
+		}
 		if generateClasses := args[2].Get("generateClasses"); generateClasses.Type() == js.TypeBoolean {
 			opts.GenerateClasses = generateClasses.Bool()
 		}
 		if generateScopes := args[2].Get("generateScopes"); generateScopes.Type() == js.TypeBoolean {
 			opts.GenerateScopes = generateScopes.Bool()
 		}
+		if value := args[2].Get("platform"); value.Type() == js.TypeString {
+			platform = value.String()
+		}
+		if value := args[2].Get("targetVersion"); value.Type() == js.TypeString {
+			targetVersion = value.String()
+		}
 	}
 
 	// Parse manifest
-	m, err := manifest.Parse([]byte(manifestContent))
+	m, err := manifest.Parse([]byte(manifestContent), parseOpts)
 	if err != nil {
 		return map[string]interface{}{
-			"success": false,
-			"error":   fmt.Sprintf("Error parsing manifest: %v", err),
+			"success":     false,
+			"error":       fmt.Sprintf("Error parsing manifest: %v", err),
+			"diagnostics": diagnosticsToJS(err),
+		}
+	}
+
+	if platform != "" {
+		if err := m.FilterPlatform(platform); err != nil {
+			return map[string]interface{}{
+				"success": false,
+				"error":   fmt.Sprintf("Error: %v", err),
+			}
+		}
+	}
+	if targetVersion != "" {
+		if err := m.FilterVersion(targetVersion); err != nil {
+			return map[string]interface{}{
+				"success": false,
+				"error":   fmt.Sprintf("Error: %v", err),
+			}
 		}
 	}
 
@@ -76,10 +161,23 @@ func convertManifest(this js.Value, args []js.Value) interface{} {
 	}
 
 	// Return result
-	return map[string]interface{}{
+	response := map[string]interface{}{
 		"success": true,
 		"files":   files,
 	}
+	if len(m.Warnings) > 0 {
+		response["diagnostics"] = diagnosticsToJS(m.Warnings)
+	}
+	return response
+}
+
+// getSchema returns the JSON Schema for .pplugin manifests
+func getSchema(this js.Value, args []js.Value) interface{} {
+	schema, err := manifest.SchemaJSON()
+	if err != nil {
+		return ""
+	}
+	return string(schema)
 }
 
 // getSupportedLanguages returns list of supported languages
@@ -100,6 +198,7 @@ func main() {
 	js.Global().Set("convertManifest", js.FuncOf(convertManifest))
 	js.Global().Set("getSupportedLanguages", js.FuncOf(getSupportedLanguages))
 	js.Global().Set("getVersion", js.FuncOf(getVersion))
+	js.Global().Set("getSchema", js.FuncOf(getSchema))
 
 	fmt.Println("Plugify Generator WebAssembly module loaded")
 	<-c
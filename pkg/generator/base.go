@@ -20,12 +20,13 @@ const (
 	EmptyHandleError = "empty handle"
 )
 
-// GeneratorOptions contains options for code generation
+// GeneratorOptions contains options for code generation. The JSON keys are
+// those of a target's "options" in a plugify-gen.json project file.
 type GeneratorOptions struct {
 	// GenerateClasses controls whether to generate class wrappers
-	GenerateClasses bool
+	GenerateClasses bool `json:"classes"`
 	// GenerateScopes controls whether to generate call scopes
-	GenerateScopes bool
+	GenerateScopes bool `json:"scopes"`
 }
 
 // EnsureOptions returns valid options, using defaults if nil
@@ -58,6 +59,9 @@ type DocOptions struct {
 	Summary      string                 // Summary/brief description
 	Description  string                 // Detailed description
 	Deprecated   string                 // Detailed deprecation message
+	Platforms    []string               // Platforms the item is limited to, if any
+	Since        string                 // Plugin version that introduced the item, if known
+	RemovedIn    string                 // Plugin version that removed the item, if any
 	Params       []manifest.ParamType   // Method parameters
 	ParamAliases []*manifest.ParamAlias // Parameter aliases for type substitution
 	//Returns          string                 // Return value description
@@ -79,6 +83,7 @@ type BaseGenerator struct {
 	enumCache     map[string]struct{}
 	delegateCache map[string]struct{}
 	aliasCache    map[string]struct{}
+	structCache   map[string]struct{}
 }
 
 // NewBaseGenerator creates a new base generator
@@ -95,6 +100,7 @@ func NewBaseGenerator(name string, typeMapper TypeMapper, invalidNames []string)
 		enumCache:     make(map[string]struct{}),
 		delegateCache: make(map[string]struct{}),
 		aliasCache:    make(map[string]struct{}),
+		structCache:   make(map[string]struct{}),
 	}
 }
 
@@ -190,11 +196,23 @@ func (g *BaseGenerator) CacheAlias(name string) {
 	g.aliasCache[name] = struct{}{}
 }
 
+// IsStructCached checks if a struct has already been generated
+func (g *BaseGenerator) IsStructCached(name string) bool {
+	_, ok := g.structCache[name]
+	return ok
+}
+
+// CacheStruct marks a struct as generated
+func (g *BaseGenerator) CacheStruct(name string) {
+	g.structCache[name] = struct{}{}
+}
+
 // ResetCaches clears enum and delegate caches (call before generation)
 func (g *BaseGenerator) ResetCaches() {
 	g.enumCache = make(map[string]struct{})
 	g.delegateCache = make(map[string]struct{})
 	g.aliasCache = make(map[string]struct{})
+	g.structCache = make(map[string]struct{})
 }
 
 // TypeMapper is the interface for type mapping strategies
@@ -202,6 +220,17 @@ type TypeMapper interface {
 	// MapType converts a manifest type to a target language type
 	MapType(baseType string, context TypeContext, isArray bool) (string, error)
 
+	// MapFixedArrayType converts an array of exactly length elements, given
+	// the element type as MapType maps it on its own
+	MapFixedArrayType(element string, length int, context TypeContext) (string, error)
+
+	// MapMapType converts a map, given the key and value types as MapType maps
+	// them on their own
+	MapMapType(key, value string, context TypeContext) (string, error)
+
+	// MapTypeNode converts a parsed type, in the given context
+	MapTypeNode(node *manifest.TypeNode, context TypeContext) (string, error)
+
 	// MapParamType converts a parameter type (handles ref, default values)
 	MapParamType(param *manifest.ParamType) (string, error)
 
@@ -212,6 +241,80 @@ type TypeMapper interface {
 	MapHandleType(class *manifest.Class) (string, string, error)
 }
 
+// mapTypeNode maps node the way most languages spell it, for a mapper's
+// MapTypeNode to fall back on. Enums and structs go by their own names, an
+// alias by its name under TypeContextAlias, and fixed-size arrays and maps
+// are objects, passed the way the mapper passes strings and vectors.
+func mapTypeNode(mapper TypeMapper, node *manifest.TypeNode, context TypeContext) (string, error) {
+	switch node.Kind {
+	case manifest.KindPrototype:
+		return node.Prototype.Name, nil
+	case manifest.KindClass:
+		return node.Class.Name, nil
+	case manifest.KindAlias:
+		return mapper.MapType(node.Alias.Name, context|TypeContextAlias, node.IsArray())
+	case manifest.KindArray:
+		return mapper.MapType(valueTypeName(node.Elem), context, true)
+	case manifest.KindFixedArray:
+		element, err := mapper.MapTypeNode(node.Elem, TypeContextReturn)
+		if err != nil {
+			return "", err
+		}
+		return mapper.MapFixedArrayType(element, node.Len, context|TypeContextObject)
+	case manifest.KindMap:
+		key, err := mapper.MapTypeNode(node.Key, TypeContextReturn)
+		if err != nil {
+			return "", err
+		}
+		value, err := mapper.MapTypeNode(node.Elem, TypeContextReturn)
+		if err != nil {
+			return "", err
+		}
+		return mapper.MapMapType(key, value, context|TypeContextObject)
+	}
+	return mapper.MapType(valueTypeName(node), context, false)
+}
+
+// valueTypeName is the name MapType knows a single value by: the enum's or
+// struct's own name, or the primitive type.
+func valueTypeName(node *manifest.TypeNode) string {
+	switch node.Kind {
+	case manifest.KindEnum:
+		return node.Enum.Name
+	case manifest.KindStruct:
+		return node.Struct.Name
+	}
+	return node.Name
+}
+
+// DefaultFormatter is implemented by type mappers that can render a parameter
+// default as a literal of their language. Defaults are left out for mappers
+// that do not, whose languages have none.
+type DefaultFormatter interface {
+	// FormatDefault renders param.DefaultValue, which Parse has checked against
+	// the parameter's type
+	FormatDefault(param *manifest.ParamType) (string, error)
+}
+
+// formatDefault renders param's default with mapper, or returns "" when param
+// has none or mapper cannot render one.
+func formatDefault(mapper TypeMapper, param *manifest.ParamType) (string, error) {
+	formatter, ok := mapper.(DefaultFormatter)
+	if !ok || param.DefaultValue == nil {
+		return "", nil
+	}
+	return formatter.FormatDefault(param)
+}
+
+// OptionalMapper is implemented by type mappers whose language spells an
+// optional value with a type of its own, such as std::optional. The function
+// pointer a wrapper calls still takes the plain type, with none as its empty
+// value, so FormatParameters applies this only where it also writes names.
+type OptionalMapper interface {
+	// MapOptionalType wraps typeName, the mapped type of an optional parameter
+	MapOptionalType(typeName string) string
+}
+
 // TypeContext represents the context in which a type appears
 type TypeContext int
 
@@ -231,6 +334,12 @@ type AliasGenerator func(*manifest.Alias, string) (string, error)
 
 type DelegateGenerator func(*manifest.Prototype) (string, error)
 
+// ConstantGenerator is a callback function that generates code for a constant
+type ConstantGenerator func(*manifest.Constant) (string, error)
+
+// StructGenerator is a callback function that generates code for a struct type
+type StructGenerator func(*manifest.Struct) (string, error)
+
 // ParameterFormat specifies what format parameters should be generated in
 type ParameterFormat int
 
@@ -269,15 +378,17 @@ func FormatParameters(params []manifest.ParamType, format ParameterFormat, mappe
 			if err != nil {
 				return "", err
 			}
+			if optional, ok := mapper.(OptionalMapper); ok && param.Optional {
+				typeName = optional.MapOptionalType(typeName)
+			}
 			paramName := param.Name
 			result += typeName + " " + paramName
-			if param.Default != nil {
-				switch v := (*param.Default).(type) {
-				case string:
-					result += fmt.Sprintf(" = %q", v)
-				default:
-					result += fmt.Sprintf(" = %v", v)
-				}
+			value, err := formatDefault(mapper, &param)
+			if err != nil {
+				return "", err
+			}
+			if value != "" {
+				result += " = " + value
 			}
 		}
 	}
@@ -360,6 +471,42 @@ func RemoveLeadingTabs(b *strings.Builder, n, startChar, endChar int) {
 	b.WriteString(strings.Join(lines, "\n"))
 }
 
+// OverloadOrder returns the manifest's methods in order, except that each
+// overload set is kept together where its first member stands, as languages
+// that declare overloads one after another require
+func OverloadOrder(m *manifest.Manifest) []*manifest.Method {
+	methods := make([]*manifest.Method, 0, len(m.Methods))
+	placed := make(map[string]bool)
+	for i := range m.Methods {
+		name := m.Methods[i].OverloadName()
+		if placed[name] {
+			continue
+		}
+		placed[name] = true
+		for j := i; j < len(m.Methods); j++ {
+			if m.Methods[j].OverloadName() == name {
+				methods = append(methods, &m.Methods[j])
+			}
+		}
+	}
+	return methods
+}
+
+// Availability describes the plugin versions an item exists in, e.g.
+// "Since 1.2; removed in 2.0", for documentation that has no tag of its own
+// for this. It is "" for an item that names neither version.
+func Availability(since, removedIn string) string {
+	switch {
+	case since != "" && removedIn != "":
+		return fmt.Sprintf("Since %s; removed in %s", since, removedIn)
+	case since != "":
+		return "Since " + since
+	case removedIn != "":
+		return "Removed in " + removedIn
+	}
+	return ""
+}
+
 // FindMethod returns the method with the given name or nil
 func FindMethod(m *manifest.Manifest, name string) *manifest.Method {
 	for i := range m.Methods {
@@ -370,9 +517,49 @@ func FindMethod(m *manifest.Manifest, name string) *manifest.Method {
 	return nil
 }
 
-// FindClass returns the method with the given name or nil
+// retAliasInvalidValue returns the invalid handle value of the class a
+// binding's return is wrapped in. For an optional return it, not the handle
+// type's empty value, is what stands for none: a class may have a valid handle
+// 0 and an invalid one of -1.
+func retAliasInvalidValue(m *manifest.Manifest, binding *manifest.Binding, mapper TypeMapper) (string, error) {
+	class := FindClass(m, binding.RetAlias.Name)
+	if class == nil {
+		return "", fmt.Errorf("binding %s: retAlias names unknown class %q", binding.Name, binding.RetAlias.Name)
+	}
+	invalidValue, _, err := mapper.MapHandleType(class)
+	return invalidValue, err
+}
+
+// EventMethods returns the methods event subscribes and unsubscribes its
+// listeners with. Parse made sure both exist.
+func EventMethods(m *manifest.Manifest, event *manifest.Event) (subscribe, unsubscribe *manifest.Method) {
+	return FindMethod(m, event.Subscribe), FindMethod(m, event.Unsubscribe)
+}
+
+// EventsInGroup returns the events whose helpers belong in group: those
+// subscribed through one of its methods.
+func EventsInGroup(m *manifest.Manifest, group string) []*manifest.Event {
+	var events []*manifest.Event
+	for i := range m.Events {
+		if subscribe, _ := EventMethods(m, &m.Events[i]); subscribe.Group == group {
+			events = append(events, &m.Events[i])
+		}
+	}
+	return events
+}
+
+// EventSummary is the description of an event's helper, for an event that
+// has none of its own.
+func EventSummary(event *manifest.Event) string {
+	if event.Description != "" {
+		return event.Description
+	}
+	return fmt.Sprintf("Subscription to the %s event", event.Name)
+}
+
+// FindClass returns the class with the given name or nil
 func FindClass(m *manifest.Manifest, name string) *manifest.Class {
-	for i := range m.Methods {
+	for i := range m.Classes {
 		if m.Classes[i].Name == name {
 			return &m.Classes[i]
 		}
@@ -445,8 +632,8 @@ func (g *BaseGenerator) FindDependentGroups(m *manifest.Manifest, currentGroupNa
 	return referencedGroups
 }
 
-type enumHandler func(enum *manifest.Enum, typeName string) error
-type aliasHandler func(alias *manifest.Alias, typeName string) error
+type enumHandler func(enum *manifest.Enum, node *manifest.TypeNode) error
+type aliasHandler func(alias *manifest.Alias, node *manifest.TypeNode) error
 type protoHandler func(proto *manifest.Prototype) error
 
 // walkPrototype traverses proto graph and calls handlers when enums/prototypes are found.
@@ -465,12 +652,12 @@ func (g *BaseGenerator) walkPrototype(proto *manifest.Prototype, onEnum enumHand
 
 	// Return type
 	if proto.RetType.Enum != nil {
-		if err := onEnum(proto.RetType.Enum, proto.RetType.Type); err != nil {
+		if err := onEnum(proto.RetType.Enum, proto.RetType.TypeNode()); err != nil {
 			return err
 		}
 	}
 	if proto.RetType.Alias != nil {
-		if err := onAlias(proto.RetType.Alias, proto.RetType.Type); err != nil {
+		if err := onAlias(proto.RetType.Alias, proto.RetType.TypeNode()); err != nil {
 			return err
 		}
 	}
@@ -486,12 +673,12 @@ func (g *BaseGenerator) walkPrototype(proto *manifest.Prototype, onEnum enumHand
 	// Parameters
 	for _, param := range proto.ParamTypes {
 		if param.Enum != nil {
-			if err := onEnum(param.Enum, param.Type); err != nil {
+			if err := onEnum(param.Enum, param.TypeNode()); err != nil {
 				return err
 			}
 		}
 		if param.Alias != nil {
-			if err := onAlias(param.Alias, param.Type); err != nil {
+			if err := onAlias(param.Alias, param.TypeNode()); err != nil {
 				return err
 			}
 		}
@@ -509,11 +696,12 @@ func (g *BaseGenerator) walkPrototype(proto *manifest.Prototype, onEnum enumHand
 }
 
 // ensureEnumGenerated centralizes mapType -> enumGen -> write -> cache
-func (g *BaseGenerator) ensureEnumGenerated(enum *manifest.Enum, typeName string, context TypeContext, sb *strings.Builder, enumGen EnumGenerator) error {
-	if g.IsEnumCached(enum.Name) {
-		return nil
+func (g *BaseGenerator) ensureEnumGenerated(enum *manifest.Enum, node *manifest.TypeNode, context TypeContext, sb *strings.Builder, enumGen EnumGenerator) error {
+	if g.IsEnumCached(enum.Name) || enum.Plugin != "" {
+		return nil // a dependency's enum is imported, see CollectExternalTypes
 	}
-	mapped, err := g.typeMapper.MapType(strings.TrimSuffix(typeName, "[]"), context, strings.Contains(typeName, "[]"))
+	// An enum stands for values of the element type, whatever holds them
+	mapped, err := g.typeMapper.MapType(node.Value().Name, context, false)
 	if err != nil {
 		return err
 	}
@@ -528,11 +716,11 @@ func (g *BaseGenerator) ensureEnumGenerated(enum *manifest.Enum, typeName string
 }
 
 // ensureEnumGenerated centralizes mapType -> enumGen -> write -> cache
-func (g *BaseGenerator) ensureAliasGenerated(alias *manifest.Alias, typeName string, context TypeContext, sb *strings.Builder, aliasGen AliasGenerator) error {
+func (g *BaseGenerator) ensureAliasGenerated(alias *manifest.Alias, node *manifest.TypeNode, context TypeContext, sb *strings.Builder, aliasGen AliasGenerator) error {
 	if g.IsAliasCached(alias.Name) {
 		return nil
 	}
-	mapped, err := g.typeMapper.MapType(strings.TrimSuffix(typeName, "[]"), context, strings.Contains(typeName, "[]"))
+	mapped, err := g.typeMapper.MapTypeNode(node.Unaliased(), context)
 	if err != nil {
 		return err
 	}
@@ -548,8 +736,8 @@ func (g *BaseGenerator) ensureAliasGenerated(alias *manifest.Alias, typeName str
 
 // ensureDelegateGenerated centralizes delegate generation -> write -> cache
 func (g *BaseGenerator) ensureDelegateGenerated(proto *manifest.Prototype, sb *strings.Builder, delegateGen DelegateGenerator) error {
-	if g.IsDelegateCached(proto.Name) {
-		return nil
+	if g.IsDelegateCached(proto.Name) || proto.Plugin != "" {
+		return nil // a dependency's prototype is imported, see CollectExternalTypes
 	}
 	code, err := delegateGen(proto)
 	if err != nil {
@@ -561,23 +749,16 @@ func (g *BaseGenerator) ensureDelegateGenerated(proto *manifest.Prototype, sb *s
 	return nil
 }
 
-func getType(prop *manifest.Property) string {
-	if prop.Type == "function" && prop.Prototype != nil {
-		return prop.Prototype.Name
-	}
-	return prop.Type
-}
-
 // CollectEnums uses the generic walker and the helper above
 func (g *BaseGenerator) CollectEnums(m *manifest.Manifest, enumGen EnumGenerator) (string, error) {
 	var sb strings.Builder
 
 	ctx := TypeContextReturn
 
-	onEnum := func(enum *manifest.Enum, typeName string) error {
-		return g.ensureEnumGenerated(enum, typeName, ctx, &sb, enumGen)
+	onEnum := func(enum *manifest.Enum, node *manifest.TypeNode) error {
+		return g.ensureEnumGenerated(enum, node, ctx, &sb, enumGen)
 	}
-	onAlias := func(alias *manifest.Alias, typeName string) error {
+	onAlias := func(alias *manifest.Alias, node *manifest.TypeNode) error {
 		return nil
 	}
 	onProto := func(proto *manifest.Prototype) error {
@@ -587,7 +768,7 @@ func (g *BaseGenerator) CollectEnums(m *manifest.Manifest, enumGen EnumGenerator
 	for _, method := range m.Methods {
 		// method return
 		if method.RetType.Enum != nil {
-			if err := g.ensureEnumGenerated(method.RetType.Enum, getType(&method.RetType), ctx, &sb, enumGen); err != nil {
+			if err := g.ensureEnumGenerated(method.RetType.Enum, method.RetType.TypeNode(), ctx, &sb, enumGen); err != nil {
 				return "", err
 			}
 		}
@@ -601,7 +782,7 @@ func (g *BaseGenerator) CollectEnums(m *manifest.Manifest, enumGen EnumGenerator
 		// parameters
 		for _, param := range method.ParamTypes {
 			if param.Enum != nil {
-				if err := g.ensureEnumGenerated(param.Enum, getType(&param), ctx, &sb, enumGen); err != nil {
+				if err := g.ensureEnumGenerated(param.Enum, param.TypeNode(), ctx, &sb, enumGen); err != nil {
 					return "", err
 				}
 			}
@@ -612,6 +793,20 @@ func (g *BaseGenerator) CollectEnums(m *manifest.Manifest, enumGen EnumGenerator
 			}
 		}
 	}
+
+	// struct fields
+	for _, structure := range g.UsedStructs(m) {
+		if structure.Plugin != "" {
+			continue // its enums are the dependency's business
+		}
+		for i := range structure.Fields {
+			if field := &structure.Fields[i]; field.Enum != nil {
+				if err := g.ensureEnumGenerated(field.Enum, field.TypeNode(), ctx, &sb, enumGen); err != nil {
+					return "", err
+				}
+			}
+		}
+	}
 	return sb.String(), nil
 }
 
@@ -621,11 +816,11 @@ func (g *BaseGenerator) CollectAliases(m *manifest.Manifest, aliasGen AliasGener
 
 	ctx := TypeContextReturn
 
-	onEnum := func(enum *manifest.Enum, typeName string) error {
+	onEnum := func(enum *manifest.Enum, node *manifest.TypeNode) error {
 		return nil
 	}
-	onAlias := func(alias *manifest.Alias, typeName string) error {
-		return g.ensureAliasGenerated(alias, typeName, ctx, &sb, aliasGen)
+	onAlias := func(alias *manifest.Alias, node *manifest.TypeNode) error {
+		return g.ensureAliasGenerated(alias, node, ctx, &sb, aliasGen)
 	}
 	onProto := func(proto *manifest.Prototype) error {
 		return nil
@@ -634,7 +829,7 @@ func (g *BaseGenerator) CollectAliases(m *manifest.Manifest, aliasGen AliasGener
 	for _, method := range m.Methods {
 		// method return
 		if method.RetType.Alias != nil {
-			if err := g.ensureAliasGenerated(method.RetType.Alias, getType(&method.RetType), ctx, &sb, aliasGen); err != nil {
+			if err := g.ensureAliasGenerated(method.RetType.Alias, method.RetType.TypeNode(), ctx, &sb, aliasGen); err != nil {
 				return "", err
 			}
 		}
@@ -648,7 +843,7 @@ func (g *BaseGenerator) CollectAliases(m *manifest.Manifest, aliasGen AliasGener
 		// parameters
 		for _, param := range method.ParamTypes {
 			if param.Alias != nil {
-				if err := g.ensureAliasGenerated(param.Alias, getType(&param), ctx, &sb, aliasGen); err != nil {
+				if err := g.ensureAliasGenerated(param.Alias, param.TypeNode(), ctx, &sb, aliasGen); err != nil {
 					return "", err
 				}
 			}
@@ -666,10 +861,10 @@ func (g *BaseGenerator) CollectAliases(m *manifest.Manifest, aliasGen AliasGener
 func (g *BaseGenerator) CollectDelegates(m *manifest.Manifest, delegateGen DelegateGenerator) (string, error) {
 	var sb strings.Builder
 
-	onEnum := func(enum *manifest.Enum, typeName string) error {
+	onEnum := func(enum *manifest.Enum, node *manifest.TypeNode) error {
 		return nil
 	}
-	onAlias := func(alias *manifest.Alias, typeName string) error {
+	onAlias := func(alias *manifest.Alias, node *manifest.TypeNode) error {
 		return nil
 	}
 	onProto := func(proto *manifest.Prototype) error {
@@ -701,3 +896,248 @@ func (g *BaseGenerator) CollectDelegates(m *manifest.Manifest, delegateGen Deleg
 	}
 	return sb.String(), nil
 }
+
+// anyProperty reports whether match holds for a parameter or return value of
+// any method or prototype of m.
+func anyProperty(m *manifest.Manifest, match func(*manifest.Property) bool) bool {
+	signature := func(params []manifest.ParamType, ret *manifest.RetType) bool {
+		if match(ret) {
+			return true
+		}
+		for i := range params {
+			if match(&params[i]) {
+				return true
+			}
+		}
+		return false
+	}
+	for i := range m.Methods {
+		if signature(m.Methods[i].ParamTypes, &m.Methods[i].RetType) {
+			return true
+		}
+	}
+	for _, proto := range m.Prototypes {
+		if signature(proto.ParamTypes, &proto.RetType) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasOptional reports whether any method of m takes or returns an optional
+// value, for languages that need an import to spell one.
+func HasOptional(m *manifest.Manifest) bool {
+	return anyProperty(m, func(prop *manifest.Property) bool { return prop.Optional })
+}
+
+// HasKind reports whether any signature in m uses a type of the given kind,
+// for languages that need an import to spell one.
+func HasKind(m *manifest.Manifest, kind manifest.TypeKind) bool {
+	return anyProperty(m, func(prop *manifest.Property) bool { return prop.TypeNode().Unaliased().Kind == kind })
+}
+
+// ensureStructGenerated centralizes struct generation -> write -> cache
+func (g *BaseGenerator) ensureStructGenerated(structure *manifest.Struct, sb *strings.Builder, structGen StructGenerator) error {
+	if g.IsStructCached(structure.Name) || structure.Plugin != "" {
+		return nil // a dependency's struct is imported, see CollectExternalTypes
+	}
+	code, err := structGen(structure)
+	if err != nil {
+		return err
+	}
+	sb.WriteString(code)
+	sb.WriteString("\n")
+	g.CacheStruct(structure.Name)
+	return nil
+}
+
+// CollectConstants generates the manifest's constants in the order it lists
+// them. Dependencies' constants are theirs to generate.
+func (g *BaseGenerator) CollectConstants(m *manifest.Manifest, constantGen ConstantGenerator) (string, error) {
+	var sb strings.Builder
+	for i := range m.Constants {
+		constant := &m.Constants[i]
+		if constant.Literal == nil {
+			return "", fmt.Errorf("constant %s: value has not been checked against its type", constant.Name)
+		}
+		code, err := constantGen(constant)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(code)
+	}
+	return sb.String(), nil
+}
+
+// CollectStructs generates every struct of the manifest's own that its methods
+// use. A struct comes after the structs its fields hold, for languages that
+// need a type declared before it is used by value.
+func (g *BaseGenerator) CollectStructs(m *manifest.Manifest, structGen StructGenerator) (string, error) {
+	var sb strings.Builder
+	for _, structure := range g.UsedStructs(m) {
+		if err := g.ensureStructGenerated(structure, &sb, structGen); err != nil {
+			return "", err
+		}
+	}
+	return sb.String(), nil
+}
+
+// UsedStructs returns every struct that m's methods use, directly, through a
+// prototype, or as a field of another struct, each after the structs its
+// fields hold. A dependency's struct is looked inside too, for languages that
+// must repeat its layout; generators still define only their own.
+func (g *BaseGenerator) UsedStructs(m *manifest.Manifest) []*manifest.Struct {
+	var ordered []*manifest.Struct
+	seenStructs := map[*manifest.Struct]bool{}
+	seenPrototypes := map[*manifest.Prototype]bool{}
+
+	var visit func(prop *manifest.Property)
+	visitSignature := func(params []manifest.ParamType, ret *manifest.RetType) {
+		for i := range params {
+			visit(&params[i])
+		}
+		visit(ret)
+	}
+	visit = func(prop *manifest.Property) {
+		if proto := prop.Prototype; proto != nil && proto.Plugin == "" && !seenPrototypes[proto] {
+			seenPrototypes[proto] = true
+			visitSignature(proto.ParamTypes, &proto.RetType)
+		}
+		structure := prop.Struct
+		if structure == nil || seenStructs[structure] {
+			return
+		}
+		seenStructs[structure] = true
+		for i := range structure.Fields {
+			visit(&structure.Fields[i])
+		}
+		ordered = append(ordered, structure)
+	}
+	for i := range m.Methods {
+		visitSignature(m.Methods[i].ParamTypes, &m.Methods[i].RetType)
+	}
+	return ordered
+}
+
+// ExternalTypes are the enums, prototypes and structs a manifest uses from its
+// dependencies. Generators import these from the dependency's own generated
+// code instead of defining them a second time.
+type ExternalTypes struct {
+	Enums      map[string][]*manifest.Enum      // by plugin, sorted by name
+	Prototypes map[string][]*manifest.Prototype // by plugin, sorted by name
+	Structs    map[string][]*manifest.Struct    // by plugin, sorted by name
+}
+
+// EnumPlugins returns the plugins that enums are imported from, sorted.
+func (x *ExternalTypes) EnumPlugins() []string {
+	return sortedPlugins(x.Enums)
+}
+
+// PrototypePlugins returns the plugins that prototypes are imported from, sorted.
+func (x *ExternalTypes) PrototypePlugins() []string {
+	return sortedPlugins(x.Prototypes)
+}
+
+// StructPlugins returns the plugins that structs are imported from, sorted.
+func (x *ExternalTypes) StructPlugins() []string {
+	return sortedPlugins(x.Structs)
+}
+
+// EnumNames returns the names of the enums imported from plugin.
+func (x *ExternalTypes) EnumNames(plugin string) []string {
+	names := make([]string, len(x.Enums[plugin]))
+	for i, enum := range x.Enums[plugin] {
+		names[i] = enum.Name
+	}
+	return names
+}
+
+// PrototypeNames returns the names of the prototypes imported from plugin.
+func (x *ExternalTypes) PrototypeNames(plugin string) []string {
+	names := make([]string, len(x.Prototypes[plugin]))
+	for i, proto := range x.Prototypes[plugin] {
+		names[i] = proto.Name
+	}
+	return names
+}
+
+// StructNames returns the names of the structs imported from plugin.
+func (x *ExternalTypes) StructNames(plugin string) []string {
+	names := make([]string, len(x.Structs[plugin]))
+	for i, structure := range x.Structs[plugin] {
+		names[i] = structure.Name
+	}
+	return names
+}
+
+func sortedPlugins[T any](byPlugin map[string][]T) []string {
+	plugins := make([]string, 0, len(byPlugin))
+	for plugin := range byPlugin {
+		plugins = append(plugins, plugin)
+	}
+	sort.Strings(plugins)
+	return plugins
+}
+
+// CollectExternalTypes finds every enum, prototype and struct that m's methods,
+// prototypes and structs take from a dependency. It does not look inside a
+// dependency's prototype or struct: whatever that refers to is the
+// dependency's own business.
+func (g *BaseGenerator) CollectExternalTypes(m *manifest.Manifest) *ExternalTypes {
+	x := &ExternalTypes{
+		Enums:      map[string][]*manifest.Enum{},
+		Prototypes: map[string][]*manifest.Prototype{},
+		Structs:    map[string][]*manifest.Struct{},
+	}
+	seenEnums := map[*manifest.Enum]bool{}
+	seenPrototypes := map[*manifest.Prototype]bool{}
+	seenStructs := map[*manifest.Struct]bool{}
+
+	var visit func(prop *manifest.Property)
+	visitSignature := func(params []manifest.ParamType, ret *manifest.RetType) {
+		for i := range params {
+			visit(&params[i])
+		}
+		visit(ret)
+	}
+	visit = func(prop *manifest.Property) {
+		if enum := prop.Enum; enum != nil && enum.Plugin != "" && !seenEnums[enum] {
+			seenEnums[enum] = true
+			x.Enums[enum.Plugin] = append(x.Enums[enum.Plugin], enum)
+		}
+		if structure := prop.Struct; structure != nil && !seenStructs[structure] {
+			seenStructs[structure] = true
+			if structure.Plugin != "" {
+				x.Structs[structure.Plugin] = append(x.Structs[structure.Plugin], structure)
+			} else {
+				for i := range structure.Fields {
+					visit(&structure.Fields[i])
+				}
+			}
+		}
+		proto := prop.Prototype
+		if proto == nil || seenPrototypes[proto] {
+			return
+		}
+		seenPrototypes[proto] = true
+		if proto.Plugin != "" {
+			x.Prototypes[proto.Plugin] = append(x.Prototypes[proto.Plugin], proto)
+			return
+		}
+		visitSignature(proto.ParamTypes, &proto.RetType)
+	}
+	for i := range m.Methods {
+		visitSignature(m.Methods[i].ParamTypes, &m.Methods[i].RetType)
+	}
+
+	for _, enums := range x.Enums {
+		sort.Slice(enums, func(i, j int) bool { return enums[i].Name < enums[j].Name })
+	}
+	for _, prototypes := range x.Prototypes {
+		sort.Slice(prototypes, func(i, j int) bool { return prototypes[i].Name < prototypes[j].Name })
+	}
+	for _, structs := range x.Structs {
+		sort.Slice(structs, func(i, j int) bool { return structs[i].Name < structs[j].Name })
+	}
+	return x
+}
@@ -2,6 +2,8 @@ package generator
 
 import (
 	"fmt"
+	"math"
+	"strconv"
 	"strings"
 
 	"github.com/untrustedmodders/plugify-gen/pkg/manifest"
@@ -68,6 +70,28 @@ func (m *CppCommonTypeMapper) MapType(baseType string, context TypeContext, isAr
 	return mapped, nil
 }
 
+// MapFixedArrayType implements TypeMapper.
+func (m *CppCommonTypeMapper) MapFixedArrayType(element string, length int, context TypeContext) (string, error) {
+	return cppPassedObject(fmt.Sprintf("std::array<%s, %d>", element, length), context), nil
+}
+
+// MapMapType implements TypeMapper.
+func (m *CppCommonTypeMapper) MapMapType(key, value string, context TypeContext) (string, error) {
+	return cppPassedObject(fmt.Sprintf("plg::map<%s, %s>", key, value), context), nil
+}
+
+// cppPassedObject qualifies an object type for how MapType passes objects:
+// by const& as a value parameter and by & as a ref one.
+func cppPassedObject(mapped string, context TypeContext) string {
+	switch {
+	case context&TypeContextRef != 0:
+		return mapped + "&"
+	case context&TypeContextValue != 0:
+		return fmt.Sprintf("const %s&", mapped)
+	}
+	return mapped
+}
+
 // isObjectLikeType returns true for types that should be passed by const& in parameters
 func (m *CppCommonTypeMapper) isObjectLikeType(baseType string) bool {
 	objectLikeTypes := map[string]struct{}{
@@ -83,55 +107,33 @@ func (m *CppCommonTypeMapper) isObjectLikeType(baseType string) bool {
 }
 
 func (m *CppCommonTypeMapper) MapParamType(param *manifest.ParamType) (string, error) {
+	node := param.TypeNode()
+
 	// Regular type mapping
 	ctx := TypeContextValue
 	if param.Ref {
 		ctx = TypeContextRef
 	}
-	if m.isObjectLikeType(param.BaseType()) {
+	value := node.Value()
+	if m.isObjectLikeType(value.Name) {
 		ctx |= TypeContextObject
 	}
-
-	var typeName string
-	switch {
-	case param.Alias != nil:
-		typeName = param.Alias.Name
-		ctx |= TypeContextAlias
-
-	case param.Enum != nil:
-		typeName = param.Enum.Name
-
-	case param.Prototype != nil:
-		return param.Prototype.Name, nil
-
-	default:
-		typeName = param.BaseType()
+	if value.Kind == manifest.KindStruct && !param.Ref {
+		// Passed by const& like the vector types, unless ref
+		ctx |= TypeContextObject
 	}
 
-	return m.MapType(typeName, ctx, param.IsArray())
+	return m.MapTypeNode(node, ctx)
 }
 
 func (m *CppCommonTypeMapper) MapReturnType(retType *manifest.RetType) (string, error) {
-	ctx := TypeContextReturn
-
-	var typeName string
-	switch {
-	case retType.Alias != nil:
-		typeName = retType.Alias.Name
-		ctx |= TypeContextAlias
-
-	case retType.Enum != nil:
-		typeName = retType.Enum.Name
-
-	case retType.Prototype != nil:
-		return retType.Prototype.Name, nil
-
-	default:
-		typeName = retType.BaseType()
-	}
-
 	// Regular type mapping - returns always by value
-	return m.MapType(typeName, ctx, retType.IsArray())
+	return m.MapTypeNode(retType.TypeNode(), TypeContextReturn)
+}
+
+// MapTypeNode implements TypeMapper.
+func (m *CppCommonTypeMapper) MapTypeNode(node *manifest.TypeNode, context TypeContext) (string, error) {
+	return mapTypeNode(m, node, context)
 }
 
 func (m *CppCommonTypeMapper) MapHandleType(class *manifest.Class) (string, string, error) {
@@ -155,6 +157,223 @@ func (m *CppCommonTypeMapper) MapHandleType(class *manifest.Class) (string, stri
 	return invalidValue, handleType, nil
 }
 
+// FormatDefault renders a parameter default as a C++ literal of the parameter's type
+func (m *CppCommonTypeMapper) FormatDefault(param *manifest.ParamType) (string, error) {
+	value := param.DefaultValue
+	if value.Kind == manifest.DefaultEnum {
+		return fmt.Sprintf("%s::%s", param.Enum.Name, value.Enum.Name), nil
+	}
+	if literal, ok := cppLiteral(param.Type, value); ok {
+		return literal, nil
+	}
+	return "", fmt.Errorf("parameter %s: unsupported default", param.Name)
+}
+
+// cppLiteral renders value as a literal of typeName, for a default or a
+// constant.
+func cppLiteral(typeName string, value *manifest.DefaultValue) (string, bool) {
+	switch value.Kind {
+	case manifest.DefaultBool:
+		return strconv.FormatBool(value.Bool), true
+	case manifest.DefaultInt:
+		if value.Int == math.MinInt64 {
+			// The literal would be the negation of a value int64_t cannot hold.
+			return "INT64_MIN", true
+		}
+		return strconv.FormatInt(value.Int, 10), true
+	case manifest.DefaultUint:
+		if typeName == "ptr64" {
+			if value.Uint == 0 {
+				return "nullptr", true
+			}
+			return fmt.Sprintf("reinterpret_cast<void*>(0x%x)", value.Uint), true
+		}
+		if value.Uint > math.MaxInt64 {
+			// Without a suffix a decimal literal must fit a signed type.
+			return strconv.FormatUint(value.Uint, 10) + "ull", true
+		}
+		return strconv.FormatUint(value.Uint, 10), true
+	case manifest.DefaultFloat:
+		literal := floatLiteral(value.Float)
+		if typeName == "float" {
+			literal += "f"
+		}
+		return literal, true
+	case manifest.DefaultChar:
+		if typeName == "char16" {
+			return "u" + cLikeCharLiteral(value.Char), true
+		}
+		return cLikeCharLiteral(value.Char), true
+	case manifest.DefaultString:
+		return strconv.Quote(value.String), true
+	}
+	return "", false
+}
+
+// cppConstant declares constant at namespace scope, as both C++ generators do.
+// A string is a std::string_view, which unlike plg::string is a literal type.
+func cppConstant(mapper TypeMapper, constant *manifest.Constant) (string, error) {
+	typeName := "std::string_view"
+	if constant.Type != "string" {
+		var err error
+		if typeName, err = mapper.MapType(constant.Type, TypeContextReturn, false); err != nil {
+			return "", err
+		}
+	}
+	literal, ok := cppLiteral(constant.Type, constant.Literal)
+	if !ok {
+		return "", fmt.Errorf("constant %s: unsupported value", constant.Name)
+	}
+
+	var sb strings.Builder
+	if constant.Description != "" {
+		sb.WriteString(fmt.Sprintf("  // %s\n", constant.Description))
+	}
+	sb.WriteString(cppAvailabilityComment(constant.Since, constant.RemovedIn))
+	sb.WriteString(fmt.Sprintf("  %sinline constexpr %s %s = %s;\n", cppDeprecatedAttr(constant.Deprecated), typeName, constant.Name, literal))
+	return sb.String(), nil
+}
+
+// cppHasStringConstant reports whether any constant needs <string_view>.
+func cppHasStringConstant(m *manifest.Manifest) bool {
+	for _, constant := range m.Constants {
+		if constant.Type == "string" {
+			return true
+		}
+	}
+	return false
+}
+
+// MapOptionalType implements OptionalMapper. An object passed by const& stays
+// passed that way, inside the std::optional.
+func (m *CppCommonTypeMapper) MapOptionalType(typeName string) string {
+	if inner, ok := strings.CutPrefix(typeName, "const "); ok {
+		return fmt.Sprintf("const std::optional<%s>&", strings.TrimSuffix(inner, "&"))
+	}
+	return fmt.Sprintf("std::optional<%s>", typeName)
+}
+
+// cppValueType is typeName without the const& an object is passed by.
+func cppValueType(typeName string) string {
+	if inner, ok := strings.CutPrefix(typeName, "const "); ok {
+		return strings.TrimSuffix(inner, "&")
+	}
+	return typeName
+}
+
+// cppCallArguments lists the arguments a wrapper passes to the function
+// pointer, with each optional parameter unwrapped to the empty value that
+// stands for none.
+func cppCallArguments(params []manifest.ParamType, mapper TypeMapper) (string, error) {
+	parts := make([]string, len(params))
+	for i := range params {
+		param := &params[i]
+		parts[i] = param.Name
+		if param.Optional {
+			typeName, err := mapper.MapParamType(param)
+			if err != nil {
+				return "", err
+			}
+			parts[i] = fmt.Sprintf("%s.value_or(%s{})", param.Name, cppValueType(typeName))
+		}
+	}
+	return strings.Join(parts, ", "), nil
+}
+
+// cppOptionalReturn returns call's result as a std::optional, which is empty
+// when the result is its type's empty value.
+func cppOptionalReturn(indent, call string) string {
+	return fmt.Sprintf("%sauto __result = %s;\n%sif (__result == decltype(__result){}) return std::nullopt;\n%sreturn __result;\n",
+		indent, call, indent, indent)
+}
+
+// cppOptionalAliasReturn returns call's optional handle wrapped in the class
+// named alias. The wrapper has already turned the empty value into none, so
+// that is undone and none is whatever the class takes for invalid instead.
+func cppOptionalAliasReturn(indent, call, alias, invalidValue, ownership string) string {
+	if invalidValue == "{}" {
+		invalidValue = "decltype(__result){}"
+	}
+	return fmt.Sprintf("%sauto __optional = %s;\n%sauto __result = __optional.value_or(decltype(__optional)::value_type{});\n%sif (__result == %s) return {};\n%sreturn %s(__result%s);\n",
+		indent, call, indent, indent, invalidValue, indent, alias, ownership)
+}
+
+// cppEnumValue renders an enum value as a literal that C++ reads as written. An
+// unsuffixed decimal literal has to fit long long, which the top half of a
+// uint64 enum does not, nor does the magnitude of INT64_MIN.
+func cppEnumValue(value manifest.Integer) string {
+	if n, ok := value.Int64(); ok {
+		if n == math.MinInt64 {
+			return "INT64_MIN"
+		}
+		return strconv.FormatInt(n, 10)
+	}
+	return value.String() + "ull"
+}
+
+// floatLiteral renders f so that it always reads as floating point, since a
+// bare "1" is an integer literal in the C family.
+func floatLiteral(f float64) string {
+	literal := strconv.FormatFloat(f, 'g', -1, 64)
+	if !strings.ContainsAny(literal, ".eEnN") {
+		literal += ".0"
+	}
+	return literal
+}
+
+// cLikeCharLiteral renders r as a character literal that C, C++, C# and D all read.
+func cLikeCharLiteral(r rune) string {
+	switch r {
+	case '\\':
+		return `'\\'`
+	case '\'':
+		return `'\''`
+	}
+	// Go's escapes are the C ones; runes past the BMP cannot reach here, as
+	// neither char type holds them.
+	return strconv.QuoteRune(r)
+}
+
+// cppFlagsOperators declares the bitwise operators for a flags enum, which an
+// enum class does not get on its own.
+func cppFlagsOperators(name, underlyingType string) string {
+	var sb strings.Builder
+	for _, op := range []string{"|", "&", "^"} {
+		sb.WriteString(fmt.Sprintf("  constexpr %s operator%s(%s lhs, %s rhs) noexcept { return static_cast<%s>(static_cast<%s>(lhs) %s static_cast<%s>(rhs)); }\n",
+			name, op, name, name, name, underlyingType, op, underlyingType))
+	}
+	sb.WriteString(fmt.Sprintf("  constexpr %s operator~(%s value) noexcept { return static_cast<%s>(~static_cast<%s>(value)); }\n",
+		name, name, name, underlyingType))
+	for _, op := range []string{"|", "&", "^"} {
+		sb.WriteString(fmt.Sprintf("  constexpr %s& operator%s=(%s& lhs, %s rhs) noexcept { return lhs = lhs %s rhs; }\n",
+			name, op, name, name, op))
+	}
+	return sb.String()
+}
+
+// cppSinceTags renders the Doxygen lines saying when an item came and when it
+// was removed, or "" for an item that names neither version.
+func cppSinceTags(indent, since, removedIn string) string {
+	var sb strings.Builder
+	if since != "" {
+		sb.WriteString(fmt.Sprintf("%s * @since %s\n", indent, since))
+	}
+	if removedIn != "" {
+		sb.WriteString(fmt.Sprintf("%s * @note Removed in %s\n", indent, removedIn))
+	}
+	return sb.String()
+}
+
+// cppAvailabilityComment renders Availability as a line comment for the
+// enums, aliases, delegates, structs and constants, whose descriptions are
+// plain comments rather than Doxygen blocks.
+func cppAvailabilityComment(since, removedIn string) string {
+	if note := Availability(since, removedIn); note != "" {
+		return fmt.Sprintf("  // %s\n", note)
+	}
+	return ""
+}
+
 // cppDeprecatedAttr renders a [[deprecated]] attribute, or "" when there is no
 // reason to render. C++ is particular about where the attribute may sit: it
 // appertains to whatever precedes it in an enum-specifier and in an
@@ -169,3 +388,72 @@ func cppDeprecatedAttr(reason string) string {
 	}
 	return fmt.Sprintf("[[deprecated(\"%s\")]] ", reason)
 }
+
+// cppPlatformMacros are the macros compilers predefine on each platform
+var cppPlatformMacros = map[string]string{
+	manifest.PlatformWindows: "_WIN32",
+	manifest.PlatformLinux:   "__linux__",
+	manifest.PlatformMacOS:   "__APPLE__",
+}
+
+// cppEventListener renders the RAII handle for an event's listener, named
+// after the event: constructing one subscribes the listener, and destroying
+// it or calling reset unsubscribes it again. doc is the class's documentation.
+func cppEventListener(m *manifest.Manifest, mapper TypeMapper, event *manifest.Event, doc string) (string, error) {
+	subscribe, unsubscribe := EventMethods(m, event)
+	listenerType, err := mapper.MapParamType(&subscribe.ParamTypes[0])
+	if err != nil {
+		return "", err
+	}
+	name := event.Name + "Listener"
+
+	var sb strings.Builder
+	sb.WriteString(doc)
+	sb.WriteString(fmt.Sprintf("  class %s%s final {\n", cppDeprecatedAttr(event.Deprecated), name))
+	sb.WriteString("  public:\n")
+	sb.WriteString(fmt.Sprintf("    %s() noexcept = default;\n", name))
+	sb.WriteString(fmt.Sprintf("    explicit %s(%s listener) : _listener(listener) {\n", name, listenerType))
+	sb.WriteString(fmt.Sprintf("      %s(listener);\n", subscribe.OverloadName()))
+	sb.WriteString("    }\n")
+	sb.WriteString(fmt.Sprintf("    ~%s() { reset(); }\n\n", name))
+	sb.WriteString(fmt.Sprintf("    %s(const %s&) = delete;\n", name, name))
+	sb.WriteString(fmt.Sprintf("    %s& operator=(const %s&) = delete;\n", name, name))
+	sb.WriteString(fmt.Sprintf("    %s(%s&& other) noexcept : _listener(other._listener) {\n", name, name))
+	sb.WriteString("      other._listener = nullptr;\n")
+	sb.WriteString("    }\n")
+	sb.WriteString(fmt.Sprintf("    %s& operator=(%s&& other) noexcept {\n", name, name))
+	sb.WriteString("      if (this != &other) {\n")
+	sb.WriteString("        reset();\n")
+	sb.WriteString("        _listener = other._listener;\n")
+	sb.WriteString("        other._listener = nullptr;\n")
+	sb.WriteString("      }\n")
+	sb.WriteString("      return *this;\n")
+	sb.WriteString("    }\n\n")
+	sb.WriteString("    // Unsubscribes the listener, if there is one\n")
+	sb.WriteString("    void reset() {\n")
+	sb.WriteString("      if (_listener != nullptr) {\n")
+	sb.WriteString(fmt.Sprintf("        %s(_listener);\n", unsubscribe.OverloadName()))
+	sb.WriteString("        _listener = nullptr;\n")
+	sb.WriteString("      }\n")
+	sb.WriteString("    }\n\n")
+	sb.WriteString("    explicit operator bool() const noexcept { return _listener != nullptr; }\n\n")
+	sb.WriteString("  private:\n")
+	sb.WriteString(fmt.Sprintf("    %s _listener = nullptr;\n", listenerType))
+	sb.WriteString("  };\n")
+	return cppPlatformGuard(subscribe.Platforms, sb.String()), nil
+}
+
+// cppPlatformGuard wraps code in an #if that holds on the given platforms,
+// or leaves it as it is when the list is empty and it exists everywhere.
+// Being the preprocessor's, the guard fits anywhere, modules included.
+func cppPlatformGuard(platforms []string, code string) string {
+	if len(platforms) == 0 {
+		return code
+	}
+	conditions := make([]string, len(platforms))
+	for i, platform := range platforms {
+		conditions[i] = fmt.Sprintf("defined(%s)", cppPlatformMacros[platform])
+	}
+	condition := strings.Join(conditions, " || ")
+	return fmt.Sprintf("#if %s\n%s#endif // %s\n", condition, code, condition)
+}
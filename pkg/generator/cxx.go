@@ -22,6 +22,9 @@ func NewCxxGenerator() *CxxGenerator {
 // Generate generates C++ bindings
 func (g *CxxGenerator) Generate(m *manifest.Manifest, opts *GeneratorOptions) (*GeneratorResult, error) {
 	g.ResetCaches()
+	if err := m.CheckSanitizedNames(g.Sanitizer); err != nil {
+		return nil, err
+	}
 	m.Sanitize(g.Sanitizer)
 	opts = EnsureOptions(opts)
 
@@ -45,6 +48,15 @@ func (g *CxxGenerator) Generate(m *manifest.Manifest, opts *GeneratorOptions) (*
 	}
 	files[fmt.Sprintf("%s/%s/aliases.hpp", folder, m.Name)] = aliasesCode
 
+	// Generate separate structs module, for manifests that use any
+	if len(g.UsedStructs(m)) > 0 {
+		structsCode, err := g.generateStructsFile(m)
+		if err != nil {
+			return nil, fmt.Errorf("generating structs file: %w", err)
+		}
+		files[fmt.Sprintf("%s/structs.ixx", folder)] = structsCode
+	}
+
 	// Generate separate delegates module
 	delegatesCode, err := g.generateDelegatesFile(m)
 	if err != nil {
@@ -86,15 +98,16 @@ func (g *CxxGenerator) generateEnum(enum *manifest.Enum, underlyingType string)
 	if enum.Description != "" {
 		sb.WriteString(fmt.Sprintf("  // %s\n", enum.Description))
 	}
+	sb.WriteString(cppAvailabilityComment(enum.Since, enum.RemovedIn))
 
 	// The attribute belongs after `enum class`, where it appertains to the enum.
 	sb.WriteString(fmt.Sprintf("  enum class %s%s : %s {\n", cppDeprecatedAttr(enum.Deprecated), enum.Name, underlyingType))
 
 	for i, val := range enum.Values {
 		if val.Description != "" {
-			sb.WriteString(fmt.Sprintf("    %s = %d, // %s\n", val.Name, val.Value, val.Description))
+			sb.WriteString(fmt.Sprintf("    %s = %s, // %s\n", val.Name, cppEnumValue(val.Value), val.Description))
 		} else {
-			sb.WriteString(fmt.Sprintf("    %s = %d", val.Name, val.Value))
+			sb.WriteString(fmt.Sprintf("    %s = %s", val.Name, cppEnumValue(val.Value)))
 			if i < len(enum.Values)-1 {
 				sb.WriteString(",")
 			}
@@ -103,9 +116,16 @@ func (g *CxxGenerator) generateEnum(enum *manifest.Enum, underlyingType string)
 	}
 
 	sb.WriteString("  };\n")
+	if enum.Flags {
+		sb.WriteString(cppFlagsOperators(enum.Name, underlyingType))
+	}
 	return sb.String(), nil
 }
 
+func (g *CxxGenerator) generateConstant(constant *manifest.Constant) (string, error) {
+	return cppConstant(g.typeMapper, constant)
+}
+
 func (g *CxxGenerator) generateAliases(m *manifest.Manifest) (string, error) {
 	// Use the base generator's CollectAliases helper
 	return g.CollectAliases(m, g.generateAlias)
@@ -117,6 +137,7 @@ func (g *CxxGenerator) generateAlias(alias *manifest.Alias, underlyingType strin
 	if alias.Description != "" {
 		sb.WriteString(fmt.Sprintf("  // %s\n", alias.Description))
 	}
+	sb.WriteString(cppAvailabilityComment(alias.Since, alias.RemovedIn))
 
 	// As with delegates, the attribute belongs after the alias name.
 	sb.WriteString(fmt.Sprintf("  using %s %s= %s;\n", alias.Name, cppDeprecatedAttr(alias.Deprecated), underlyingType))
@@ -134,6 +155,7 @@ func (g *CxxGenerator) generateDelegate(proto *manifest.Prototype) (string, erro
 	if proto.Description != "" {
 		sb.WriteString(fmt.Sprintf("  // %s\n", proto.Description))
 	}
+	sb.WriteString(cppAvailabilityComment(proto.Since, proto.RemovedIn))
 
 	// Generate return type
 	retType, err := g.typeMapper.MapReturnType(&proto.RetType)
@@ -153,6 +175,38 @@ func (g *CxxGenerator) generateDelegate(proto *manifest.Prototype) (string, erro
 }
 
 // generateDocumentation generates Doxygen-style comments for C++ methods
+func (g *CxxGenerator) generateStructs(m *manifest.Manifest) (string, error) {
+	return g.CollectStructs(m, g.generateStruct)
+}
+
+func (g *CxxGenerator) generateStruct(structure *manifest.Struct) (string, error) {
+	var sb strings.Builder
+
+	if structure.Description != "" {
+		sb.WriteString(fmt.Sprintf("  // %s\n", structure.Description))
+	}
+	sb.WriteString(cppAvailabilityComment(structure.Since, structure.RemovedIn))
+
+	// As with enums, the attribute belongs after `struct`.
+	sb.WriteString(fmt.Sprintf("  struct %s%s {\n", cppDeprecatedAttr(structure.Deprecated), structure.Name))
+
+	for i := range structure.Fields {
+		field := &structure.Fields[i]
+		fieldType, err := g.typeMapper.MapReturnType(field)
+		if err != nil {
+			return "", err
+		}
+		if field.Description != "" {
+			sb.WriteString(fmt.Sprintf("    %s %s; // %s\n", fieldType, field.Name, field.Description))
+		} else {
+			sb.WriteString(fmt.Sprintf("    %s %s;\n", fieldType, field.Name))
+		}
+	}
+
+	sb.WriteString("  };\n")
+	return sb.String(), nil
+}
+
 func (g *CxxGenerator) generateDocumentation(opts DocOptions) string {
 	var sb strings.Builder
 
@@ -195,6 +249,7 @@ func (g *CxxGenerator) generateDocumentation(opts DocOptions) string {
 		}
 		sb.WriteString("\n")
 	}
+	sb.WriteString(cppSinceTags(opts.Indent, opts.Since, opts.RemovedIn))
 
 	sb.WriteString(opts.Indent + " */\n")
 
@@ -231,7 +286,7 @@ func (g *CxxGenerator) generateMethod(method *manifest.Method, pluginName string
 	sb.WriteString(fmt.Sprintf("  PLUGIFY_EXPORT _%s __%s_%s = nullptr;\n", method.Name, pluginName, method.Name))
 
 	// Generate exported wrapper function
-	paramNames, err := FormatParameters(method.ParamTypes, ParamFormatNames, g.typeMapper)
+	paramNames, err := cppCallArguments(method.ParamTypes, g.typeMapper)
 	if err != nil {
 		return "", err
 	}
@@ -240,6 +295,8 @@ func (g *CxxGenerator) generateMethod(method *manifest.Method, pluginName string
 	sb.WriteString(g.generateDocumentation(DocOptions{
 		Description: method.Description,
 		Deprecated:  method.Deprecated,
+		Since:       method.Since,
+		RemovedIn:   method.RemovedIn,
 		Params:      method.ParamTypes,
 		RetType:     method.RetType,
 		Indent:      "  ",
@@ -252,14 +309,18 @@ func (g *CxxGenerator) generateMethod(method *manifest.Method, pluginName string
 		formattedParams += "plg::source_location __location = plg::source_location::current()"
 	}
 
-	sb.WriteString(fmt.Sprintf("\n  %s %s(%s) {\n", retType, method.Name, formattedParams))
+	wrapperRetType := retType
+	if method.RetType.Optional {
+		wrapperRetType = fmt.Sprintf("std::optional<%s>", retType)
+	}
+	sb.WriteString(fmt.Sprintf("\n  %s %s(%s) {\n", wrapperRetType, method.OverloadName(), formattedParams))
 
 	if generateScopes {
 		sb.WriteString(fmt.Sprintf("    [[maybe_unused]] auto __scope = plg::Scope(\"%s::%s\", __location);\n", pluginName, method.Name))
 	}
 
-	if method.RetType.Type == "void" {
-		sb.WriteString(fmt.Sprintf("    return __%s_%s(%s);\n", pluginName, method.Name, paramNames))
+	if method.RetType.Optional {
+		sb.WriteString(cppOptionalReturn("    ", fmt.Sprintf("__%s_%s(%s)", pluginName, method.Name, paramNames)))
 	} else {
 		sb.WriteString(fmt.Sprintf("    return __%s_%s(%s);\n", pluginName, method.Name, paramNames))
 	}
@@ -316,6 +377,8 @@ func (g *CxxGenerator) generateClass(m *manifest.Manifest, class *manifest.Class
 	sb.WriteString(g.generateDocumentation(DocOptions{
 		Description: class.Description,
 		Deprecated:  class.Deprecated,
+		Since:       class.Since,
+		RemovedIn:   class.RemovedIn,
 		Indent:      "  ",
 	}))
 
@@ -374,7 +437,7 @@ func (g *CxxGenerator) generateClass(m *manifest.Manifest, class *manifest.Class
 		if err != nil {
 			return "", err
 		}
-		sb.WriteString(methodCode)
+		sb.WriteString(cppPlatformGuard(binding.Platforms, methodCode))
 		sb.WriteString("\n")
 	}
 
@@ -519,6 +582,8 @@ func (g *CxxGenerator) generateConstructor(m *manifest.Manifest, class *manifest
 	sb.WriteString(g.generateDocumentation(DocOptions{
 		Description: method.Description,
 		Deprecated:  method.Deprecated,
+		Since:       method.Since,
+		RemovedIn:   method.RemovedIn,
 		Params:      method.ParamTypes,
 		Indent:      "    ",
 	}))
@@ -537,7 +602,17 @@ func (g *CxxGenerator) generateConstructor(m *manifest.Manifest, class *manifest
 		return "", err
 	}
 
-	sb.WriteString(fmt.Sprintf("      : %s(%s(%s), %s::Owned) {}\n\n", class.Name, method.FuncName, paramNames, OwnershipEnumName))
+	// An optional handle that is none is the empty handle, so an empty wrapper
+	handle := fmt.Sprintf("%s(%s)", method.OverloadName(), paramNames)
+	if method.RetType.Optional {
+		handleType, err := g.typeMapper.MapReturnType(&method.RetType)
+		if err != nil {
+			return "", err
+		}
+		handle += fmt.Sprintf(".value_or(%s{})", handleType)
+	}
+
+	sb.WriteString(fmt.Sprintf("      : %s(%s, %s::Owned) {}\n\n", class.Name, handle, OwnershipEnumName))
 
 	return sb.String(), nil
 }
@@ -568,6 +643,8 @@ func (g *CxxGenerator) generateBinding(m *manifest.Manifest, class *manifest.Cla
 	sb.WriteString(g.generateDocumentation(DocOptions{
 		Description:  method.Description,
 		Deprecated:   deprecationReason,
+		Since:        binding.Since,
+		RemovedIn:    binding.RemovedIn,
 		Params:       methodParams,
 		RetType:      method.RetType,
 		ParamAliases: binding.ParamAliases,
@@ -581,9 +658,12 @@ func (g *CxxGenerator) generateBinding(m *manifest.Manifest, class *manifest.Cla
 		return "", err
 	}
 
-	// Handle return type alias
+	// Handle return type alias. None becomes an empty wrapper, so only a
+	// plain optional value stays one.
 	if binding.RetAlias != nil && binding.RetAlias.Name != "" {
 		retType = binding.RetAlias.Name
+	} else if method.RetType.Optional {
+		retType = fmt.Sprintf("std::optional<%s>", retType)
 	}
 
 	formattedParams, err := FormatParameters(methodParams, ParamFormatTypesAndNames, g.typeMapper)
@@ -606,10 +686,10 @@ func (g *CxxGenerator) generateBinding(m *manifest.Manifest, class *manifest.Cla
 	// Generate null check if needed
 	nullPolicy := class.NullPolicy
 	if nullPolicy == "" {
-		nullPolicy = "throw"
+		nullPolicy = manifest.NullPolicyThrow
 	}
 
-	if binding.BindSelf && nullPolicy == "throw" {
+	if binding.BindSelf && nullPolicy == manifest.NullPolicyThrow {
 		invalidValue, _, err := g.typeMapper.MapHandleType(class)
 		if err != nil {
 			return "", err
@@ -647,7 +727,7 @@ func (g *CxxGenerator) generateBinding(m *manifest.Manifest, class *manifest.Cla
 
 	// Generate return statement
 	if method.RetType.Type == "void" {
-		sb.WriteString(fmt.Sprintf("      %s::%s(%s);\n", m.Name, method.FuncName, callArgs))
+		sb.WriteString(fmt.Sprintf("      %s::%s(%s);\n", m.Name, method.OverloadName(), callArgs))
 	} else {
 		// Handle return alias
 		if binding.RetAlias != nil && binding.RetAlias.Name != "" {
@@ -659,9 +739,18 @@ func (g *CxxGenerator) generateBinding(m *manifest.Manifest, class *manifest.Cla
 					ownership = fmt.Sprintf(", %s::Borrowed", OwnershipEnumName)
 				}
 			}
-			sb.WriteString(fmt.Sprintf("      return %s(%s::%s(%s)%s);\n", binding.RetAlias.Name, m.Name, method.FuncName, callArgs, ownership)) // always pass ownership just as a tag
+			if method.RetType.Optional {
+				invalidValue, err := retAliasInvalidValue(m, binding, g.typeMapper)
+				if err != nil {
+					return "", err
+				}
+				call := fmt.Sprintf("%s::%s(%s)", m.Name, method.OverloadName(), callArgs)
+				sb.WriteString(cppOptionalAliasReturn("      ", call, binding.RetAlias.Name, invalidValue, ownership))
+			} else {
+				sb.WriteString(fmt.Sprintf("      return %s(%s::%s(%s)%s);\n", binding.RetAlias.Name, m.Name, method.OverloadName(), callArgs, ownership)) // always pass ownership just as a tag
+			}
 		} else {
-			sb.WriteString(fmt.Sprintf("      return %s::%s(%s);\n", m.Name, method.FuncName, callArgs))
+			sb.WriteString(fmt.Sprintf("      return %s::%s(%s);\n", m.Name, method.OverloadName(), callArgs))
 		}
 	}
 
@@ -679,6 +768,9 @@ func (g *CxxGenerator) applyParamAliases(formattedParams string, params []manife
 		if i < len(aliases) && aliases[i] != nil {
 			// Build the parameter type to search for
 			paramType, _ := g.typeMapper.MapParamType(&param)
+			if param.Optional {
+				paramType = g.typeMapper.(OptionalMapper).MapOptionalType(paramType)
+			}
 
 			// Determine the replacement type
 			replacementType := aliases[i].Name
@@ -706,12 +798,29 @@ func (g *CxxGenerator) generateEnumsFile(m *manifest.Manifest) (string, error) {
 	sb.WriteString(fmt.Sprintf("// Generated from %s.pplugin\n\n", m.Name))
 	sb.WriteString(fmt.Sprintf("export module %s.enums;\n\n", m.Name))
 
+	external := g.CollectExternalTypes(m)
+
 	// Global module fragment for standard library includes
-	sb.WriteString("import <cstdint>;\n\n")
+	sb.WriteString("import <cstdint>;\n")
+	if cppHasStringConstant(m) {
+		sb.WriteString("import <string_view>;\n")
+	}
+	for _, plugin := range external.EnumPlugins() {
+		sb.WriteString(fmt.Sprintf("import %s.enums;\n", plugin))
+	}
+	sb.WriteString("\n")
 
 	// Namespace
 	sb.WriteString(fmt.Sprintf("export namespace %s {\n\n", m.Name))
 
+	// Enums from dependencies are brought into this namespace, not redefined
+	for _, plugin := range external.EnumPlugins() {
+		for _, enum := range external.Enums[plugin] {
+			sb.WriteString(fmt.Sprintf("  using %s::%s;\n", plugin, enum.Name))
+		}
+		sb.WriteString("\n")
+	}
+
 	// Generate enums
 	enumsCode, err := g.generateEnums(m)
 	if err != nil {
@@ -722,6 +831,16 @@ func (g *CxxGenerator) generateEnumsFile(m *manifest.Manifest) (string, error) {
 		sb.WriteString("\n")
 	}
 
+	// Generate constants
+	constantsCode, err := g.CollectConstants(m, g.generateConstant)
+	if err != nil {
+		return "", err
+	}
+	if constantsCode != "" {
+		sb.WriteString(constantsCode)
+		sb.WriteString("\n")
+	}
+
 	// Ownership enum (if any class has destructor)
 	sb.WriteString(fmt.Sprintf("  /// %s type for RAII wrappers\n", OwnershipEnumName))
 	sb.WriteString(fmt.Sprintf("  enum class %s : bool { Borrowed, Owned };\n\n", OwnershipEnumName))
@@ -742,6 +861,9 @@ func (g *CxxGenerator) generateAliasesFile(m *manifest.Manifest) (string, error)
 
 	// Global module fragment for standard library includes
 	sb.WriteString("import <cstdint>;\n")
+	if HasKind(m, manifest.KindFixedArray) {
+		sb.WriteString("export import <array>;\n")
+	}
 	sb.WriteString("import <plg>;\n\n")
 
 	// Namespace
@@ -763,6 +885,53 @@ func (g *CxxGenerator) generateAliasesFile(m *manifest.Manifest) (string, error)
 	return sb.String(), nil
 }
 
+// generateStructsFile generates a file containing all struct definitions
+func (g *CxxGenerator) generateStructsFile(m *manifest.Manifest) (string, error) {
+	var sb strings.Builder
+
+	// Module declaration
+	sb.WriteString(fmt.Sprintf("// Generated from %s.pplugin\n\n", m.Name))
+	sb.WriteString(fmt.Sprintf("export module %s.structs;\n\n", m.Name))
+
+	// Import enums module
+	sb.WriteString(fmt.Sprintf("export import %s.enums;\n", m.Name))
+	sb.WriteString(fmt.Sprintf("export import %s.aliases;\n\n", m.Name))
+
+	external := g.CollectExternalTypes(m)
+
+	sb.WriteString("import <cstdint>;\n")
+	sb.WriteString("import <plg>;\n")
+	for _, plugin := range external.StructPlugins() {
+		sb.WriteString(fmt.Sprintf("import %s.structs;\n", plugin))
+	}
+	sb.WriteString("\n")
+
+	// Namespace
+	sb.WriteString(fmt.Sprintf("export namespace %s {\n\n", m.Name))
+
+	// Structs from dependencies are brought into this namespace, not redefined
+	for _, plugin := range external.StructPlugins() {
+		for _, structure := range external.Structs[plugin] {
+			sb.WriteString(fmt.Sprintf("  using %s::%s;\n", plugin, structure.Name))
+		}
+		sb.WriteString("\n")
+	}
+
+	// Generate structs
+	structsCode, err := g.generateStructs(m)
+	if err != nil {
+		return "", err
+	}
+	if structsCode != "" {
+		sb.WriteString(structsCode)
+	}
+
+	// Close namespace
+	sb.WriteString(fmt.Sprintf("} // namespace %s\n", m.Name))
+
+	return sb.String(), nil
+}
+
 // generateDelegatesFile generates a file containing all delegate typedefs
 func (g *CxxGenerator) generateDelegatesFile(m *manifest.Manifest) (string, error) {
 	var sb strings.Builder
@@ -773,14 +942,33 @@ func (g *CxxGenerator) generateDelegatesFile(m *manifest.Manifest) (string, erro
 
 	// Import enums module
 	sb.WriteString(fmt.Sprintf("export import %s.enums;\n", m.Name))
-	sb.WriteString(fmt.Sprintf("export import %s.aliases;\n\n", m.Name))
+	if len(g.UsedStructs(m)) > 0 {
+		sb.WriteString(fmt.Sprintf("export import %s.aliases;\n", m.Name))
+		sb.WriteString(fmt.Sprintf("export import %s.structs;\n\n", m.Name))
+	} else {
+		sb.WriteString(fmt.Sprintf("export import %s.aliases;\n\n", m.Name))
+	}
+
+	external := g.CollectExternalTypes(m)
 
 	sb.WriteString("import <cstdint>;\n")
-	sb.WriteString("import <plg>;\n\n")
+	sb.WriteString("import <plg>;\n")
+	for _, plugin := range external.PrototypePlugins() {
+		sb.WriteString(fmt.Sprintf("import %s.delegates;\n", plugin))
+	}
+	sb.WriteString("\n")
 
 	// Namespace
 	sb.WriteString(fmt.Sprintf("export namespace %s {\n\n", m.Name))
 
+	// Delegates from dependencies are brought into this namespace, not redefined
+	for _, plugin := range external.PrototypePlugins() {
+		for _, proto := range external.Prototypes[plugin] {
+			sb.WriteString(fmt.Sprintf("  using %s::%s;\n", plugin, proto.Name))
+		}
+		sb.WriteString("\n")
+	}
+
 	// Generate delegates
 	delegatesCode, err := g.generateDelegates(m)
 	if err != nil {
@@ -810,6 +998,9 @@ func (g *CxxGenerator) generateGroupFile(m *manifest.Manifest, groupName string,
 	sb.WriteString(fmt.Sprintf("export import %s.delegates;\n\n", m.Name))
 
 	sb.WriteString("import <cstdint>;\n")
+	if HasOptional(m) {
+		sb.WriteString("import <optional>;\n")
+	}
 	sb.WriteString("import <plg>;\n\n")
 
 	// Find which other groups this group depends on (for method calls from classes)
@@ -841,11 +1032,27 @@ func (g *CxxGenerator) generateGroupFile(m *manifest.Manifest, groupName string,
 			if err != nil {
 				return "", fmt.Errorf("failed to generate method %s: %w", method.Name, err)
 			}
-			sb.WriteString(methodCode)
+			sb.WriteString(cppPlatformGuard(method.Platforms, methodCode))
 			sb.WriteString("\n")
 		}
 	}
 
+	// Generate listener handles for the events subscribed in this group
+	for _, event := range EventsInGroup(m, groupName) {
+		listenerCode, err := cppEventListener(m, g.typeMapper, event, g.generateDocumentation(DocOptions{
+			Description: EventSummary(event),
+			RetType:     manifest.RetType{Type: "void"},
+			Since:       event.Since,
+			RemovedIn:   event.RemovedIn,
+			Indent:      "  ",
+		}))
+		if err != nil {
+			return "", fmt.Errorf("failed to generate event %s: %w", event.Name, err)
+		}
+		sb.WriteString(listenerCode)
+		sb.WriteString("\n")
+	}
+
 	// Generate classes for this group (if enabled)
 	if opts.GenerateClasses {
 		for _, class := range m.Classes {
@@ -855,7 +1062,7 @@ func (g *CxxGenerator) generateGroupFile(m *manifest.Manifest, groupName string,
 				if err != nil {
 					return "", fmt.Errorf("failed to generate class %s: %w", class.Name, err)
 				}
-				sb.WriteString(classCode)
+				sb.WriteString(cppPlatformGuard(class.Platforms, classCode))
 				sb.WriteString("\n")
 			}
 		}
@@ -879,6 +1086,9 @@ func (g *CxxGenerator) generateMainHeader(m *manifest.Manifest, groups map[strin
 	// Re-export enums and delegates
 	sb.WriteString(fmt.Sprintf("export import %s.enums;\n", m.Name))
 	sb.WriteString(fmt.Sprintf("export import %s.aliases;\n", m.Name))
+	if len(g.UsedStructs(m)) > 0 {
+		sb.WriteString(fmt.Sprintf("export import %s.structs;\n", m.Name))
+	}
 	sb.WriteString(fmt.Sprintf("export import %s.delegates;\n", m.Name))
 
 	// Re-export all group modules
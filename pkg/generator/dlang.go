@@ -2,6 +2,8 @@ package generator
 
 import (
 	"fmt"
+	"math"
+	"strconv"
 	"strings"
 
 	"github.com/untrustedmodders/plugify-gen/pkg/manifest"
@@ -22,6 +24,9 @@ func NewDlangGenerator() *DlangGenerator {
 // Generate generates D language bindings
 func (g *DlangGenerator) Generate(m *manifest.Manifest, opts *GeneratorOptions) (*GeneratorResult, error) {
 	g.ResetCaches()
+	if err := m.CheckSanitizedNames(g.Sanitizer); err != nil {
+		return nil, err
+	}
 	m.Sanitize(g.Sanitizer)
 	opts = EnsureOptions(opts)
 
@@ -47,6 +52,15 @@ func (g *DlangGenerator) Generate(m *manifest.Manifest, opts *GeneratorOptions)
 	}
 	files[fmt.Sprintf("source/imported/%s/aliases.d", moduleName)] = aliasesCode
 
+	// Structs get their own file, when there are any
+	if len(g.UsedStructs(m)) > 0 {
+		structsCode, err := g.generateStructsFile(m)
+		if err != nil {
+			return nil, fmt.Errorf("generating structs file: %w", err)
+		}
+		files[fmt.Sprintf("source/imported/%s/structs.d", moduleName)] = structsCode
+	}
+
 	// Thirdly, generate delegates file
 	delegateCode, err := g.generateDelegatesFile(m)
 	if err != nil {
@@ -80,6 +94,16 @@ func (g *DlangGenerator) generateEnumsFile(m *manifest.Manifest) (string, error)
 	moduleName := strings.ToLower(m.Name)
 	sb.WriteString(fmt.Sprintf("module imported.%s.enums;\n\n", moduleName))
 
+	// Enums from dependencies are imported by name, which leaves out the
+	// Ownership every module defines
+	external := g.CollectExternalTypes(m)
+	for _, plugin := range external.EnumPlugins() {
+		sb.WriteString(fmt.Sprintf("public import imported.%s.enums : %s;\n", strings.ToLower(plugin), strings.Join(external.EnumNames(plugin), ", ")))
+	}
+	if len(external.Enums) > 0 {
+		sb.WriteString("\n")
+	}
+
 	sb.WriteString("/// Ownership type for RAII wrappers\n")
 	sb.WriteString(fmt.Sprintf("enum %s : bool {\n", OwnershipEnumName))
 	sb.WriteString("\tBorrowed = false,\n")
@@ -93,6 +117,12 @@ func (g *DlangGenerator) generateEnumsFile(m *manifest.Manifest) (string, error)
 	}
 	sb.WriteString(enumsCode)
 
+	constantsCode, err := g.CollectConstants(m, g.generateConstant)
+	if err != nil {
+		return "", fmt.Errorf("generating enums file: %w", err)
+	}
+	sb.WriteString(constantsCode)
+
 	return sb.String(), nil
 }
 
@@ -120,13 +150,43 @@ func (g *DlangGenerator) generateAliases(m *manifest.Manifest) (string, error) {
 	return g.CollectAliases(m, g.generateAlias)
 }
 
+func (g *DlangGenerator) generateStructsFile(m *manifest.Manifest) (string, error) {
+	var sb strings.Builder
+
+	moduleName := strings.ToLower(m.Name)
+	sb.WriteString(fmt.Sprintf("module imported.%s.structs;\n\n", moduleName))
+	sb.WriteString("public import plugify;\n")
+	sb.WriteString(fmt.Sprintf("public import imported.%s.enums;\n", moduleName))
+	external := g.CollectExternalTypes(m)
+	for _, plugin := range external.StructPlugins() {
+		sb.WriteString(fmt.Sprintf("public import imported.%s.structs : %s;\n", strings.ToLower(plugin), strings.Join(external.StructNames(plugin), ", ")))
+	}
+	sb.WriteString("\n")
+
+	structsCode, err := g.CollectStructs(m, g.generateStruct)
+	if err != nil {
+		return "", fmt.Errorf("generating structs file: %w", err)
+	}
+	sb.WriteString(structsCode)
+
+	return sb.String(), nil
+}
+
 func (g *DlangGenerator) generateDelegatesFile(m *manifest.Manifest) (string, error) {
 	var sb strings.Builder
 
 	moduleName := strings.ToLower(m.Name)
 	sb.WriteString(fmt.Sprintf("module imported.%s.delegates;\n\n", moduleName))
 	sb.WriteString(fmt.Sprintf("public import imported.%s.enums;\n", moduleName))
-	sb.WriteString(fmt.Sprintf("public import imported.%s.aliases;\n\n", moduleName))
+	sb.WriteString(fmt.Sprintf("public import imported.%s.aliases;\n", moduleName))
+	if len(g.UsedStructs(m)) > 0 {
+		sb.WriteString(fmt.Sprintf("public import imported.%s.structs;\n", moduleName))
+	}
+	external := g.CollectExternalTypes(m)
+	for _, plugin := range external.PrototypePlugins() {
+		sb.WriteString(fmt.Sprintf("public import imported.%s.delegates : %s;\n", strings.ToLower(plugin), strings.Join(external.PrototypeNames(plugin), ", ")))
+	}
+	sb.WriteString("\n")
 
 	delegatesCode, err := g.generateDelegates(m)
 	if err != nil {
@@ -150,6 +210,9 @@ func (g *DlangGenerator) generatePackageFile(m *manifest.Manifest, moduleName st
 	// Always import enums first
 	sb.WriteString(fmt.Sprintf("public import imported.%s.enums;\n", moduleName))
 	sb.WriteString(fmt.Sprintf("public import imported.%s.aliases;\n", moduleName))
+	if len(g.UsedStructs(m)) > 0 {
+		sb.WriteString(fmt.Sprintf("public import imported.%s.structs;\n", moduleName))
+	}
 	sb.WriteString(fmt.Sprintf("public import imported.%s.delegates;\n", moduleName))
 
 	// Import all group modules
@@ -160,12 +223,102 @@ func (g *DlangGenerator) generatePackageFile(m *manifest.Manifest, moduleName st
 	return sb.String()
 }
 
+func (g *DlangGenerator) generateConstant(constant *manifest.Constant) (string, error) {
+	var sb strings.Builder
+
+	if constant.Description != "" {
+		sb.WriteString(fmt.Sprintf("/// %s\n", constant.Description))
+	}
+	sb.WriteString(dlangHistoryComment(constant.Since, constant.RemovedIn))
+	if constant.Deprecated != "" {
+		sb.WriteString(fmt.Sprintf("deprecated(\"%s\")\n", constant.Deprecated))
+	}
+
+	typeName, err := g.typeMapper.MapType(constant.Type, TypeContextReturn, false)
+	if err != nil {
+		return "", err
+	}
+	sb.WriteString(fmt.Sprintf("const %s %s = %s;\n", typeName, constant.Name, dlangLiteral(constant.Type, constant.Literal)))
+	return sb.String(), nil
+}
+
+// dlangLiteral renders a checked value as a D literal of typeName, for a
+// default or a constant.
+func dlangLiteral(typeName string, value *manifest.DefaultValue) string {
+	switch value.Kind {
+	case manifest.DefaultBool:
+		return strconv.FormatBool(value.Bool)
+	case manifest.DefaultInt:
+		return dlangInteger(manifest.NewInt(value.Int))
+	case manifest.DefaultUint:
+		if typeName == "ptr64" {
+			if value.Uint == 0 {
+				return "null"
+			}
+			return fmt.Sprintf("cast(void*) 0x%x", value.Uint)
+		}
+		return dlangInteger(manifest.NewUint(value.Uint))
+	case manifest.DefaultFloat:
+		literal := floatLiteral(value.Float)
+		if typeName == "float" {
+			literal += "f"
+		}
+		return literal
+	case manifest.DefaultChar:
+		return cLikeCharLiteral(value.Char)
+	}
+	return strconv.Quote(value.String)
+}
+
+// dlangInteger writes value as a D integer literal.
+func dlangInteger(value manifest.Integer) string {
+	signed, ok := value.Int64()
+	if !ok {
+		// An unsuffixed decimal literal must fit a long.
+		return value.String() + "UL"
+	}
+	if signed == math.MinInt64 {
+		// The literal would be the negation of a value long cannot hold.
+		return "long.min"
+	}
+	return strconv.FormatInt(signed, 10)
+}
+
+// groupDigits adds an underscore between each group of three digits of a D
+// integer literal, leaving its sign and suffix alone.
+func groupDigits(literal string) string {
+	start := 0
+	if strings.HasPrefix(literal, "-") {
+		start = 1
+	}
+	end := start
+	for end < len(literal) && literal[end] >= '0' && literal[end] <= '9' {
+		end++
+	}
+	digits := literal[start:end]
+	if len(digits) <= 3 {
+		return literal
+	}
+
+	var sb strings.Builder
+	sb.WriteString(literal[:start])
+	for i, c := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			sb.WriteRune('_')
+		}
+		sb.WriteRune(c)
+	}
+	sb.WriteString(literal[end:])
+	return sb.String()
+}
+
 func (g *DlangGenerator) generateEnum(enum *manifest.Enum, underlyingType string) (string, error) {
 	var sb strings.Builder
 
 	if enum.Description != "" {
 		sb.WriteString(fmt.Sprintf("/// %s\n", enum.Description))
 	}
+	sb.WriteString(dlangHistoryComment(enum.Since, enum.RemovedIn))
 	if enum.Deprecated != "" {
 		sb.WriteString(fmt.Sprintf("deprecated(\"%s\")\n", enum.Deprecated))
 	}
@@ -174,18 +327,7 @@ func (g *DlangGenerator) generateEnum(enum *manifest.Enum, underlyingType string
 
 	for _, val := range enum.Values {
 		// Format the value with underscores for readability (like the legacy version)
-		valueStr := fmt.Sprintf("%d", val.Value)
-		if len(valueStr) > 3 {
-			// Add underscores for thousands separator
-			var formatted strings.Builder
-			for i, c := range valueStr {
-				if i > 0 && (len(valueStr)-i)%3 == 0 {
-					formatted.WriteRune('_')
-				}
-				formatted.WriteRune(c)
-			}
-			valueStr = formatted.String()
-		}
+		valueStr := groupDigits(dlangInteger(val.Value))
 
 		sb.WriteString(fmt.Sprintf("\t%s = %s,", val.Name, valueStr))
 		if val.Description != "" {
@@ -198,12 +340,42 @@ func (g *DlangGenerator) generateEnum(enum *manifest.Enum, underlyingType string
 	return sb.String(), nil
 }
 
+func (g *DlangGenerator) generateStruct(structure *manifest.Struct) (string, error) {
+	var sb strings.Builder
+
+	if structure.Description != "" {
+		sb.WriteString(fmt.Sprintf("/// %s\n", structure.Description))
+	}
+	sb.WriteString(dlangHistoryComment(structure.Since, structure.RemovedIn))
+	if structure.Deprecated != "" {
+		sb.WriteString(fmt.Sprintf("deprecated(\"%s\")\n", structure.Deprecated))
+	}
+
+	sb.WriteString(fmt.Sprintf("extern (C) struct %s {\n", structure.Name))
+	for i := range structure.Fields {
+		field := &structure.Fields[i]
+		fieldType, err := g.typeMapper.MapReturnType(field)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(fmt.Sprintf("\t%s %s;", fieldType, field.Name))
+		if field.Description != "" {
+			sb.WriteString(fmt.Sprintf(" /// %s", field.Description))
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("}\n")
+
+	return sb.String(), nil
+}
+
 func (g *DlangGenerator) generateAlias(alias *manifest.Alias, underlyingType string) (string, error) {
 	var sb strings.Builder
 
 	if alias.Description != "" {
 		sb.WriteString(fmt.Sprintf("/// %s\n", alias.Description))
 	}
+	sb.WriteString(dlangHistoryComment(alias.Since, alias.RemovedIn))
 
 	if alias.Deprecated != "" {
 		sb.WriteString(fmt.Sprintf("deprecated(\"%s\")\n", alias.Deprecated))
@@ -225,6 +397,9 @@ func (g *DlangGenerator) generateModuleFile(m *manifest.Manifest, moduleName, gr
 	sb.WriteString("public import plugify;\n")
 	sb.WriteString(fmt.Sprintf("public import imported.%s.enums;\n", moduleName))
 	sb.WriteString(fmt.Sprintf("public import imported.%s.aliases;\n", moduleName))
+	if len(g.UsedStructs(m)) > 0 {
+		sb.WriteString(fmt.Sprintf("public import imported.%s.structs;\n", moduleName))
+	}
 	sb.WriteString(fmt.Sprintf("public import imported.%s.delegates;\n", moduleName))
 
 	// Find which other groups this group depends on (for method calls from classes)
@@ -249,16 +424,26 @@ func (g *DlangGenerator) generateModuleFile(m *manifest.Manifest, moduleName, gr
 			if err != nil {
 				return "", fmt.Errorf("failed to generate method wrapper %s: %w", method.Name, err)
 			}
-			sb.WriteString(methodCode)
 			aliasCode, err := g.generateMethodAlias(&method, m.Name)
 			if err != nil {
 				return "", fmt.Errorf("failed to generate method alias %s: %w", method.Name, err)
 			}
-			sb.WriteString(aliasCode)
+			sb.WriteString(dlangPlatformVersion(method.Platforms, methodCode+aliasCode))
 			sb.WriteString("\n")
 		}
 	}
 
+	// Generate listener handles for the events subscribed in this group
+	for _, event := range EventsInGroup(m, groupName) {
+		eventCode, err := g.generateEventListener(m, event)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate event %s: %w", event.Name, err)
+		}
+		subscribe, _ := EventMethods(m, event)
+		sb.WriteString(dlangPlatformVersion(subscribe.Platforms, eventCode))
+		sb.WriteString("\n")
+	}
+
 	// Generate classes for this group (if enabled)
 	if opts.GenerateClasses {
 		for _, class := range m.Classes {
@@ -268,7 +453,7 @@ func (g *DlangGenerator) generateModuleFile(m *manifest.Manifest, moduleName, gr
 				if err != nil {
 					return "", fmt.Errorf("failed to generate class %s: %w", class.Name, err)
 				}
-				sb.WriteString(classCode)
+				sb.WriteString(dlangPlatformVersion(class.Platforms, classCode))
 				sb.WriteString("\n")
 			}
 		}
@@ -277,6 +462,58 @@ func (g *DlangGenerator) generateModuleFile(m *manifest.Manifest, moduleName, gr
 	return sb.String(), nil
 }
 
+// generateEventListener renders the RAII handle for an event's listener, named
+// after the event: constructing one subscribes the listener, and destroying it
+// or calling reset unsubscribes it again. Like the classes, it can be moved
+// but not copied.
+func (g *DlangGenerator) generateEventListener(m *manifest.Manifest, event *manifest.Event) (string, error) {
+	subscribe, unsubscribe := EventMethods(m, event)
+	listenerType, err := g.typeMapper.MapParamType(&subscribe.ParamTypes[0])
+	if err != nil {
+		return "", err
+	}
+	name := event.Name + "Listener"
+
+	var sb strings.Builder
+	sb.WriteString(g.generateDocumentation(DocOptions{
+		Description: EventSummary(event),
+		Deprecated:  event.Deprecated,
+		Since:       event.Since,
+		RemovedIn:   event.RemovedIn,
+		RetType:     manifest.RetType{Type: "void"},
+		Indent:      "",
+	}))
+	sb.WriteString(fmt.Sprintf("struct %s {\n", name))
+	sb.WriteString(fmt.Sprintf("\tprivate %s _listener;\n\n", listenerType))
+	sb.WriteString("\t/// Subscribes listener until the handle is destroyed or reset\n")
+	sb.WriteString(fmt.Sprintf("\tthis(%s listener) {\n", listenerType))
+	sb.WriteString(fmt.Sprintf("\t\t%s(listener);\n", subscribe.OverloadName()))
+	sb.WriteString("\t\t_listener = listener;\n")
+	sb.WriteString("\t}\n\n")
+	sb.WriteString("\t~this() {\n")
+	sb.WriteString("\t\treset();\n")
+	sb.WriteString("\t}\n\n")
+	sb.WriteString("\t@disable this(this);\n\n")
+	sb.WriteString("\t/// Unsubscribes the listener, if there is one\n")
+	sb.WriteString("\tvoid reset() {\n")
+	sb.WriteString("\t\tif (_listener !is null) {\n")
+	sb.WriteString(fmt.Sprintf("\t\t\t%s(_listener);\n", unsubscribe.OverloadName()))
+	sb.WriteString("\t\t\t_listener = null;\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("}\n")
+	return sb.String(), nil
+}
+
+// dlangHistoryComment renders Availability as a Ddoc History section for the
+// constants, enums, structs and aliases, whose documentation is a line comment.
+func dlangHistoryComment(since, removedIn string) string {
+	if note := Availability(since, removedIn); note != "" {
+		return fmt.Sprintf("/// History: %s\n", note)
+	}
+	return ""
+}
+
 // generateDocumentation generates DDoc-style comments for D language
 func (g *DlangGenerator) generateDocumentation(opts DocOptions) string {
 	var sb strings.Builder
@@ -310,6 +547,15 @@ func (g *DlangGenerator) generateDocumentation(opts DocOptions) string {
 		}
 	}
 
+	// History section
+	if note := Availability(opts.Since, opts.RemovedIn); note != "" {
+		if len(opts.Params) > 0 || opts.Description != "" || opts.RetType.Type != "void" {
+			sb.WriteString(opts.Indent + "\n")
+		}
+		sb.WriteString(opts.Indent + "\tHistory:\n")
+		sb.WriteString(fmt.Sprintf("%s\t\t%s\n", opts.Indent, note))
+	}
+
 	// Throws section
 	if opts.AddThrows {
 		sb.WriteString(opts.Indent + "\n")
@@ -437,6 +683,8 @@ func (g *DlangGenerator) generateDelegate(proto *manifest.Prototype) (string, er
 	sb.WriteString(g.generateDocumentation(DocOptions{
 		Description: proto.Description,
 		Deprecated:  proto.Deprecated,
+		Since:       proto.Since,
+		RemovedIn:   proto.RemovedIn,
 		Params:      proto.ParamTypes,
 		Indent:      "",
 	}))
@@ -493,6 +741,8 @@ func (g *DlangGenerator) generateMethodWrapper(method *manifest.Method, pluginNa
 	sb.WriteString(g.generateDocumentation(DocOptions{
 		Description: method.Description,
 		Deprecated:  method.Deprecated,
+		Since:       method.Since,
+		RemovedIn:   method.RemovedIn,
 		Params:      method.ParamTypes,
 		RetType:     method.RetType,
 		Indent:      "",
@@ -504,7 +754,7 @@ func (g *DlangGenerator) generateMethodWrapper(method *manifest.Method, pluginNa
 		return "", err
 	}
 
-	sb.WriteString(fmt.Sprintf("%s %s(", retType, method.Name))
+	sb.WriteString(fmt.Sprintf("%s %s(", retType, method.OverloadName()))
 
 	// Parameters
 	var params []string
@@ -515,6 +765,12 @@ func (g *DlangGenerator) generateMethodWrapper(method *manifest.Method, pluginNa
 			return "", err
 		}
 
+		if value, err := formatDefault(g.typeMapper, &param); err != nil {
+			return "", err
+		} else if value != "" {
+			paramName += " = " + value
+		}
+
 		if param.Ref {
 			params = append(params, fmt.Sprintf("ref %s %s", paramType, paramName))
 		} else {
@@ -544,12 +800,13 @@ func (g *DlangGenerator) generateMethodWrapper(method *manifest.Method, pluginNa
 
 		// Check if we need conversion
 		cType, _ := g.toCType(paramType, &manifest.RetType{
-			Type: param.Type,
-			Enum: param.Enum,
+			Type:   param.Type,
+			Enum:   param.Enum,
+			Struct: param.Struct,
 		}, param.Ref)
 
-		if strings.HasPrefix(cType, "ref") && (strings.Contains(cType, "PlgA") || strings.Contains(cType, "PlgS")) {
-			// Need conversion for arrays and strings
+		if strings.HasPrefix(cType, "ref") && (strings.Contains(cType, "PlgA") || strings.Contains(cType, "PlgS") || strings.Contains(cType, "PlgM")) {
+			// Need conversion for arrays, strings and maps
 			varName := fmt.Sprintf("_var%d", i)
 			valType := strings.TrimPrefix(cType, "ref ")
 			conversions.WriteString(fmt.Sprintf("\t%s %s = (%s);\n", valType, varName, paramName))
@@ -616,8 +873,9 @@ func (g *DlangGenerator) generateMethodAlias(method *manifest.Method, pluginName
 		paramType, _ := g.typeMapper.MapParamType(&param)
 
 		cType, _ := g.toCType(paramType, &manifest.RetType{
-			Type: param.Type,
-			Enum: param.Enum,
+			Type:   param.Type,
+			Enum:   param.Enum,
+			Struct: param.Struct,
 		}, param.Ref)
 
 		if strings.HasPrefix(cType, "ref") {
@@ -668,10 +926,29 @@ func (g *DlangGenerator) toCType(nativeType string, typeInfo *manifest.RetType,
 		"PlgV":     "ref PlgV",
 	}
 
-	// Check for array types
-	if strings.HasSuffix(nativeType, "[]") {
-		baseType := strings.TrimSuffix(nativeType, "[]")
-		return fmt.Sprintf("ref PlgA!%s", baseType), nil
+	// An array goes as a PlgA of its elements, a fixed-size array by
+	// reference to its std::array and a map as a PlgM
+	if typeInfo != nil {
+		switch node := typeInfo.TypeNode().Unaliased(); node.Kind {
+		case manifest.KindArray:
+			element, err := g.typeMapper.MapTypeNode(node.Elem, TypeContextValue)
+			if err != nil {
+				return "", err
+			}
+			return "ref PlgA!" + element, nil
+		case manifest.KindFixedArray:
+			return "ref " + nativeType, nil
+		case manifest.KindMap:
+			key, err := g.typeMapper.MapTypeNode(node.Key, TypeContextValue)
+			if err != nil {
+				return "", err
+			}
+			value, err := g.typeMapper.MapTypeNode(node.Elem, TypeContextValue)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("ref PlgM!(%s, %s)", key, value), nil
+		}
 	}
 
 	// Check for vector types
@@ -686,6 +963,11 @@ func (g *DlangGenerator) toCType(nativeType string, typeInfo *manifest.RetType,
 		return cType, nil
 	}
 
+	// Structs travel by reference, the same as vectors
+	if typeInfo != nil && typeInfo.Struct != nil {
+		return "ref " + nativeType, nil
+	}
+
 	// Check type map
 	if cType, ok := typeMap[nativeType]; ok {
 		return cType, nil
@@ -736,6 +1018,8 @@ func (g *DlangGenerator) generateClass(m *manifest.Manifest, class *manifest.Cla
 	sb.WriteString(g.generateDocumentation(DocOptions{
 		Description: class.Description,
 		Deprecated:  class.Deprecated,
+		Since:       class.Since,
+		RemovedIn:   class.RemovedIn,
 		Indent:      "",
 	}))
 
@@ -825,7 +1109,7 @@ func (g *DlangGenerator) generateClass(m *manifest.Manifest, class *manifest.Cla
 		if err != nil {
 			return "", err
 		}
-		sb.WriteString(methodCode)
+		sb.WriteString(dlangPlatformVersion(binding.Platforms, methodCode))
 		sb.WriteString("\n")
 	}
 
@@ -857,6 +1141,8 @@ func (g *DlangGenerator) generateConstructor(m *manifest.Manifest, class *manife
 	sb.WriteString(g.generateDocumentation(DocOptions{
 		Description: description,
 		Deprecated:  method.Deprecated,
+		Since:       method.Since,
+		RemovedIn:   method.RemovedIn,
 		Params:      method.ParamTypes,
 		Indent:      "\t",
 	}))
@@ -873,6 +1159,12 @@ func (g *DlangGenerator) generateConstructor(m *manifest.Manifest, class *manife
 			return "", err
 		}
 
+		if value, err := formatDefault(g.typeMapper, &param); err != nil {
+			return "", err
+		} else if value != "" {
+			paramName += " = " + value
+		}
+
 		if param.Ref {
 			params = append(params, fmt.Sprintf("ref %s %s", paramType, paramName))
 		} else {
@@ -890,7 +1182,7 @@ func (g *DlangGenerator) generateConstructor(m *manifest.Manifest, class *manife
 		callArgs = append(callArgs, paramName)
 	}
 
-	sb.WriteString(fmt.Sprintf("\t\tthis(%s(", method.Name))
+	sb.WriteString(fmt.Sprintf("\t\tthis(%s(", method.OverloadName()))
 	sb.WriteString(strings.Join(callArgs, ", "))
 	sb.WriteString(fmt.Sprintf("), %s.Owned);\n", OwnershipEnumName))
 	sb.WriteString("\t}\n")
@@ -925,6 +1217,8 @@ func (g *DlangGenerator) generateBinding(m *manifest.Manifest, class *manifest.C
 	sb.WriteString(g.generateDocumentation(DocOptions{
 		Description:  method.Description,
 		Deprecated:   deprecationReason,
+		Since:        binding.Since,
+		RemovedIn:    binding.RemovedIn,
 		Params:       methodParams,
 		RetType:      method.RetType,
 		ParamAliases: binding.ParamAliases,
@@ -970,6 +1264,12 @@ func (g *DlangGenerator) generateBinding(m *manifest.Manifest, class *manifest.C
 			}
 		}
 
+		if value, err := formatDefault(g.typeMapper, &param); err != nil {
+			return "", err
+		} else if value != "" {
+			paramName += " = " + value
+		}
+
 		if paramRef {
 			paramStrs = append(paramStrs, fmt.Sprintf("%s %s %s", paramMode, paramType, paramName))
 		} else {
@@ -1031,11 +1331,11 @@ func (g *DlangGenerator) generateBinding(m *manifest.Manifest, class *manifest.C
 				ownership = fmt.Sprintf(", %s.Borrowed", OwnershipEnumName)
 			}
 		}
-		sb.WriteString(fmt.Sprintf("%s(%s(", binding.RetAlias.Name, method.Name))
+		sb.WriteString(fmt.Sprintf("%s(%s(", binding.RetAlias.Name, method.OverloadName()))
 		sb.WriteString(strings.Join(callArgs, ", "))
 		sb.WriteString(fmt.Sprintf(")%s);\n", ownership))
 	} else {
-		sb.WriteString(fmt.Sprintf("%s(", method.Name))
+		sb.WriteString(fmt.Sprintf("%s(", method.OverloadName()))
 		sb.WriteString(strings.Join(callArgs, ", "))
 		sb.WriteString(");\n")
 	}
@@ -1091,54 +1391,45 @@ func (m *DlangTypeMapper) MapType(baseType string, context TypeContext, isArray
 	return mapped, nil
 }
 
+func (m *DlangTypeMapper) MapFixedArrayType(element string, length int, context TypeContext) (string, error) {
+	return fmt.Sprintf("%s[%d]", element, length), nil
+}
+
+func (m *DlangTypeMapper) MapMapType(key, value string, context TypeContext) (string, error) {
+	// D spells an associative array value first
+	return fmt.Sprintf("%s[%s]", value, key), nil
+}
+
+// FormatDefault renders a parameter default as a D literal of the parameter's
+// type, or the enum member it names.
+func (m *DlangTypeMapper) FormatDefault(param *manifest.ParamType) (string, error) {
+	value := param.DefaultValue
+	if value.Kind == manifest.DefaultEnum {
+		return fmt.Sprintf("%s.%s", param.Enum.Name, value.Enum.Name), nil
+	}
+	return dlangLiteral(param.Type, value), nil
+}
+
 func (m *DlangTypeMapper) MapParamType(param *manifest.ParamType) (string, error) {
+	node := param.TypeNode()
+
 	// Regular type mapping
 	ctx := TypeContextValue
 	if param.Ref {
 		ctx = TypeContextRef
 	}
 
-	var typeName string
-	switch {
-	case param.Alias != nil:
-		typeName = param.Alias.Name
-		ctx |= TypeContextAlias
-
-	case param.Enum != nil:
-		typeName = param.Enum.Name
-
-	case param.Prototype != nil:
-		return param.Prototype.Name, nil
-
-	default:
-		typeName = param.BaseType()
-	}
-
-	// Regular type mapping
-	return m.MapType(typeName, ctx, param.IsArray())
+	return m.MapTypeNode(node, ctx)
 }
 
 func (m *DlangTypeMapper) MapReturnType(retType *manifest.RetType) (string, error) {
-	ctx := TypeContextReturn
-
-	var typeName string
-	switch {
-	case retType.Alias != nil:
-		typeName = retType.Alias.Name
-		ctx |= TypeContextAlias
-
-	case retType.Enum != nil:
-		typeName = retType.Enum.Name
-
-	case retType.Prototype != nil:
-		return retType.Prototype.Name, nil
-
-	default:
-		typeName = retType.BaseType()
-	}
-
 	// Regular type mapping
-	return m.MapType(typeName, ctx, retType.IsArray())
+	return m.MapTypeNode(retType.TypeNode(), TypeContextReturn)
+}
+
+// MapTypeNode implements TypeMapper.
+func (m *DlangTypeMapper) MapTypeNode(node *manifest.TypeNode, context TypeContext) (string, error) {
+	return mapTypeNode(m, node, context)
 }
 
 func (m *DlangTypeMapper) MapHandleType(class *manifest.Class) (string, string, error) {
@@ -1161,3 +1452,25 @@ func (m *DlangTypeMapper) MapHandleType(class *manifest.Class) (string, string,
 
 	return invalidValue, handleType, err
 }
+
+// dlangPlatformVersions are the predefined versions of each platform
+var dlangPlatformVersions = map[string]string{
+	manifest.PlatformWindows: "Windows",
+	manifest.PlatformLinux:   "linux",
+	manifest.PlatformMacOS:   "OSX",
+}
+
+// dlangPlatformVersion puts code in a version block for the given platforms,
+// or leaves it as it is when the list is empty and it exists everywhere. A
+// version condition names a single version, so code for several platforms is
+// repeated in a chain of them, of which only one is ever compiled.
+func dlangPlatformVersion(platforms []string, code string) string {
+	if len(platforms) == 0 {
+		return code
+	}
+	blocks := make([]string, len(platforms))
+	for i, platform := range platforms {
+		blocks[i] = fmt.Sprintf("version (%s)\n{\n%s}", dlangPlatformVersions[platform], code)
+	}
+	return strings.Join(blocks, "\nelse ") + "\n"
+}
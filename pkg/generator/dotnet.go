@@ -2,6 +2,8 @@ package generator
 
 import (
 	"fmt"
+	"math"
+	"strconv"
 	"strings"
 
 	"github.com/untrustedmodders/plugify-gen/pkg/manifest"
@@ -25,6 +27,9 @@ func NewDotnetGenerator() *DotnetGenerator {
 // Generate generates .NET bindings
 func (g *DotnetGenerator) Generate(m *manifest.Manifest, opts *GeneratorOptions) (*GeneratorResult, error) {
 	g.ResetCaches()
+	if err := m.CheckSanitizedNames(g.Sanitizer); err != nil {
+		return nil, err
+	}
 	m.Sanitize(g.Sanitizer)
 	opts = EnsureOptions(opts)
 
@@ -47,6 +52,16 @@ func (g *DotnetGenerator) Generate(m *manifest.Manifest, opts *GeneratorOptions)
 	}
 	files[fmt.Sprintf("imported/%s/aliases.cs", m.Name)] = aliasesCode
 
+	// Generate separate structs file, for manifests that use any or return a
+	// fixed-size array
+	if len(g.UsedStructs(m)) > 0 || g.hasFixedArrayReturn(m) {
+		structsCode, err := g.generateStructsFile(m)
+		if err != nil {
+			return nil, fmt.Errorf("generating structs file: %w", err)
+		}
+		files[fmt.Sprintf("imported/%s/structs.cs", m.Name)] = structsCode
+	}
+
 	// Generate separate delegates file
 	delegatesCode, err := g.generateDelegatesFile(m)
 	if err != nil {
@@ -102,6 +117,11 @@ func (g *DotnetGenerator) generateDocumentation(opts DocOptions) string {
 			opts.Indent, opts.RetType.Description))
 	}
 
+	// Remarks
+	if note := Availability(opts.Since, opts.RemovedIn); note != "" {
+		sb.WriteString(fmt.Sprintf("%s/// <remarks>%s.</remarks>\n", opts.Indent, note))
+	}
+
 	// Add deprecation attribute if present
 	if opts.Deprecated != "" {
 		sb.WriteString(fmt.Sprintf("%s[Obsolete(\"%s\", true)]\n", opts.Indent, opts.Deprecated))
@@ -145,20 +165,86 @@ func (g *DotnetGenerator) generateEnums(m *manifest.Manifest) (string, error) {
 	return g.CollectEnums(m, g.generateEnum)
 }
 
+// dotnetConstantTypes are the C# types of constants whose type MapType gives
+// as a marshalling struct, which a const cannot hold.
+var dotnetConstantTypes = map[string]string{
+	"bool":   "bool",
+	"char8":  "sbyte",
+	"char16": "char",
+}
+
+func (g *DotnetGenerator) generateConstant(constant *manifest.Constant) (string, error) {
+	var sb strings.Builder
+
+	if constant.Description != "" || constant.Deprecated != "" || constant.Since != "" || constant.RemovedIn != "" {
+		sb.WriteString(g.generateDocumentation(DocOptions{
+			Indent:     "\t\t",
+			Summary:    constant.Description,
+			Deprecated: constant.Deprecated,
+			Since:      constant.Since,
+			RemovedIn:  constant.RemovedIn,
+		}))
+	}
+
+	typeName, ok := dotnetConstantTypes[constant.Type]
+	if !ok {
+		var err error
+		if typeName, err = g.typeMapper.MapType(constant.Type, TypeContextReturn, false); err != nil {
+			return "", err
+		}
+	}
+	sb.WriteString(fmt.Sprintf("\t\tpublic const %s %s = %s;\n", typeName, constant.Name, dotnetLiteral(constant)))
+	return sb.String(), nil
+}
+
+// dotnetLiteral renders a constant's checked value as a C# constant expression.
+func dotnetLiteral(constant *manifest.Constant) string {
+	value := constant.Literal
+	switch value.Kind {
+	case manifest.DefaultBool:
+		return strconv.FormatBool(value.Bool)
+	case manifest.DefaultInt:
+		if value.Int == math.MinInt64 {
+			return "long.MinValue"
+		}
+		return strconv.FormatInt(value.Int, 10)
+	case manifest.DefaultUint:
+		return strconv.FormatUint(value.Uint, 10)
+	case manifest.DefaultFloat:
+		literal := floatLiteral(value.Float)
+		if constant.Type == "float" {
+			literal += "f"
+		}
+		return literal
+	case manifest.DefaultChar:
+		if constant.Type == "char8" {
+			return "(sbyte)" + cLikeCharLiteral(value.Char)
+		}
+		return cLikeCharLiteral(value.Char)
+	}
+	return strconv.Quote(value.String)
+}
+
 func (g *DotnetGenerator) generateEnum(enum *manifest.Enum, underlyingType string) (string, error) {
 	var sb strings.Builder
 
 	// XML documentation. Also entered for a bare deprecation, since the
 	// [Obsolete] attribute is emitted from here.
-	if enum.Description != "" || enum.Deprecated != "" {
+	if enum.Description != "" || enum.Deprecated != "" || enum.Since != "" || enum.RemovedIn != "" {
 		sb.WriteString(g.generateDocumentation(DocOptions{
 			Indent:     "\t",
 			Summary:    enum.Description,
 			Deprecated: enum.Deprecated,
+			Since:      enum.Since,
+			RemovedIn:  enum.RemovedIn,
 		}))
 	}
 
-	sb.WriteString(fmt.Sprintf("\n\t[Flags]\tpublic enum %s : %s\n\t{\n", enum.Name, underlyingType))
+	sb.WriteString("\n")
+	if enum.Flags {
+		sb.WriteString("\t[Flags]\n")
+	}
+	sb.WriteString(fmt.Sprintf("\tpublic enum %s : %s\n\t{\n", enum.Name, underlyingType))
 
 	for i, val := range enum.Values {
 		if val.Description != "" {
@@ -178,6 +264,81 @@ func (g *DotnetGenerator) generateEnum(enum *manifest.Enum, underlyingType strin
 	return sb.String(), nil
 }
 
+func (g *DotnetGenerator) generateStructs(m *manifest.Manifest) (string, error) {
+	return g.CollectStructs(m, g.generateStruct)
+}
+
+// hasFixedArrayReturn reports whether any method of m returns a fixed-size
+// array, which needs an inline array struct to come back in.
+func (g *DotnetGenerator) hasFixedArrayReturn(m *manifest.Manifest) bool {
+	for i := range m.Methods {
+		if g.typeMapper.isFixedArray(&m.Methods[i].RetType) {
+			return true
+		}
+	}
+	return false
+}
+
+// generateFixedArrayStructs declares an inline array struct for every
+// fixed-size array type a method returns, laid out as the std::array C++
+// returns by value.
+func (g *DotnetGenerator) generateFixedArrayStructs(m *manifest.Manifest) string {
+	var sb strings.Builder
+	declared := map[string]bool{}
+	for i := range m.Methods {
+		ret := &m.Methods[i].RetType
+		if !g.typeMapper.isFixedArray(ret) {
+			continue
+		}
+		name, _ := g.typeMapper.fixedArrayStruct(ret)
+		if declared[name] {
+			continue
+		}
+		declared[name] = true
+		element, _ := g.typeMapper.fixedArrayElement(ret)
+		sb.WriteString(fmt.Sprintf("\t[System.Runtime.CompilerServices.InlineArray(%d)]\n", ret.TypeNode().Unaliased().Len))
+		sb.WriteString(fmt.Sprintf("\tinternal struct %s\n\t{\n\t\tprivate %s _element0;\n\t}\n\n", name, element))
+	}
+	return sb.String()
+}
+
+func (g *DotnetGenerator) generateStruct(structure *manifest.Struct) (string, error) {
+	var sb strings.Builder
+
+	// XML documentation. Also entered for a bare deprecation, since the
+	// [Obsolete] attribute is emitted from here.
+	if structure.Description != "" || structure.Deprecated != "" || structure.Since != "" || structure.RemovedIn != "" {
+		sb.WriteString(g.generateDocumentation(DocOptions{
+			Indent:     "\t",
+			Summary:    structure.Description,
+			Deprecated: structure.Deprecated,
+			Since:      structure.Since,
+			RemovedIn:  structure.RemovedIn,
+		}))
+	}
+
+	// Sequential layout keeps the fields where the C++ side has them
+	sb.WriteString(fmt.Sprintf("\t[StructLayout(LayoutKind.Sequential)]\n\tpublic struct %s\n\t{\n", structure.Name))
+
+	for i := range structure.Fields {
+		field := &structure.Fields[i]
+		if field.Description != "" {
+			sb.WriteString(g.generateDocumentation(DocOptions{
+				Indent:  "\t\t",
+				Summary: field.Description,
+			}))
+		}
+		fieldType, err := g.typeMapper.MapReturnType(field)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(fmt.Sprintf("\t\tpublic %s %s;\n", fieldType, field.Name))
+	}
+
+	sb.WriteString("\t}\n")
+	return sb.String(), nil
+}
+
 func (g *DotnetGenerator) generateAliases(m *manifest.Manifest) (string, error) {
 	return g.CollectAliases(m, g.generateAlias)
 }
@@ -186,10 +347,12 @@ func (g *DotnetGenerator) generateAlias(alias *manifest.Alias, underlyingType st
 	var sb strings.Builder
 
 	// XML documentation
-	if alias.Description != "" {
+	if alias.Description != "" || alias.Since != "" || alias.RemovedIn != "" {
 		sb.WriteString(g.generateDocumentation(DocOptions{
-			Indent:  "\t",
-			Summary: alias.Description,
+			Indent:    "\t",
+			Summary:   alias.Description,
+			Since:     alias.Since,
+			RemovedIn: alias.RemovedIn,
 		}))
 	}
 
@@ -215,11 +378,13 @@ func (g *DotnetGenerator) generateDelegate(proto *manifest.Prototype) (string, e
 
 	// XML documentation. Also entered for a bare deprecation, since the
 	// [Obsolete] attribute is emitted from here.
-	if proto.Description != "" || proto.Deprecated != "" {
+	if proto.Description != "" || proto.Deprecated != "" || proto.Since != "" || proto.RemovedIn != "" {
 		sb.WriteString(g.generateDocumentation(DocOptions{
 			Indent:     "\t",
 			Summary:    proto.Description,
 			Deprecated: proto.Deprecated,
+			Since:      proto.Since,
+			RemovedIn:  proto.RemovedIn,
 		}))
 	}
 
@@ -281,7 +446,7 @@ func (g *DotnetGenerator) generateMethod(method *manifest.Method, pluginName str
 	sb.WriteString(fmt.Sprintf("\t\tprivate static delegate* unmanaged[Cdecl]<%s> __%s;\n", unmanagedTypes, methodName))
 
 	// Wrapper method signature
-	params, err := g.formatMethodParameters(method.ParamTypes)
+	params, err := g.formatMethodParameters(method.ParamTypes, false)
 	if err != nil {
 		return "", err
 	}
@@ -306,12 +471,20 @@ func (g *DotnetGenerator) generateMethod(method *manifest.Method, pluginName str
 	sb.WriteString(fmt.Sprintf("#endregion %s\n", methodName))
 
 	sb.WriteString(g.generateDocumentation(DocOptions{
-		Indent:  "\t\t",
-		Summary: summary,
-		Params:  method.ParamTypes,
-		RetType: method.RetType,
+		Indent:    "\t\t",
+		Summary:   summary,
+		Since:     method.Since,
+		RemovedIn: method.RemovedIn,
+		Params:    method.ParamTypes,
+		RetType:   method.RetType,
 	}))
 
+	// The public wrapper takes and returns optional values as nullable ones
+	params, err = g.formatMethodParameters(method.ParamTypes, true)
+	if err != nil {
+		return "", err
+	}
+
 	if generateScopes {
 		if len(params) > 0 {
 			params += ", "
@@ -319,7 +492,12 @@ func (g *DotnetGenerator) generateMethod(method *manifest.Method, pluginName str
 		params += "[CallerMemberName] string callerFunction = \"\", [CallerFilePath] string callerFile = \"\", [CallerLineNumber] int callerLine = 0"
 	}
 
-	sb.WriteString(fmt.Sprintf("\t\tinternal static %s %s(%s)\n", retType, methodName, params))
+	wrapperRetType := retType
+	if method.RetType.Optional {
+		wrapperRetType += "?"
+	}
+
+	sb.WriteString(fmt.Sprintf("\t\tinternal static %s %s(%s)\n", wrapperRetType, method.OverloadName(), params))
 	sb.WriteString("\t\t{\n")
 
 	// Generate exported wrapper function
@@ -332,7 +510,10 @@ func (g *DotnetGenerator) generateMethod(method *manifest.Method, pluginName str
 		sb.WriteString(fmt.Sprintf("\t\t\tusing var scope = new Scope(\"%s::%s\", callerLine, callerFile, callerFunction, callerModule);\n", pluginName, methodName))
 	}
 
-	if method.RetType.Type != "void" {
+	if method.RetType.Optional && method.RetType.Prototype == nil {
+		sb.WriteString(fmt.Sprintf("\t\t\tvar __result = _%s(%s);\n", methodName, paramNames))
+		sb.WriteString(fmt.Sprintf("\t\t\treturn %s ? null : __result;\n", dotnetIsEmpty(&method.RetType, "__result")))
+	} else if method.RetType.Type != "void" {
 		sb.WriteString(fmt.Sprintf("\t\t\treturn _%s(%s);\n", methodName, paramNames))
 	} else {
 		sb.WriteString(fmt.Sprintf("\t\t\t_%s(%s);\n", methodName, paramNames))
@@ -383,21 +564,46 @@ func (g *DotnetGenerator) formatUnmanagedTypes(method *manifest.Method) (string,
 	return retType, nil
 }
 
-func (g *DotnetGenerator) formatMethodParameters(params []manifest.ParamType) (string, error) {
-	return g.formatParameters(params, func(_ int, param *manifest.ParamType) (string, error) {
+// formatDefaults renders the default of each of params, or "" for one that
+// has none or whose default C# cannot express. C# wants the defaulted
+// parameters last, so the defaults before one it cannot express go too.
+func (g *DotnetGenerator) formatDefaults(params []manifest.ParamType) ([]string, error) {
+	defaults := make([]string, len(params))
+	for i := range params {
+		if params[i].DefaultValue == nil {
+			continue
+		}
+		value, err := g.typeMapper.FormatDefault(&params[i])
+		if err != nil {
+			return nil, err
+		}
+		if value == "" {
+			clear(defaults[:i])
+		}
+		defaults[i] = value
+	}
+	return defaults, nil
+}
+
+// formatMethodParameters formats params as declared. With nullable, an
+// optional parameter is declared nullable, as callers of a wrapper see it.
+func (g *DotnetGenerator) formatMethodParameters(params []manifest.ParamType, nullable bool) (string, error) {
+	defaults, err := g.formatDefaults(params)
+	if err != nil {
+		return "", err
+	}
+	return g.formatParameters(params, func(i int, param *manifest.ParamType) (string, error) {
 		typeName, err := g.typeMapper.MapParamType(param)
 		if err != nil {
 			return "", err
 		}
+		if nullable && param.Optional {
+			typeName += "?"
+		}
 
 		result := typeName + " " + param.Name
-		if param.Default != nil {
-			switch v := (*param.Default).(type) {
-			case string:
-				result += fmt.Sprintf(" = %q", v)
-			default:
-				result += fmt.Sprintf(" = %v", v)
-			}
+		if defaults[i] != "" {
+			result += " = " + defaults[i]
 		}
 		return result, nil
 	})
@@ -410,10 +616,57 @@ func (g *DotnetGenerator) formatCallParameters(params []manifest.ParamType) (str
 			result = "ref "
 		}
 		result += param.Name
+		if param.Optional {
+			result += dotnetNoneValue(param)
+		}
 		return result, nil
 	})
 }
 
+// dotnetPlatformSymbols are the symbols defined when building for each
+// platform. The SDK defines WINDOWS and MACOS for OS-specific target
+// frameworks; a project built for Linux defines LINUX itself.
+var dotnetPlatformSymbols = map[string]string{
+	manifest.PlatformWindows: "WINDOWS",
+	manifest.PlatformLinux:   "LINUX",
+	manifest.PlatformMacOS:   "MACOS",
+}
+
+// dotnetPlatformGuard wraps code in an #if that holds on the given platforms,
+// or leaves it as it is when the list is empty and it exists everywhere.
+func dotnetPlatformGuard(platforms []string, code string) string {
+	if len(platforms) == 0 {
+		return code
+	}
+	symbols := make([]string, len(platforms))
+	for i, platform := range platforms {
+		symbols[i] = dotnetPlatformSymbols[platform]
+	}
+	return fmt.Sprintf("#if %s\n%s#endif\n", strings.Join(symbols, " || "), code)
+}
+
+// dotnetNoneValue is what a null optional argument is replaced with: the
+// empty value that stands for none. A null delegate already marshals as a
+// null function pointer.
+func dotnetNoneValue(param *manifest.ParamType) string {
+	switch {
+	case param.Prototype != nil:
+		return "!"
+	case param.Alias == nil && param.Type == "string":
+		return " ?? string.Empty"
+	}
+	return " ?? default"
+}
+
+// dotnetIsEmpty is the condition under which the value of an optional return
+// held in name means none.
+func dotnetIsEmpty(ret *manifest.RetType, name string) string {
+	if ret.Alias == nil && ret.Type == "string" {
+		return fmt.Sprintf("string.IsNullOrEmpty(%s)", name)
+	}
+	return name + " == default"
+}
+
 // methodBodyContext holds the context for generating a method body
 type methodBodyContext struct {
 	needsMarshaling  bool
@@ -484,14 +737,14 @@ func (g *DotnetGenerator) initializeMethodContext(method *manifest.Method) *meth
 		indent:           "\t\t\t",
 		needsMarshaling:  g.needsMarshaling(method),
 		hasReturn:        method.RetType.Type != "void",
-		isObjectReturn:   g.typeMapper.isObjectReturn(method.RetType.Type),
+		isObjectReturn:   g.typeMapper.isObjectProperty(&method.RetType) || g.typeMapper.isFixedArray(&method.RetType),
 		isFunctionReturn: g.typeMapper.isFunction(method.RetType.Type),
 		methodName:       method.Name,
 	}
 
 	// Check if we'll have fixed blocks
 	for _, param := range method.ParamTypes {
-		if param.Ref && !g.typeMapper.isObjectReturn(param.Type) {
+		if (param.Ref && !g.typeMapper.isObjectProperty(&param)) || g.typeMapper.isFixedArray(&param) {
 			ctx.hasFixedBlocks = true
 			break
 		}
@@ -515,6 +768,12 @@ func (g *DotnetGenerator) declareMethodVariables(method *manifest.Method, sb *st
 	// Declare native return variable for object returns
 	if ctx.isObjectReturn {
 		nativeRetType := g.typeMapper.getNativeReturnType(method.RetType.Type)
+		switch method.RetType.TypeNode().Unaliased().Kind {
+		case manifest.KindFixedArray:
+			nativeRetType, _ = g.typeMapper.fixedArrayStruct(&method.RetType)
+		case manifest.KindMap:
+			nativeRetType = "Map192"
+		}
 		sb.WriteString(fmt.Sprintf("%s%s __retVal_native;\n", ctx.indent, nativeRetType))
 	}
 
@@ -596,12 +855,12 @@ func (g *DotnetGenerator) generateReturnStatement(method *manifest.Method, sb *s
 }
 
 func (g *DotnetGenerator) needsMarshaling(method *manifest.Method) bool {
-	if g.typeMapper.isObjectReturn(method.RetType.Type) {
+	if g.typeMapper.isObjectProperty(&method.RetType) {
 		return true
 	}
 
 	for _, param := range method.ParamTypes {
-		if g.typeMapper.isObjectReturn(param.Type) {
+		if g.typeMapper.isObjectProperty(&param) {
 			return true
 		}
 	}
@@ -613,13 +872,24 @@ func (g *DotnetGenerator) generateFixedBlocks(method *manifest.Method, indent st
 	var blocks []string
 
 	for _, param := range method.ParamTypes {
+		// A fixed-size array is pinned and passed as its first element, once
+		// it is known to hold as many as native code reads
+		if g.typeMapper.isFixedArray(&param) {
+			element, _ := g.typeMapper.fixedArrayElement(&param)
+			length := param.TypeNode().Unaliased().Len
+			block := fmt.Sprintf("%sif (%s.Length != %d) throw new ArgumentException(\"%s must hold %d elements\", nameof(%s));\n", indent, param.Name, length, param.Name, length, param.Name)
+			block += fmt.Sprintf("%sfixed(%s* __%s = %s) {\n", indent, element, param.Name, param.Name)
+			blocks = append(blocks, block)
+			continue
+		}
+
 		// Only create fixed blocks for ref parameters that don't need marshaling (primitives, POD, enums)
 		if !param.Ref {
 			continue
 		}
 
 		// Skip object types (they need marshaling, not fixed blocks)
-		if g.typeMapper.isObjectReturn(param.Type) {
+		if g.typeMapper.isObjectProperty(&param) {
 			continue
 		}
 
@@ -628,8 +898,10 @@ func (g *DotnetGenerator) generateFixedBlocks(method *manifest.Method, indent st
 
 		if param.Enum != nil {
 			typeName = param.Enum.Name
+		} else if param.Struct != nil {
+			typeName = param.Struct.Name
 		} else {
-			typeName, _ = g.typeMapper.MapType(param.BaseType(), TypeContextValue, false)
+			typeName, _ = g.typeMapper.MapType(param.TypeNode().Value().Name, TypeContextValue, false)
 		}
 
 		block := fmt.Sprintf("%sfixed(%s* __%s = &%s) {\n", indent, typeName, paramName, paramName)
@@ -641,6 +913,11 @@ func (g *DotnetGenerator) generateFixedBlocks(method *manifest.Method, indent st
 
 func (g *DotnetGenerator) generateParameterMarshaling(method *manifest.Method, indent string) string {
 	codes := g.processParameters(method.ParamTypes, func(param *manifest.ParamType) string {
+		if param.TypeNode().Unaliased().Kind == manifest.KindMap {
+			typeArgs, _ := g.typeMapper.mapTypeArgs(param)
+			return fmt.Sprintf("%svar __%s = NativeMethods.ConstructMap<%s>(%s);\n", indent, param.Name, typeArgs, param.Name)
+		}
+
 		constructor := g.typeMapper.getConstructor(param.Type)
 		if constructor == "" {
 			return ""
@@ -670,7 +947,9 @@ func (g *DotnetGenerator) generateCallParameters(method *manifest.Method) string
 	params := g.processParameters(method.ParamTypes, func(param *manifest.ParamType) string {
 		paramName := param.Name
 
-		if g.typeMapper.isObjectReturn(param.Type) {
+		if g.typeMapper.isFixedArray(param) {
+			return fmt.Sprintf("__%s", paramName)
+		} else if g.typeMapper.isObjectProperty(param) {
 			return fmt.Sprintf("&__%s", paramName)
 		} else if g.typeMapper.isPODType(param.Type) && param.Ref {
 			return fmt.Sprintf("__%s", paramName)
@@ -691,7 +970,15 @@ func (g *DotnetGenerator) generateUnmarshaling(method *manifest.Method, indent s
 	var sb strings.Builder
 
 	// Unmarshal return value
-	if g.typeMapper.isObjectReturn(method.RetType.Type) {
+	switch method.RetType.TypeNode().Unaliased().Kind {
+	case manifest.KindFixedArray:
+		element, _ := g.typeMapper.fixedArrayElement(&method.RetType)
+		sb.WriteString(fmt.Sprintf("%s__retVal = ((ReadOnlySpan<%s>)__retVal_native).ToArray();\n", indent, element))
+	case manifest.KindMap:
+		typeArgs, _ := g.typeMapper.mapTypeArgs(&method.RetType)
+		sb.WriteString(fmt.Sprintf("%s__retVal = NativeMethods.GetMapData<%s>(&__retVal_native);\n", indent, typeArgs))
+	}
+	if g.typeMapper.isObjectProperty(&method.RetType) {
 		converter := g.typeMapper.getDataConverter(method.RetType.Type)
 		if converter != "" {
 			// Use NativeMethodsT for enum parameters
@@ -701,12 +988,9 @@ func (g *DotnetGenerator) generateUnmarshaling(method *manifest.Method, indent s
 
 			if strings.Contains(converter, "VectorData") {
 				sizeFunc := g.typeMapper.getSizeFunction(method.RetType.Type)
-				retType := method.RetType
-				retType.Alias = nil
-				retTypeName, _ := g.typeMapper.MapReturnType(&retType)
-				// Remove [] suffix for array initialization
-				baseType := strings.TrimSuffix(retTypeName, "[]")
-				sb.WriteString(fmt.Sprintf("%s__retVal = new %s[%s(&__retVal_native)];\n", indent, baseType, sizeFunc))
+				// The element type, whatever alias the array goes by
+				element, _ := g.typeMapper.MapTypeNode(method.RetType.TypeNode().Unaliased().Elem, TypeContextReturn)
+				sb.WriteString(fmt.Sprintf("%s__retVal = new %s[%s(&__retVal_native)];\n", indent, element, sizeFunc))
 				sb.WriteString(fmt.Sprintf("%s%s(&__retVal_native, __retVal);\n", indent, converter))
 			} else {
 				sb.WriteString(fmt.Sprintf("%s__retVal = %s(&__retVal_native);\n", indent, converter))
@@ -720,6 +1004,11 @@ func (g *DotnetGenerator) generateUnmarshaling(method *manifest.Method, indent s
 			return ""
 		}
 
+		if param.TypeNode().Unaliased().Kind == manifest.KindMap {
+			typeArgs, _ := g.typeMapper.mapTypeArgs(param)
+			return fmt.Sprintf("%s%s = NativeMethods.GetMapData<%s>(&__%s);\n", indent, param.Name, typeArgs, param.Name)
+		}
+
 		converter := g.typeMapper.getDataConverter(param.Type)
 		if converter == "" {
 			return ""
@@ -751,7 +1040,11 @@ func (g *DotnetGenerator) generateCleanup(method *manifest.Method, indent string
 	var sb strings.Builder
 
 	// Cleanup return value
-	if g.typeMapper.isObjectReturn(method.RetType.Type) {
+	if method.RetType.TypeNode().Unaliased().Kind == manifest.KindMap {
+		typeArgs, _ := g.typeMapper.mapTypeArgs(&method.RetType)
+		sb.WriteString(fmt.Sprintf("%sNativeMethods.DestroyMap<%s>(&__retVal_native);\n", indent, typeArgs))
+	}
+	if g.typeMapper.isObjectProperty(&method.RetType) {
 		destructor := g.typeMapper.getDestructor(method.RetType.Type)
 		if destructor != "" {
 			sb.WriteString(fmt.Sprintf("%s%s(&__retVal_native);\n", indent, destructor))
@@ -760,6 +1053,11 @@ func (g *DotnetGenerator) generateCleanup(method *manifest.Method, indent string
 
 	// Cleanup parameters
 	codes := g.processParameters(method.ParamTypes, func(param *manifest.ParamType) string {
+		if param.TypeNode().Unaliased().Kind == manifest.KindMap {
+			typeArgs, _ := g.typeMapper.mapTypeArgs(param)
+			return fmt.Sprintf("%sNativeMethods.DestroyMap<%s>(&__%s);\n", indent, typeArgs, param.Name)
+		}
+
 		destructor := g.typeMapper.getDestructor(param.Type)
 		if destructor == "" {
 			return ""
@@ -826,6 +1124,8 @@ func (g *DotnetGenerator) generateClass(m *manifest.Manifest, class *manifest.Cl
 	}
 	sb.WriteString(g.generateDocumentation(DocOptions{
 		Deprecated: class.Deprecated,
+		Since:      class.Since,
+		RemovedIn:  class.RemovedIn,
 		Indent:     "\t",
 		Summary:    summary,
 	}))
@@ -979,7 +1279,7 @@ func (g *DotnetGenerator) generateClass(m *manifest.Manifest, class *manifest.Cl
 		if err != nil {
 			return "", err
 		}
-		sb.WriteString(bindingCode)
+		sb.WriteString(dotnetPlatformGuard(binding.Platforms, bindingCode))
 	}
 
 	sb.WriteString("\t}\n\n")
@@ -987,6 +1287,45 @@ func (g *DotnetGenerator) generateClass(m *manifest.Manifest, class *manifest.Cl
 	return sb.String(), nil
 }
 
+// generateEventSubscription renders the IDisposable subscription for an
+// event, named after it: constructing one subscribes the listener and
+// disposing of it unsubscribes it again. Holding the delegate also keeps it
+// from being collected while the plugin may still call it.
+func (g *DotnetGenerator) generateEventSubscription(m *manifest.Manifest, event *manifest.Event) (string, error) {
+	subscribe, unsubscribe := EventMethods(m, event)
+	listenerType, err := g.typeMapper.MapParamType(&subscribe.ParamTypes[0])
+	if err != nil {
+		return "", err
+	}
+	name := event.Name + "Subscription"
+
+	var sb strings.Builder
+	sb.WriteString(g.generateDocumentation(DocOptions{
+		Summary:    EventSummary(event),
+		Deprecated: event.Deprecated,
+		Since:      event.Since,
+		RemovedIn:  event.RemovedIn,
+		Indent:     "\t",
+	}))
+	sb.WriteString(fmt.Sprintf("\tinternal sealed class %s : IDisposable\n\t{\n", name))
+	sb.WriteString(fmt.Sprintf("\t\tprivate %s? _listener;\n\n", listenerType))
+	sb.WriteString(fmt.Sprintf("\t\tpublic %s(%s listener)\n\t\t{\n", name, listenerType))
+	sb.WriteString(fmt.Sprintf("\t\t\t%s.%s(listener);\n", m.Name, subscribe.OverloadName()))
+	sb.WriteString("\t\t\t_listener = listener;\n")
+	sb.WriteString("\t\t}\n\n")
+	sb.WriteString("\t\t/// <summary>\n")
+	sb.WriteString("\t\t/// Unsubscribes the listener, if it is still subscribed\n")
+	sb.WriteString("\t\t/// </summary>\n")
+	sb.WriteString("\t\tpublic void Dispose()\n\t\t{\n")
+	sb.WriteString("\t\t\tif (_listener != null)\n\t\t\t{\n")
+	sb.WriteString(fmt.Sprintf("\t\t\t\t%s.%s(_listener);\n", m.Name, unsubscribe.OverloadName()))
+	sb.WriteString("\t\t\t\t_listener = null;\n")
+	sb.WriteString("\t\t\t}\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t}\n\n")
+	return sb.String(), nil
+}
+
 func (g *DotnetGenerator) generateClassConstructor(m *manifest.Manifest, class *manifest.Class, methodName string) (string, error) {
 	// Find the method in the manifest
 	method := FindMethod(m, methodName)
@@ -1005,11 +1344,13 @@ func (g *DotnetGenerator) generateClassConstructor(m *manifest.Manifest, class *
 		Indent:     "\t\t",
 		Summary:    summary,
 		Deprecated: method.Deprecated,
+		Since:      method.Since,
+		RemovedIn:  method.RemovedIn,
 		Params:     method.ParamTypes,
 	}))
 
 	// Generate constructor signature
-	params, err := g.formatMethodParameters(method.ParamTypes)
+	params, err := g.formatMethodParameters(method.ParamTypes, true)
 	if err != nil {
 		return "", err
 	}
@@ -1021,17 +1362,20 @@ func (g *DotnetGenerator) generateClassConstructor(m *manifest.Manifest, class *
 	for _, param := range method.ParamTypes {
 		paramNames = append(paramNames, param.Name)
 	}
+	// A create method that returns none leaves the handle invalid
+	call := fmt.Sprintf("%s.%s(%s)", m.Name, method.OverloadName(), strings.Join(paramNames, ", "))
+	if method.RetType.Optional {
+		call += " ?? default"
+	}
 
 	hasDtor := class.Destructor != nil
 
 	if hasDtor {
-		sb.WriteString(fmt.Sprintf(" : this(%s.%s(%s), %s.Owned)\n",
-			m.Name, methodName, strings.Join(paramNames, ", "), OwnershipEnumName))
+		sb.WriteString(fmt.Sprintf(" : this(%s, %s.Owned)\n", call, OwnershipEnumName))
 		sb.WriteString("\t\t{\n\t\t}\n\n")
 	} else {
 		sb.WriteString("\n\t\t{\n")
-		sb.WriteString(fmt.Sprintf("\t\t\tthis.handle = %s.%s(%s);\n",
-			m.Name, methodName, strings.Join(paramNames, ", ")))
+		sb.WriteString(fmt.Sprintf("\t\t\tthis.handle = %s;\n", call))
 		sb.WriteString("\t\t}\n\n")
 	}
 
@@ -1074,6 +1418,8 @@ func (g *DotnetGenerator) generateClassBinding(m *manifest.Manifest, class *mani
 		RetType:      method.RetType,
 		ParamAliases: binding.ParamAliases,
 		Deprecated:   deprecationReason,
+		Since:        binding.Since,
+		RemovedIn:    binding.RemovedIn,
 	}))
 
 	// Generate method signature
@@ -1086,8 +1432,15 @@ func (g *DotnetGenerator) generateClassBinding(m *manifest.Manifest, class *mani
 	if binding.RetAlias != nil && binding.RetAlias.Name != "" {
 		retType = binding.RetAlias.Name
 	}
+	if method.RetType.Optional {
+		retType += "?"
+	}
 
 	// Format parameters with aliases
+	defaults, err := g.formatDefaults(methodParams)
+	if err != nil {
+		return "", err
+	}
 	formattedParams := ""
 	for i, param := range methodParams {
 		if i > 0 {
@@ -1103,17 +1456,15 @@ func (g *DotnetGenerator) generateClassBinding(m *manifest.Manifest, class *mani
 		if i < len(binding.ParamAliases) && binding.ParamAliases[i] != nil {
 			paramType = binding.ParamAliases[i].Name
 		}
+		if param.Optional {
+			paramType += "?"
+		}
 
 		formattedParams += paramType + " " + param.Name
 
 		// Add default value if present
-		if param.Default != nil {
-			switch v := (*param.Default).(type) {
-			case string:
-				formattedParams += fmt.Sprintf(" = %q", v)
-			default:
-				formattedParams += fmt.Sprintf(" = %v", v)
-			}
+		if defaults[i] != "" {
+			formattedParams += " = " + defaults[i]
 		}
 	}
 
@@ -1134,7 +1485,7 @@ func (g *DotnetGenerator) generateClassBinding(m *manifest.Manifest, class *mani
 	//hasCtor := len(class.Constructors) > 0
 	hasDtor := class.Destructor != nil
 
-	methodName := method.Name
+	methodName := method.OverloadName()
 
 	// Generate method body for SafeHandle classes (need ref counting)
 	if hasDtor && binding.BindSelf {
@@ -1160,8 +1511,18 @@ func (g *DotnetGenerator) generateClassBinding(m *manifest.Manifest, class *mani
 						ownership = fmt.Sprintf(", %s.Borrowed", OwnershipEnumName)
 					}
 				}
-				sb.WriteString(fmt.Sprintf("\t\t\t\treturn new %s(%s.%s(%s)%s);\n",
-					binding.RetAlias.Name, m.Name, methodName, callArgs, ownership))
+				if method.RetType.Optional {
+					// A handle of default may be valid; the class's invalid one is null
+					invalidValue, err := retAliasInvalidValue(m, binding, g.typeMapper)
+					if err != nil {
+						return "", err
+					}
+					sb.WriteString(fmt.Sprintf("\t\t\t\tvar __handle = %s.%s(%s) ?? default;\n", m.Name, methodName, callArgs))
+					sb.WriteString(fmt.Sprintf("\t\t\t\treturn __handle == %s ? null : new %s(__handle%s);\n", invalidValue, binding.RetAlias.Name, ownership))
+				} else {
+					sb.WriteString(fmt.Sprintf("\t\t\t\treturn new %s(%s.%s(%s)%s);\n",
+						binding.RetAlias.Name, m.Name, methodName, callArgs, ownership))
+				}
 			} else {
 				sb.WriteString(fmt.Sprintf("\t\t\t\treturn %s.%s(%s);\n",
 					m.Name, methodName, callArgs))
@@ -1191,8 +1552,18 @@ func (g *DotnetGenerator) generateClassBinding(m *manifest.Manifest, class *mani
 						ownership = fmt.Sprintf(", %s.Borrowed", OwnershipEnumName)
 					}
 				}
-				sb.WriteString(fmt.Sprintf("\t\t\treturn new %s(%s.%s(%s)%s);\n",
-					binding.RetAlias.Name, m.Name, methodName, callArgs, ownership))
+				if method.RetType.Optional {
+					// A handle of default may be valid; the class's invalid one is null
+					invalidValue, err := retAliasInvalidValue(m, binding, g.typeMapper)
+					if err != nil {
+						return "", err
+					}
+					sb.WriteString(fmt.Sprintf("\t\t\tvar __handle = %s.%s(%s) ?? default;\n", m.Name, methodName, callArgs))
+					sb.WriteString(fmt.Sprintf("\t\t\treturn __handle == %s ? null : new %s(__handle%s);\n", invalidValue, binding.RetAlias.Name, ownership))
+				} else {
+					sb.WriteString(fmt.Sprintf("\t\t\treturn new %s(%s.%s(%s)%s);\n",
+						binding.RetAlias.Name, m.Name, methodName, callArgs, ownership))
+				}
 			} else {
 				sb.WriteString(fmt.Sprintf("\t\t\treturn %s.%s(%s);\n",
 					m.Name, methodName, callArgs))
@@ -1227,6 +1598,9 @@ func (g *DotnetGenerator) buildCallArguments(binding *manifest.Binding, methodPa
 
 		// Check if parameter has alias and needs .Release() or .Get()
 		if i < len(binding.ParamAliases) && binding.ParamAliases[i] != nil {
+			if param.Optional {
+				paramName += "?"
+			}
 			if binding.ParamAliases[i].Owner {
 				callArgs += paramName + ".Release()"
 			} else {
@@ -1244,6 +1618,16 @@ func (g *DotnetGenerator) buildCallArguments(binding *manifest.Binding, methodPa
 func (g *DotnetGenerator) generateEnumsFile(m *manifest.Manifest) (string, error) {
 	var sb strings.Builder
 
+	// Enums from dependencies are aliased rather than redefined. Global usings
+	// have to come before any other directive.
+	external := g.CollectExternalTypes(m)
+	for _, plugin := range external.EnumPlugins() {
+		for _, enum := range external.Enums[plugin] {
+			sb.WriteString(fmt.Sprintf("global using %s = %s.%s;\n", enum.Name, plugin, enum.Name))
+		}
+		sb.WriteString("\n")
+	}
+
 	// Using statements
 	sb.WriteString("using System;\n")
 	sb.WriteString("using System.Reflection;\n\n")
@@ -1273,6 +1657,16 @@ func (g *DotnetGenerator) generateEnumsFile(m *manifest.Manifest) (string, error
 
 	sb.WriteString("\t\tprivate static readonly string callerModule = Assembly.GetExecutingAssembly().GetName().Name!;\n")
 
+	// Constants sit beside the methods, as C# has nothing at namespace scope
+	constantsCode, err := g.CollectConstants(m, g.generateConstant)
+	if err != nil {
+		return "", err
+	}
+	if constantsCode != "" {
+		sb.WriteString("\n")
+		sb.WriteString(constantsCode)
+	}
+
 	sb.WriteString("\t}\n\n")
 
 	sb.WriteString("#pragma warning restore CS0649\n")
@@ -1301,12 +1695,66 @@ func (g *DotnetGenerator) generateAliasesFile(m *manifest.Manifest) (string, err
 	return sb.String(), nil
 }
 
+// generateStructsFile generates a file containing all struct definitions
+func (g *DotnetGenerator) generateStructsFile(m *manifest.Manifest) (string, error) {
+	var sb strings.Builder
+
+	// Structs from dependencies are aliased rather than redefined. Global
+	// usings have to come before any other directive.
+	external := g.CollectExternalTypes(m)
+	for _, plugin := range external.StructPlugins() {
+		for _, structure := range external.Structs[plugin] {
+			sb.WriteString(fmt.Sprintf("global using %s = %s.%s;\n", structure.Name, plugin, structure.Name))
+		}
+		sb.WriteString("\n")
+	}
+
+	// Using statements
+	sb.WriteString("using System;\n")
+	sb.WriteString("using System.Numerics;\n")
+	sb.WriteString("using System.Runtime.InteropServices;\n\n")
+	sb.WriteString("using Plugify;\n\n")
+	sb.WriteString(fmt.Sprintf("// Generated from %s.pplugin\n\n", m.Name))
+
+	// Namespace
+	sb.WriteString(fmt.Sprintf("namespace %s {\n", m.Name))
+	sb.WriteString("#pragma warning disable CS0649\n\n")
+
+	// Generate structs
+	structsCode, err := g.generateStructs(m)
+	if err != nil {
+		return "", err
+	}
+	if structsCode != "" {
+		sb.WriteString(structsCode)
+	}
+	sb.WriteString(g.generateFixedArrayStructs(m))
+
+	sb.WriteString("#pragma warning restore CS0649\n")
+	sb.WriteString("}\n")
+
+	return sb.String(), nil
+}
+
 // generateDelegatesFile generates a file containing all delegate definitions
 func (g *DotnetGenerator) generateDelegatesFile(m *manifest.Manifest) (string, error) {
 	var sb strings.Builder
 
+	// Delegates from dependencies are aliased rather than redefined. Global
+	// usings have to come before any other directive.
+	external := g.CollectExternalTypes(m)
+	for _, plugin := range external.PrototypePlugins() {
+		for _, proto := range external.Prototypes[plugin] {
+			sb.WriteString(fmt.Sprintf("global using %s = %s.%s;\n", proto.Name, plugin, proto.Name))
+		}
+		sb.WriteString("\n")
+	}
+
 	// Using statements
 	sb.WriteString("using System;\n")
+	if HasKind(m, manifest.KindMap) {
+		sb.WriteString("using System.Collections.Generic;\n")
+	}
 	sb.WriteString("using System.Numerics;\n\n")
 	sb.WriteString("using Plugify;\n\n")
 	sb.WriteString(fmt.Sprintf("// Generated from %s.pplugin\n\n", m.Name))
@@ -1336,6 +1784,9 @@ func (g *DotnetGenerator) generateGroupFile(m *manifest.Manifest, groupName stri
 
 	// Using statements
 	sb.WriteString("using System;\n")
+	if HasKind(m, manifest.KindMap) {
+		sb.WriteString("using System.Collections.Generic;\n")
+	}
 	sb.WriteString("using System.Numerics;\n")
 	sb.WriteString("using System.Runtime.CompilerServices;\n")
 	sb.WriteString("using System.Runtime.InteropServices;\n")
@@ -1357,13 +1808,23 @@ func (g *DotnetGenerator) generateGroupFile(m *manifest.Manifest, groupName stri
 			if err != nil {
 				return "", fmt.Errorf("failed to generate method %s: %w", method.Name, err)
 			}
-			sb.WriteString(methodCode)
+			sb.WriteString(dotnetPlatformGuard(method.Platforms, methodCode))
 			sb.WriteString("\n")
 		}
 	}
 
 	sb.WriteString("\t}\n\n")
 
+	// Generate subscriptions for the events subscribed in this group
+	for _, event := range EventsInGroup(m, groupName) {
+		eventCode, err := g.generateEventSubscription(m, event)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate event %s: %w", event.Name, err)
+		}
+		subscribe, _ := EventMethods(m, event)
+		sb.WriteString(dotnetPlatformGuard(subscribe.Platforms, eventCode))
+	}
+
 	// Generate classes for this group (if enabled)
 	if opts.GenerateClasses {
 		for _, class := range m.Classes {
@@ -1373,7 +1834,7 @@ func (g *DotnetGenerator) generateGroupFile(m *manifest.Manifest, groupName stri
 				if err != nil {
 					return "", fmt.Errorf("failed to generate class %s: %w", class.Name, err)
 				}
-				sb.WriteString(classCode)
+				sb.WriteString(dotnetPlatformGuard(class.Platforms, classCode))
 			}
 		}
 	}
@@ -1,6 +1,9 @@
 package generator
 
 import (
+	"fmt"
+	"math"
+	"strconv"
 	"strings"
 
 	"github.com/untrustedmodders/plugify-gen/pkg/manifest"
@@ -252,52 +255,43 @@ func (m *DotnetTypeMapper) MapType(baseType string, context TypeContext, isArray
 	return mapped, nil
 }
 
+// MapFixedArrayType implements TypeMapper.
+func (m *DotnetTypeMapper) MapFixedArrayType(element string, length int, context TypeContext) (string, error) {
+	return dotnetPassed(element+"[]", context), nil
+}
+
+// MapMapType implements TypeMapper.
+func (m *DotnetTypeMapper) MapMapType(key, value string, context TypeContext) (string, error) {
+	return dotnetPassed(fmt.Sprintf("Dictionary<%s, %s>", key, value), context), nil
+}
+
+// dotnetPassed adds ref for a ref parameter, as MapType does.
+func dotnetPassed(mapped string, context TypeContext) string {
+	if context&TypeContextRef != 0 {
+		return "ref " + mapped
+	}
+	return mapped
+}
+
 func (m *DotnetTypeMapper) MapParamType(param *manifest.ParamType) (string, error) {
+	node := param.TypeNode()
+
 	// Regular type mapping
 	ctx := TypeContextValue
 	if param.Ref {
 		ctx = TypeContextRef
 	}
 
-	var typeName string
-	switch {
-	case param.Alias != nil:
-		typeName = param.Alias.Name
-		ctx |= TypeContextAlias
-
-	case param.Enum != nil:
-		typeName = param.Enum.Name
-
-	case param.Prototype != nil:
-		return param.Prototype.Name, nil
-
-	default:
-		typeName = param.BaseType()
-	}
-
-	return m.MapType(typeName, ctx, param.IsArray())
+	return m.MapTypeNode(node, ctx)
 }
 
 func (m *DotnetTypeMapper) MapReturnType(retType *manifest.RetType) (string, error) {
-	ctx := TypeContextReturn
-
-	var typeName string
-	switch {
-	case retType.Alias != nil:
-		typeName = retType.Alias.Name
-		ctx |= TypeContextAlias
-
-	case retType.Enum != nil:
-		typeName = retType.Enum.Name
-
-	case retType.Prototype != nil:
-		return retType.Prototype.Name, nil
-
-	default:
-		typeName = retType.BaseType()
-	}
+	return m.MapTypeNode(retType.TypeNode(), TypeContextReturn)
+}
 
-	return m.MapType(typeName, ctx, retType.IsArray())
+// MapTypeNode implements TypeMapper.
+func (m *DotnetTypeMapper) MapTypeNode(node *manifest.TypeNode, context TypeContext) (string, error) {
+	return mapTypeNode(m, node, context)
 }
 
 func (m *DotnetTypeMapper) MapHandleType(class *manifest.Class) (string, string, error) {
@@ -321,12 +315,50 @@ func (m *DotnetTypeMapper) MapHandleType(class *manifest.Class) (string, string,
 	return invalidValue, handleType, err
 }
 
+// FormatDefault renders a parameter default as a C# constant. Bool8, Char8 and
+// Char16 are structs, and C# only allows `default` as the default of a struct
+// parameter, so for other values of those types it returns "" and the
+// parameter goes without; see formatDefaults.
+func (m *DotnetTypeMapper) FormatDefault(param *manifest.ParamType) (string, error) {
+	value := param.DefaultValue
+	switch value.Kind {
+	case manifest.DefaultBool:
+		if !value.Bool {
+			return "default", nil
+		}
+	case manifest.DefaultChar:
+		if value.Char == 0 {
+			return "default", nil
+		}
+	case manifest.DefaultInt:
+		if value.Int == math.MinInt64 {
+			return "long.MinValue", nil
+		}
+		return strconv.FormatInt(value.Int, 10), nil
+	case manifest.DefaultUint:
+		return strconv.FormatUint(value.Uint, 10), nil
+	case manifest.DefaultFloat:
+		literal := floatLiteral(value.Float)
+		if param.Type == "float" {
+			literal += "f"
+		}
+		return literal, nil
+	case manifest.DefaultString:
+		return strconv.Quote(value.String), nil
+	case manifest.DefaultEnum:
+		return fmt.Sprintf("%s.%s", param.Enum.Name, value.Enum.Name), nil
+	}
+	return "", nil
+}
+
 // MapDelegateParamType maps parameter types for delegate definitions
 func (m *DotnetTypeMapper) MapDelegateParamType(param *manifest.ParamType) (string, error) {
+	node := param.TypeNode()
+
 	// Delegates use ref for POD types automatically, enums only if ref=true
 	if param.Enum != nil {
 		typeName := param.Enum.Name
-		if param.IsArray() {
+		if node.IsArray() {
 			typeName = typeName + "[]"
 		}
 		// Enums only get ref if explicitly marked in manifest
@@ -340,7 +372,12 @@ func (m *DotnetTypeMapper) MapDelegateParamType(param *manifest.ParamType) (stri
 		return param.Prototype.Name, nil
 	}
 
-	typeName, err := m.MapType(param.BaseType(), TypeContextValue, param.IsArray())
+	// Structs are POD, so always by ref like the vector types
+	if param.Struct != nil {
+		return "ref " + param.Struct.Name, nil
+	}
+
+	typeName, err := m.MapType(node.Value().Name, TypeContextValue, node.IsArray())
 	if err != nil {
 		return "", err
 	}
@@ -363,8 +400,22 @@ func (m *DotnetTypeMapper) MapDelegateReturnType(retType *manifest.RetType) (str
 
 // MapUnmanagedParamType maps parameters for unmanaged function pointer declarations
 func (m *DotnetTypeMapper) MapUnmanagedParamType(param *manifest.ParamType) (string, error) {
+	node := param.TypeNode()
+
+	// A fixed-size array is passed as its first element, a map as a Map192
+	switch node.Unaliased().Kind {
+	case manifest.KindFixedArray:
+		element, err := m.fixedArrayElement(param)
+		if err != nil {
+			return "", err
+		}
+		return element + "*", nil
+	case manifest.KindMap:
+		return "Map192*", nil
+	}
+
 	// Check for enum
-	if param.Enum != nil && !param.IsArray() {
+	if param.Enum != nil && !node.IsArray() {
 		typeName := param.Enum.Name
 		if param.Ref {
 			return typeName + "*", nil
@@ -377,19 +428,24 @@ func (m *DotnetTypeMapper) MapUnmanagedParamType(param *manifest.ParamType) (str
 		return "nint", nil
 	}
 
+	// Structs are passed by pointer like the vector types
+	if param.Struct != nil {
+		return param.Struct.Name + "*", nil
+	}
+
 	// Get C type
 	typeName := param.Type
-	if param.IsArray() {
+	if node.IsArray() {
 		typeName = typeName + "[]"
 	}
 
 	cType, ok := m.ctypesMap[typeName]
 	if !ok {
-		cType, ok = m.ctypesMap[param.BaseType()]
+		cType, ok = m.ctypesMap[node.Value().Name]
 		if !ok {
-			cType = param.BaseType()
+			cType = node.Value().Name
 		}
-		if param.IsArray() {
+		if node.IsArray() {
 			cType = "Vector192*"
 		}
 	}
@@ -404,8 +460,17 @@ func (m *DotnetTypeMapper) MapUnmanagedParamType(param *manifest.ParamType) (str
 
 // MapUnmanagedReturnType maps return type for unmanaged function pointer declarations
 func (m *DotnetTypeMapper) MapUnmanagedReturnType(retType *manifest.RetType) (string, error) {
+	node := retType.TypeNode()
+
+	switch node.Unaliased().Kind {
+	case manifest.KindFixedArray:
+		return m.fixedArrayStruct(retType)
+	case manifest.KindMap:
+		return "Map192", nil
+	}
+
 	// Check for enum
-	if retType.Enum != nil && !retType.IsArray() {
+	if retType.Enum != nil && !node.IsArray() {
 		return retType.Enum.Name, nil
 	}
 
@@ -414,18 +479,22 @@ func (m *DotnetTypeMapper) MapUnmanagedReturnType(retType *manifest.RetType) (st
 		return "nint", nil
 	}
 
+	if retType.Struct != nil {
+		return retType.Struct.Name, nil
+	}
+
 	typeName := retType.Type
-	if retType.IsArray() {
+	if node.IsArray() {
 		typeName = typeName + "[]"
 	}
 
 	cType, ok := m.ctypesMap[typeName]
 	if !ok {
-		cType, ok = m.ctypesMap[retType.BaseType()]
+		cType, ok = m.ctypesMap[node.Value().Name]
 		if !ok {
-			cType = retType.BaseType()
+			cType = node.Value().Name
 		}
-		if retType.IsArray() {
+		if node.IsArray() {
 			cType = "Vector192*"
 		}
 	}
@@ -440,12 +509,56 @@ func (m *DotnetTypeMapper) MapUnmanagedReturnType(retType *manifest.RetType) (st
 
 // Helper methods
 
-func (m *DotnetTypeMapper) isObjectReturn(typeName string) bool {
-	return strings.Contains(typeName, "[]") || typeName == "string" || typeName == "any"
+// isObjectProperty reports whether prop crosses as a native object the wrapper
+// constructs and destroys: a string, any, vector or map.
+func (m *DotnetTypeMapper) isObjectProperty(prop *manifest.Property) bool {
+	switch node := prop.TypeNode().Unaliased(); node.Kind {
+	case manifest.KindArray, manifest.KindMap:
+		return true
+	case manifest.KindPrimitive:
+		return node.Name == "string" || node.Name == "any"
+	}
+	return false
+}
+
+// isFixedArray reports whether prop is a fixed-size array, which crosses as a
+// pinned pointer to its elements and comes back in an inline array struct.
+func (m *DotnetTypeMapper) isFixedArray(prop *manifest.Property) bool {
+	return prop.TypeNode().Unaliased().Kind == manifest.KindFixedArray
+}
+
+// fixedArrayElement returns the element type of the fixed-size array prop.
+func (m *DotnetTypeMapper) fixedArrayElement(prop *manifest.Property) (string, error) {
+	return m.MapTypeNode(prop.TypeNode().Unaliased().Elem, TypeContextValue)
+}
+
+// fixedArrayStruct names the [InlineArray] struct the fixed-size array prop is
+// returned in, as C++ returns a std::array by value.
+func (m *DotnetTypeMapper) fixedArrayStruct(prop *manifest.Property) (string, error) {
+	element, err := m.fixedArrayElement(prop)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("FixedArray_%s_%d", element, prop.TypeNode().Unaliased().Len), nil
+}
+
+// mapTypeArgs returns the key and value types of the map prop, as the type
+// arguments of the NativeMethods map helpers.
+func (m *DotnetTypeMapper) mapTypeArgs(prop *manifest.Property) (string, error) {
+	node := prop.TypeNode().Unaliased()
+	key, err := m.MapTypeNode(node.Key, TypeContextValue)
+	if err != nil {
+		return "", err
+	}
+	value, err := m.MapTypeNode(node.Elem, TypeContextValue)
+	if err != nil {
+		return "", err
+	}
+	return key + ", " + value, nil
 }
 
 func (m *DotnetTypeMapper) isPODType(typeName string) bool {
-	return typeName == "vec2" || typeName == "vec3" || typeName == "vec4" || typeName == "mat4x4"
+	return typeName == "vec2" || typeName == "vec3" || typeName == "vec4" || typeName == "mat4x4" || typeName == "struct"
 }
 
 func (m *DotnetTypeMapper) isFunction(typeName string) bool {
@@ -453,36 +566,21 @@ func (m *DotnetTypeMapper) isFunction(typeName string) bool {
 }
 
 func (m *DotnetTypeMapper) getConstructor(typeName string) string {
-	if strings.Contains(typeName, "[]") {
-		return m.constructorMap[typeName]
-	}
 	return m.constructorMap[typeName]
 }
 
 func (m *DotnetTypeMapper) getNativeReturnType(typeName string) string {
-	if strings.Contains(typeName, "[]") {
-		return m.returnTypeMap[typeName]
-	}
 	return m.returnTypeMap[typeName]
 }
 
 func (m *DotnetTypeMapper) getDataConverter(typeName string) string {
-	if strings.Contains(typeName, "[]") {
-		return m.dataConverterMap[typeName]
-	}
 	return m.dataConverterMap[typeName]
 }
 
 func (m *DotnetTypeMapper) getSizeFunction(typeName string) string {
-	if strings.Contains(typeName, "[]") {
-		return m.sizeFunctionMap[typeName]
-	}
-	return ""
+	return m.sizeFunctionMap[typeName]
 }
 
 func (m *DotnetTypeMapper) getDestructor(typeName string) string {
-	if strings.Contains(typeName, "[]") {
-		return m.destructorMap[typeName]
-	}
 	return m.destructorMap[typeName]
 }
@@ -2,6 +2,8 @@ package generator
 
 import (
 	"fmt"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/untrustedmodders/plugify-gen/pkg/manifest"
@@ -29,6 +31,9 @@ func NewGolangGenerator() *GolangGenerator {
 // Generate generates Go bindings (.go and .h files)
 func (g *GolangGenerator) Generate(m *manifest.Manifest, opts *GeneratorOptions) (*GeneratorResult, error) {
 	g.ResetCaches()
+	if err := m.CheckSanitizedNames(g.Sanitizer); err != nil {
+		return nil, err
+	}
 	m.Sanitize(g.Sanitizer)
 	g.usedNames = make(map[string]struct{})
 	g.usedLocals = make(map[string]int)
@@ -53,6 +58,15 @@ func (g *GolangGenerator) Generate(m *manifest.Manifest, opts *GeneratorOptions)
 	}
 	files[fmt.Sprintf("%s/aliases.go", m.Name)] = aliasesGoCode
 
+	// Generate separate structs file, if there are any
+	if len(g.UsedStructs(m)) > 0 {
+		structsGoCode, err := g.generateStructsGoFile(m)
+		if err != nil {
+			return nil, fmt.Errorf("generating structs file: %w", err)
+		}
+		files[fmt.Sprintf("%s/structs.go", m.Name)] = structsGoCode
+	}
+
 	// Generate separate delegates file
 	delegatesGoCode, err := g.generateDelegatesGoFile(m)
 	if err != nil {
@@ -74,32 +88,39 @@ func (g *GolangGenerator) Generate(m *manifest.Manifest, opts *GeneratorOptions)
 	}
 	files[fmt.Sprintf("%s/shared.go", m.Name)] = sharedGoCode
 
-	// Generate .go file for exports
-	exportGoCode, err := g.generateExportGoFile(m)
-	if err != nil {
-		return nil, fmt.Errorf("generating export header: %w", err)
+	// Generate .go file for exports, and one for the methods of each platform
+	// that not every platform has
+	for _, platform := range append([]string{""}, g.platforms(m, "", opts)...) {
+		exportGoCode, err := g.generateExportGoFile(m, platform)
+		if err != nil {
+			return nil, fmt.Errorf("generating export header: %w", err)
+		}
+		files[golangPlatformFile(m.Name, platform)+".go"] = exportGoCode
 	}
-	files[fmt.Sprintf("%s.go", m.Name)] = exportGoCode
 
-	// Generate group-specific files
+	// Generate group-specific files, split the same way
 	for _, groupName := range g.SortedGroups(groups) {
-		goCode, err := g.generateGroupGoFile(m, groupName, opts)
-		if err != nil {
-			return nil, fmt.Errorf("failed to generate group %s: %w", groupName, err)
-		}
-		files[fmt.Sprintf("%s/%s.go", m.Name, groupName)] = goCode
+		for _, platform := range append([]string{""}, g.platforms(m, groupName, opts)...) {
+			fileName := golangPlatformFile(groupName, platform)
 
-		hCode, err := g.generateGroupHFile(m, groupName, opts)
-		if err != nil {
-			return nil, fmt.Errorf("failed to generate group %s header: %w", groupName, err)
-		}
-		files[fmt.Sprintf("%s/%s.h", m.Name, groupName)] = hCode
+			goCode, err := g.generateGroupGoFile(m, groupName, platform, opts)
+			if err != nil {
+				return nil, fmt.Errorf("failed to generate group %s: %w", groupName, err)
+			}
+			files[fmt.Sprintf("%s/%s.go", m.Name, fileName)] = goCode
 
-		cCode, err := g.generateGroupCFile(m, groupName, opts)
-		if err != nil {
-			return nil, fmt.Errorf("failed to generate group %s impl: %w", groupName, err)
+			hCode, err := g.generateGroupHFile(m, groupName, platform, opts)
+			if err != nil {
+				return nil, fmt.Errorf("failed to generate group %s header: %w", groupName, err)
+			}
+			files[fmt.Sprintf("%s/%s.h", m.Name, fileName)] = hCode
+
+			cCode, err := g.generateGroupCFile(m, groupName, platform, opts)
+			if err != nil {
+				return nil, fmt.Errorf("failed to generate group %s impl: %w", groupName, err)
+			}
+			files[fmt.Sprintf("%s/%s.c", m.Name, fileName)] = cCode
 		}
-		files[fmt.Sprintf("%s/%s.c", m.Name, groupName)] = cCode
 	}
 
 	result := &GeneratorResult{
@@ -109,11 +130,121 @@ func (g *GolangGenerator) Generate(m *manifest.Manifest, opts *GeneratorOptions)
 	return result, nil
 }
 
+// golangGOOS is the GOOS each platform is built for
+var golangGOOS = map[string]string{
+	manifest.PlatformWindows: "windows",
+	manifest.PlatformLinux:   "linux",
+	manifest.PlatformMacOS:   "darwin",
+}
+
+// golangPlatformFile names the file for platform, "" being the one built
+// everywhere. The GOOS suffix alone keeps go build from compiling the file
+// anywhere else; a //go:build line inside spells that out.
+func golangPlatformFile(name, platform string) string {
+	if platform == "" {
+		return name
+	}
+	return name + "_" + golangGOOS[platform]
+}
+
+// golangInFile reports whether an item limited to platforms goes in the file
+// for platform. An item for several platforms is repeated in the file of each,
+// of which go build only ever compiles one.
+func golangInFile(platforms []string, platform string) bool {
+	if platform == "" {
+		return len(platforms) == 0
+	}
+	return slices.Contains(platforms, platform)
+}
+
+// golangBindingInFile reports whether a binding goes in the file for platform.
+// One that lists no platforms goes wherever its class does.
+func golangBindingInFile(class *manifest.Class, binding *manifest.Binding, platform string) bool {
+	if len(binding.Platforms) == 0 {
+		return golangInFile(class.Platforms, platform)
+	}
+	return platform != "" && slices.Contains(binding.Platforms, platform)
+}
+
+// platforms returns the platforms that need files of their own for a group,
+// or for the whole manifest when groupName is empty.
+func (g *GolangGenerator) platforms(m *manifest.Manifest, groupName string, opts *GeneratorOptions) []string {
+	var platforms []string
+	for _, platform := range manifest.KnownPlatforms {
+		found := false
+		for i := range m.Methods {
+			if (groupName == "" || m.Methods[i].Group == groupName) && slices.Contains(m.Methods[i].Platforms, platform) {
+				found = true
+			}
+		}
+		if groupName != "" && opts.GenerateClasses {
+			for i := range m.Classes {
+				class := &m.Classes[i]
+				if class.Group != groupName {
+					continue
+				}
+				for j := range class.Bindings {
+					if golangBindingInFile(class, &class.Bindings[j], platform) {
+						found = true
+					}
+				}
+				if slices.Contains(class.Platforms, platform) {
+					found = true
+				}
+			}
+		}
+		if found {
+			platforms = append(platforms, platform)
+		}
+	}
+	return platforms
+}
+
 // generateEnums generates enum definitions
 func (g *GolangGenerator) generateEnums(m *manifest.Manifest) (string, error) {
 	return g.CollectEnums(m, g.generateEnum)
 }
 
+func (g *GolangGenerator) generateConstant(constant *manifest.Constant) (string, error) {
+	var sb strings.Builder
+
+	if constant.Description != "" {
+		sb.WriteString(fmt.Sprintf("// %s - %s\n", constant.Name, constant.Description))
+	}
+	sb.WriteString(golangAvailability(constant.Description, constant.Since, constant.RemovedIn))
+	if constant.Deprecated != "" {
+		if constant.Description != "" || constant.Since != "" || constant.RemovedIn != "" {
+			sb.WriteString("//\n")
+		}
+		sb.WriteString(fmt.Sprintf("// Deprecated: %s\n", constant.Deprecated))
+	}
+
+	typeName, err := g.typeMapper.MapType(constant.Type, TypeContextReturn, false)
+	if err != nil {
+		return "", err
+	}
+	sb.WriteString(fmt.Sprintf("const %s %s = %s\n", constant.Name, typeName, golangLiteral(constant.Literal)))
+	return sb.String(), nil
+}
+
+// golangLiteral renders a checked value as a Go constant expression, which
+// takes on the type it is declared with.
+func golangLiteral(value *manifest.DefaultValue) string {
+	switch value.Kind {
+	case manifest.DefaultBool:
+		return strconv.FormatBool(value.Bool)
+	case manifest.DefaultInt:
+		return strconv.FormatInt(value.Int, 10)
+	case manifest.DefaultUint:
+		return strconv.FormatUint(value.Uint, 10)
+	case manifest.DefaultFloat:
+		return strconv.FormatFloat(value.Float, 'g', -1, 64)
+	case manifest.DefaultChar:
+		return strconv.QuoteRune(value.Char)
+	}
+	return strconv.Quote(value.String)
+}
+
 func (g *GolangGenerator) generateEnum(enum *manifest.Enum, underlyingType string) (string, error) {
 	var sb strings.Builder
 
@@ -121,10 +252,11 @@ func (g *GolangGenerator) generateEnum(enum *manifest.Enum, underlyingType strin
 	if enum.Description != "" {
 		sb.WriteString(fmt.Sprintf("// %s - %s\n", enum.Name, enum.Description))
 	}
+	sb.WriteString(golangAvailability(enum.Description, enum.Since, enum.RemovedIn))
 	// `// Deprecated:` is the convention gopls and staticcheck read; it has to be
 	// its own paragraph at the end of the doc comment.
 	if enum.Deprecated != "" {
-		if enum.Description != "" {
+		if enum.Description != "" || enum.Since != "" || enum.RemovedIn != "" {
 			sb.WriteString("//\n")
 		}
 		sb.WriteString(fmt.Sprintf("// Deprecated: %s\n", enum.Deprecated))
@@ -160,6 +292,13 @@ func (g *GolangGenerator) generateEnum(enum *manifest.Enum, underlyingType strin
 	}
 
 	sb.WriteString(")\n")
+
+	if enum.Flags {
+		name := enum.Name
+		sb.WriteString(fmt.Sprintf("\n// Has reports whether every flag of flag is set in f.\nfunc (f %s) Has(flag %s) bool { return f&flag == flag }\n", name, name))
+		sb.WriteString(fmt.Sprintf("\n// Set returns f with the flags of flag set.\nfunc (f %s) Set(flag %s) %s { return f | flag }\n", name, name, name))
+		sb.WriteString(fmt.Sprintf("\n// Clear returns f with the flags of flag cleared.\nfunc (f %s) Clear(flag %s) %s { return f &^ flag }\n", name, name, name))
+	}
 	return sb.String(), nil
 }
 
@@ -175,8 +314,9 @@ func (g *GolangGenerator) generateAlias(alias *manifest.Alias, underlyingType st
 	if alias.Description != "" {
 		sb.WriteString(fmt.Sprintf("// %s - %s\n", alias.Name, alias.Description))
 	}
+	sb.WriteString(golangAvailability(alias.Description, alias.Since, alias.RemovedIn))
 	if alias.Deprecated != "" {
-		if alias.Description != "" {
+		if alias.Description != "" || alias.Since != "" || alias.RemovedIn != "" {
 			sb.WriteString("//\n")
 		}
 		sb.WriteString(fmt.Sprintf("// Deprecated: %s\n", alias.Deprecated))
@@ -222,6 +362,19 @@ func (g *GolangGenerator) generateOwnershipTypes() string {
 	return sb.String()
 }
 
+// golangAvailability renders Availability as a paragraph of the doc comment
+// of a constant, enum, alias, delegate or struct, following its description.
+func golangAvailability(description, since, removedIn string) string {
+	note := Availability(since, removedIn)
+	switch {
+	case note == "":
+		return ""
+	case description != "":
+		return fmt.Sprintf("//\n// %s.\n", note)
+	}
+	return fmt.Sprintf("// %s.\n", note)
+}
+
 // generateDocumentation generates documentation comments for functions/methods
 func (g *GolangGenerator) generateDocumentation(opts DocOptions) string {
 	var sb strings.Builder
@@ -244,6 +397,13 @@ func (g *GolangGenerator) generateDocumentation(opts DocOptions) string {
 		sb.WriteString(fmt.Sprintf("//\n//  @return %s\n", opts.RetType.Description))
 	}
 
+	if opts.Since != "" {
+		sb.WriteString(fmt.Sprintf("//  @since %s\n", opts.Since))
+	}
+	if opts.RemovedIn != "" {
+		sb.WriteString(fmt.Sprintf("//  @note Removed in %s\n", opts.RemovedIn))
+	}
+
 	// Add deprecation comment if present
 	if opts.Deprecated != "" {
 		sb.WriteString(fmt.Sprintf("// Deprecated: %s\n", opts.Deprecated))
@@ -279,10 +439,11 @@ func (g *GolangGenerator) generateDelegate(proto *manifest.Prototype) (string, e
 	if proto.Description != "" {
 		sb.WriteString(fmt.Sprintf("// %s - %s\n", proto.Name, proto.Description))
 	}
+	sb.WriteString(golangAvailability(proto.Description, proto.Since, proto.RemovedIn))
 	// `// Deprecated:` is the convention gopls and staticcheck read; it has to be
 	// its own paragraph at the end of the doc comment.
 	if proto.Deprecated != "" {
-		if proto.Description != "" {
+		if proto.Description != "" || proto.Since != "" || proto.RemovedIn != "" {
 			sb.WriteString("//\n")
 		}
 		sb.WriteString(fmt.Sprintf("// Deprecated: %s\n", proto.Deprecated))
@@ -351,6 +512,8 @@ func (g *GolangGenerator) generateMethod(method *manifest.Method, pluginName str
 		Summary:     method.Name,
 		Description: method.Description,
 		Deprecated:  method.Deprecated,
+		Since:       method.Since,
+		RemovedIn:   method.RemovedIn,
 		Params:      method.ParamTypes,
 		RetType:     method.RetType,
 	}))
@@ -381,6 +544,38 @@ func (g *GolangGenerator) generateMethod(method *manifest.Method, pluginName str
 	return sb.String(), nil
 }
 
+// generateEventSubscription renders Subscribe<Event>, which subscribes a
+// listener to an event and returns the function that unsubscribes it again;
+// calling that more than once does nothing.
+func (g *GolangGenerator) generateEventSubscription(m *manifest.Manifest, event *manifest.Event) (string, error) {
+	subscribe, unsubscribe := EventMethods(m, event)
+	listenerType, err := g.typeMapper.MapParamType(&subscribe.ParamTypes[0])
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	sb.WriteString(g.generateDocumentation(DocOptions{
+		Summary:     "Subscribe" + event.Name,
+		Description: EventSummary(event),
+		Deprecated:  event.Deprecated,
+		Since:       event.Since,
+		RemovedIn:   event.RemovedIn,
+		RetType:     manifest.RetType{Type: "void"},
+	}))
+	sb.WriteString(fmt.Sprintf("func Subscribe%s(listener %s) (unsubscribe func()) {\n", event.Name, listenerType))
+	sb.WriteString(fmt.Sprintf("\t%s(listener)\n", subscribe.Name))
+	sb.WriteString("\tsubscribed := true\n")
+	sb.WriteString("\treturn func() {\n")
+	sb.WriteString("\t\tif subscribed {\n")
+	sb.WriteString("\t\t\tsubscribed = false\n")
+	sb.WriteString(fmt.Sprintf("\t\t\t%s(listener)\n", unsubscribe.Name))
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("}\n")
+	return sb.String(), nil
+}
+
 // generateMethodBody generates the method body with marshaling
 // goMethodBodyContext holds the context for generating a Go method body
 type goMethodBodyContext struct {
@@ -437,8 +632,8 @@ func (g *GolangGenerator) initializeGoMethodContext(method *manifest.Method) (*g
 	ctx := &goMethodBodyContext{
 		indent:      "\t",
 		innerIndent: "\t",
-		isObjRet:    method.RetType.Type != "void" && g.typeMapper.IsObjType(method.RetType.Type),
-		isPodRet:    method.RetType.Type != "void" && g.typeMapper.IsPodType(method.RetType.Type),
+		isObjRet:    method.RetType.Type != "void" && g.typeMapper.IsObjType(&method.RetType),
+		isPodRet:    method.RetType.Type != "void" && (g.typeMapper.IsPodType(method.RetType.Type) || method.RetType.TypeNode().Unaliased().Kind == manifest.KindFixedArray),
 	}
 
 	ctx.hasRet = method.RetType.Type != "void" && !ctx.isObjRet
@@ -493,9 +688,16 @@ func (g *GolangGenerator) generateGoMethodCall(method *manifest.Method, sb *stri
 	if method.RetType.Enum != nil {
 		retTypeCast = method.RetType.Enum.Name
 	}
+	if method.RetType.Struct != nil {
+		retTypeCast = method.RetType.Struct.Name
+	}
+	if method.RetType.TypeNode().Unaliased().Kind == manifest.KindFixedArray {
+		// The struct it is returned in holds just the array
+		retTypeCast, _ = g.typeMapper.MapReturnType(&method.RetType)
+	}
 
 	if ctx.isObjRet {
-		retTypeCast = g.typeMapper.retTypeCastMap[method.RetType.Type]
+		retTypeCast = g.typeMapper.NativeType(&method.RetType)
 		sb.WriteString(fmt.Sprintf("%s__native := %s\n", ctx.innerIndent, functionCall))
 		sb.WriteString(fmt.Sprintf("%s__retVal_native = *(*%s)(unsafe.Pointer(&__native))\n", ctx.innerIndent, retTypeCast))
 	} else if ctx.hasTry {
@@ -581,8 +783,8 @@ func (g *GolangGenerator) generateParamsCast(method *manifest.Method, indent str
 	var result []string
 
 	// Handle return type
-	if method.RetType.Type != "void" && g.typeMapper.IsObjType(method.RetType.Type) {
-		retTypeCast := g.typeMapper.retTypeCastMap[method.RetType.Type]
+	if method.RetType.Type != "void" && g.typeMapper.IsObjType(&method.RetType) {
+		retTypeCast := g.typeMapper.NativeType(&method.RetType)
 		if retTypeCast != "" {
 			result = append(result, fmt.Sprintf("%svar __retVal_native %s\n", indent, retTypeCast))
 		}
@@ -605,12 +807,31 @@ func (g *GolangGenerator) generateParamCast(param *manifest.ParamType, indent st
 	paramType := g.typeMapper.valTypeCastMap[param.Type]
 	name := param.Name
 
+	switch node := param.TypeNode().Unaliased(); node.Kind {
+	case manifest.KindFixedArray:
+		// Copied like the vectors, so that C is handed memory of its own
+		native := g.typeMapper.FixedArrayCgoType(node)
+		if param.Ref {
+			return fmt.Sprintf("%s__%s := *(*%s)(unsafe.Pointer(%s))\n", indent, name, native, name), nil
+		}
+		return fmt.Sprintf("%s__%s := *(*%s)(unsafe.Pointer(&%s))\n", indent, name, native, name), nil
+	case manifest.KindMap:
+		if param.Ref {
+			return fmt.Sprintf("%s__%s := plugify.ConstructMap(*%s)\n", indent, name, name), nil
+		}
+		return fmt.Sprintf("%s__%s := plugify.ConstructMap(%s)\n", indent, name, name), nil
+	}
+
+	if param.Struct != nil {
+		paramType = "C." + param.Struct.Name
+	}
+
 	if paramType == "" {
 		return "", nil
 	}
 
-	// Handle vector/matrix types
-	if strings.HasPrefix(paramType, "C.Vector") || strings.HasPrefix(paramType, "C.Matrix") {
+	// Handle vector/matrix and struct types
+	if param.Struct != nil || strings.HasPrefix(paramType, "C.Vector") || strings.HasPrefix(paramType, "C.Matrix") {
 		if param.Ref {
 			return fmt.Sprintf("%s__%s := *(*%s)(unsafe.Pointer(%s))\n", indent, name, paramType, name), nil
 		}
@@ -629,7 +850,7 @@ func (g *GolangGenerator) generateParamsCastAssign(method *manifest.Method, inde
 	var result []string
 
 	// Handle return type
-	if method.RetType.Type != "void" && g.typeMapper.IsObjType(method.RetType.Type) {
+	if method.RetType.Type != "void" && g.typeMapper.IsObjType(&method.RetType) {
 		assign, err := g.generateReturnAssign(&method.RetType, indent)
 		if err != nil {
 			return nil, err
@@ -659,6 +880,26 @@ func (g *GolangGenerator) generateParamAssign(param *manifest.ParamType, indent
 	paramType := g.typeMapper.assTypeCastMap[param.Type]
 	name := param.Name
 
+	switch param.TypeNode().Unaliased().Kind {
+	case manifest.KindFixedArray:
+		typeName, err := g.typeMapper.MapTypeNode(param.TypeNode(), TypeContextReturn)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s*%s = *(*%s)(unsafe.Pointer(&__%s))\n", indent, name, typeName, name), nil
+	case manifest.KindMap:
+		typeArgs, err := g.typeMapper.MapTypeArgs(param)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s*%s = plugify.GetMapData[%s](&__%s)\n", indent, name, typeArgs, name), nil
+	}
+
+	// Structs share their layout with C, like vectors
+	if param.Struct != nil {
+		return fmt.Sprintf("%s*%s = *(*%s)(unsafe.Pointer(&__%s))\n", indent, name, param.Struct.Name, name), nil
+	}
+
 	if paramType == "" {
 		return "", nil
 	}
@@ -704,6 +945,14 @@ func (g *GolangGenerator) generateParamAssign(param *manifest.ParamType, indent
 func (g *GolangGenerator) generateReturnAssign(retType *manifest.RetType, indent string) (string, error) {
 	paramType := g.typeMapper.assTypeCastMap[retType.Type]
 
+	if retType.TypeNode().Unaliased().Kind == manifest.KindMap {
+		typeArgs, err := g.typeMapper.MapTypeArgs(retType)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s__retVal = plugify.GetMapData[%s](&__retVal_native)\n", indent, typeArgs), nil
+	}
+
 	if paramType == "" {
 		return "", nil
 	}
@@ -744,8 +993,11 @@ func (g *GolangGenerator) generateParamsCastCleanup(method *manifest.Method, ind
 	var result []string
 
 	// Handle return type
-	if method.RetType.Type != "void" && g.typeMapper.IsObjType(method.RetType.Type) {
-		cleanup := g.generateReturnCleanup(&method.RetType, indent)
+	if method.RetType.Type != "void" && g.typeMapper.IsObjType(&method.RetType) {
+		cleanup, err := g.generateReturnCleanup(&method.RetType, indent)
+		if err != nil {
+			return nil, err
+		}
 		if cleanup != "" {
 			result = append(result, cleanup)
 		}
@@ -753,7 +1005,10 @@ func (g *GolangGenerator) generateParamsCastCleanup(method *manifest.Method, ind
 
 	// Handle parameters using generic processor
 	paramCleanups, err := g.processParameters(method.ParamTypes, func(_ int, param *manifest.ParamType) (string, error) {
-		cleanup := g.generateParamCleanup(param)
+		cleanup, err := g.generateParamCleanup(param)
+		if err != nil {
+			return "", err
+		}
 		if cleanup != "" {
 			return indent + cleanup, nil
 		}
@@ -768,24 +1023,37 @@ func (g *GolangGenerator) generateParamsCastCleanup(method *manifest.Method, ind
 }
 
 // generateParamCleanup generates cleanup code for a parameter
-func (g *GolangGenerator) generateParamCleanup(param *manifest.ParamType) string {
-	paramType := g.typeMapper.delTypeCastMap[param.Type]
-	if paramType == "" {
-		return ""
+func (g *GolangGenerator) generateParamCleanup(param *manifest.ParamType) (string, error) {
+	paramType, err := g.destructor(param)
+	if err != nil || paramType == "" {
+		return "", err
 	}
 
 	name := param.Name
-	return fmt.Sprintf("%s(&__%s)\n", paramType, name)
+	return fmt.Sprintf("%s(&__%s)\n", paramType, name), nil
 }
 
 // generateReturnCleanup generates cleanup code for return value
-func (g *GolangGenerator) generateReturnCleanup(retType *manifest.RetType, indent string) string {
-	returnType := g.typeMapper.delTypeCastMap[retType.Type]
-	if returnType == "" {
-		return ""
+func (g *GolangGenerator) generateReturnCleanup(retType *manifest.RetType, indent string) (string, error) {
+	returnType, err := g.destructor(retType)
+	if err != nil || returnType == "" {
+		return "", err
 	}
 
-	return fmt.Sprintf("%s%s(&__retVal_native)\n", indent, returnType)
+	return fmt.Sprintf("%s%s(&__retVal_native)\n", indent, returnType), nil
+}
+
+// destructor names the function destroying the native copy of prop, or ""
+// when it needs none.
+func (g *GolangGenerator) destructor(prop *manifest.Property) (string, error) {
+	if prop.TypeNode().Unaliased().Kind == manifest.KindMap {
+		typeArgs, err := g.typeMapper.MapTypeArgs(prop)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("plugify.DestroyMap[%s]", typeArgs), nil
+	}
+	return g.typeMapper.delTypeCastMap[prop.Type], nil
 }
 
 // generateFunctionCall generates the C function call
@@ -856,7 +1124,16 @@ func (g *GolangGenerator) formatCastParams(params []manifest.ParamType) (string,
 	for _, param := range params {
 		name := param.Name
 
-		if g.typeMapper.IsObjType(param.Type) {
+		switch param.TypeNode().Unaliased().Kind {
+		case manifest.KindFixedArray:
+			parts = append(parts, fmt.Sprintf("&__%s[0]", name))
+			continue
+		case manifest.KindMap:
+			parts = append(parts, fmt.Sprintf("(*C.Map)(unsafe.Pointer(&__%s))", name))
+			continue
+		}
+
+		if g.typeMapper.IsObjType(&param) {
 			ctype := g.typeMapper.ctypesMap[param.Type]
 			parts = append(parts, fmt.Sprintf("(*%s)(unsafe.Pointer(&__%s))", ctype, name))
 		} else if g.typeMapper.IsPodType(param.Type) || (param.Type[:3] == "vec" || param.Type[:3] == "mat") {
@@ -878,7 +1155,7 @@ func (g *GolangGenerator) generateHMethod(method *manifest.Method, pluginName st
 	// Get return type
 	retType := "void"
 	if method.RetType.Type != "void" {
-		retType = g.typeMapper.GetCType(method.RetType.Type, false, true)
+		retType = g.typeMapper.GetPropertyCType(&method.RetType, true)
 	}
 
 	// Get parameters
@@ -922,7 +1199,7 @@ func (g *GolangGenerator) generateCMethod(method *manifest.Method, pluginName st
 	// Get return type
 	retType := "void"
 	if method.RetType.Type != "void" {
-		retType = g.typeMapper.GetCType(method.RetType.Type, false, true)
+		retType = g.typeMapper.GetPropertyCType(&method.RetType, true)
 	}
 
 	paramTypes, err := g.formatCParams(method.ParamTypes, false)
@@ -945,7 +1222,7 @@ func (g *GolangGenerator) formatCParams(params []manifest.ParamType, withNames b
 
 	var parts []string
 	for _, param := range params {
-		typeName := g.typeMapper.GetCType(param.Type, param.Ref, false)
+		typeName := g.typeMapper.GetPropertyCType(&param, false)
 
 		if withNames {
 			name := param.Name
@@ -991,7 +1268,7 @@ func (g *GolangGenerator) generateClasses(m *manifest.Manifest) (string, error)
 
 	// Generate each class
 	for _, class := range m.Classes {
-		classCode, err := g.generateClass(m, &class)
+		classCode, err := g.generateClass(m, &class, "")
 		if err != nil {
 			return "", fmt.Errorf("failed to generate class %s: %w", class.Name, err)
 		}
@@ -1002,7 +1279,7 @@ func (g *GolangGenerator) generateClasses(m *manifest.Manifest) (string, error)
 	return sb.String(), nil
 }
 
-func (g *GolangGenerator) generateClass(m *manifest.Manifest, class *manifest.Class) (string, error) {
+func (g *GolangGenerator) generateClass(m *manifest.Manifest, class *manifest.Class, platform string) (string, error) {
 	var sb strings.Builder
 
 	// Check if this is a handleless class (static methods only)
@@ -1040,6 +1317,8 @@ func (g *GolangGenerator) generateClass(m *manifest.Manifest, class *manifest.Cl
 	sb.WriteString(g.generateDocumentation(DocOptions{
 		Description: class.Description,
 		Deprecated:  class.Deprecated,
+		Since:       class.Since,
+		RemovedIn:   class.RemovedIn,
 	}))
 
 	// Struct definition
@@ -1098,8 +1377,25 @@ func (g *GolangGenerator) generateClass(m *manifest.Manifest, class *manifest.Cl
 	}
 
 	// Generate class bindings
+	bindingsCode, err := g.generateBindings(m, class, platform)
+	if err != nil {
+		return "", err
+	}
+	sb.WriteString(bindingsCode)
+
+	return sb.String(), nil
+}
+
+// generateBindings generates the bindings of a class that go in the file for
+// platform
+func (g *GolangGenerator) generateBindings(m *manifest.Manifest, class *manifest.Class, platform string) (string, error) {
+	var sb strings.Builder
+
 	errVarName := fmt.Sprintf("%sErrEmptyHandle", class.Name)
 	for _, binding := range class.Bindings {
+		if !golangBindingInFile(class, &binding, platform) {
+			continue
+		}
 		methodCode, err := g.generateBinding(m, class, &binding, errVarName)
 		if err != nil {
 			return "", err
@@ -1125,6 +1421,8 @@ func (g *GolangGenerator) generateConstructor(m *manifest.Manifest, class *manif
 		Summary:     funcName,
 		Description: method.Description,
 		Deprecated:  method.Deprecated,
+		Since:       method.Since,
+		RemovedIn:   method.RemovedIn,
 		Params:      method.ParamTypes,
 	}))
 
@@ -1336,6 +1634,8 @@ func (g *GolangGenerator) generateBinding(m *manifest.Manifest, class *manifest.
 		Summary:      binding.Name,
 		Description:  method.Description,
 		Deprecated:   deprecationReason,
+		Since:        binding.Since,
+		RemovedIn:    binding.RemovedIn,
 		Params:       method.ParamTypes,
 		RetType:      method.RetType,
 		ParamAliases: binding.ParamAliases,
@@ -1390,11 +1690,11 @@ func (g *GolangGenerator) generateBinding(m *manifest.Manifest, class *manifest.
 
 	nullPolicy := class.NullPolicy
 	if nullPolicy == "" {
-		nullPolicy = "throw"
+		nullPolicy = manifest.NullPolicyThrow
 	}
 
 	if binding.BindSelf {
-		if nullPolicy == "throw" {
+		if nullPolicy == manifest.NullPolicyThrow {
 			sb.WriteString(fmt.Sprintf("\tif w.handle == %s {\n", invalidValue))
 			if method.RetType.Type != "void" {
 				sb.WriteString("\t\tvar zero ")
@@ -1516,12 +1816,31 @@ func (g *GolangGenerator) formatClassCallArgs(params []manifest.ParamType, bindi
 func (g *GolangGenerator) generateEnumsGoFile(m *manifest.Manifest) (string, error) {
 	var sb strings.Builder
 
+	external := g.CollectExternalTypes(m)
+
 	// Package declaration
 	sb.WriteString(fmt.Sprintf("package %s\n\n", m.Name))
 
+	// Packages generated for dependencies sit next to this one
+	if plugins := external.EnumPlugins(); len(plugins) > 0 {
+		sb.WriteString("import (\n")
+		for _, plugin := range plugins {
+			sb.WriteString(fmt.Sprintf("\t\"__package__/%s\"\n", plugin))
+		}
+		sb.WriteString(")\n\n")
+	}
+
 	// Add comment header
 	sb.WriteString(fmt.Sprintf("// Generated from %s\n\n", m.Name))
 
+	// Enums from dependencies are aliased rather than redefined
+	for _, plugin := range external.EnumPlugins() {
+		for _, enum := range external.Enums[plugin] {
+			sb.WriteString(fmt.Sprintf("type %s = %s.%s\n", enum.Name, plugin, enum.Name))
+		}
+		sb.WriteString("\n")
+	}
+
 	// Generate enums
 	enumsCode, err := g.generateEnums(m)
 	if err != nil {
@@ -1532,6 +1851,16 @@ func (g *GolangGenerator) generateEnumsGoFile(m *manifest.Manifest) (string, err
 		sb.WriteString("\n")
 	}
 
+	// Generate constants
+	constantsCode, err := g.CollectConstants(m, g.generateConstant)
+	if err != nil {
+		return "", err
+	}
+	if constantsCode != "" {
+		sb.WriteString(constantsCode)
+		sb.WriteString("\n")
+	}
+
 	// Generate ownership types if any class has a destructor
 	hasDestructor := false
 	for _, class := range m.Classes {
@@ -1575,20 +1904,119 @@ func (g *GolangGenerator) generateAliasesGoFile(m *manifest.Manifest) (string, e
 	return sb.String(), nil
 }
 
+// generateStructsGoFile generates a file containing all struct types
+func (g *GolangGenerator) generateStructsGoFile(m *manifest.Manifest) (string, error) {
+	var sb strings.Builder
+
+	external := g.CollectExternalTypes(m)
+
+	// Package declaration
+	sb.WriteString(fmt.Sprintf("package %s\n\n", m.Name))
+
+	if plugins := external.StructPlugins(); len(plugins) > 0 {
+		// Packages generated for dependencies sit next to this one
+		sb.WriteString("import (\n")
+		sb.WriteString("\t\"github.com/untrustedmodders/go-plugify\"\n")
+		for _, plugin := range plugins {
+			sb.WriteString(fmt.Sprintf("\t\"__package__/%s\"\n", plugin))
+		}
+		sb.WriteString(")\n\n")
+	} else {
+		sb.WriteString("import \"github.com/untrustedmodders/go-plugify\"\n\n")
+	}
+
+	sb.WriteString("var _ = plugify.ApiVersion\n\n")
+
+	// Add comment header
+	sb.WriteString(fmt.Sprintf("// Generated from %s\n\n", m.Name))
+
+	// Structs from dependencies are aliased rather than redefined
+	for _, plugin := range external.StructPlugins() {
+		for _, structure := range external.Structs[plugin] {
+			sb.WriteString(fmt.Sprintf("type %s = %s.%s\n", structure.Name, plugin, structure.Name))
+		}
+		sb.WriteString("\n")
+	}
+
+	// Generate structs
+	structsCode, err := g.CollectStructs(m, g.generateStruct)
+	if err != nil {
+		return "", err
+	}
+	if structsCode != "" {
+		sb.WriteString(structsCode)
+	}
+
+	return sb.String(), nil
+}
+
+// generateStruct generates a Go struct with the same layout as its C typedef
+func (g *GolangGenerator) generateStruct(structure *manifest.Struct) (string, error) {
+	var sb strings.Builder
+
+	if structure.Description != "" {
+		sb.WriteString(fmt.Sprintf("// %s - %s\n", structure.Name, structure.Description))
+	}
+	sb.WriteString(golangAvailability(structure.Description, structure.Since, structure.RemovedIn))
+	if structure.Deprecated != "" {
+		if structure.Description != "" || structure.Since != "" || structure.RemovedIn != "" {
+			sb.WriteString("//\n")
+		}
+		sb.WriteString(fmt.Sprintf("// Deprecated: %s\n", structure.Deprecated))
+	}
+
+	sb.WriteString(fmt.Sprintf("type %s struct {\n", structure.Name))
+	for i := range structure.Fields {
+		field := &structure.Fields[i]
+		fieldType, err := g.typeMapper.MapReturnType(field)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(fmt.Sprintf("\t%s %s", manifest.Capitalize(field.Name), fieldType))
+		if field.Description != "" {
+			sb.WriteString(fmt.Sprintf(" // %s", field.Description))
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("}\n")
+
+	return sb.String(), nil
+}
+
 // generateDelegatesGoFile generates a file containing all delegate types
 func (g *GolangGenerator) generateDelegatesGoFile(m *manifest.Manifest) (string, error) {
 	var sb strings.Builder
 
+	external := g.CollectExternalTypes(m)
+
 	// Package declaration
 	sb.WriteString(fmt.Sprintf("package %s\n\n", m.Name))
 
-	sb.WriteString("import \"github.com/untrustedmodders/go-plugify\"\n\n")
+	if plugins := external.PrototypePlugins(); len(plugins) > 0 {
+		// Packages generated for dependencies sit next to this one
+		sb.WriteString("import (\n")
+		sb.WriteString("\t\"github.com/untrustedmodders/go-plugify\"\n")
+		for _, plugin := range plugins {
+			sb.WriteString(fmt.Sprintf("\t\"__package__/%s\"\n", plugin))
+		}
+		sb.WriteString(")\n\n")
+	} else {
+		sb.WriteString("import \"github.com/untrustedmodders/go-plugify\"\n\n")
+	}
 
 	sb.WriteString("var _ = plugify.ApiVersion\n\n")
 
 	// Add comment header
 	sb.WriteString(fmt.Sprintf("// Generated from %s\n\n", m.Name))
 
+	// Delegates from dependencies are aliased rather than redefined
+	for _, plugin := range external.PrototypePlugins() {
+		for _, proto := range external.Prototypes[plugin] {
+			sb.WriteString(fmt.Sprintf("type %s = %s.%s\n", proto.Name, plugin, proto.Name))
+		}
+		sb.WriteString("\n")
+	}
+
 	// Generate delegates
 	delegatesCode, err := g.generateDelegates(m)
 	if err != nil {
@@ -1651,6 +2079,35 @@ func (g *GolangGenerator) generateSharedHFile(m *manifest.Manifest) (string, err
 	sb.WriteString("#endif\n")
 	sb.WriteString("\tuint8_t current;\n")
 	sb.WriteString("} Variant;\n\n")
+	if HasKind(m, manifest.KindMap) {
+		sb.WriteString("typedef struct Map { Vector entries; } Map;\n\n")
+	}
+
+	// Fixed-size arrays returned, each in a struct of its own
+	declared := map[string]bool{}
+	for i := range m.Methods {
+		node := m.Methods[i].RetType.TypeNode().Unaliased()
+		if node.Kind != manifest.KindFixedArray {
+			continue
+		}
+		if name := g.typeMapper.FixedArrayCType(node); !declared[name] {
+			declared[name] = true
+			sb.WriteString(fmt.Sprintf("typedef struct %s { %s data[%d]; } %s;\n", name, g.typeMapper.GetCType(node.Value().Name, false, true), node.Len, name))
+		}
+	}
+	if len(declared) > 0 {
+		sb.WriteString("\n")
+	}
+
+	// Structs, dependencies' included, since cgo needs every layout spelled out
+	for _, structure := range g.UsedStructs(m) {
+		fields := make([]string, len(structure.Fields))
+		for i := range structure.Fields {
+			field := &structure.Fields[i]
+			fields[i] = fmt.Sprintf("%s %s;", g.typeMapper.GetPropertyCType(field, true), field.Name)
+		}
+		sb.WriteString(fmt.Sprintf("typedef struct %s { %s } %s;\n", structure.Name, strings.Join(fields, " "), structure.Name))
+	}
 
 	return sb.String(), nil
 }
@@ -1669,14 +2126,19 @@ func (g *GolangGenerator) generateSharedGoFile(m *manifest.Manifest) (string, er
 }
 
 // generateExportGoFile generates a export-specific Go file
-func (g *GolangGenerator) generateExportGoFile(m *manifest.Manifest) (string, error) {
+func (g *GolangGenerator) generateExportGoFile(m *manifest.Manifest, platform string) (string, error) {
 	var sb strings.Builder
 
 	packageName := manifest.Capitalize(m.Name)
 	packagePath := fmt.Sprintf("__package__/%s", m.Name)
 
 	// Package declaration
-	sb.WriteString("//go:build plugin\n// +build plugin\npackage main\n\n")
+	if platform == "" {
+		sb.WriteString("//go:build plugin\n// +build plugin\npackage main\n\n")
+	} else {
+		goos := golangGOOS[platform]
+		sb.WriteString(fmt.Sprintf("//go:build plugin && %s\n// +build plugin,%s\npackage main\n\n", goos, goos))
+	}
 	sb.WriteString("//TODO: replace \"__package__\" by your package name\n")
 	sb.WriteString("import (\n")
 	sb.WriteString("\t\"unsafe\"\n")
@@ -1689,6 +2151,10 @@ func (g *GolangGenerator) generateExportGoFile(m *manifest.Manifest) (string, er
 
 	// Generate methods for this group
 	for _, method := range m.Methods {
+		if !golangInFile(method.Platforms, platform) {
+			continue
+		}
+
 		// Generate function signature
 		params, err := g.formatFullParams(method.ParamTypes, true, m.Name)
 		if err != nil {
@@ -1720,20 +2186,23 @@ func (g *GolangGenerator) generateExportGoFile(m *manifest.Manifest) (string, er
 }
 
 // generateGroupGoFile generates a group-specific Go file
-func (g *GolangGenerator) generateGroupGoFile(m *manifest.Manifest, groupName string, opts *GeneratorOptions) (string, error) {
+func (g *GolangGenerator) generateGroupGoFile(m *manifest.Manifest, groupName, platform string, opts *GeneratorOptions) (string, error) {
 	var sb strings.Builder
 
 	// Package declaration
+	if platform != "" {
+		sb.WriteString(fmt.Sprintf("//go:build %s\n\n", golangGOOS[platform]))
+	}
 	sb.WriteString(fmt.Sprintf("package %s\n\n", m.Name))
 
 	// CGo comment block
 	sb.WriteString("/*\n")
-	sb.WriteString(fmt.Sprintf("#include \"%s.h\"\n", groupName))
+	sb.WriteString(fmt.Sprintf("#include \"%s.h\"\n", golangPlatformFile(groupName, platform)))
 
 	// Add noescape directives for methods in this group
 	for _, method := range m.Methods {
 		methodGroup := method.Group
-		if methodGroup == groupName {
+		if methodGroup == groupName && golangInFile(method.Platforms, platform) {
 			sb.WriteString(fmt.Sprintf("#cgo noescape %s\n", method.Name))
 		}
 	}
@@ -1760,7 +2229,7 @@ func (g *GolangGenerator) generateGroupGoFile(m *manifest.Manifest, groupName st
 	// Generate methods for this group
 	for _, method := range m.Methods {
 		methodGroup := method.Group
-		if methodGroup == groupName {
+		if methodGroup == groupName && golangInFile(method.Platforms, platform) {
 			methodCode, err := g.generateMethod(&method, m.Name, opts.GenerateScopes)
 			if err != nil {
 				return "", fmt.Errorf("failed to generate method %s: %w", method.Name, err)
@@ -1770,16 +2239,39 @@ func (g *GolangGenerator) generateGroupGoFile(m *manifest.Manifest, groupName st
 		}
 	}
 
+	// Generate subscription helpers for the events subscribed in this group
+	for _, event := range EventsInGroup(m, groupName) {
+		if subscribe, _ := EventMethods(m, event); golangInFile(subscribe.Platforms, platform) {
+			eventCode, err := g.generateEventSubscription(m, event)
+			if err != nil {
+				return "", fmt.Errorf("failed to generate event %s: %w", event.Name, err)
+			}
+			sb.WriteString(eventCode)
+			sb.WriteString("\n")
+		}
+	}
+
 	// Generate classes for this group (if enabled)
 	if opts.GenerateClasses {
 		for _, class := range m.Classes {
 			classGroup := class.Group
-			if classGroup == groupName {
-				classCode, err := g.generateClass(m, &class)
+			if classGroup != groupName {
+				continue
+			}
+			// A class built everywhere has its type here only once; the file
+			// of a platform adds just the bindings that platform has.
+			if golangInFile(class.Platforms, platform) {
+				classCode, err := g.generateClass(m, &class, platform)
 				if err != nil {
 					return "", fmt.Errorf("failed to generate class %s: %w", class.Name, err)
 				}
 				sb.WriteString(classCode)
+			} else {
+				bindingsCode, err := g.generateBindings(m, &class, platform)
+				if err != nil {
+					return "", fmt.Errorf("failed to generate class %s: %w", class.Name, err)
+				}
+				sb.WriteString(bindingsCode)
 			}
 		}
 	}
@@ -1788,7 +2280,7 @@ func (g *GolangGenerator) generateGroupGoFile(m *manifest.Manifest, groupName st
 }
 
 // generateGroupHFile generates a group-specific C header file
-func (g *GolangGenerator) generateGroupHFile(m *manifest.Manifest, groupName string, opts *GeneratorOptions) (string, error) {
+func (g *GolangGenerator) generateGroupHFile(m *manifest.Manifest, groupName, platform string, opts *GeneratorOptions) (string, error) {
 	var sb strings.Builder
 
 	// Header guard and includes
@@ -1798,7 +2290,7 @@ func (g *GolangGenerator) generateGroupHFile(m *manifest.Manifest, groupName str
 	// Method implementations for this group
 	for _, method := range m.Methods {
 		methodGroup := method.Group
-		if methodGroup == groupName {
+		if methodGroup == groupName && golangInFile(method.Platforms, platform) {
 			methodCode, err := g.generateHMethod(&method, m.Name)
 			if err != nil {
 				return "", err
@@ -1812,16 +2304,19 @@ func (g *GolangGenerator) generateGroupHFile(m *manifest.Manifest, groupName str
 }
 
 // generateGroupCFile generates a group-specific C impl file
-func (g *GolangGenerator) generateGroupCFile(m *manifest.Manifest, groupName string, opts *GeneratorOptions) (string, error) {
+func (g *GolangGenerator) generateGroupCFile(m *manifest.Manifest, groupName, platform string, opts *GeneratorOptions) (string, error) {
 	var sb strings.Builder
 
 	// Header guard and includes
+	if platform != "" {
+		sb.WriteString(fmt.Sprintf("//go:build %s\n\n", golangGOOS[platform]))
+	}
 	sb.WriteString("#include \"shared.h\"\n\n")
 
 	// Method implementations for this group
 	for _, method := range m.Methods {
 		methodGroup := method.Group
-		if methodGroup == groupName {
+		if methodGroup == groupName && golangInFile(method.Platforms, platform) {
 			methodCode, err := g.generateCMethod(&method, m.Name)
 			if err != nil {
 				return "", err
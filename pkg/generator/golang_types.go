@@ -459,58 +459,40 @@ func (m *GolangTypeMapper) MapType(baseType string, context TypeContext, isArray
 	return mapped, nil
 }
 
-// MapParamType implements TypeMapper interface
-func (m *GolangTypeMapper) MapParamType(param *manifest.ParamType) (string, error) {
-	// Regular type mapping
-	ctx := TypeContextValue
-	if param.Ref {
-		ctx = TypeContextRef
-	}
-
-	var typeName string
-	switch {
-	case param.Alias != nil:
-		typeName = param.Alias.Name
-		ctx |= TypeContextAlias
-
-	case param.Enum != nil:
-		typeName = param.Enum.Name
+// MapFixedArrayType implements TypeMapper interface
+func (m *GolangTypeMapper) MapFixedArrayType(element string, length int, context TypeContext) (string, error) {
+	return golangPassed(fmt.Sprintf("[%d]%s", length, element), context), nil
+}
 
-	case param.Prototype != nil:
-		return param.Prototype.Name, nil
+// MapMapType implements TypeMapper interface
+func (m *GolangTypeMapper) MapMapType(key, value string, context TypeContext) (string, error) {
+	return golangPassed(fmt.Sprintf("map[%s]%s", key, value), context), nil
+}
 
-	default:
-		typeName = param.BaseType()
+// golangPassed makes a ref parameter a pointer, as MapType does.
+func golangPassed(mapped string, context TypeContext) string {
+	if context&TypeContextRef != 0 {
+		return "*" + mapped
 	}
+	return mapped
+}
 
-	return m.MapType(typeName, ctx, param.IsArray())
+// MapParamType implements TypeMapper interface
+func (m *GolangTypeMapper) MapParamType(param *manifest.ParamType) (string, error) {
+	return m.MapTypeNode(param.TypeNode(), golangParamContext(param))
 }
 
 // MapParamTypeFull implements TypeMapper interface with package
 func (m *GolangTypeMapper) MapParamTypeFull(param *manifest.ParamType, packageName string) (string, error) {
-	// Regular type mapping
-	ctx := TypeContextValue
-	if param.Ref {
-		ctx = TypeContextRef
-	}
-
-	var typeName string
-	switch {
-	case param.Alias != nil:
-		typeName = fmt.Sprintf("%s.%s", packageName, param.Alias.Name)
-		ctx |= TypeContextAlias
-
-	case param.Enum != nil:
-		typeName = fmt.Sprintf("%s.%s", packageName, param.Enum.Name)
-
-	case param.Prototype != nil:
-		return fmt.Sprintf("%s.%s", packageName, param.Prototype.Name), nil
+	return m.MapTypeNodeFull(param.TypeNode(), golangParamContext(param), packageName)
+}
 
-	default:
-		typeName = param.BaseType()
+// golangParamContext is the context a parameter is passed in
+func golangParamContext(param *manifest.ParamType) TypeContext {
+	if param.Ref {
+		return TypeContextRef
 	}
-
-	return m.MapType(typeName, ctx, param.IsArray())
+	return TypeContextValue
 }
 
 // MapReturnType implements TypeMapper interface
@@ -518,26 +500,7 @@ func (m *GolangTypeMapper) MapReturnType(retType *manifest.RetType) (string, err
 	if retType == nil || retType.Type == "void" {
 		return "", nil
 	}
-
-	ctx := TypeContextReturn
-
-	var typeName string
-	switch {
-	case retType.Alias != nil:
-		typeName = retType.Alias.Name
-		ctx |= TypeContextAlias
-
-	case retType.Enum != nil:
-		typeName = retType.Enum.Name
-
-	case retType.Prototype != nil:
-		return retType.Prototype.Name, nil
-
-	default:
-		typeName = retType.BaseType()
-	}
-
-	return m.MapType(typeName, ctx, retType.IsArray())
+	return m.MapTypeNode(retType.TypeNode(), TypeContextReturn)
 }
 
 // MapReturnTypeFull implements TypeMapper interface with package
@@ -545,26 +508,56 @@ func (m *GolangTypeMapper) MapReturnTypeFull(retType *manifest.RetType, packageN
 	if retType == nil || retType.Type == "void" {
 		return "", nil
 	}
+	return m.MapTypeNodeFull(retType.TypeNode(), TypeContextReturn, packageName)
+}
+
+// MapTypeNode implements TypeMapper interface
+func (m *GolangTypeMapper) MapTypeNode(node *manifest.TypeNode, context TypeContext) (string, error) {
+	return mapTypeNode(m, node, context)
+}
+
+// MapTypeNodeFull is MapTypeNode with the types the manifest declares
+// qualified by packageName
+func (m *GolangTypeMapper) MapTypeNodeFull(node *manifest.TypeNode, context TypeContext, packageName string) (string, error) {
+	qualified := func(name string) string {
+		return fmt.Sprintf("%s.%s", packageName, name)
+	}
 
-	ctx := TypeContextReturn
+	switch node.Kind {
+	case manifest.KindPrototype:
+		return qualified(node.Prototype.Name), nil
 
-	var typeName string
-	switch {
-	case retType.Alias != nil:
-		typeName = fmt.Sprintf("%s.%s", packageName, retType.Alias.Name)
-		ctx |= TypeContextAlias
+	case manifest.KindAlias:
+		return m.MapType(qualified(node.Alias.Name), context|TypeContextAlias, node.IsArray())
 
-	case retType.Enum != nil:
-		typeName = fmt.Sprintf("%s.%s", packageName, retType.Enum.Name)
+	case manifest.KindEnum, manifest.KindStruct:
+		return m.MapType(qualified(valueTypeName(node)), context, false)
+
+	case manifest.KindArray:
+		if kind := node.Elem.Kind; kind == manifest.KindEnum || kind == manifest.KindStruct {
+			return m.MapType(qualified(valueTypeName(node.Elem)), context, true)
+		}
 
-	case retType.Prototype != nil:
-		return fmt.Sprintf("%s.%s", packageName, retType.Prototype.Name), nil
+	case manifest.KindFixedArray:
+		element, err := m.MapTypeNodeFull(node.Elem, TypeContextReturn, packageName)
+		if err != nil {
+			return "", err
+		}
+		return m.MapFixedArrayType(element, node.Len, context|TypeContextObject)
 
-	default:
-		typeName = retType.BaseType()
+	case manifest.KindMap:
+		value, err := m.MapTypeNodeFull(node.Elem, TypeContextReturn, packageName)
+		if err != nil {
+			return "", err
+		}
+		key, err := m.MapTypeNode(node.Key, TypeContextReturn)
+		if err != nil {
+			return "", err
+		}
+		return m.MapMapType(key, value, context|TypeContextObject)
 	}
 
-	return m.MapType(typeName, ctx, retType.IsArray())
+	return m.MapTypeNode(node, context)
 }
 
 // MapHandleType implements TypeMapper interface
@@ -606,11 +599,83 @@ func (m *GolangTypeMapper) GetCType(baseType string, isRef bool, isRet bool) str
 	return result
 }
 
+// GetPropertyCType returns the C type for a parameter or return type. Unlike
+// GetCType it knows structs, which are passed by pointer like the vectors, and
+// fixed-size arrays and maps.
+func (m *GolangTypeMapper) GetPropertyCType(prop *manifest.Property, isRet bool) string {
+	switch node := prop.TypeNode().Unaliased(); node.Kind {
+	case manifest.KindFixedArray:
+		// Passed as its first element like any C array, and returned in a
+		// struct since C cannot return an array
+		if isRet {
+			return m.FixedArrayCType(node)
+		}
+		return m.GetCType(node.Value().Name, false, true) + "*"
+	case manifest.KindMap:
+		if isRet {
+			return "Map"
+		}
+		return "Map*"
+	}
+	if prop.Struct != nil {
+		if isRet {
+			return prop.Struct.Name
+		}
+		return prop.Struct.Name + "*"
+	}
+	return m.GetCType(prop.Type, prop.Ref, isRet)
+}
+
 // Helper methods for type checking
-func (m *GolangTypeMapper) IsObjType(baseType string) bool {
-	return baseType == "string" || baseType == "any" || len(baseType) > 2 && baseType[len(baseType)-2:] == "[]"
+
+// IsObjType reports whether prop crosses as a native object: a string, any,
+// vector or map.
+func (m *GolangTypeMapper) IsObjType(prop *manifest.Property) bool {
+	switch node := prop.TypeNode().Unaliased(); node.Kind {
+	case manifest.KindArray, manifest.KindMap:
+		return true
+	case manifest.KindPrimitive:
+		return node.Name == "string" || node.Name == "any"
+	}
+	return false
 }
 
 func (m *GolangTypeMapper) IsPodType(baseType string) bool {
-	return baseType == "vec2" || baseType == "vec3" || baseType == "vec4" || baseType == "mat4x4"
+	return baseType == "vec2" || baseType == "vec3" || baseType == "vec4" || baseType == "mat4x4" || baseType == "struct"
+}
+
+// FixedArrayCType names the struct shared.h declares to return a fixed-size
+// array in, as FixedArray_float_3 for float[3].
+func (m *GolangTypeMapper) FixedArrayCType(node *manifest.TypeNode) string {
+	return fmt.Sprintf("FixedArray_%s_%d", m.GetCType(node.Value().Name, false, true), node.Len)
+}
+
+// FixedArrayCgoType is the cgo type a fixed-size array is copied to before a
+// call, as [3]C.float for float[3].
+func (m *GolangTypeMapper) FixedArrayCgoType(node *manifest.TypeNode) string {
+	return fmt.Sprintf("[%d]C.%s", node.Len, m.GetCType(node.Value().Name, false, true))
+}
+
+// MapTypeArgs lists the Go key and value types of a map, as the type arguments
+// of the plugify map helpers, which cannot infer them from a native map.
+func (m *GolangTypeMapper) MapTypeArgs(prop *manifest.Property) (string, error) {
+	node := prop.TypeNode()
+	key, err := m.MapTypeNode(node.Key, TypeContextReturn)
+	if err != nil {
+		return "", err
+	}
+	value, err := m.MapTypeNode(node.Elem, TypeContextReturn)
+	if err != nil {
+		return "", err
+	}
+	return key + ", " + value, nil
+}
+
+// NativeType is the Go type holding a native object returned by value before
+// its data is copied out, or "" for a type returned as it is.
+func (m *GolangTypeMapper) NativeType(prop *manifest.Property) string {
+	if prop.TypeNode().Unaliased().Kind == manifest.KindMap {
+		return "plugify.PlgMap"
+	}
+	return m.retTypeCastMap[prop.Type]
 }
@@ -2,6 +2,8 @@ package generator
 
 import (
 	"fmt"
+	"math"
+	"strconv"
 	"strings"
 
 	"github.com/untrustedmodders/plugify-gen/pkg/manifest"
@@ -22,6 +24,9 @@ func NewLuaGenerator() *LuaGenerator {
 // Generate generates Lua bindings
 func (g *LuaGenerator) Generate(m *manifest.Manifest, opts *GeneratorOptions) (*GeneratorResult, error) {
 	g.ResetCaches()
+	if err := m.CheckSanitizedNames(g.Sanitizer); err != nil {
+		return nil, err
+	}
 	m.Sanitize(g.Sanitizer)
 	opts = EnsureOptions(opts)
 
@@ -30,6 +35,19 @@ func (g *LuaGenerator) Generate(m *manifest.Manifest, opts *GeneratorOptions) (*
 	// Header comment
 	sb.WriteString(fmt.Sprintf("-- Generated from %s.pplugin\n\n", m.Name))
 
+	// Enums from dependencies are the globals their own stubs define, and their
+	// structs are documented there
+	external := g.CollectExternalTypes(m)
+	for _, plugin := range external.EnumPlugins() {
+		sb.WriteString(fmt.Sprintf("-- %s: defined by %s.pplugin\n", strings.Join(external.EnumNames(plugin), ", "), plugin))
+	}
+	for _, plugin := range external.StructPlugins() {
+		sb.WriteString(fmt.Sprintf("-- %s: defined by %s.pplugin\n", strings.Join(external.StructNames(plugin), ", "), plugin))
+	}
+	if len(external.Enums) > 0 || len(external.Structs) > 0 {
+		sb.WriteString("\n")
+	}
+
 	// Generate enums
 	enumsCode, err := g.generateEnums(m)
 	if err != nil {
@@ -40,6 +58,25 @@ func (g *LuaGenerator) Generate(m *manifest.Manifest, opts *GeneratorOptions) (*
 		sb.WriteString("\n")
 	}
 
+	// Generate constants
+	constantsCode, err := g.CollectConstants(m, g.generateConstant)
+	if err != nil {
+		return nil, err
+	}
+	if constantsCode != "" {
+		sb.WriteString(constantsCode)
+		sb.WriteString("\n")
+	}
+
+	// Generate structs
+	structsCode, err := g.CollectStructs(m, g.generateStruct)
+	if err != nil {
+		return nil, err
+	}
+	if structsCode != "" {
+		sb.WriteString(structsCode)
+	}
+
 	// Generate methods
 	for _, method := range m.Methods {
 		methodCode, err := g.generateMethod(&method)
@@ -74,6 +111,60 @@ func (g *LuaGenerator) generateEnums(m *manifest.Manifest) (string, error) {
 	return g.CollectEnums(m, g.generateEnum)
 }
 
+// generateConstant generates a constant as a global annotated with its type
+// for the language server. Lua has no constants, so nothing stops a script
+// from assigning to it.
+func (g *LuaGenerator) generateConstant(constant *manifest.Constant) (string, error) {
+	var sb strings.Builder
+
+	if constant.Description != "" {
+		sb.WriteString(fmt.Sprintf("-- %s\n", constant.Description))
+	}
+	sb.WriteString(formatAvailabilityComment(constant.Since, constant.RemovedIn))
+	if constant.Deprecated != "" {
+		sb.WriteString(fmt.Sprintf("---@deprecated %s\n", constant.Deprecated))
+	}
+
+	typeName, literal := luaLiteral(constant.Literal)
+	sb.WriteString(fmt.Sprintf("---@type %s\n", typeName))
+	sb.WriteString(fmt.Sprintf("%s = %s\n", constant.Name, literal))
+	return sb.String(), nil
+}
+
+// luaLiteral gives the type the language server knows a checked value by, and
+// the value as a Lua literal. A character is its code.
+func luaLiteral(value *manifest.DefaultValue) (string, string) {
+	switch value.Kind {
+	case manifest.DefaultBool:
+		return "boolean", strconv.FormatBool(value.Bool)
+	case manifest.DefaultInt:
+		return "integer", luaInteger(manifest.NewInt(value.Int))
+	case manifest.DefaultUint:
+		return "integer", luaInteger(manifest.NewUint(value.Uint))
+	case manifest.DefaultFloat:
+		return "number", floatLiteral(value.Float)
+	case manifest.DefaultChar:
+		return "integer", strconv.Itoa(int(value.Char))
+	}
+	return "string", bracedQuote(value.String, '"')
+}
+
+// luaInteger writes value as a Lua integer literal that reads back as the same
+// 64 bits.
+func luaInteger(value manifest.Integer) string {
+	if signed, ok := value.Int64(); ok {
+		if signed == math.MinInt64 {
+			// The literal would be the negation of a value Lua cannot hold.
+			return "math.mininteger"
+		}
+		return strconv.FormatInt(signed, 10)
+	}
+	// A decimal literal past the integers would be read as a float; a hex one
+	// wraps around to the same 64 bits.
+	unsigned, _ := value.Uint64()
+	return fmt.Sprintf("0x%X", unsigned)
+}
+
 func (g *LuaGenerator) generateEnum(enum *manifest.Enum, underlyingType string) (string, error) {
 	var sb strings.Builder
 
@@ -83,6 +174,7 @@ func (g *LuaGenerator) generateEnum(enum *manifest.Enum, underlyingType string)
 	} else {
 		sb.WriteString(fmt.Sprintf("-- Enum: %s\n", enum.Name))
 	}
+	sb.WriteString(formatAvailabilityComment(enum.Since, enum.RemovedIn))
 
 	// Lua has no deprecation of its own; this is the annotation the language
 	// server reads. Delegates are not emitted as named declarations here, so
@@ -98,7 +190,7 @@ func (g *LuaGenerator) generateEnum(enum *manifest.Enum, underlyingType string)
 		if val.Description != "" {
 			sb.WriteString(fmt.Sprintf("  -- %s\n", val.Description))
 		}
-		sb.WriteString(fmt.Sprintf("  %s = %d,\n", val.Name, val.Value))
+		sb.WriteString(fmt.Sprintf("  %s = %s,\n", val.Name, luaInteger(val.Value)))
 	}
 
 	sb.WriteString("}\n")
@@ -131,6 +223,8 @@ func (g *LuaGenerator) generateClass(m *manifest.Manifest, class *manifest.Class
 
 	// Class comment
 	sb.WriteString(g.formatDescriptionComment(class.Description, fmt.Sprintf("Class: %s", class.Name)))
+	sb.WriteString(g.formatPlatformsComment(class.Platforms))
+	sb.WriteString(formatAvailabilityComment(class.Since, class.RemovedIn))
 
 	// Class table declaration
 	sb.WriteString(fmt.Sprintf("%s = {}\n\n", class.Name))
@@ -222,6 +316,8 @@ func (g *LuaGenerator) generateConstructor(m *manifest.Manifest, class *manifest
 	sb.WriteString(g.generateLuaDocumentation(DocOptions{
 		Description: method.Description,
 		Deprecated:  method.Deprecated,
+		Since:       method.Since,
+		RemovedIn:   method.RemovedIn,
 		Summary:     class.Name,
 		Params:      method.ParamTypes,
 		RetType:     classRetType,
@@ -261,6 +357,9 @@ func (g *LuaGenerator) generateBinding(m *manifest.Manifest, class *manifest.Cla
 	sb.WriteString(g.generateLuaDocumentation(DocOptions{
 		Description:  method.Description,
 		Deprecated:   deprecationReason,
+		Since:        binding.Since,
+		RemovedIn:    binding.RemovedIn,
+		Platforms:    binding.Platforms,
 		Summary:      binding.Name,
 		Params:       methodParams,
 		RetType:      method.RetType,
@@ -315,6 +414,35 @@ func (g *LuaGenerator) formatParameters(params []manifest.ParamType) string {
 }
 
 // formatDescriptionComment generates description comment with fallback
+// generateStruct documents a struct. It crosses into Lua as a plain table, so
+// there is nothing to define, only the fields to list.
+func (g *LuaGenerator) generateStruct(structure *manifest.Struct) (string, error) {
+	var sb strings.Builder
+
+	if structure.Description != "" {
+		sb.WriteString(fmt.Sprintf("-- %s: %s\n", structure.Name, structure.Description))
+	} else {
+		sb.WriteString(fmt.Sprintf("-- Struct: %s\n", structure.Name))
+	}
+	sb.WriteString(formatAvailabilityComment(structure.Since, structure.RemovedIn))
+	if structure.Deprecated != "" {
+		sb.WriteString(fmt.Sprintf("-- Deprecated: %s\n", structure.Deprecated))
+	}
+
+	for _, field := range structure.Fields {
+		fieldType := field.Type
+		switch {
+		case field.Enum != nil:
+			fieldType = field.Enum.Name
+		case field.Struct != nil:
+			fieldType = field.Struct.Name
+		}
+		sb.WriteString(fmt.Sprintf("--   %s %s %s\n", field.Name, fieldType, field.Description))
+	}
+
+	return sb.String(), nil
+}
+
 func (g *LuaGenerator) formatDescriptionComment(description, fallbackName string) string {
 	if description != "" {
 		return fmt.Sprintf("--- %s\n", description)
@@ -322,12 +450,33 @@ func (g *LuaGenerator) formatDescriptionComment(description, fallbackName string
 	return fmt.Sprintf("--- %s\n", fallbackName)
 }
 
+// formatPlatformsComment notes the platforms an item is limited to, if any.
+// Lua has nothing to leave it out with, so calling it elsewhere fails at run
+// time.
+func (g *LuaGenerator) formatPlatformsComment(platforms []string) string {
+	if len(platforms) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("-- Platforms: %s\n", strings.Join(platforms, ", "))
+}
+
+// formatAvailabilityComment notes the plugin versions an item exists in, if
+// the manifest names them.
+func formatAvailabilityComment(since, removedIn string) string {
+	if note := Availability(since, removedIn); note != "" {
+		return fmt.Sprintf("-- %s\n", note)
+	}
+	return ""
+}
+
 // generateLuaDocumentation generates LDoc-style documentation for methods
 func (g *LuaGenerator) generateLuaDocumentation(opts DocOptions) string {
 	var sb strings.Builder
 
 	// Main description
 	sb.WriteString(g.formatDescriptionComment(opts.Description, opts.Summary))
+	sb.WriteString(g.formatPlatformsComment(opts.Platforms))
+	sb.WriteString(formatAvailabilityComment(opts.Since, opts.RemovedIn))
 
 	// Parameters
 	for i, param := range opts.Params {
@@ -338,6 +487,10 @@ func (g *LuaGenerator) generateLuaDocumentation(opts DocOptions) string {
 		if i < len(opts.ParamAliases) && opts.ParamAliases[i] != nil && opts.ParamAliases[i].Name != "" {
 			paramType = opts.ParamAliases[i].Name
 		}
+		// LuaLS and EmmyLua read a trailing ? as "or nil"
+		if param.Optional {
+			paramType += "?"
+		}
 
 		sb.WriteString(fmt.Sprintf("-- @param %s %s %s\n",
 			paramName, paramType, param.Description))
@@ -352,6 +505,9 @@ func (g *LuaGenerator) generateLuaDocumentation(opts DocOptions) string {
 		if opts.RetAlias != nil && opts.RetAlias.Name != "" {
 			retType = opts.RetAlias.Name
 		}
+		if opts.RetType.Optional {
+			retType += "?"
+		}
 
 		sb.WriteString(fmt.Sprintf("-- @return %s %s\n", retType, retDesc))
 	}
@@ -391,6 +547,9 @@ func (g *LuaGenerator) generateDocumentation(method *manifest.Method) string {
 	return g.generateLuaDocumentation(DocOptions{
 		Description:      method.Description,
 		Deprecated:       method.Deprecated,
+		Since:            method.Since,
+		RemovedIn:        method.RemovedIn,
+		Platforms:        method.Platforms,
 		Summary:          method.Name,
 		Params:           method.ParamTypes,
 		RetType:          method.RetType,
@@ -410,6 +569,18 @@ func (m *LuaTypeMapper) MapType(baseType string, context TypeContext, isArray bo
 	return "", nil
 }
 
+func (m *LuaTypeMapper) MapFixedArrayType(element string, length int, context TypeContext) (string, error) {
+	return "", nil
+}
+
+func (m *LuaTypeMapper) MapMapType(key, value string, context TypeContext) (string, error) {
+	return "", nil
+}
+
+func (m *LuaTypeMapper) MapTypeNode(node *manifest.TypeNode, context TypeContext) (string, error) {
+	return "", nil
+}
+
 func (m *LuaTypeMapper) MapParamType(param *manifest.ParamType) (string, error) {
 	return "", nil
 }
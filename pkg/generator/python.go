@@ -2,6 +2,7 @@ package generator
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/untrustedmodders/plugify-gen/pkg/manifest"
@@ -22,6 +23,9 @@ func NewPythonGenerator() *PythonGenerator {
 // Generate generates Python bindings
 func (g *PythonGenerator) Generate(m *manifest.Manifest, opts *GeneratorOptions) (*GeneratorResult, error) {
 	g.ResetCaches()
+	if err := m.CheckSanitizedNames(g.Sanitizer); err != nil {
+		return nil, err
+	}
 	m.Sanitize(g.Sanitizer)
 	opts = EnsureOptions(opts)
 
@@ -39,8 +43,37 @@ func (g *PythonGenerator) Generate(m *manifest.Manifest, opts *GeneratorOptions)
 	if g.needsDeprecated(m) {
 		sb.WriteString("from deprecated import deprecated\n")
 	}
-	sb.WriteString("from enum import IntEnum\n")
-	sb.WriteString("from plugify.plugin import Vector2, Vector3, Vector4, Matrix4x4\n\n")
+	if g.needsIntFlag(m) {
+		sb.WriteString("from enum import IntEnum, IntFlag\n")
+	} else {
+		sb.WriteString("from enum import IntEnum\n")
+	}
+	sb.WriteString("from plugify.plugin import Vector2, Vector3, Vector4, Matrix4x4\n")
+	var typing []string
+	if len(m.Constants) > 0 {
+		typing = append(typing, "Final")
+	}
+	if g.needsOverload(m) {
+		typing = append(typing, "overload")
+	}
+	if len(typing) > 0 {
+		sb.WriteString(fmt.Sprintf("from typing import %s\n", strings.Join(typing, ", ")))
+	}
+	// Enums and structs from dependencies come from their stubs, which sit next
+	// to this one. Prototypes need no import, being spelled out as Callable where
+	// used.
+	external := g.CollectExternalTypes(m)
+	imported := map[string][]string{}
+	for _, plugin := range external.EnumPlugins() {
+		imported[plugin] = append(imported[plugin], external.EnumNames(plugin)...)
+	}
+	for _, plugin := range external.StructPlugins() {
+		imported[plugin] = append(imported[plugin], external.StructNames(plugin)...)
+	}
+	for _, plugin := range sortedPlugins(imported) {
+		sb.WriteString(fmt.Sprintf("from .%s import %s\n", plugin, strings.Join(imported[plugin], ", ")))
+	}
+	sb.WriteString("\n")
 	sb.WriteString(fmt.Sprintf("# Generated from %s.pplugin\n\n", m.Name))
 
 	// Generate enums
@@ -52,6 +85,23 @@ func (g *PythonGenerator) Generate(m *manifest.Manifest, opts *GeneratorOptions)
 		sb.WriteString(enumsCode)
 		sb.WriteString("\n")
 	}
+	// Generate constants
+	constantsCode, err := g.CollectConstants(m, g.generateConstant)
+	if err != nil {
+		return nil, err
+	}
+	if constantsCode != "" {
+		sb.WriteString(constantsCode)
+		sb.WriteString("\n")
+	}
+	// Generate structs
+	structsCode, err := g.CollectStructs(m, g.generateStruct)
+	if err != nil {
+		return nil, err
+	}
+	if structsCode != "" {
+		sb.WriteString(structsCode)
+	}
 	// Generate aliases
 	aliasesCode, err := g.generateAliases(m)
 	if err != nil {
@@ -63,8 +113,8 @@ func (g *PythonGenerator) Generate(m *manifest.Manifest, opts *GeneratorOptions)
 	}
 
 	// Generate methods
-	for _, method := range m.Methods {
-		methodCode, err := g.generateMethod(&method)
+	for _, method := range OverloadOrder(m) {
+		methodCode, err := g.generateMethod(m, method)
 		if err != nil {
 			return nil, fmt.Errorf("failed to generate method %s: %w", method.Name, err)
 		}
@@ -72,6 +122,16 @@ func (g *PythonGenerator) Generate(m *manifest.Manifest, opts *GeneratorOptions)
 		sb.WriteString("\n")
 	}
 
+	// Generate event decorators
+	for i := range m.Events {
+		eventCode, err := g.generateEvent(m, &m.Events[i])
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate event %s: %w", m.Events[i].Name, err)
+		}
+		sb.WriteString(eventCode)
+		sb.WriteString("\n")
+	}
+
 	// Generate classes (if enabled)
 	if opts.GenerateClasses && len(m.Classes) > 0 {
 		classesCode, err := g.generateClasses(m)
@@ -104,6 +164,16 @@ func (g *PythonGenerator) needsDeprecated(m *manifest.Manifest) bool {
 			return true
 		}
 	}
+	for _, structure := range m.Structs {
+		if structure.Deprecated != "" {
+			return true
+		}
+	}
+	for i := range m.Events {
+		if m.Events[i].Deprecated != "" {
+			return true
+		}
+	}
 	for i := range m.Classes {
 		if m.Classes[i].Deprecated != "" {
 			return true
@@ -117,6 +187,28 @@ func (g *PythonGenerator) needsDeprecated(m *manifest.Manifest) bool {
 	return false
 }
 
+// needsOverload reports whether any method belongs to an overload set, whose
+// signatures are stubbed under @overload.
+func (g *PythonGenerator) needsOverload(m *manifest.Manifest) bool {
+	for i := range m.Methods {
+		if m.IsOverloaded(&m.Methods[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// needsIntFlag reports whether any enum is a flags enum, which derives from
+// IntFlag so that its values combine into another member rather than an int.
+func (g *PythonGenerator) needsIntFlag(m *manifest.Manifest) bool {
+	for _, e := range m.Enums {
+		if e.Flags && e.Plugin == "" {
+			return true
+		}
+	}
+	return false
+}
+
 // needsCallable checks if any method uses function/delegate types
 func (g *PythonGenerator) needsCallable(m *manifest.Manifest) bool {
 	for _, method := range m.Methods {
@@ -145,11 +237,13 @@ func (g *PythonGenerator) generateEnum(enum *manifest.Enum, underlyingType strin
 	if enum.Deprecated != "" {
 		sb.WriteString(fmt.Sprintf("@deprecated(reason=\"%s\")\n", enum.Deprecated))
 	}
-	sb.WriteString(fmt.Sprintf("class %s(IntEnum):\n", enum.Name))
-
-	if enum.Description != "" {
-		sb.WriteString(fmt.Sprintf("    \"\"\"\n    %s\n    \"\"\"\n", enum.Description))
+	base := "IntEnum"
+	if enum.Flags {
+		base = "IntFlag"
 	}
+	sb.WriteString(fmt.Sprintf("class %s(%s):\n", enum.Name, base))
+
+	sb.WriteString(pythonDocstring(enum.Description, pythonAvailability(enum.Since, enum.RemovedIn)))
 
 	for _, val := range enum.Values {
 		if val.Description != "" {
@@ -161,6 +255,75 @@ func (g *PythonGenerator) generateEnum(enum *manifest.Enum, underlyingType strin
 	return sb.String(), nil
 }
 
+// generateConstant generates a constant as a Final module attribute. Nothing
+// marks a variable deprecated, so a deprecation is only a comment.
+func (g *PythonGenerator) generateConstant(constant *manifest.Constant) (string, error) {
+	var sb strings.Builder
+
+	if constant.Description != "" {
+		sb.WriteString(fmt.Sprintf("# %s\n", constant.Description))
+	}
+	if note := pythonAvailability(constant.Since, constant.RemovedIn); note != "" {
+		sb.WriteString(fmt.Sprintf("# %s\n", note))
+	}
+	if constant.Deprecated != "" {
+		sb.WriteString(fmt.Sprintf("# Deprecated: %s\n", constant.Deprecated))
+	}
+
+	typeName, err := g.typeMapper.MapType(constant.Type, TypeContextReturn, false)
+	if err != nil {
+		return "", err
+	}
+	sb.WriteString(fmt.Sprintf("%s: Final[%s] = %s\n", constant.Name, typeName, pythonLiteral(constant.Literal)))
+	return sb.String(), nil
+}
+
+// pythonLiteral renders a checked value as a Python literal.
+func pythonLiteral(value *manifest.DefaultValue) string {
+	switch value.Kind {
+	case manifest.DefaultBool:
+		if value.Bool {
+			return "True"
+		}
+		return "False"
+	case manifest.DefaultInt:
+		return strconv.FormatInt(value.Int, 10)
+	case manifest.DefaultUint:
+		return strconv.FormatUint(value.Uint, 10)
+	case manifest.DefaultFloat:
+		return floatLiteral(value.Float)
+	case manifest.DefaultChar:
+		return strconv.Quote(string(value.Char))
+	}
+	return strconv.Quote(value.String)
+}
+
+// generateStruct generates a struct as a class of annotated fields
+func (g *PythonGenerator) generateStruct(structure *manifest.Struct) (string, error) {
+	var sb strings.Builder
+
+	if structure.Deprecated != "" {
+		sb.WriteString(fmt.Sprintf("@deprecated(reason=\"%s\")\n", structure.Deprecated))
+	}
+	sb.WriteString(fmt.Sprintf("class %s:\n", structure.Name))
+
+	sb.WriteString(pythonDocstring(structure.Description, pythonAvailability(structure.Since, structure.RemovedIn)))
+
+	for i := range structure.Fields {
+		field := &structure.Fields[i]
+		fieldType, err := g.typeMapper.MapReturnType(field)
+		if err != nil {
+			return "", err
+		}
+		if field.Description != "" {
+			sb.WriteString(fmt.Sprintf("    # %s\n", field.Description))
+		}
+		sb.WriteString(fmt.Sprintf("    %s: %s\n", field.Name, fieldType))
+	}
+
+	return sb.String(), nil
+}
+
 func (g *PythonGenerator) generateAliases(m *manifest.Manifest) (string, error) {
 	// Use the base generator's CollectAliases helper
 	return g.CollectAliases(m, g.generateAlias)
@@ -169,9 +332,7 @@ func (g *PythonGenerator) generateAliases(m *manifest.Manifest) (string, error)
 func (g *PythonGenerator) generateAlias(alias *manifest.Alias, underlyingType string) (string, error) {
 	var sb strings.Builder
 
-	if alias.Description != "" {
-		sb.WriteString(fmt.Sprintf("    \"\"\"\n    %s\n    \"\"\"\n", alias.Description))
-	}
+	sb.WriteString(pythonDocstring(alias.Description, pythonAvailability(alias.Since, alias.RemovedIn)))
 
 	sb.WriteString(fmt.Sprintf("    %s = %s\n", alias.Name, underlyingType))
 
@@ -208,9 +369,11 @@ func (g *PythonGenerator) generateClass(m *manifest.Manifest, class *manifest.Cl
 
 	// Class declaration with docstring
 	sb.WriteString(fmt.Sprintf("class %s:\n", class.Name))
-	if class.Description != "" {
-		sb.WriteString(fmt.Sprintf("    \"\"\"\n    %s\n    \"\"\"\n", class.Description))
+	platforms := ""
+	if len(class.Platforms) > 0 {
+		platforms = "Platforms: " + strings.Join(class.Platforms, ", ")
 	}
+	sb.WriteString(pythonDocstring(class.Description, platforms, pythonAvailability(class.Since, class.RemovedIn)))
 
 	// Generate constructors
 	if hasCtor {
@@ -410,7 +573,7 @@ func (g *PythonGenerator) generateBinding(m *manifest.Manifest, class *manifest.
 
 	// Handle return alias
 	if binding.RetAlias != nil && binding.RetAlias.Name != "" {
-		retType = binding.RetAlias.Name
+		retType = pythonOptional(binding.RetAlias.Name, method.RetType.Optional)
 	}
 
 	// Add deprecation decorator if present (check both binding and underlying method)
@@ -442,6 +605,9 @@ func (g *PythonGenerator) generateBinding(m *manifest.Manifest, class *manifest.
 	if method.Description != "" {
 		sb.WriteString(g.generateDocumentation(DocOptions{
 			Description:  method.Description,
+			Platforms:    binding.Platforms,
+			Since:        binding.Since,
+			RemovedIn:    binding.RemovedIn,
 			Params:       methodParams,
 			RetType:      method.RetType,
 			ParamAliases: binding.ParamAliases,
@@ -474,16 +640,21 @@ func (g *PythonGenerator) applyParamAliases(formattedParams string, params []man
 					end++
 				}
 				// Replace the type
-				result = result[:start] + alias.Name + result[end:]
+				result = result[:start] + pythonOptional(alias.Name, params[i].Optional) + result[end:]
 			}
 		}
 	}
 	return result
 }
 
-func (g *PythonGenerator) generateMethod(method *manifest.Method) (string, error) {
+func (g *PythonGenerator) generateMethod(m *manifest.Manifest, method *manifest.Method) (string, error) {
 	var sb strings.Builder
 
+	// Each signature of an overload set is a stub of its own
+	if m.IsOverloaded(method) {
+		sb.WriteString("@overload\n")
+	}
+
 	// Add deprecation decorator if present
 	if method.Deprecated != "" {
 		sb.WriteString(fmt.Sprintf("@deprecated(reason=\"%s\")\n", method.Deprecated))
@@ -501,7 +672,7 @@ func (g *PythonGenerator) generateMethod(method *manifest.Method) (string, error
 		return "", err
 	}
 
-	sb.WriteString(fmt.Sprintf("def %s(%s) -> %s:\n", method.Name, params, retType))
+	sb.WriteString(fmt.Sprintf("def %s(%s) -> %s:\n", method.OverloadName(), params, retType))
 
 	// Generate docstring
 	sb.WriteString(g.generateDocstring(method))
@@ -512,6 +683,34 @@ func (g *PythonGenerator) generateMethod(method *manifest.Method) (string, error
 	return sb.String(), nil
 }
 
+// generateEvent stubs the decorator named after an event, which subscribes
+// the function it decorates to the event and returns it unchanged, so that
+// it can be passed to the unsubscribe method later.
+func (g *PythonGenerator) generateEvent(m *manifest.Manifest, event *manifest.Event) (string, error) {
+	subscribe, unsubscribe := EventMethods(m, event)
+	listenerType, err := g.typeMapper.MapParamType(&subscribe.ParamTypes[0])
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	if event.Deprecated != "" {
+		sb.WriteString(fmt.Sprintf("@deprecated(reason=\"%s\")\n", event.Deprecated))
+	}
+	sb.WriteString(fmt.Sprintf("def %s(listener: %s) -> %s:\n", event.Name, listenerType, listenerType))
+	sb.WriteString(g.generateDocumentation(DocOptions{
+		Description: fmt.Sprintf("%s. Decorating a function subscribes it with %s; pass it to %s to unsubscribe it.",
+			strings.TrimSuffix(EventSummary(event), "."), subscribe.OverloadName(), unsubscribe.OverloadName()),
+		Since:     event.Since,
+		RemovedIn: event.RemovedIn,
+		Platforms: subscribe.Platforms,
+		RetType:   manifest.RetType{Type: "void"},
+		Indent:    "",
+	}))
+	sb.WriteString("    ...\n")
+	return sb.String(), nil
+}
+
 func (g *PythonGenerator) formatParameters(params []manifest.ParamType) (string, error) {
 	if len(params) == 0 {
 		return "", nil
@@ -531,6 +730,13 @@ func (g *PythonGenerator) formatParameters(params []manifest.ParamType) (string,
 		paramName := param.Name
 
 		result += paramName + ": " + typeName
+		value, err := formatDefault(g.typeMapper, &param)
+		if err != nil {
+			return "", err
+		}
+		if value != "" {
+			result += " = " + value
+		}
 	}
 
 	return result, nil
@@ -570,6 +776,29 @@ func (g *PythonGenerator) generateReturnType(retType *manifest.RetType, params [
 	return fmt.Sprintf("tuple[%s]", strings.Join(types, ", ")), nil
 }
 
+// pythonAvailability renders Availability as a sentence, or "".
+func pythonAvailability(since, removedIn string) string {
+	if note := Availability(since, removedIn); note != "" {
+		return note + "."
+	}
+	return ""
+}
+
+// pythonDocstring renders the docstring of a class, enum, struct or alias,
+// one paragraph per non-empty string, or "" when all of them are empty.
+func pythonDocstring(paragraphs ...string) string {
+	var lines []string
+	for _, paragraph := range paragraphs {
+		if paragraph != "" {
+			lines = append(lines, "    "+paragraph)
+		}
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return "    \"\"\"\n" + strings.Join(lines, "\n\n") + "\n    \"\"\"\n"
+}
+
 // generateDocumentation generates a Python docstring with Args and Returns sections
 func (g *PythonGenerator) generateDocumentation(opts DocOptions) string {
 	var sb strings.Builder
@@ -578,6 +807,12 @@ func (g *PythonGenerator) generateDocumentation(opts DocOptions) string {
 	if opts.Description != "" {
 		sb.WriteString(fmt.Sprintf("%s    %s\n", opts.Indent, opts.Description))
 	}
+	if len(opts.Platforms) > 0 {
+		sb.WriteString(fmt.Sprintf("\n%s    Platforms: %s\n", opts.Indent, strings.Join(opts.Platforms, ", ")))
+	}
+	if note := pythonAvailability(opts.Since, opts.RemovedIn); note != "" {
+		sb.WriteString(fmt.Sprintf("\n%s    %s\n", opts.Indent, note))
+	}
 
 	// Parameters section
 	if len(opts.Params) > 0 {
@@ -640,6 +875,9 @@ func (g *PythonGenerator) generateDocstring(method *manifest.Method) string {
 	return g.generateDocumentation(DocOptions{
 		Description:      method.Description,
 		Deprecated:       method.Deprecated,
+		Since:            method.Since,
+		RemovedIn:        method.RemovedIn,
+		Platforms:        method.Platforms,
 		Params:           method.ParamTypes,
 		RetType:          method.RetType,
 		IncludeCallbacks: true,
@@ -692,48 +930,48 @@ func (m *PythonTypeMapper) MapType(baseType string, context TypeContext, isArray
 	return mapped, nil
 }
 
-func (m *PythonTypeMapper) MapParamType(param *manifest.ParamType) (string, error) {
-	ctx := TypeContextValue
-
-	var typeName string
-	switch {
-	case param.Alias != nil:
-		typeName = param.Alias.Name
-		ctx |= TypeContextAlias
-
-	case param.Enum != nil:
-		typeName = param.Enum.Name
-
-	case param.Prototype != nil:
-		return m.generateCallableType(param.Prototype)
+func (m *PythonTypeMapper) MapFixedArrayType(element string, length int, context TypeContext) (string, error) {
+	return fmt.Sprintf("list[%s]", element), nil
+}
 
-	default:
-		typeName = param.BaseType()
-	}
+func (m *PythonTypeMapper) MapMapType(key, value string, context TypeContext) (string, error) {
+	return fmt.Sprintf("dict[%s, %s]", key, value), nil
+}
 
-	return m.MapType(typeName, ctx, param.IsArray())
+func (m *PythonTypeMapper) MapParamType(param *manifest.ParamType) (string, error) {
+	mapped, err := m.MapTypeNode(param.TypeNode(), TypeContextValue)
+	return pythonOptional(mapped, param.Optional), err
 }
 
 func (m *PythonTypeMapper) MapReturnType(retType *manifest.RetType) (string, error) {
-	ctx := TypeContextReturn
-
-	var typeName string
-	switch {
-	case retType.Alias != nil:
-		typeName = retType.Alias.Name
-		ctx |= TypeContextAlias
-
-	case retType.Enum != nil:
-		typeName = retType.Enum.Name
+	mapped, err := m.MapTypeNode(retType.TypeNode(), TypeContextReturn)
+	return pythonOptional(mapped, retType.Optional), err
+}
 
-	case retType.Prototype != nil:
-		return m.generateCallableType(retType.Prototype)
+// MapTypeNode implements TypeMapper; a prototype is spelled as a Callable.
+func (m *PythonTypeMapper) MapTypeNode(node *manifest.TypeNode, context TypeContext) (string, error) {
+	if node.Kind == manifest.KindPrototype {
+		return m.generateCallableType(node.Prototype)
+	}
+	return mapTypeNode(m, node, context)
+}
 
-	default:
-		typeName = retType.BaseType()
+// FormatDefault renders a parameter default as a Python literal, or the enum
+// member it names.
+func (m *PythonTypeMapper) FormatDefault(param *manifest.ParamType) (string, error) {
+	value := param.DefaultValue
+	if value.Kind == manifest.DefaultEnum {
+		return fmt.Sprintf("%s.%s", param.Enum.Name, value.Enum.Name), nil
 	}
+	return pythonLiteral(value), nil
+}
 
-	return m.MapType(typeName, ctx, retType.IsArray())
+// pythonOptional adds None to the type of an optional value.
+func pythonOptional(typeName string, optional bool) string {
+	if optional {
+		return typeName + " | None"
+	}
+	return typeName
 }
 
 func (m *PythonTypeMapper) generateCallableType(proto *manifest.Prototype) (string, error) {
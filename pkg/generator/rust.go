@@ -2,7 +2,10 @@ package generator
 
 import (
 	"fmt"
+	"math"
+	"strconv"
 	"strings"
+	"unicode"
 
 	"github.com/untrustedmodders/plugify-gen/pkg/manifest"
 )
@@ -22,6 +25,9 @@ func NewRustGenerator() *RustGenerator {
 // Generate generates Rust bindings
 func (g *RustGenerator) Generate(m *manifest.Manifest, opts *GeneratorOptions) (*GeneratorResult, error) {
 	g.ResetCaches()
+	if err := m.CheckSanitizedNames(g.Sanitizer); err != nil {
+		return nil, err
+	}
 	m.Sanitize(g.Sanitizer)
 	opts = EnsureOptions(opts)
 
@@ -45,6 +51,15 @@ func (g *RustGenerator) Generate(m *manifest.Manifest, opts *GeneratorOptions) (
 	}
 	files[fmt.Sprintf("%s/aliases.rs", folder)] = aliasesCode
 
+	// Generate separate structs file, if there are any
+	if len(g.UsedStructs(m)) > 0 {
+		structsCode, err := g.generateStructsFile(m)
+		if err != nil {
+			return nil, fmt.Errorf("generating structs file: %w", err)
+		}
+		files[fmt.Sprintf("%s/structs.rs", folder)] = structsCode
+	}
+
 	// Generate separate delegates file
 	delegatesCode, err := g.generateDelegatesFile(m)
 	if err != nil {
@@ -123,6 +138,14 @@ func (g *RustGenerator) generateDocumentation(opts DocOptions) string {
 		sb.WriteString(fmt.Sprintf("%s/// %s\n", opts.Indent, opts.RetType.Description))
 	}
 
+	// Availability
+	if note := Availability(opts.Since, opts.RemovedIn); note != "" {
+		if sb.Len() > 0 {
+			sb.WriteString(fmt.Sprintf("%s///\n", opts.Indent))
+		}
+		sb.WriteString(fmt.Sprintf("%s/// %s.\n", opts.Indent, note))
+	}
+
 	// Add deprecation attribute if present
 	if opts.Deprecated != "" {
 		sb.WriteString(fmt.Sprintf("%s#[deprecated(note = \"%s\")]\n", opts.Indent, opts.Deprecated))
@@ -157,21 +180,36 @@ func (g *RustGenerator) generateEnums(m *manifest.Manifest) (string, error) {
 func (g *RustGenerator) generateEnum(enum *manifest.Enum, underlyingType string) (string, error) {
 	var sb strings.Builder
 
-	if enum.Description != "" || enum.Deprecated != "" {
+	if enum.Description != "" || enum.Deprecated != "" || enum.Since != "" || enum.RemovedIn != "" {
 		sb.WriteString(g.generateDocumentation(DocOptions{
 			Description: enum.Description,
 			Deprecated:  enum.Deprecated,
+			Since:       enum.Since,
+			RemovedIn:   enum.RemovedIn,
 			Indent:      "",
 		}))
 	}
 
+	if enum.Flags {
+		return g.generateFlags(&sb, enum, underlyingType), nil
+	}
+
 	sb.WriteString("#[repr(")
 	sb.WriteString(underlyingType)
 	sb.WriteString(")]\n")
 	sb.WriteString("#[derive(Debug, Clone, Copy, PartialEq, Eq)]\n")
 	sb.WriteString(fmt.Sprintf("pub enum %s {\n", enum.Name))
 
+	// A #[repr] enum cannot give two variants one discriminant, so a value
+	// repeating an earlier one's number becomes an associated const instead.
+	variants := make(map[manifest.Integer]string, len(enum.Values))
+	var aliases []manifest.Value
 	for _, val := range enum.Values {
+		if _, seen := variants[val.Value]; seen {
+			aliases = append(aliases, val)
+			continue
+		}
+		variants[val.Value] = val.Name
 		if val.Description != "" {
 			sb.WriteString(g.generateDocumentation(DocOptions{
 				Description: val.Description,
@@ -182,10 +220,146 @@ func (g *RustGenerator) generateEnum(enum *manifest.Enum, underlyingType string)
 	}
 
 	sb.WriteString("}\n")
+	if len(aliases) > 0 {
+		sb.WriteString(fmt.Sprintf("#[allow(non_upper_case_globals)]\nimpl %s {\n", enum.Name))
+		for _, val := range aliases {
+			if val.Description != "" {
+				sb.WriteString(g.generateDocumentation(DocOptions{
+					Description: val.Description,
+					Indent:      "    ",
+				}))
+			}
+			sb.WriteString(fmt.Sprintf("    pub const %s: %s = %s::%s;\n", val.Name, enum.Name, enum.Name, variants[val.Value]))
+		}
+		sb.WriteString("}\n")
+	}
 	sb.WriteString(fmt.Sprintf("vector_enum_traits!(%s, %s);\n", enum.Name, underlyingType))
 	return sb.String(), nil
 }
 
+// generateConstant declares a constant under the manifest's name for it,
+// which need not be upper case. A string is a &str, the only string type a
+// const can hold.
+func (g *RustGenerator) generateConstant(constant *manifest.Constant) (string, error) {
+	var sb strings.Builder
+
+	if constant.Description != "" || constant.Deprecated != "" || constant.Since != "" || constant.RemovedIn != "" {
+		sb.WriteString(g.generateDocumentation(DocOptions{
+			Description: constant.Description,
+			Deprecated:  constant.Deprecated,
+			Since:       constant.Since,
+			RemovedIn:   constant.RemovedIn,
+			Indent:      "",
+		}))
+	}
+
+	typeName := "&str"
+	if constant.Type != "string" {
+		var err error
+		if typeName, err = g.typeMapper.MapType(constant.Type, TypeContextReturn, false); err != nil {
+			return "", err
+		}
+	}
+	sb.WriteString(fmt.Sprintf("#[allow(non_upper_case_globals)]\npub const %s: %s = %s;\n", constant.Name, typeName, rustLiteral(constant.Literal, typeName)))
+	return sb.String(), nil
+}
+
+// rustLiteral renders a checked value as a literal of typeName, the Rust type
+// it is declared with.
+func rustLiteral(value *manifest.DefaultValue, typeName string) string {
+	switch value.Kind {
+	case manifest.DefaultBool:
+		return strconv.FormatBool(value.Bool)
+	case manifest.DefaultInt:
+		if value.Int == math.MinInt64 {
+			// The literal would be the negation of a value i64 cannot hold.
+			return "i64::MIN"
+		}
+		return strconv.FormatInt(value.Int, 10)
+	case manifest.DefaultUint:
+		return strconv.FormatUint(value.Uint, 10)
+	case manifest.DefaultFloat:
+		return floatLiteral(value.Float)
+	case manifest.DefaultChar:
+		// Both char types are integers in Rust; a char casts to either.
+		return fmt.Sprintf("%s as %s", bracedQuote(string(value.Char), '\''), typeName)
+	}
+	return bracedQuote(value.String, '"')
+}
+
+// bracedQuote quotes s between quote characters with the escapes Rust and Lua
+// share, which spell a code point as \u{...} rather than Go's \uXXXX.
+func bracedQuote(s string, quote rune) string {
+	var sb strings.Builder
+	sb.WriteRune(quote)
+	for _, r := range s {
+		switch {
+		case r == quote || r == '\\':
+			sb.WriteRune('\\')
+			sb.WriteRune(r)
+		case r == '\n':
+			sb.WriteString(`\n`)
+		case r == '\r':
+			sb.WriteString(`\r`)
+		case r == '\t':
+			sb.WriteString(`\t`)
+		case unicode.IsPrint(r):
+			sb.WriteRune(r)
+		default:
+			sb.WriteString(fmt.Sprintf(`\u{%x}`, r))
+		}
+	}
+	sb.WriteRune(quote)
+	return sb.String()
+}
+
+// generateFlags finishes a flags enum as a newtype over its integer, in the
+// style of the bitflags crate. A #[repr] enum cannot hold a combination of its
+// variants, so the values become associated consts instead.
+func (g *RustGenerator) generateFlags(sb *strings.Builder, enum *manifest.Enum, underlyingType string) string {
+	name := enum.Name
+
+	sb.WriteString("#[repr(transparent)]\n")
+	sb.WriteString("#[derive(Debug, Clone, Copy, PartialEq, Eq, Hash, Default)]\n")
+	sb.WriteString(fmt.Sprintf("pub struct %s(pub %s);\n", name, underlyingType))
+
+	sb.WriteString(fmt.Sprintf("#[allow(non_upper_case_globals, dead_code)]\nimpl %s {\n", name))
+	for _, val := range enum.Values {
+		if val.Description != "" {
+			sb.WriteString(g.generateDocumentation(DocOptions{
+				Description: val.Description,
+				Indent:      "    ",
+			}))
+		}
+		sb.WriteString(fmt.Sprintf("    pub const %s: Self = Self(%d);\n", val.Name, val.Value))
+	}
+	sb.WriteString("\n")
+	sb.WriteString("    /// Returns the value with no flags set\n")
+	sb.WriteString("    pub const fn empty() -> Self { Self(0) }\n")
+	sb.WriteString("    /// Returns the raw bits of the value\n")
+	sb.WriteString(fmt.Sprintf("    pub const fn bits(self) -> %s { self.0 }\n", underlyingType))
+	sb.WriteString("    /// Returns whether every flag of `other` is set\n")
+	sb.WriteString("    pub const fn contains(self, other: Self) -> bool { self.0 & other.0 == other.0 }\n")
+	sb.WriteString("    /// Returns whether any flag of `other` is set\n")
+	sb.WriteString("    pub const fn intersects(self, other: Self) -> bool { self.0 & other.0 != 0 }\n")
+	sb.WriteString("}\n")
+
+	for _, op := range []struct{ trait, method, symbol string }{
+		{"BitOr", "bitor", "|"},
+		{"BitAnd", "bitand", "&"},
+		{"BitXor", "bitxor", "^"},
+	} {
+		sb.WriteString(fmt.Sprintf("impl std::ops::%s for %s {\n    type Output = Self;\n    fn %s(self, rhs: Self) -> Self { Self(self.0 %s rhs.0) }\n}\n",
+			op.trait, name, op.method, op.symbol))
+		sb.WriteString(fmt.Sprintf("impl std::ops::%sAssign for %s {\n    fn %s_assign(&mut self, rhs: Self) { self.0 = self.0 %s rhs.0; }\n}\n",
+			op.trait, name, op.method, op.symbol))
+	}
+	sb.WriteString(fmt.Sprintf("impl std::ops::Not for %s {\n    type Output = Self;\n    fn not(self) -> Self { Self(!self.0) }\n}\n", name))
+
+	sb.WriteString(fmt.Sprintf("vector_enum_traits!(%s, %s);\n", name, underlyingType))
+	return sb.String()
+}
+
 func (g *RustGenerator) generateAliases(m *manifest.Manifest) (string, error) {
 	return g.CollectAliases(m, g.generateAlias)
 }
@@ -195,10 +369,12 @@ func (g *RustGenerator) generateAlias(alias *manifest.Alias, underlyingType stri
 
 	// rustc accepts #[deprecated] on a type alias but does not lint uses of one,
 	// so this states the intent without enforcing it.
-	if alias.Description != "" || alias.Deprecated != "" {
+	if alias.Description != "" || alias.Deprecated != "" || alias.Since != "" || alias.RemovedIn != "" {
 		sb.WriteString(g.generateDocumentation(DocOptions{
 			Description: alias.Description,
 			Deprecated:  alias.Deprecated,
+			Since:       alias.Since,
+			RemovedIn:   alias.RemovedIn,
 			Indent:      "",
 		}))
 	}
@@ -208,6 +384,41 @@ func (g *RustGenerator) generateAlias(alias *manifest.Alias, underlyingType stri
 	return sb.String(), nil
 }
 
+func (g *RustGenerator) generateStruct(structure *manifest.Struct) (string, error) {
+	var sb strings.Builder
+
+	if structure.Description != "" || structure.Deprecated != "" || structure.Since != "" || structure.RemovedIn != "" {
+		sb.WriteString(g.generateDocumentation(DocOptions{
+			Description: structure.Description,
+			Deprecated:  structure.Deprecated,
+			Since:       structure.Since,
+			RemovedIn:   structure.RemovedIn,
+			Indent:      "",
+		}))
+	}
+
+	sb.WriteString("#[repr(C)]\n")
+	sb.WriteString("#[derive(Debug, Clone, Copy)]\n")
+	sb.WriteString(fmt.Sprintf("pub struct %s {\n", structure.Name))
+	for i := range structure.Fields {
+		field := &structure.Fields[i]
+		fieldType, err := g.typeMapper.MapReturnType(field)
+		if err != nil {
+			return "", err
+		}
+		if field.Description != "" {
+			sb.WriteString(g.generateDocumentation(DocOptions{
+				Description: field.Description,
+				Indent:      "    ",
+			}))
+		}
+		sb.WriteString(fmt.Sprintf("    pub %s: %s,\n", field.Name, fieldType))
+	}
+	sb.WriteString("}\n")
+
+	return sb.String(), nil
+}
+
 func (g *RustGenerator) generateDelegates(m *manifest.Manifest) (string, error) {
 	return g.CollectDelegates(m, g.generateDelegate)
 }
@@ -215,10 +426,12 @@ func (g *RustGenerator) generateDelegates(m *manifest.Manifest) (string, error)
 func (g *RustGenerator) generateDelegate(proto *manifest.Prototype) (string, error) {
 	var sb strings.Builder
 
-	if proto.Description != "" || proto.Deprecated != "" {
+	if proto.Description != "" || proto.Deprecated != "" || proto.Since != "" || proto.RemovedIn != "" {
 		sb.WriteString(g.generateDocumentation(DocOptions{
 			Description: proto.Description,
 			Deprecated:  proto.Deprecated,
+			Since:       proto.Since,
+			RemovedIn:   proto.RemovedIn,
 			Indent:      "",
 		}))
 	}
@@ -259,18 +472,81 @@ func (g *RustGenerator) formatRustParams(params []manifest.ParamType, includeNam
 		}
 
 		if includeNames {
+			if param.Optional {
+				typeName = fmt.Sprintf("Option<%s>", typeName)
+			}
 			return param.Name + ": " + typeName, nil
 		}
+		if param.Optional {
+			return g.rustOptionalFFIType(param, typeName)
+		}
 		return typeName, nil
 	})
 }
 
+// rustOptionalFFIType is the type an optional value crosses plugify as. A
+// function pointer is wrapped in Option, whose None is the null pointer, and
+// an enum crosses as its backing integer so that none never has to be a
+// variant.
+func (g *RustGenerator) rustOptionalFFIType(prop *manifest.Property, typeName string) (string, error) {
+	switch {
+	case prop.Prototype != nil:
+		return fmt.Sprintf("Option<%s>", typeName), nil
+	case prop.Enum != nil:
+		return g.typeMapper.MapType(prop.TypeNode().Value().Name, TypeContextReturn, false)
+	}
+	return typeName, nil
+}
+
+// rustCallArguments lists the arguments a wrapper passes to the function
+// pointer, with each optional parameter unwrapped to what stands for none.
+func (g *RustGenerator) rustCallArguments(params []manifest.ParamType) (string, error) {
+	return g.formatParameters(params, func(_ int, param *manifest.ParamType) (string, error) {
+		if !param.Optional {
+			return param.Name, nil
+		}
+		typeName, err := g.typeMapper.MapParamType(param)
+		if err != nil {
+			return "", err
+		}
+		switch {
+		case param.Prototype != nil:
+			return param.Name, nil
+		case param.Enum != nil:
+			backing, err := g.typeMapper.MapType(param.TypeNode().Value().Name, TypeContextReturn, false)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%s.map_or(0, |v| v as %s)", param.Name, backing), nil
+		case strings.HasPrefix(typeName, "&"):
+			return fmt.Sprintf("%s.unwrap_or(&Default::default())", param.Name), nil
+		}
+		return fmt.Sprintf("%s.unwrap_or_default()", param.Name), nil
+	})
+}
+
+// rustOptionalReturn is the body of a wrapper returning an optional value,
+// with the empty value turned into None.
+func (g *RustGenerator) rustOptionalReturn(ret *manifest.RetType, retType, call string) string {
+	switch {
+	case ret.Prototype != nil:
+		return fmt.Sprintf("    unsafe { %s }\n", call)
+	case ret.Enum != nil:
+		backing, _ := g.typeMapper.MapType(ret.TypeNode().Value().Name, TypeContextReturn, false)
+		return fmt.Sprintf("    let __result = unsafe { %s };\n    if __result == 0 { None } else { Some(unsafe { std::mem::transmute::<%s, %s>(__result) }) }\n",
+			call, backing, retType)
+	}
+	return fmt.Sprintf("    let __result = unsafe { %s };\n    if __result == %s::default() { None } else { Some(__result) }\n", call, retType)
+}
+
 func (g *RustGenerator) generateMethod(method *manifest.Method, pluginName string, generateScopes bool) (string, error) {
 	var sb strings.Builder
 
 	sb.WriteString(g.generateDocumentation(DocOptions{
 		Description: method.Description,
 		Deprecated:  method.Deprecated,
+		Since:       method.Since,
+		RemovedIn:   method.RemovedIn,
 		Params:      method.ParamTypes,
 		RetType:     method.RetType,
 		Indent:      "",
@@ -289,7 +565,7 @@ func (g *RustGenerator) generateMethod(method *manifest.Method, pluginName strin
 	}
 
 	// Call function - just use parameter names
-	paramNames, err := FormatParameters(method.ParamTypes, ParamFormatNames, g.typeMapper)
+	paramNames, err := g.rustCallArguments(method.ParamTypes)
 	if err != nil {
 		return "", err
 	}
@@ -307,7 +583,11 @@ func (g *RustGenerator) generateMethod(method *manifest.Method, pluginName strin
 	sb.WriteString(fmt.Sprintf("pub fn %s(%s)", method.Name, paramTypes))
 	if retType != "" && retType != "()" {
 		sb.WriteString(" -> ")
-		sb.WriteString(retType)
+		if method.RetType.Optional {
+			sb.WriteString(fmt.Sprintf("Option<%s>", retType))
+		} else {
+			sb.WriteString(retType)
+		}
 	}
 	sb.WriteString(" {\n")
 
@@ -315,13 +595,12 @@ func (g *RustGenerator) generateMethod(method *manifest.Method, pluginName strin
 		sb.WriteString(fmt.Sprintf("    let __scope = scope!(\"%s::%s\");\n", pluginName, method.Name))
 	}
 
-	sb.WriteString("    unsafe { ")
-	sb.WriteString(fmt.Sprintf("__%s_%s", pluginName, method.Name))
-	sb.WriteString(".expect(\"")
-	sb.WriteString(method.Name)
-	sb.WriteString(" function was not found\")(")
-	sb.WriteString(paramNames)
-	sb.WriteString(") }\n")
+	call := fmt.Sprintf("__%s_%s.expect(\"%s function was not found\")(%s)", pluginName, method.Name, method.Name, paramNames)
+	if method.RetType.Optional {
+		sb.WriteString(g.rustOptionalReturn(&method.RetType, retType, call))
+	} else {
+		sb.WriteString(fmt.Sprintf("    unsafe { %s }\n", call))
+	}
 	sb.WriteString("}\n")
 
 	// pub type FuncType = unsafe extern "C" fn(params...) -> ret;
@@ -331,8 +610,15 @@ func (g *RustGenerator) generateMethod(method *manifest.Method, pluginName strin
 	))
 
 	if retType != "" && retType != "()" {
+		ffiRetType := retType
+		if method.RetType.Optional {
+			ffiRetType, err = g.rustOptionalFFIType(&method.RetType, retType)
+			if err != nil {
+				return "", err
+			}
+		}
 		sb.WriteString(" -> ")
-		sb.WriteString(retType)
+		sb.WriteString(ffiRetType)
 	}
 
 	sb.WriteString(";\n")
@@ -352,6 +638,15 @@ func (g *RustGenerator) generateEnumsFile(m *manifest.Manifest) (string, error)
 	sb.WriteString("#[allow(unused_imports)]\n")
 	sb.WriteString("use plugify::{vector_enum_traits};\n\n")
 
+	// Enums from dependencies are re-exported from their own modules
+	external := g.CollectExternalTypes(m)
+	for _, plugin := range external.EnumPlugins() {
+		for _, enum := range external.Enums[plugin] {
+			sb.WriteString(fmt.Sprintf("pub use crate::%s::%s;\n", plugin, enum.Name))
+		}
+		sb.WriteString("\n")
+	}
+
 	// Generate enums
 	enumsCode, err := g.generateEnums(m)
 	if err != nil {
@@ -362,6 +657,16 @@ func (g *RustGenerator) generateEnumsFile(m *manifest.Manifest) (string, error)
 		sb.WriteString("\n")
 	}
 
+	// Generate constants
+	constantsCode, err := g.CollectConstants(m, g.generateConstant)
+	if err != nil {
+		return "", err
+	}
+	if constantsCode != "" {
+		sb.WriteString(constantsCode)
+		sb.WriteString("\n")
+	}
+
 	// Ownership enum (if any class has destructor)
 	sb.WriteString(fmt.Sprintf("/// %s type for RAII wrappers\n", OwnershipEnumName))
 	sb.WriteString("#[derive(Debug, Clone, Copy, PartialEq, Eq)]\n")
@@ -373,11 +678,58 @@ func (g *RustGenerator) generateEnumsFile(m *manifest.Manifest) (string, error)
 	return sb.String(), nil
 }
 
+// rustTargetOS is the target_os each platform is built for
+var rustTargetOS = map[string]string{
+	manifest.PlatformWindows: "windows",
+	manifest.PlatformLinux:   "linux",
+	manifest.PlatformMacOS:   "macos",
+}
+
+// rustPlatformCfg puts a #[cfg] for the given platforms on every item in code
+// that starts at indent, or leaves code as it is when the list is empty and
+// the items exist everywhere. Rust has no conditional block, so an item is
+// only left out by an attribute of its own.
+func rustPlatformCfg(platforms []string, indent, code string) string {
+	if len(platforms) == 0 {
+		return code
+	}
+	conditions := make([]string, len(platforms))
+	for i, platform := range platforms {
+		conditions[i] = fmt.Sprintf("target_os = %q", rustTargetOS[platform])
+	}
+	cfg := conditions[0]
+	if len(conditions) > 1 {
+		cfg = fmt.Sprintf("any(%s)", strings.Join(conditions, ", "))
+	}
+
+	var sb strings.Builder
+	attributes := false // within the attributes already given the cfg
+	for _, line := range strings.SplitAfter(code, "\n") {
+		item, isItem := strings.CutPrefix(line, indent)
+		isItem = isItem && strings.TrimSpace(item) != "" && !strings.HasPrefix(item, " ") && !strings.HasPrefix(item, "}") && !strings.HasPrefix(item, "//")
+		if isItem && !attributes {
+			sb.WriteString(fmt.Sprintf("%s#[cfg(%s)]\n", indent, cfg))
+		}
+		attributes = isItem && strings.HasPrefix(item, "#[")
+		sb.WriteString(line)
+	}
+	return sb.String()
+}
+
+// rustMapImport imports HashMap into a file of a manifest that has map types.
+func rustMapImport(m *manifest.Manifest) string {
+	if !HasKind(m, manifest.KindMap) {
+		return ""
+	}
+	return "#[allow(unused_imports)]\nuse std::collections::HashMap;\n"
+}
+
 // generateAliasesFile generates a file containing all aliases
 func (g *RustGenerator) generateAliasesFile(m *manifest.Manifest) (string, error) {
 	var sb strings.Builder
 
 	sb.WriteString(fmt.Sprintf("// Generated from %s.pplugin\n\n", m.Name))
+	sb.WriteString(rustMapImport(m))
 	sb.WriteString("#[allow(unused_imports)]\n")
 	sb.WriteString("use plugify::{Str, Arr, Var, Vec2, Vec3, Vec4, Mat4x4};\n\n")
 
@@ -394,6 +746,37 @@ func (g *RustGenerator) generateAliasesFile(m *manifest.Manifest) (string, error
 	return sb.String(), nil
 }
 
+// generateStructsFile generates a file containing all struct definitions
+func (g *RustGenerator) generateStructsFile(m *manifest.Manifest) (string, error) {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("// Generated from %s.pplugin\n\n", m.Name))
+	sb.WriteString("#[allow(unused_imports)]\n")
+	sb.WriteString("use super::enums::*;\n")
+	sb.WriteString("#[allow(unused_imports)]\n")
+	sb.WriteString("use plugify::{Vec2, Vec3, Vec4, Mat4x4};\n\n")
+
+	// Structs from dependencies are re-exported from their own modules
+	external := g.CollectExternalTypes(m)
+	for _, plugin := range external.StructPlugins() {
+		for _, structure := range external.Structs[plugin] {
+			sb.WriteString(fmt.Sprintf("pub use crate::%s::%s;\n", plugin, structure.Name))
+		}
+		sb.WriteString("\n")
+	}
+
+	// Generate structs
+	structsCode, err := g.CollectStructs(m, g.generateStruct)
+	if err != nil {
+		return "", err
+	}
+	if structsCode != "" {
+		sb.WriteString(structsCode)
+	}
+
+	return sb.String(), nil
+}
+
 // generateDelegatesFile generates a file containing all delegate type definitions
 func (g *RustGenerator) generateDelegatesFile(m *manifest.Manifest) (string, error) {
 	var sb strings.Builder
@@ -403,9 +786,23 @@ func (g *RustGenerator) generateDelegatesFile(m *manifest.Manifest) (string, err
 	sb.WriteString("use super::enums::*;\n")
 	sb.WriteString("#[allow(unused_imports)]\n")
 	sb.WriteString("use super::aliases::*;\n")
+	if len(g.UsedStructs(m)) > 0 {
+		sb.WriteString("#[allow(unused_imports)]\n")
+		sb.WriteString("use super::structs::*;\n")
+	}
+	sb.WriteString(rustMapImport(m))
 	sb.WriteString("#[allow(unused_imports)]\n")
 	sb.WriteString("use plugify::{Str, Arr, Var, Vec2, Vec3, Vec4, Mat4x4};\n\n")
 
+	// Delegates from dependencies are re-exported from their own modules
+	external := g.CollectExternalTypes(m)
+	for _, plugin := range external.PrototypePlugins() {
+		for _, proto := range external.Prototypes[plugin] {
+			sb.WriteString(fmt.Sprintf("pub use crate::%s::%s;\n", plugin, proto.Name))
+		}
+		sb.WriteString("\n")
+	}
+
 	// Generate delegates
 	delegatesCode, err := g.generateDelegates(m)
 	if err != nil {
@@ -429,8 +826,13 @@ func (g *RustGenerator) generateGroupFile(m *manifest.Manifest, groupName string
 	sb.WriteString("use super::enums::*;\n")
 	sb.WriteString("#[allow(unused_imports)]\n")
 	sb.WriteString("use super::aliases::*;\n")
+	if len(g.UsedStructs(m)) > 0 {
+		sb.WriteString("#[allow(unused_imports)]\n")
+		sb.WriteString("use super::structs::*;\n")
+	}
 	sb.WriteString("#[allow(unused_imports)]\n")
 	sb.WriteString("use super::delegates::*;\n")
+	sb.WriteString(rustMapImport(m))
 	sb.WriteString("#[allow(unused_imports)]\n")
 	sb.WriteString("use plugify::{scope, Str, Arr, Var, Vec2, Vec3, Vec4, Mat4x4};\n\n")
 
@@ -442,11 +844,22 @@ func (g *RustGenerator) generateGroupFile(m *manifest.Manifest, groupName string
 			if err != nil {
 				return "", fmt.Errorf("failed to generate method %s: %w", method.Name, err)
 			}
-			sb.WriteString(methodCode)
+			sb.WriteString(rustPlatformCfg(method.Platforms, "", methodCode))
 			sb.WriteString("\n")
 		}
 	}
 
+	// Generate subscription guards for the events subscribed in this group
+	for _, event := range EventsInGroup(m, groupName) {
+		eventCode, err := g.generateEventGuard(m, event)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate event %s: %w", event.Name, err)
+		}
+		subscribe, _ := EventMethods(m, event)
+		sb.WriteString(rustPlatformCfg(subscribe.Platforms, "", eventCode))
+		sb.WriteString("\n")
+	}
+
 	// Generate classes for this group (if enabled)
 	if opts.GenerateClasses {
 		for _, class := range m.Classes {
@@ -456,7 +869,7 @@ func (g *RustGenerator) generateGroupFile(m *manifest.Manifest, groupName string
 				if err != nil {
 					return "", fmt.Errorf("failed to generate class %s: %w", class.Name, err)
 				}
-				sb.WriteString(classCode)
+				sb.WriteString(rustPlatformCfg(class.Platforms, "", classCode))
 				sb.WriteString("\n")
 			}
 		}
@@ -465,6 +878,57 @@ func (g *RustGenerator) generateGroupFile(m *manifest.Manifest, groupName string
 	return sb.String(), nil
 }
 
+// generateEventGuard renders the guard for an event's listener, named after
+// the event: creating one subscribes the listener and dropping it unsubscribes
+// it again.
+func (g *RustGenerator) generateEventGuard(m *manifest.Manifest, event *manifest.Event) (string, error) {
+	subscribe, unsubscribe := EventMethods(m, event)
+	listenerType, err := g.typeMapper.MapParamType(&subscribe.ParamTypes[0])
+	if err != nil {
+		return "", err
+	}
+	name := event.Name + "Guard"
+
+	// The impls of a deprecated guard would otherwise warn about it themselves
+	allow := ""
+	if event.Deprecated != "" {
+		allow = "#[allow(deprecated)]\n"
+	}
+
+	var sb strings.Builder
+	sb.WriteString(g.generateDocumentation(DocOptions{
+		Description: EventSummary(event),
+		Deprecated:  event.Deprecated,
+		Since:       event.Since,
+		RemovedIn:   event.RemovedIn,
+		Indent:      "",
+	}))
+	sb.WriteString("#[must_use = \"the listener is unsubscribed as soon as the guard is dropped\"]\n")
+	sb.WriteString("#[allow(dead_code)]\n")
+	sb.WriteString(fmt.Sprintf("pub struct %s {\n", name))
+	sb.WriteString(fmt.Sprintf("    listener: %s,\n", listenerType))
+	sb.WriteString("}\n\n")
+
+	sb.WriteString(allow)
+	sb.WriteString(fmt.Sprintf("impl %s {\n", name))
+	sb.WriteString("    /// Subscribes `listener` until the guard is dropped\n")
+	sb.WriteString("    #[allow(dead_code)]\n")
+	sb.WriteString(fmt.Sprintf("    pub fn new(listener: %s) -> Self {\n", listenerType))
+	sb.WriteString(fmt.Sprintf("        %s(listener);\n", subscribe.Name))
+	sb.WriteString("        Self { listener }\n")
+	sb.WriteString("    }\n")
+	sb.WriteString("}\n\n")
+
+	sb.WriteString(allow)
+	sb.WriteString(fmt.Sprintf("impl Drop for %s {\n", name))
+	sb.WriteString("    fn drop(&mut self) {\n")
+	sb.WriteString(fmt.Sprintf("        %s(self.listener);\n", unsubscribe.Name))
+	sb.WriteString("    }\n")
+	sb.WriteString("}\n")
+
+	return sb.String(), nil
+}
+
 // generateModFile generates the mod.rs file that re-exports all modules
 func (g *RustGenerator) generateModFile(m *manifest.Manifest, groups map[string]struct{}) (string, error) {
 	var sb strings.Builder
@@ -474,8 +938,12 @@ func (g *RustGenerator) generateModFile(m *manifest.Manifest, groups map[string]
 
 	// Declare modules
 
+	hasStructs := len(g.UsedStructs(m)) > 0
 	sb.WriteString("pub mod enums;\n")
 	sb.WriteString("pub mod aliases;\n")
+	if hasStructs {
+		sb.WriteString("pub mod structs;\n")
+	}
 	sb.WriteString("pub mod delegates;\n")
 	for _, groupName := range g.SortedGroups(groups) {
 		sb.WriteString(fmt.Sprintf("pub mod %s;\n", groupName))
@@ -487,6 +955,10 @@ func (g *RustGenerator) generateModFile(m *manifest.Manifest, groups map[string]
 	sb.WriteString("pub use enums::*;\n")
 	sb.WriteString("#[allow(unused_imports)]\n")
 	sb.WriteString("pub use aliases::*;\n")
+	if hasStructs {
+		sb.WriteString("#[allow(unused_imports)]\n")
+		sb.WriteString("pub use structs::*;\n")
+	}
 	sb.WriteString("#[allow(unused_imports)]\n")
 	sb.WriteString("pub use delegates::*;\n")
 	for _, groupName := range g.SortedGroups(groups) {
@@ -562,6 +1034,28 @@ func (m *RustTypeMapper) MapType(baseType string, context TypeContext, isArray b
 	return mapped, nil
 }
 
+// MapFixedArrayType implements TypeMapper.
+func (m *RustTypeMapper) MapFixedArrayType(element string, length int, context TypeContext) (string, error) {
+	return rustPassedObject(fmt.Sprintf("[%s; %d]", element, length), context), nil
+}
+
+// MapMapType implements TypeMapper.
+func (m *RustTypeMapper) MapMapType(key, value string, context TypeContext) (string, error) {
+	return rustPassedObject(fmt.Sprintf("HashMap<%s, %s>", key, value), context), nil
+}
+
+// rustPassedObject qualifies an object type for how MapType passes objects:
+// by & as a value parameter and by &mut as a ref one.
+func rustPassedObject(mapped string, context TypeContext) string {
+	switch {
+	case context&TypeContextRef != 0:
+		return "&mut " + mapped
+	case context&TypeContextValue != 0:
+		return "&" + mapped
+	}
+	return mapped
+}
+
 // isObjectLikeType returns true for types that should be passed by reference in parameters
 func (m *RustTypeMapper) isObjectLikeType(baseType string) bool {
 	objectLikeTypes := map[string]struct{}{
@@ -571,61 +1065,35 @@ func (m *RustTypeMapper) isObjectLikeType(baseType string) bool {
 		"vec3":   {},
 		"vec4":   {},
 		"mat4x4": {},
+		"struct": {},
 	}
 	_, ok := objectLikeTypes[baseType]
 	return ok
 }
 
 func (m *RustTypeMapper) MapParamType(param *manifest.ParamType) (string, error) {
+	node := param.TypeNode()
+
 	// Regular type mapping
 	ctx := TypeContextValue
 	if param.Ref {
 		ctx = TypeContextRef
 	}
-	if m.isObjectLikeType(param.BaseType()) {
+	if m.isObjectLikeType(node.Value().Name) {
 		ctx |= TypeContextObject
 	}
 
-	var typeName string
-	switch {
-	case param.Alias != nil:
-		typeName = param.Alias.Name
-		ctx |= TypeContextAlias
-
-	case param.Enum != nil:
-		typeName = param.Enum.Name
-
-	case param.Prototype != nil:
-		return param.Prototype.Name, nil
-
-	default:
-		typeName = param.BaseType()
-	}
-
-	return m.MapType(typeName, ctx, param.IsArray())
+	return m.MapTypeNode(node, ctx)
 }
 
 func (m *RustTypeMapper) MapReturnType(retType *manifest.RetType) (string, error) {
-	ctx := TypeContextReturn
-
-	var typeName string
-	switch {
-	case retType.Alias != nil:
-		typeName = retType.Alias.Name
-		ctx |= TypeContextAlias
-
-	case retType.Enum != nil:
-		typeName = retType.Enum.Name
-
-	case retType.Prototype != nil:
-		return retType.Prototype.Name, nil
-
-	default:
-		typeName = retType.BaseType()
-	}
-
 	// Regular type mapping - returns always by value
-	return m.MapType(typeName, ctx, retType.IsArray())
+	return m.MapTypeNode(retType.TypeNode(), TypeContextReturn)
+}
+
+// MapTypeNode implements TypeMapper.
+func (m *RustTypeMapper) MapTypeNode(node *manifest.TypeNode, context TypeContext) (string, error) {
+	return mapTypeNode(m, node, context)
 }
 
 func (m *RustTypeMapper) MapHandleType(class *manifest.Class) (string, string, error) {
@@ -699,6 +1167,8 @@ func (g *RustGenerator) generateClass(m *manifest.Manifest, class *manifest.Clas
 		sb.WriteString(g.generateDocumentation(DocOptions{
 			Description: class.Description,
 			Deprecated:  class.Deprecated,
+			Since:       class.Since,
+			RemovedIn:   class.RemovedIn,
 			Indent:      "",
 		}))
 	}
@@ -764,7 +1234,7 @@ func (g *RustGenerator) generateClass(m *manifest.Manifest, class *manifest.Clas
 			if err != nil {
 				return "", err
 			}
-			sb.WriteString(methodCode)
+			sb.WriteString(rustPlatformCfg(binding.Platforms, "    ", methodCode))
 		}
 
 		sb.WriteString("}\n\n")
@@ -789,7 +1259,7 @@ func (g *RustGenerator) generateClass(m *manifest.Manifest, class *manifest.Clas
 			if err != nil {
 				return "", err
 			}
-			sb.WriteString(methodCode)
+			sb.WriteString(rustPlatformCfg(binding.Platforms, "    ", methodCode))
 		}
 		sb.WriteString("}\n\n")
 	}
@@ -808,7 +1278,13 @@ func (g *RustGenerator) generateSafeConstructorBody(m *manifest.Manifest, class
 
 	hasDtor := class.Destructor != nil
 
-	sb.WriteString(fmt.Sprintf("        let h = crate::%s::%s(%s);\n", m.Name, method.FuncName, paramNames))
+	if method.RetType.Optional {
+		sb.WriteString(fmt.Sprintf("        let Some(h) = crate::%s::%s(%s) else {\n", m.Name, method.FuncName, paramNames))
+		sb.WriteString(fmt.Sprintf("            return Err(%sError::EmptyHandle);\n", class.Name))
+		sb.WriteString("        };\n")
+	} else {
+		sb.WriteString(fmt.Sprintf("        let h = crate::%s::%s(%s);\n", m.Name, method.FuncName, paramNames))
+	}
 	sb.WriteString(fmt.Sprintf("        if h == %s {\n", invalidValue))
 	sb.WriteString(fmt.Sprintf("            return Err(%sError::EmptyHandle);\n", class.Name))
 	sb.WriteString("        }\n")
@@ -835,6 +1311,8 @@ func (g *RustGenerator) generateConstructor(m *manifest.Manifest, class *manifes
 	sb.WriteString(g.generateDocumentation(DocOptions{
 		Description: method.Description,
 		Deprecated:  method.Deprecated,
+		Since:       method.Since,
+		RemovedIn:   method.RemovedIn,
 		Params:      method.ParamTypes,
 		Indent:      "    ",
 	}))
@@ -982,6 +1460,8 @@ func (g *RustGenerator) generateBinding(m *manifest.Manifest, class *manifest.Cl
 	sb.WriteString(g.generateDocumentation(DocOptions{
 		Description:  method.Description,
 		Deprecated:   deprecationReason,
+		Since:        binding.Since,
+		RemovedIn:    binding.RemovedIn,
 		Params:       methodParams,
 		RetType:      method.RetType,
 		ParamAliases: binding.ParamAliases,
@@ -1002,15 +1482,11 @@ func (g *RustGenerator) generateBinding(m *manifest.Manifest, class *manifest.Cl
 	// Determine if method is static
 	if !binding.BindSelf {
 		if method.RetType.Type != "void" {
-			if binding.RetAlias != nil && binding.RetAlias.Name != "" {
-				returnSignature = fmt.Sprintf(" -> %s", binding.RetAlias.Name)
-			} else {
-				retType, err := g.typeMapper.MapReturnType(&method.RetType)
-				if err != nil {
-					return "", err
-				}
-				returnSignature = fmt.Sprintf(" -> %s", retType)
+			retType, err := g.rustBindingReturnType(method, binding)
+			if err != nil {
+				return "", err
 			}
+			returnSignature = fmt.Sprintf(" -> %s", retType)
 		}
 		sb.WriteString("    #[allow(dead_code, non_snake_case)]\n")
 		sb.WriteString(fmt.Sprintf("    pub fn %s(%s)%s {\n", binding.Name, formattedParams, returnSignature))
@@ -1029,15 +1505,11 @@ func (g *RustGenerator) generateBinding(m *manifest.Manifest, class *manifest.Cl
 		}
 
 		if method.RetType.Type != "void" {
-			if binding.RetAlias != nil && binding.RetAlias.Name != "" {
-				returnSignature = fmt.Sprintf(" -> Result<%s, %sError>", binding.RetAlias.Name, class.Name)
-			} else {
-				retType, err := g.typeMapper.MapReturnType(&method.RetType)
-				if err != nil {
-					return "", err
-				}
-				returnSignature = fmt.Sprintf(" -> Result<%s, %sError>", retType, class.Name)
+			retType, err := g.rustBindingReturnType(method, binding)
+			if err != nil {
+				return "", err
 			}
+			returnSignature = fmt.Sprintf(" -> Result<%s, %sError>", retType, class.Name)
 		} else {
 			returnSignature = fmt.Sprintf(" -> Result<(), %sError>", class.Name)
 		}
@@ -1052,10 +1524,10 @@ func (g *RustGenerator) generateBinding(m *manifest.Manifest, class *manifest.Cl
 	// Generate null check
 	nullPolicy := class.NullPolicy
 	if nullPolicy == "" {
-		nullPolicy = "throw"
+		nullPolicy = manifest.NullPolicyThrow
 	}
 
-	if binding.BindSelf && hasHandle && nullPolicy == "throw" {
+	if binding.BindSelf && hasHandle && nullPolicy == manifest.NullPolicyThrow {
 		sb.WriteString(fmt.Sprintf("        if self.handle == %s {\n", invalidValue))
 		sb.WriteString(fmt.Sprintf("            return Err(%sError::EmptyHandle);\n", class.Name))
 		sb.WriteString("        }\n")
@@ -1088,10 +1560,21 @@ func (g *RustGenerator) generateBinding(m *manifest.Manifest, class *manifest.Cl
 					ownership = fmt.Sprintf(", %s::Borrowed", OwnershipEnumName)
 				}
 			}
+			wrapped := fmt.Sprintf("unsafe { %s::from_raw(%s(%s)%s) }", binding.RetAlias.Name, functionName, callArgs, ownership)
+			if method.RetType.Optional {
+				// Take the raw handle back out, since for a class it is the
+				// invalid value rather than zero that means None
+				aliasInvalid, err := retAliasInvalidValue(m, binding, g.typeMapper)
+				if err != nil {
+					return "", err
+				}
+				sb.WriteString(fmt.Sprintf("        let __result = %s(%s).unwrap_or_default();\n", functionName, callArgs))
+				wrapped = fmt.Sprintf("if __result == %s { None } else { Some(unsafe { %s::from_raw(__result%s) }) }", aliasInvalid, binding.RetAlias.Name, ownership)
+			}
 			if binding.BindSelf {
-				sb.WriteString(fmt.Sprintf("        Ok(unsafe { %s::from_raw(%s(%s)%s) })\n", binding.RetAlias.Name, functionName, callArgs, ownership))
+				sb.WriteString(fmt.Sprintf("        Ok(%s)\n", wrapped))
 			} else {
-				sb.WriteString(fmt.Sprintf("        unsafe { %s::from_raw(%s(%s)%s) }\n", binding.RetAlias.Name, functionName, callArgs, ownership))
+				sb.WriteString(fmt.Sprintf("        %s\n", wrapped))
 			}
 		} else {
 			if binding.BindSelf {
@@ -1107,6 +1590,24 @@ func (g *RustGenerator) generateBinding(m *manifest.Manifest, class *manifest.Cl
 	return sb.String(), nil
 }
 
+// rustBindingReturnType is what a binding returns, before any Result.
+func (g *RustGenerator) rustBindingReturnType(method *manifest.Method, binding *manifest.Binding) (string, error) {
+	retType := ""
+	if binding.RetAlias != nil && binding.RetAlias.Name != "" {
+		retType = binding.RetAlias.Name
+	} else {
+		var err error
+		retType, err = g.typeMapper.MapReturnType(&method.RetType)
+		if err != nil {
+			return "", err
+		}
+	}
+	if method.RetType.Optional {
+		retType = fmt.Sprintf("Option<%s>", retType)
+	}
+	return retType, nil
+}
+
 func (g *RustGenerator) formatClassParams(params []manifest.ParamType, aliases []*manifest.ParamAlias) (string, error) {
 	return g.formatParameters(params, func(i int, param *manifest.ParamType) (string, error) {
 		name := param.Name
@@ -1126,6 +1627,9 @@ func (g *RustGenerator) formatClassParams(params []manifest.ParamType, aliases [
 				return "", err
 			}
 		}
+		if param.Optional {
+			typeName = fmt.Sprintf("Option<%s>", typeName)
+		}
 		return fmt.Sprintf("%s: %s", name, typeName), nil
 	})
 }
@@ -1144,10 +1648,14 @@ func (g *RustGenerator) formatClassCallArgs(params []manifest.ParamType, binding
 
 		// Check if parameter has alias
 		if i < len(binding.ParamAliases) && binding.ParamAliases[i] != nil {
+			accessor := "get"
 			if binding.ParamAliases[i].Owner {
-				return fmt.Sprintf("%s.release()", name), nil
+				accessor = "release"
+			}
+			if param.Optional {
+				return fmt.Sprintf("%s.map(|v| v.%s())", name, accessor), nil
 			}
-			return fmt.Sprintf("%s.get()", name), nil
+			return fmt.Sprintf("%s.%s()", name, accessor), nil
 		}
 		return name, nil
 	})
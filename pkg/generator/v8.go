@@ -1,7 +1,10 @@
 package generator
 
 import (
+	"encoding/json"
 	"fmt"
+	"math/bits"
+	"strconv"
 	"strings"
 
 	"github.com/untrustedmodders/plugify-gen/pkg/manifest"
@@ -22,6 +25,9 @@ func NewV8Generator() *V8Generator {
 // Generate generates V8/JavaScript TypeScript definitions
 func (g *V8Generator) Generate(m *manifest.Manifest, opts *GeneratorOptions) (*GeneratorResult, error) {
 	g.ResetCaches()
+	if err := m.CheckSanitizedNames(g.Sanitizer); err != nil {
+		return nil, err
+	}
 	m.Sanitize(g.Sanitizer)
 	opts = EnsureOptions(opts)
 
@@ -38,7 +44,9 @@ func (g *V8Generator) Generate(m *manifest.Manifest, opts *GeneratorOptions) (*G
 
 	// Module declaration
 	sb.WriteString(fmt.Sprintf("declare module \":%s\" {\n", m.Name))
-	sb.WriteString("  import { Vector2, Vector3, Vector4, Matrix4x4 } from \"plugify\";\n\n")
+	sb.WriteString("  import { Vector2, Vector3, Vector4, Matrix4x4 } from \"plugify\";\n")
+	sb.WriteString(g.generateExternalImports(m))
+	sb.WriteString("\n")
 
 	// Generate enums
 	enumsCode, err := g.generateEnums(m)
@@ -50,6 +58,25 @@ func (g *V8Generator) Generate(m *manifest.Manifest, opts *GeneratorOptions) (*G
 		sb.WriteString("\n")
 	}
 
+	// Generate constants
+	constantsCode, err := g.CollectConstants(m, g.generateConstant)
+	if err != nil {
+		return nil, fmt.Errorf("generating constants: %w", err)
+	}
+	if constantsCode != "" {
+		sb.WriteString(constantsCode)
+		sb.WriteString("\n")
+	}
+
+	// Generate structs
+	structsCode, err := g.CollectStructs(m, g.generateStruct)
+	if err != nil {
+		return nil, fmt.Errorf("generating structs: %w", err)
+	}
+	if structsCode != "" {
+		sb.WriteString(structsCode)
+	}
+
 	// Generate aliases
 	aliasesCode, err := g.generateAliases(m)
 	if err != nil {
@@ -71,8 +98,8 @@ func (g *V8Generator) Generate(m *manifest.Manifest, opts *GeneratorOptions) (*G
 	}
 
 	// Generate methods
-	for _, method := range m.Methods {
-		methodCode, err := g.generateMethod(&method)
+	for _, method := range OverloadOrder(m) {
+		methodCode, err := g.generateMethod(method)
 		if err != nil {
 			return nil, fmt.Errorf("failed to generate method %s: %w", method.Name, err)
 		}
@@ -286,16 +313,63 @@ func (g *V8Generator) generateEnums(m *manifest.Manifest) (string, error) {
 	return g.CollectEnums(m, g.generateEnum)
 }
 
+// generateConstant declares a constant of the module. The module is already
+// ambient, so the const is implicitly declare; being ambient it cannot have an
+// initializer of every type, so its value is given as a literal type instead.
+func (g *V8Generator) generateConstant(constant *manifest.Constant) (string, error) {
+	var sb strings.Builder
+
+	sb.WriteString(v8TypeDoc(constant.Description, constant.Deprecated, constant.Since, constant.RemovedIn))
+
+	typeName, err := g.typeMapper.MapType(constant.Type, TypeContextReturn, false)
+	if err != nil {
+		return "", err
+	}
+	literal, err := v8Literal(constant.Literal, typeName)
+	if err != nil {
+		return "", err
+	}
+	sb.WriteString(fmt.Sprintf("  export const %s: %s;\n", constant.Name, literal))
+	return sb.String(), nil
+}
+
+// v8Literal renders a checked value as a TypeScript literal of typeName, the
+// type it maps to: a character is a number, and a 64-bit integer may be a
+// bigint.
+func v8Literal(value *manifest.DefaultValue, typeName string) (string, error) {
+	suffix := ""
+	if typeName == "bigint" {
+		suffix = "n"
+	}
+	switch value.Kind {
+	case manifest.DefaultBool:
+		return strconv.FormatBool(value.Bool), nil
+	case manifest.DefaultInt:
+		return strconv.FormatInt(value.Int, 10) + suffix, nil
+	case manifest.DefaultUint:
+		return strconv.FormatUint(value.Uint, 10) + suffix, nil
+	case manifest.DefaultFloat:
+		return strconv.FormatFloat(value.Float, 'g', -1, 64), nil
+	case manifest.DefaultChar:
+		return strconv.Itoa(int(value.Char)), nil
+	}
+	// JSON's escapes are all JavaScript ones, unlike Go's \a
+	var sb strings.Builder
+	encoder := json.NewEncoder(&sb)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value.String); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(sb.String(), "\n"), nil
+}
+
 func (g *V8Generator) generateEnum(enum *manifest.Enum, underlyingType string) (string, error) {
 	var sb strings.Builder
 
-	switch {
-	case enum.Description != "" && enum.Deprecated != "":
-		sb.WriteString(fmt.Sprintf("  /**\n   * %s\n   * @deprecated %s\n   */\n", enum.Description, enum.Deprecated))
-	case enum.Deprecated != "":
-		sb.WriteString(fmt.Sprintf("  /** @deprecated %s */\n", enum.Deprecated))
-	case enum.Description != "":
-		sb.WriteString(fmt.Sprintf("  /** %s */\n", enum.Description))
+	sb.WriteString(v8TypeDoc(enum.Description, enum.Deprecated, enum.Since, enum.RemovedIn))
+
+	if !v8NumberEnum(enum) {
+		return sb.String() + v8BigintEnum(enum), nil
 	}
 
 	sb.WriteString(fmt.Sprintf("  export const enum %s {\n", enum.Name))
@@ -304,7 +378,11 @@ func (g *V8Generator) generateEnum(enum *manifest.Enum, underlyingType string) (
 		if val.Description != "" {
 			sb.WriteString(fmt.Sprintf("    /** %s */\n", val.Description))
 		}
-		sb.WriteString(fmt.Sprintf("    %s = %d", val.Name, val.Value))
+		if enum.Flags {
+			sb.WriteString(fmt.Sprintf("    %s = %s", val.Name, v8FlagValue(val.Value)))
+		} else {
+			sb.WriteString(fmt.Sprintf("    %s = %d", val.Name, val.Value))
+		}
 		if i < len(enum.Values)-1 {
 			sb.WriteString(",")
 		}
@@ -315,6 +393,69 @@ func (g *V8Generator) generateEnum(enum *manifest.Enum, underlyingType string) (
 	return sb.String(), nil
 }
 
+func (g *V8Generator) generateStruct(structure *manifest.Struct) (string, error) {
+	var sb strings.Builder
+
+	sb.WriteString(v8TypeDoc(structure.Description, structure.Deprecated, structure.Since, structure.RemovedIn))
+
+	sb.WriteString(fmt.Sprintf("  export interface %s {\n", structure.Name))
+
+	for i := range structure.Fields {
+		field := &structure.Fields[i]
+		fieldType, err := g.typeMapper.MapReturnType(field)
+		if err != nil {
+			return "", err
+		}
+		if field.Description != "" {
+			sb.WriteString(fmt.Sprintf("    /** %s */\n", field.Description))
+		}
+		sb.WriteString(fmt.Sprintf("    %s: %s;\n", field.Name, fieldType))
+	}
+
+	sb.WriteString("  }\n")
+	return sb.String(), nil
+}
+
+// v8NumberEnum reports whether a number holds every value of enum exactly,
+// which it does only up to 2^53.
+func v8NumberEnum(enum *manifest.Enum) bool {
+	const maxSafeInteger = 1<<53 - 1
+	for _, val := range enum.Values {
+		if n, ok := val.Value.Int64(); !ok || n < -maxSafeInteger || n > maxSafeInteger {
+			return false
+		}
+	}
+	return true
+}
+
+// v8BigintEnum declares an enum with values a number cannot hold. A const
+// enum's members are numbers, so it is declared as bigint constants under a
+// bigint type of the same name instead, used the same way.
+func v8BigintEnum(enum *manifest.Enum) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("  export type %s = bigint;\n", enum.Name))
+	sb.WriteString(fmt.Sprintf("  export const %s: {\n", enum.Name))
+	for _, val := range enum.Values {
+		if val.Description != "" {
+			sb.WriteString(fmt.Sprintf("    /** %s */\n", val.Description))
+		}
+		sb.WriteString(fmt.Sprintf("    readonly %s: %dn;\n", val.Name, val.Value))
+	}
+	sb.WriteString("  };\n")
+	return sb.String()
+}
+
+// v8FlagValue spells a single bit of a flags enum as a shift, which a const
+// enum still folds to a number but reads as the bit it is. The result of
+// combining members with | is a number, which TypeScript accepts wherever the
+// enum is expected.
+func v8FlagValue(value manifest.Integer) string {
+	if n, ok := value.Int64(); ok && n > 0 && n < 1<<31 && n&(n-1) == 0 {
+		return fmt.Sprintf("1 << %d", bits.TrailingZeros64(uint64(n)))
+	}
+	return value.String()
+}
+
 func (g *V8Generator) generateAliases(m *manifest.Manifest) (string, error) {
 	return g.CollectAliases(m, g.generateAlias)
 }
@@ -322,14 +463,7 @@ func (g *V8Generator) generateAliases(m *manifest.Manifest) (string, error) {
 func (g *V8Generator) generateAlias(alias *manifest.Alias, underlyingType string) (string, error) {
 	var sb strings.Builder
 
-	switch {
-	case alias.Description != "" && alias.Deprecated != "":
-		sb.WriteString(fmt.Sprintf("  /**\n   * %s\n   * @deprecated %s\n   */\n", alias.Description, alias.Deprecated))
-	case alias.Deprecated != "":
-		sb.WriteString(fmt.Sprintf("  /** @deprecated %s */\n", alias.Deprecated))
-	case alias.Description != "":
-		sb.WriteString(fmt.Sprintf("  /** %s */\n", alias.Description))
-	}
+	sb.WriteString(v8TypeDoc(alias.Description, alias.Deprecated, alias.Since, alias.RemovedIn))
 
 	sb.WriteString(fmt.Sprintf("    type %s = %s;\n", alias.Name, underlyingType))
 
@@ -343,14 +477,7 @@ func (g *V8Generator) generateDelegates(m *manifest.Manifest) (string, error) {
 func (g *V8Generator) generateDelegate(proto *manifest.Prototype) (string, error) {
 	var sb strings.Builder
 
-	switch {
-	case proto.Description != "" && proto.Deprecated != "":
-		sb.WriteString(fmt.Sprintf("  /**\n   * %s\n   * @deprecated %s\n   */\n", proto.Description, proto.Deprecated))
-	case proto.Deprecated != "":
-		sb.WriteString(fmt.Sprintf("  /** @deprecated %s */\n", proto.Deprecated))
-	case proto.Description != "":
-		sb.WriteString(fmt.Sprintf("  /** %s */\n", proto.Description))
-	}
+	sb.WriteString(v8TypeDoc(proto.Description, proto.Deprecated, proto.Since, proto.RemovedIn))
 
 	// Generate return type (with tuple for ref parameters)
 	retType, err := g.generateReturnType(&proto.RetType, proto.ParamTypes)
@@ -395,6 +522,9 @@ func (g *V8Generator) generateClass(m *manifest.Manifest, class *manifest.Class)
 	sb.WriteString(g.generateDocumentation(DocOptions{
 		Description: class.Description,
 		Deprecated:  class.Deprecated,
+		Since:       class.Since,
+		RemovedIn:   class.RemovedIn,
+		Platforms:   class.Platforms,
 		Indent:      "  ",
 	}))
 
@@ -498,6 +628,8 @@ func (g *V8Generator) generateConstructor(m *manifest.Manifest, class *manifest.
 	sb.WriteString(g.generateDocumentation(DocOptions{
 		Description: method.Description,
 		Deprecated:  method.Deprecated,
+		Since:       method.Since,
+		RemovedIn:   method.RemovedIn,
 		Params:      method.ParamTypes,
 		Indent:      "    ",
 	}))
@@ -549,13 +681,16 @@ func (g *V8Generator) generateBinding(m *manifest.Manifest, class *manifest.Clas
 
 	// Handle return alias
 	if binding.RetAlias != nil && binding.RetAlias.Name != "" {
-		retType = binding.RetAlias.Name
+		retType = v8Optional(binding.RetAlias.Name, method.RetType.Optional)
 	}
 
 	// JSDoc comment
 	sb.WriteString(g.generateDocumentation(DocOptions{
 		Description:  method.Description,
 		Deprecated:   method.Deprecated,
+		Since:        method.Since,
+		RemovedIn:    method.RemovedIn,
+		Platforms:    binding.Platforms,
 		Params:       methodParams,
 		RetType:      method.RetType,
 		ParamAliases: binding.ParamAliases,
@@ -591,7 +726,7 @@ func (g *V8Generator) applyParamAliases(formattedParams string, params []manifes
 					end++
 				}
 				// Replace the type
-				result = result[:start] + alias.Name + result[end:]
+				result = result[:start] + v8Optional(alias.Name, params[i].Optional) + result[end:]
 			}
 		}
 	}
@@ -651,13 +786,43 @@ func (g *V8Generator) formatTSParameters(params []manifest.ParamType) (string, e
 
 		paramName := param.Name
 
-		// TypeScript style: name: type
+		// TypeScript style: name: type, and name?: type when a call may
+		// leave it out
+		if param.DefaultValue != nil {
+			paramName += "?"
+		}
 		result += paramName + ": " + typeName
 	}
 
 	return result, nil
 }
 
+// v8TypeDoc renders the JSDoc comment for a constant, enum, struct, alias or
+// delegate: on one line when it has a single thing to say, or "" when it has
+// nothing.
+func v8TypeDoc(description, deprecated, since, removedIn string) string {
+	var lines []string
+	if description != "" {
+		lines = append(lines, description)
+	}
+	if since != "" {
+		lines = append(lines, "@since "+since)
+	}
+	if removedIn != "" {
+		lines = append(lines, "@remarks Removed in "+removedIn)
+	}
+	if deprecated != "" {
+		lines = append(lines, "@deprecated "+deprecated)
+	}
+	switch len(lines) {
+	case 0:
+		return ""
+	case 1:
+		return fmt.Sprintf("  /** %s */\n", lines[0])
+	}
+	return "  /**\n   * " + strings.Join(lines, "\n   * ") + "\n   */\n"
+}
+
 // generateDocumentation generates JSDoc-style comments for methods
 func (g *V8Generator) generateDocumentation(opts DocOptions) string {
 	var sb strings.Builder
@@ -666,10 +831,19 @@ func (g *V8Generator) generateDocumentation(opts DocOptions) string {
 	if opts.Description != "" {
 		sb.WriteString(fmt.Sprintf("%s * %s\n", opts.Indent, opts.Description))
 	}
+	if len(opts.Platforms) > 0 {
+		sb.WriteString(fmt.Sprintf("%s * @platform %s\n", opts.Indent, strings.Join(opts.Platforms, ", ")))
+	}
 
 	// Parameters section
 	for i, param := range opts.Params {
-		sb.WriteString(fmt.Sprintf("%s * @param %s", opts.Indent, param.Name))
+		name := param.Name
+		// The declaration only marks a parameter with a default optional, so
+		// its value is given here
+		if value, err := formatDefault(g.typeMapper, &param); err == nil && value != "" {
+			name = fmt.Sprintf("[%s=%s]", name, value)
+		}
+		sb.WriteString(fmt.Sprintf("%s * @param %s", opts.Indent, name))
 
 		// Always append description if available (alias check is redundant)
 		if param.Description != "" {
@@ -685,6 +859,12 @@ func (g *V8Generator) generateDocumentation(opts DocOptions) string {
 	if opts.RetType.Type != "void" && opts.RetType.Description != "" {
 		sb.WriteString(fmt.Sprintf("%s * @returns %s\n", opts.Indent, opts.RetType.Description))
 	}
+	if opts.Since != "" {
+		sb.WriteString(fmt.Sprintf("%s * @since %s\n", opts.Indent, opts.Since))
+	}
+	if opts.RemovedIn != "" {
+		sb.WriteString(fmt.Sprintf("%s * @remarks Removed in %s\n", opts.Indent, opts.RemovedIn))
+	}
 	// Add deprecation comment if present
 	if opts.Deprecated != "" {
 		sb.WriteString(fmt.Sprintf("%s   * @deprecated %s\n", opts.Indent, opts.Deprecated))
@@ -701,6 +881,9 @@ func (g *V8Generator) generateMethod(method *manifest.Method) (string, error) {
 	sb.WriteString(g.generateDocumentation(DocOptions{
 		Description: method.Description,
 		Deprecated:  method.Deprecated,
+		Since:       method.Since,
+		RemovedIn:   method.RemovedIn,
+		Platforms:   method.Platforms,
 		Params:      method.ParamTypes,
 		RetType:     method.RetType,
 		Indent:      "  ",
@@ -717,7 +900,7 @@ func (g *V8Generator) generateMethod(method *manifest.Method) (string, error) {
 		return "", err
 	}
 
-	sb.WriteString(fmt.Sprintf("  export function %s(%s): %s;\n\n", method.Name, params, retType))
+	sb.WriteString(fmt.Sprintf("  export function %s(%s): %s;\n\n", method.OverloadName(), params, retType))
 
 	return sb.String(), nil
 }
@@ -768,50 +951,50 @@ func (m *V8TypeMapper) MapType(baseType string, context TypeContext, isArray boo
 	return mapped, nil
 }
 
-func (m *V8TypeMapper) MapParamType(param *manifest.ParamType) (string, error) {
-	ctx := TypeContextValue
-
-	var typeName string
-	switch {
-	case param.Alias != nil:
-		typeName = param.Alias.Name
-		ctx |= TypeContextAlias
-
-	case param.Enum != nil:
-		typeName = param.Enum.Name
+func (m *V8TypeMapper) MapFixedArrayType(element string, length int, context TypeContext) (string, error) {
+	return element + "[]", nil
+}
 
-	case param.Prototype != nil:
-		return param.Prototype.Name, nil
+func (m *V8TypeMapper) MapMapType(key, value string, context TypeContext) (string, error) {
+	return fmt.Sprintf("Map<%s, %s>", key, value), nil
+}
 
-	default:
-		typeName = param.BaseType()
+// FormatDefault renders a parameter default as a TypeScript literal, or the
+// enum member it names. Declarations cannot give defaults, so it is only
+// documented.
+func (m *V8TypeMapper) FormatDefault(param *manifest.ParamType) (string, error) {
+	value := param.DefaultValue
+	if value.Kind == manifest.DefaultEnum {
+		return fmt.Sprintf("%s.%s", param.Enum.Name, value.Enum.Name), nil
+	}
+	typeName, err := m.MapType(param.Type, TypeContextValue, false)
+	if err != nil {
+		return "", err
 	}
+	return v8Literal(value, typeName)
+}
 
-	// Regular type mapping
-	return m.MapType(typeName, ctx, param.IsArray())
+func (m *V8TypeMapper) MapParamType(param *manifest.ParamType) (string, error) {
+	mapped, err := m.MapTypeNode(param.TypeNode(), TypeContextValue)
+	return v8Optional(mapped, param.Optional), err
 }
 
 func (m *V8TypeMapper) MapReturnType(retType *manifest.RetType) (string, error) {
-	ctx := TypeContextReturn
-
-	var typeName string
-	switch {
-	case retType.Alias != nil:
-		typeName = retType.Alias.Name
-		ctx |= TypeContextAlias
-
-	case retType.Enum != nil:
-		typeName = retType.Enum.Name
+	mapped, err := m.MapTypeNode(retType.TypeNode(), TypeContextReturn)
+	return v8Optional(mapped, retType.Optional), err
+}
 
-	case retType.Prototype != nil:
-		return retType.Prototype.Name, nil
+// MapTypeNode implements TypeMapper.
+func (m *V8TypeMapper) MapTypeNode(node *manifest.TypeNode, context TypeContext) (string, error) {
+	return mapTypeNode(m, node, context)
+}
 
-	default:
-		typeName = retType.BaseType()
+// v8Optional adds undefined to the type of an optional value.
+func v8Optional(typeName string, optional bool) string {
+	if optional {
+		return typeName + " | undefined"
 	}
-
-	// Regular type mapping
-	return m.MapType(typeName, ctx, retType.IsArray())
+	return typeName
 }
 
 func (m *V8TypeMapper) MapHandleType(class *manifest.Class) (string, string, error) {
@@ -824,3 +1007,25 @@ func (m *V8TypeMapper) MapHandleType(class *manifest.Class) (string, string, err
 	}
 	return "null", handleType, err
 }
+
+// generateExternalImports imports the enums, delegates and structs m uses from
+// each dependency's module.
+func (g *V8Generator) generateExternalImports(m *manifest.Manifest) string {
+	external := g.CollectExternalTypes(m)
+	names := map[string][]string{}
+	for _, plugin := range external.EnumPlugins() {
+		names[plugin] = append(names[plugin], external.EnumNames(plugin)...)
+	}
+	for _, plugin := range external.PrototypePlugins() {
+		names[plugin] = append(names[plugin], external.PrototypeNames(plugin)...)
+	}
+	for _, plugin := range external.StructPlugins() {
+		names[plugin] = append(names[plugin], external.StructNames(plugin)...)
+	}
+
+	var sb strings.Builder
+	for _, plugin := range sortedPlugins(names) {
+		sb.WriteString(fmt.Sprintf("  import { %s } from \":%s\";\n", strings.Join(names[plugin], ", "), plugin))
+	}
+	return sb.String()
+}
@@ -0,0 +1,130 @@
+package manifest
+
+import "fmt"
+
+// Null policies a class may apply to instance bindings called on an empty handle
+const (
+	NullPolicyThrow  = "throw"  // check the handle first and fail (the default)
+	NullPolicyIgnore = "ignore" // pass the handle through unchecked
+)
+
+// checkClasses makes sure every class refers to things that exist. Generators
+// look the methods up by name and used to skip whatever they could not find,
+// so a typo in a binding produced a wrapper that was silently missing a method.
+// It returns non-nil only when p wants checking to stop.
+func checkClasses(m *Manifest, p *problems) error {
+	methods := make(map[string]*Method, 2*len(m.Methods))
+	for i := range m.Methods {
+		method := &m.Methods[i]
+		// Match FindMethod in the generators, which accepts either name.
+		methods[method.FuncName] = method
+		methods[method.Name] = method
+	}
+	classes := make(map[string]struct{}, len(m.Classes))
+	for _, class := range m.Classes {
+		classes[class.Name] = struct{}{}
+	}
+
+	for i := range m.Classes {
+		class := &m.Classes[i]
+		at := fmt.Sprintf("/classes/%d", i)
+		context := fmt.Sprintf("class %q", class.Name)
+		if class.Name == "" {
+			context = fmt.Sprintf("class[%d]", i)
+			if err := p.report(errorAt(at+"/name", "%s: name is required", context)); err != nil {
+				return err
+			}
+		}
+
+		switch class.NullPolicy {
+		case "", NullPolicyThrow, NullPolicyIgnore:
+		default:
+			if err := p.report(errorAt(at+"/nullPolicy", "%s: unknown nullPolicy %q (expected %q or %q)",
+				context, class.NullPolicy, NullPolicyThrow, NullPolicyIgnore)); err != nil {
+				return err
+			}
+		}
+
+		for j, name := range class.Constructors {
+			if _, found := methods[name]; !found {
+				if err := p.report(errorAt(fmt.Sprintf("%s/constructors/%d", at, j), "%s: unknown constructor method %q", context, name)); err != nil {
+					return err
+				}
+			}
+		}
+		if class.Destructor != nil {
+			if _, found := methods[*class.Destructor]; !found {
+				if err := p.report(errorAt(at+"/destructor", "%s: unknown destructor method %q", context, *class.Destructor)); err != nil {
+					return err
+				}
+			}
+		}
+
+		hasHandle := class.HandleType != "" && class.HandleType != "void"
+		for j := range class.Bindings {
+			if err := checkBinding(&class.Bindings[j], class, hasHandle, methods, classes, fmt.Sprintf("%s/bindings/%d", at, j), context, p); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func checkBinding(binding *Binding, class *Class, hasHandle bool, methods map[string]*Method, classes map[string]struct{}, at, context string, p *problems) error {
+	context = fmt.Sprintf("%s binding %q", context, binding.Name)
+
+	// A bad class name in an alias is worth reporting whether or not the
+	// method turns out to exist.
+	for k, alias := range binding.ParamAliases {
+		if alias == nil {
+			continue
+		}
+		if _, found := classes[alias.Name]; !found {
+			if err := p.report(errorAt(fmt.Sprintf("%s/paramAliases/%d/name", at, k), "%s: paramAliases[%d] names unknown class %q", context, k, alias.Name)); err != nil {
+				return err
+			}
+		}
+	}
+	if binding.RetAlias != nil {
+		if _, found := classes[binding.RetAlias.Name]; !found {
+			if err := p.report(errorAt(at+"/retAlias/name", "%s: retAlias names unknown class %q", context, binding.RetAlias.Name)); err != nil {
+				return err
+			}
+		}
+	}
+
+	method, found := methods[binding.Method]
+	if !found {
+		return p.report(errorAt(at+"/method", "%s: unknown method %q", context, binding.Method))
+	}
+
+	params := method.ParamTypes
+	if binding.BindSelf {
+		switch {
+		case !hasHandle:
+			if err := p.report(errorAt(at+"/bindSelf", "%s: a class without a handle type cannot bind self", context)); err != nil {
+				return err
+			}
+		case len(params) == 0:
+			if err := p.report(errorAt(at+"/bindSelf", "%s: method %q takes no parameters to bind self to", context, method.Name)); err != nil {
+				return err
+			}
+		case params[0].Type != class.HandleType:
+			if err := p.report(errorAt(at+"/bindSelf", "%s: first parameter of method %q is %s, not the handle type %s",
+				context, method.Name, params[0].Type, class.HandleType)); err != nil {
+				return err
+			}
+		}
+		if len(params) > 0 {
+			params = params[1:]
+		}
+	}
+
+	if len(binding.ParamAliases) > len(params) {
+		if err := p.report(errorAt(at+"/paramAliases", "%s: %d paramAliases, but method %q has only %d parameters to alias",
+			context, len(binding.ParamAliases), method.Name, len(params))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package manifest
+
+import "fmt"
+
+// constantTypes are the types a constant may have: those every target
+// language can write as a compile-time literal.
+var constantTypes = map[string]struct{}{
+	"bool": {}, "char8": {}, "char16": {},
+	"int8": {}, "int16": {}, "int32": {}, "int64": {},
+	"uint8": {}, "uint16": {}, "uint32": {}, "uint64": {},
+	"float": {}, "double": {}, "string": {},
+}
+
+// constantPointer is the JSON pointer of field in the manifest's i'th constant.
+func constantPointer(i int, field string) string {
+	return fmt.Sprintf("/constants/%d/%s", i, field)
+}
+
+// checkConstants checks each constant's name and type, and its value against
+// that type, filling in Literal. It returns non-nil only when p wants checking
+// to stop.
+func checkConstants(m *Manifest, p *problems) error {
+	names := make(map[string]int, len(m.Constants))
+	for i := range m.Constants {
+		constant := &m.Constants[i]
+		context := scope("constant", i, constant.Name)
+		if constant.Name == "" {
+			if err := p.report(errorAt(constantPointer(i, "name"), "%s: name is required", context)); err != nil {
+				return err
+			}
+		} else if first, seen := names[constant.Name]; seen {
+			if err := p.report(errorAt(constantPointer(i, "name"),
+				"%s: name %q is already used by constant[%d]", context, constant.Name, first)); err != nil {
+				return err
+			}
+		} else {
+			names[constant.Name] = i
+		}
+
+		if _, ok := constantTypes[constant.Type]; !ok {
+			if err := p.report(errorAt(constantPointer(i, "type"),
+				"%s: a constant cannot be %q; it must be bool, a number, a character or string", context, constant.Type)); err != nil {
+				return err
+			}
+			continue
+		}
+		if constant.Value == nil {
+			if err := p.report(errorAt(constantPointer(i, "value"), "%s: value is required", context)); err != nil {
+				return err
+			}
+			continue
+		}
+		literal, err := literalFor(constant.Type, constant.Value, "value")
+		if err != nil {
+			if err := p.report(errorAt(constantPointer(i, "value"), "%s: %v", context, err)); err != nil {
+				return err
+			}
+			continue
+		}
+		constant.Literal = literal
+	}
+	return nil
+}
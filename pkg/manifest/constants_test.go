@@ -0,0 +1,64 @@
+package manifest
+
+import (
+	"math"
+	"testing"
+)
+
+// constants is a manifest holding the given constants.
+func constants(list string) string {
+	return testManifest(`"methods": [], "constants": [` + list + `]`)
+}
+
+func TestConstantLiterals(t *testing.T) {
+	m := mustParse(t, constants(`
+		{"name": "Enabled", "type": "bool", "value": true},
+		{"name": "Min", "type": "int8", "value": -128},
+		{"name": "Max", "type": "uint64", "value": 18446744073709551615},
+		{"name": "Pi", "type": "double", "value": 3.25},
+		{"name": "Sep", "type": "char16", "value": "é"},
+		{"name": "Greeting", "type": "string", "value": "hi"}`), nil)
+
+	want := []DefaultValue{
+		{Kind: DefaultBool, Bool: true},
+		{Kind: DefaultInt, Int: math.MinInt8},
+		{Kind: DefaultUint, Uint: math.MaxUint64},
+		{Kind: DefaultFloat, Float: 3.25},
+		{Kind: DefaultChar, Char: 'é'},
+		{Kind: DefaultString, String: "hi"},
+	}
+	for i, constant := range m.Constants {
+		if constant.Literal == nil || *constant.Literal != want[i] {
+			t.Errorf("constant %s is %+v, want %+v", constant.Name, constant.Literal, want[i])
+		}
+	}
+}
+
+func TestConstantChecks(t *testing.T) {
+	found := parseProblems(t, constants(`
+		{"name": "A", "type": "int32", "value": 1},
+		{"name": "A", "type": "int32", "value": 2},
+		{"type": "int32", "value": 3},
+		{"name": "P", "type": "ptr64", "value": 0},
+		{"name": "V", "type": "int32[]", "value": [1]},
+		{"name": "N", "type": "int32"},
+		{"name": "Big", "type": "uint8", "value": 256},
+		{"name": "Half", "type": "int32", "value": 0.5},
+		{"name": "Word", "type": "bool", "value": "yes"},
+		{"name": "Wide", "type": "char8", "value": "é"},
+		{"name": "Huge", "type": "float", "value": 1e39}`),
+		&ParseOptions{AllErrors: true},
+		"/constants/1/name", "/constants/2/name", "/constants/3/type", "/constants/4/type", "/constants/5/value",
+		"/constants/6/value", "/constants/7/value", "/constants/8/value", "/constants/9/value", "/constants/10/value")
+	wantContains(t, "diagnostics", found.Error(),
+		`name "A" is already used by constant[0]`,
+		"name is required",
+		`a constant cannot be "ptr64"`,
+		`a constant cannot be "int32[]"`,
+		`constant "N": value is required`,
+		"value 256 is out of range for uint8",
+		"value 0.5 for int32 is not an integer",
+		`value "yes" does not fit type bool`,
+		`value "é" does not fit in a char8`,
+		"value 1e39 is out of range for float")
+}
@@ -0,0 +1,209 @@
+package manifest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"unicode/utf8"
+)
+
+// DefaultKind says which field of a DefaultValue holds the value
+type DefaultKind int
+
+const (
+	DefaultBool   DefaultKind = iota + 1 // Bool
+	DefaultInt                           // Int, for the signed integer types
+	DefaultUint                          // Uint, for the unsigned integer types and ptr64
+	DefaultFloat                         // Float, for float and double
+	DefaultChar                          // Char, for char8 and char16
+	DefaultString                        // String
+	DefaultEnum                          // Enum, with its number in Int or Uint as for the underlying type
+)
+
+// DefaultValue is a parameter default that has been checked against the
+// parameter's type, so generators can render it as a literal of that type
+// instead of guessing from whatever JSON happened to decode to.
+type DefaultValue struct {
+	Kind   DefaultKind
+	Bool   bool
+	Int    int64
+	Uint   uint64
+	Float  float64
+	Char   rune
+	String string
+	Enum   *Value // the value of the parameter's enum that the default names
+}
+
+// integerRanges bounds each integer type. The upper bound of the unsigned
+// types is checked separately, since it does not fit an int64.
+var integerRanges = map[string]struct {
+	min, max int64
+	unsigned bool
+	maxUint  uint64
+}{
+	"int8":   {min: math.MinInt8, max: math.MaxInt8},
+	"int16":  {min: math.MinInt16, max: math.MaxInt16},
+	"int32":  {min: math.MinInt32, max: math.MaxInt32},
+	"int64":  {min: math.MinInt64, max: math.MaxInt64},
+	"uint8":  {unsigned: true, maxUint: math.MaxUint8},
+	"uint16": {unsigned: true, maxUint: math.MaxUint16},
+	"uint32": {unsigned: true, maxUint: math.MaxUint32},
+	"uint64": {unsigned: true, maxUint: math.MaxUint64},
+	"ptr64":  {unsigned: true, maxUint: math.MaxUint64},
+}
+
+// checkDefaults checks each default in a parameter list against its parameter
+// and fills in DefaultValue. Only trailing parameters may have defaults: every
+// language that has them requires it, and a call that relies on a default
+// cannot skip past a parameter that has none.
+func checkDefaults(params []ParamType, context where, p *problems) error {
+	firstDefault := -1
+	for i := range params {
+		param := &params[i]
+		if param.Default == nil {
+			if firstDefault >= 0 {
+				if err := p.report(context.param(i).fail("default",
+					"parameter %q has no default but follows param[%d], which has one", param.Name, firstDefault)); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if firstDefault < 0 {
+			firstDefault = i
+		}
+		if !IsValueType(param.Type) {
+			continue // checkType has reported this already
+		}
+
+		value, err := defaultFor(param, *param.Default)
+		if err != nil {
+			if err := p.report(context.param(i).fail("default", "%v", err)); err != nil {
+				return err
+			}
+			continue
+		}
+		param.DefaultValue = value
+	}
+	return nil
+}
+
+// defaultFor converts a decoded default to the type of param.
+func defaultFor(param *ParamType, raw any) (*DefaultValue, error) {
+	if param.TypeNode().IsContainer() {
+		return nil, fmt.Errorf("parameters of type %s cannot have a default", param.Type)
+	}
+
+	if param.Enum != nil {
+		// An enum default is best written by value name, which survives the
+		// values being renumbered; a plain number is accepted as well.
+		if name, ok := raw.(string); ok {
+			for i := range param.Enum.Values {
+				if value := &param.Enum.Values[i]; value.Name == name {
+					result, err := integerLiteral(param.Type, json.Number(value.Value.String()), "default")
+					if err != nil {
+						return nil, err
+					}
+					result.Kind, result.Enum = DefaultEnum, value
+					return result, nil
+				}
+			}
+			return nil, fmt.Errorf("default %q is not a value of enum %q", name, param.Enum.Name)
+		}
+	}
+
+	if _, ok := literalTypes[param.Type]; !ok {
+		return nil, fmt.Errorf("parameters of type %s cannot have a default", param.Type)
+	}
+	return literalFor(param.Type, raw, "default")
+}
+
+// literalTypes are the types a value can be written for in a manifest.
+var literalTypes = map[string]struct{}{
+	"bool": {}, "char8": {}, "char16": {},
+	"int8": {}, "int16": {}, "int32": {}, "int64": {},
+	"uint8": {}, "uint16": {}, "uint32": {}, "uint64": {},
+	"ptr64": {}, "float": {}, "double": {}, "string": {},
+}
+
+// literalFor converts a decoded value to typeName, one of literalTypes. what
+// names the value in errors, as in "default 300 is out of range for int8".
+func literalFor(typeName string, raw any, what string) (*DefaultValue, error) {
+	switch typeName {
+	case "bool":
+		if b, ok := raw.(bool); ok {
+			return &DefaultValue{Kind: DefaultBool, Bool: b}, nil
+		}
+	case "int8", "int16", "int32", "int64", "uint8", "uint16", "uint32", "uint64", "ptr64":
+		if number, ok := raw.(json.Number); ok {
+			return integerLiteral(typeName, number, what)
+		}
+	case "float", "double":
+		if number, ok := raw.(json.Number); ok {
+			f, err := number.Float64()
+			if err != nil {
+				return nil, fmt.Errorf("%s %s is out of range for %s", what, number, typeName)
+			}
+			if typeName == "float" && math.Abs(f) > math.MaxFloat32 {
+				return nil, fmt.Errorf("%s %s is out of range for float", what, number)
+			}
+			return &DefaultValue{Kind: DefaultFloat, Float: f}, nil
+		}
+	case "char8", "char16":
+		if s, ok := raw.(string); ok {
+			r, size := utf8.DecodeRuneInString(s)
+			if size == 0 || size != len(s) {
+				return nil, fmt.Errorf("%s %q for %s must be a single character", what, s, typeName)
+			}
+			if (typeName == "char8" && r > math.MaxInt8) || (typeName == "char16" && r > math.MaxUint16) {
+				return nil, fmt.Errorf("%s %q does not fit in a %s", what, s, typeName)
+			}
+			return &DefaultValue{Kind: DefaultChar, Char: r}, nil
+		}
+	case "string":
+		if s, ok := raw.(string); ok {
+			return &DefaultValue{Kind: DefaultString, String: s}, nil
+		}
+	}
+	return nil, fmt.Errorf("%s %s does not fit type %s", what, describeDefault(raw), typeName)
+}
+
+func integerLiteral(typeName string, number json.Number, what string) (*DefaultValue, error) {
+	bounds := integerRanges[typeName]
+	text := number.String()
+	// Parsing as signed first tells a fraction or exponent, which is not an
+	// integer at all, apart from a negative number given to an unsigned type.
+	i, err := strconv.ParseInt(text, 10, 64)
+	if errors.Is(err, strconv.ErrSyntax) {
+		return nil, fmt.Errorf("%s %s for %s is not an integer", what, text, typeName)
+	}
+	if bounds.unsigned {
+		u, err := strconv.ParseUint(text, 10, 64)
+		if err != nil || u > bounds.maxUint {
+			return nil, fmt.Errorf("%s %s is out of range for %s", what, text, typeName)
+		}
+		return &DefaultValue{Kind: DefaultUint, Uint: u}, nil
+	}
+	if err != nil || i < bounds.min || i > bounds.max {
+		return nil, fmt.Errorf("%s %s is out of range for %s", what, text, typeName)
+	}
+	return &DefaultValue{Kind: DefaultInt, Int: i}, nil
+}
+
+func describeDefault(raw any) string {
+	switch v := raw.(type) {
+	case string:
+		return strconv.Quote(v)
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	case []any:
+		return "[...]"
+	case map[string]any:
+		return "{...}"
+	}
+	return "null"
+}
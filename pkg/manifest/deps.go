@@ -0,0 +1,168 @@
+package manifest
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+// splitQualified splits a reference such as "otherplugin.EnumName" into the
+// plugin and the name it defines. Type names cannot contain a dot, so the last
+// one separates the two.
+func splitQualified(reference string) (plugin, name string, ok bool) {
+	i := strings.LastIndexByte(reference, '.')
+	if i <= 0 || i == len(reference)-1 {
+		return "", "", false
+	}
+	return reference[:i], reference[i+1:], true
+}
+
+// dependencyCache is shared by a manifest and every dependency it loads, so
+// that each dependency is parsed once and a cycle among them is caught.
+type dependencyCache struct {
+	loaded  map[string]*Manifest
+	failed  map[string]error
+	loading map[string]bool
+}
+
+// dependencyLoader finds the manifests of a manifest's dependencies. It reads
+// one only when a qualified reference needs it, so a dependency that nothing
+// refers to does not have to be on the search path at all.
+type dependencyLoader struct {
+	declared map[string]bool
+	opts     *ParseOptions
+	cache    *dependencyCache
+	problems *problems // where the problems of a dependency's own manifest go
+}
+
+func newDependencyLoader(m *Manifest, opts *ParseOptions, p *problems) *dependencyLoader {
+	cache := opts.dependencies
+	if cache == nil {
+		cache = &dependencyCache{
+			loaded:  map[string]*Manifest{},
+			failed:  map[string]error{},
+			loading: map[string]bool{},
+		}
+	}
+	declared := make(map[string]bool, len(m.Dependencies))
+	for _, dependency := range m.Dependencies {
+		declared[dependency.Name] = true
+	}
+	return &dependencyLoader{declared: declared, opts: opts, cache: cache, problems: p}
+}
+
+func (d *dependencyLoader) enum(plugin, name string) (*Enum, error) {
+	dependency, err := d.load(plugin)
+	if err != nil {
+		return nil, err
+	}
+	for _, enum := range dependency.Enums {
+		if enum.Name == name {
+			return enum, nil
+		}
+	}
+	return nil, fmt.Errorf("dependency %q has no enum %q", plugin, name)
+}
+
+func (d *dependencyLoader) prototype(plugin, name string) (*Prototype, error) {
+	dependency, err := d.load(plugin)
+	if err != nil {
+		return nil, err
+	}
+	for _, prototype := range dependency.Prototypes {
+		if prototype.Name == name {
+			return prototype, nil
+		}
+	}
+	return nil, fmt.Errorf("dependency %q has no prototype %q", plugin, name)
+}
+
+func (d *dependencyLoader) structure(plugin, name string) (*Struct, error) {
+	dependency, err := d.load(plugin)
+	if err != nil {
+		return nil, err
+	}
+	for _, structure := range dependency.Structs {
+		if structure.Name == name {
+			return structure, nil
+		}
+	}
+	return nil, fmt.Errorf("dependency %q has no struct %q", plugin, name)
+}
+
+// load parses the manifest of the named dependency, from the first directory
+// on the search path that has one, either as plugin.pplugin or as
+// plugin/plugin.pplugin, the way plugins are laid out once installed.
+func (d *dependencyLoader) load(plugin string) (*Manifest, error) {
+	if !d.declared[plugin] {
+		return nil, fmt.Errorf("%q is not listed in the manifest's dependencies", plugin)
+	}
+	if dependency, ok := d.cache.loaded[plugin]; ok {
+		return dependency, nil
+	}
+	if err, ok := d.cache.failed[plugin]; ok {
+		return nil, err
+	}
+	if d.cache.loading[plugin] {
+		return nil, fmt.Errorf("dependency %q refers back to this manifest through its own dependencies", plugin)
+	}
+
+	d.cache.loading[plugin] = true
+	dependency, err := d.parse(plugin)
+	delete(d.cache.loading, plugin)
+	if err != nil {
+		d.cache.failed[plugin] = err
+		return nil, err
+	}
+
+	// Everything the dependency defines is marked as its own, so generators
+	// refer to it rather than defining it again.
+	for _, enum := range dependency.Enums {
+		enum.Plugin = plugin
+	}
+	for _, prototype := range dependency.Prototypes {
+		prototype.Plugin = plugin
+	}
+	for _, structure := range dependency.Structs {
+		structure.Plugin = plugin
+	}
+	d.cache.loaded[plugin] = dependency
+	return dependency, nil
+}
+
+func (d *dependencyLoader) parse(plugin string) (*Manifest, error) {
+	for _, dir := range d.opts.SearchPath {
+		for _, path := range []string{
+			filepath.Join(dir, plugin+".pplugin"),
+			filepath.Join(dir, plugin, plugin+".pplugin"),
+		} {
+			data, err := d.opts.readFile(path)
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("reading the manifest of dependency %q: %v", plugin, unwrapPathError(err))
+			}
+
+			opts := *d.opts
+			opts.dependencies = d.cache
+			dependency, err := parse(path, data, &opts)
+			if err != nil {
+				// Its diagnostics already name its own file, so they are passed on
+				// as they are, after a note of their own at the reference.
+				var diags Diagnostics
+				if errors.As(err, &diags) {
+					d.problems.found = append(d.problems.found, diags...)
+				}
+				return nil, fmt.Errorf("the manifest of dependency %q, %s, has errors", plugin, path)
+			}
+			return dependency, nil
+		}
+	}
+	if len(d.opts.SearchPath) == 0 {
+		return nil, fmt.Errorf("no search path to find the manifest of dependency %q on", plugin)
+	}
+	return nil, fmt.Errorf("no manifest for dependency %q on the search path", plugin)
+}
@@ -0,0 +1,94 @@
+package manifest
+
+import (
+	"fmt"
+	"testing"
+)
+
+// dependent is a manifest depending on other, with a parameter whose enum is
+// the reference enum.
+func dependent(enum string) string {
+	return testManifest(`"dependencies": [{"name": "other"}],
+		"methods": [` + testMethod("A", fmt.Sprintf(`{"name": "c", "type": "int32", "enum": %q}`, enum), "void", "") + `]`)
+}
+
+// other is the manifest of the dependency other, holding rest.
+func other(rest string) string {
+	return `{"name": "other", "version": "1.0", "language": "cpp", "entry": "other", ` + rest + `}`
+}
+
+func TestQualifiedReferences(t *testing.T) {
+	files := map[string]string{
+		"plugins/other/other.pplugin": other(`"enums": [` + colorRed + `], "methods": []`),
+	}
+	m := mustParse(t, dependent("other.Color"), &ParseOptions{SearchPath: []string{"missing", "plugins"}, ReadFile: readFrom(files)})
+	enum := m.Methods[0].ParamTypes[0].Enum
+	if enum == nil || enum.Name != "Color" || enum.Plugin != "other" {
+		t.Fatalf("other.Color resolves to %+v", enum)
+	}
+	if len(m.Enums) != 0 {
+		t.Errorf("the dependency's enum is among the manifest's own: %v", m.Enums)
+	}
+
+	// Nothing refers to the dependency, so it need not be found
+	mustParse(t, testManifest(`"dependencies": [{"name": "other"}], "methods": []`), nil)
+}
+
+func TestQualifiedReferenceFailures(t *testing.T) {
+	files := map[string]string{
+		"plugins/other.pplugin": other(`"enums": [` + colorRed + `], "methods": []`),
+		"broken/other.pplugin":  other(`"methods": [` + testMethod("B", `{"name": "x", "type": "strng"}`, "void", "") + `]`),
+		"cycle/other.pplugin":   other(`"dependencies": [{"name": "demo"}], "methods": [` + testMethod("B", `{"name": "c", "type": "int32", "enum": "demo.Color"}`, "void", "") + `]`),
+		"cycle/demo.pplugin":    testManifest(`"dependencies": [{"name": "other"}], "methods": [` + testMethod("A", `{"name": "c", "type": "int32", "enum": "other.Color"}`, "void", "") + `]`),
+	}
+	opts := func(dirs ...string) *ParseOptions {
+		return &ParseOptions{SearchPath: dirs, ReadFile: readFrom(files)}
+	}
+	const at = "/methods/0/paramTypes/0/enum"
+
+	found := parseProblems(t, testManifest(`"methods": [`+testMethod("A", `{"name": "c", "type": "int32", "enum": "other.Color"}`, "void", "")+`]`), opts("plugins"), at)
+	wantContains(t, "diagnostics", found.Error(), `"other" is not listed in the manifest's dependencies`)
+
+	found = parseProblems(t, dependent("other.Colour"), opts("plugins"), at)
+	wantContains(t, "diagnostics", found.Error(), `dependency "other" has no enum "Colour"`)
+
+	found = parseProblems(t, dependent("other.Color"), opts(), at)
+	wantContains(t, "diagnostics", found.Error(), `no search path to find the manifest of dependency "other"`)
+
+	found = parseProblems(t, dependent("other.Color"), opts("missing"), at)
+	wantContains(t, "diagnostics", found.Error(), `no manifest for dependency "other" on the search path`)
+
+	// The dependency's own problems are passed on, in its own file
+	found = parseProblems(t, dependent("other.Color"), opts("broken"), "/methods/0/paramTypes/0/type", at)
+	if found[0].File != "broken/other.pplugin" {
+		t.Errorf("the dependency's problem is reported in %q", found[0].File)
+	}
+	wantContains(t, "diagnostics", found.Error(), `unknown type "strng"`, `the manifest of dependency "other", broken/other.pplugin, has errors`)
+
+	// The cycle is reported where it closes, in demo.pplugin as other loads
+	// it, and then at each reference that led there
+	found = parseProblems(t, dependent("other.Color"), opts("cycle"), at, at, at)
+	if found[0].File != "cycle/demo.pplugin" || found[1].File != "cycle/other.pplugin" || found[2].File != "" {
+		t.Errorf("the cycle is reported in %q, %q and %q", found[0].File, found[1].File, found[2].File)
+	}
+	wantContains(t, "diagnostics", found.Error(), `dependency "other" refers back to this manifest through its own dependencies`)
+}
+
+func TestSplitQualified(t *testing.T) {
+	tests := []struct {
+		reference    string
+		plugin, name string
+	}{
+		{"other.Color", "other", "Color"},
+		{"my.other.Color", "my.other", "Color"},
+		{"Color", "", ""},
+		{".Color", "", ""},
+		{"other.", "", ""},
+	}
+	for _, tt := range tests {
+		plugin, name, ok := splitQualified(tt.reference)
+		if plugin != tt.plugin || name != tt.name || ok != (tt.plugin != "") {
+			t.Errorf("splitQualified(%q) = %q, %q, %v", tt.reference, plugin, name, ok)
+		}
+	}
+}
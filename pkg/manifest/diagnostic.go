@@ -0,0 +1,334 @@
+package manifest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Severity says how seriously a Diagnostic should be taken
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Diagnostic is a problem found in a manifest, located both by source position
+// and by JSON pointer. The pointer is what resolve and validate know about; the
+// line and column are filled in from it by Parse, which has the source text.
+type Diagnostic struct {
+	File     string   `json:"file,omitempty"`
+	Line     int      `json:"line,omitempty"`
+	Column   int      `json:"column,omitempty"`
+	Pointer  string   `json:"pointer"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+	Rule     string   `json:"rule,omitempty"` // the lint rule that reported this, if any
+}
+
+// Error renders the diagnostic the way a compiler would, so editors and CI logs
+// can jump straight to the offending line.
+func (d *Diagnostic) Error() string {
+	var sb strings.Builder
+	if d.File != "" {
+		sb.WriteString(d.File)
+		sb.WriteString(":")
+	}
+	if d.Line > 0 {
+		sb.WriteString(fmt.Sprintf("%d:%d:", d.Line, d.Column))
+	}
+	if sb.Len() > 0 {
+		sb.WriteString(" ")
+	}
+	sb.WriteString(fmt.Sprintf("%s: %s", d.Severity, d.Message))
+	if d.Rule != "" {
+		sb.WriteString(fmt.Sprintf(" [%s]", d.Rule))
+	}
+	return sb.String()
+}
+
+// Diagnostics is every problem Parse found, in the order it found them.
+type Diagnostics []*Diagnostic
+
+// Error renders one diagnostic per line.
+func (ds Diagnostics) Error() string {
+	lines := make([]string, len(ds))
+	for i, d := range ds {
+		lines[i] = d.Error()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Unwrap exposes the individual diagnostics to errors.As and errors.Is.
+func (ds Diagnostics) Unwrap() []error {
+	errs := make([]error, len(ds))
+	for i, d := range ds {
+		errs[i] = d
+	}
+	return errs
+}
+
+// Count returns how many of the diagnostics have the given severity.
+func (ds Diagnostics) Count(severity Severity) int {
+	n := 0
+	for _, d := range ds {
+		if d.Severity == severity {
+			n++
+		}
+	}
+	return n
+}
+
+// errorAt builds an error diagnostic for the given pointer. Its position is
+// left for locate to fill in.
+func errorAt(pointer, format string, args ...any) *Diagnostic {
+	return &Diagnostic{
+		Pointer:  pointer,
+		Severity: SeverityError,
+		Message:  fmt.Sprintf(format, args...),
+	}
+}
+
+// warningAt builds a warning diagnostic for the given pointer. A warning is
+// reported, but does not stop the manifest from parsing.
+func warningAt(pointer, format string, args ...any) *Diagnostic {
+	d := errorAt(pointer, format, args...)
+	d.Severity = SeverityWarning
+	return d
+}
+
+// problems gathers what resolve and validate find wrong with a manifest. Unless
+// every problem is wanted, the first one ends the walk, which keeps a broken
+// manifest from costing more than a valid one.
+type problems struct {
+	all   bool
+	found Diagnostics
+}
+
+// report records d. It hands d back when checking should stop there, and nil
+// when it should carry on looking for more. A warning never stops it.
+func (p *problems) report(d *Diagnostic) error {
+	p.found = append(p.found, d)
+	if p.all || d.Severity == SeverityWarning {
+		return nil
+	}
+	return d
+}
+
+// decodeError turns an encoding/json failure into a positioned diagnostic.
+// Decoder errors carry a byte offset, from which the pointer is worked out.
+func decodeError(err error, file string, data []byte) Diagnostics {
+	d := &Diagnostic{File: file, Severity: SeverityError, Message: fmt.Sprintf("invalid manifest JSON: %v", err)}
+
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		// The offset counts the offending byte as read already.
+		d.Line, d.Column = lineColumn(data, int(syntaxErr.Offset)-1)
+	case errors.As(err, &typeErr):
+		// The offset sits just past the value that failed to decode.
+		offset := int(typeErr.Offset) - 1
+		d.Line, d.Column = lineColumn(data, offset)
+		if span, ok := indexPositions(data).enclosing(offset); ok {
+			d.Pointer = span.pointer
+			d.Line, d.Column = lineColumn(data, span.start)
+		}
+	}
+	return Diagnostics{d}
+}
+
+// lineColumn converts a byte offset into a 1-based line and column. Columns
+// count bytes, as most compilers do.
+func lineColumn(data []byte, offset int) (int, int) {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > len(data) {
+		offset = len(data)
+	}
+	line, column := 1, 1
+	for _, c := range data[:offset] {
+		if c == '\n' {
+			line++
+			column = 1
+		} else {
+			column++
+		}
+	}
+	return line, column
+}
+
+// span is where one JSON value sits in the source.
+type span struct {
+	pointer    string
+	start, end int
+}
+
+// positionIndex records the span of every value in a JSON document, in the
+// order the values start. It is only built when something needs reporting.
+type positionIndex []span
+
+func (idx positionIndex) find(pointer string) (span, bool) {
+	for _, s := range idx {
+		if s.pointer == pointer {
+			return s, true
+		}
+	}
+	// A pointer into something the manifest left out (a missing "name", say)
+	// is best reported at the nearest thing that is there.
+	if i := strings.LastIndexByte(pointer, '/'); i >= 0 {
+		return idx.find(pointer[:i])
+	}
+	return span{}, false
+}
+
+// enclosing returns the innermost value containing offset.
+func (idx positionIndex) enclosing(offset int) (span, bool) {
+	best, found := span{}, false
+	for _, s := range idx {
+		if s.start <= offset && offset < s.end && (!found || s.start >= best.start) {
+			best, found = s, true
+		}
+	}
+	return best, found
+}
+
+// indexPositions scans data and records where each value lies. It is lenient
+// about malformed input and simply stops where the document stops making
+// sense, so whatever was indexed up to that point is still usable.
+func indexPositions(data []byte) positionIndex {
+	s := &positionScanner{data: data}
+	s.value("")
+	return s.spans
+}
+
+type positionScanner struct {
+	data  []byte
+	pos   int
+	spans positionIndex
+}
+
+func (s *positionScanner) skipSpace() {
+	for s.pos < len(s.data) {
+		switch s.data[s.pos] {
+		case ' ', '\t', '\n', '\r':
+			s.pos++
+		default:
+			return
+		}
+	}
+}
+
+func (s *positionScanner) value(pointer string) bool {
+	s.skipSpace()
+	if s.pos >= len(s.data) {
+		return false
+	}
+
+	slot := len(s.spans)
+	s.spans = append(s.spans, span{pointer: pointer, start: s.pos, end: len(s.data)})
+
+	ok := true
+	switch s.data[s.pos] {
+	case '{':
+		ok = s.object(pointer)
+	case '[':
+		ok = s.array(pointer)
+	case '"':
+		_, ok = s.str()
+	default:
+		for s.pos < len(s.data) && !strings.ContainsRune(",]} \t\r\n", rune(s.data[s.pos])) {
+			s.pos++
+		}
+	}
+	if ok {
+		s.spans[slot].end = s.pos
+	}
+	return ok
+}
+
+func (s *positionScanner) object(pointer string) bool {
+	s.pos++ // '{'
+	for {
+		s.skipSpace()
+		if s.pos >= len(s.data) {
+			return false
+		}
+		if s.data[s.pos] == '}' {
+			s.pos++
+			return true
+		}
+		if s.data[s.pos] == ',' {
+			s.pos++
+			continue
+		}
+		key, ok := s.str()
+		if !ok {
+			return false
+		}
+		s.skipSpace()
+		if s.pos >= len(s.data) || s.data[s.pos] != ':' {
+			return false
+		}
+		s.pos++
+		if !s.value(pointer + "/" + escapePointer(key)) {
+			return false
+		}
+	}
+}
+
+func (s *positionScanner) array(pointer string) bool {
+	s.pos++ // '['
+	for i := 0; ; {
+		s.skipSpace()
+		if s.pos >= len(s.data) {
+			return false
+		}
+		if s.data[s.pos] == ']' {
+			s.pos++
+			return true
+		}
+		if s.data[s.pos] == ',' {
+			s.pos++
+			continue
+		}
+		if !s.value(pointer + "/" + strconv.Itoa(i)) {
+			return false
+		}
+		i++
+	}
+}
+
+func (s *positionScanner) str() (string, bool) {
+	if s.pos >= len(s.data) || s.data[s.pos] != '"' {
+		return "", false
+	}
+	start := s.pos
+	for s.pos++; s.pos < len(s.data); s.pos++ {
+		switch s.data[s.pos] {
+		case '\\':
+			s.pos++
+		case '"':
+			s.pos++
+			var text string
+			if err := json.Unmarshal(s.data[start:s.pos], &text); err != nil {
+				return "", false
+			}
+			return text, true
+		}
+	}
+	return "", false
+}
+
+// escapePointer escapes a key for use as a JSON pointer reference token
+// (RFC 6901).
+func escapePointer(key string) string {
+	if !strings.ContainsAny(key, "~/") {
+		return key
+	}
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
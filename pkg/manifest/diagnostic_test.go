@@ -0,0 +1,230 @@
+package manifest
+
+import (
+	"errors"
+	"io/fs"
+	"strings"
+	"testing"
+)
+
+// position is where a test expects a diagnostic: in file, at the first
+// occurrence of text, with the given pointer.
+type position struct {
+	file    string
+	text    string
+	pointer string
+}
+
+// positionOf returns the 1-based line and column of the first occurrence of
+// text in src, counting columns in bytes.
+func positionOf(t *testing.T, src, text string) (int, int) {
+	t.Helper()
+	offset := strings.Index(src, text)
+	if offset < 0 {
+		t.Fatalf("%q does not occur in the test source", text)
+	}
+	before := src[:offset]
+	line := strings.Count(before, "\n") + 1
+	return line, offset - strings.LastIndexByte(before, '\n')
+}
+
+// checkPositions parses data with files to import from and compares the
+// diagnostics Parse fails with against want.
+func checkPositions(t *testing.T, data string, files map[string]string, want []position) {
+	t.Helper()
+	opts := &ParseOptions{
+		AllErrors: true,
+		Strict:    true,
+		ReadFile: func(name string) ([]byte, error) {
+			if content, ok := files[name]; ok {
+				return []byte(content), nil
+			}
+			return nil, fs.ErrNotExist
+		},
+	}
+	_, err := Parse([]byte(data), opts)
+	var found Diagnostics
+	if !errors.As(err, &found) {
+		t.Fatalf("Parse returned %v, want diagnostics", err)
+	}
+	if len(found) != len(want) {
+		t.Fatalf("Parse reported %d problems, want %d:\n%v", len(found), len(want), found)
+	}
+	for i, d := range found {
+		src := data
+		if want[i].file != "" {
+			src = files[want[i].file]
+		}
+		line, column := positionOf(t, src, want[i].text)
+		if d.File != want[i].file || d.Line != line || d.Column != column || d.Pointer != want[i].pointer {
+			t.Errorf("problem %d (%s) is at %q %d:%d %s, want %q %d:%d %s", i, d.Message,
+				d.File, d.Line, d.Column, d.Pointer, want[i].file, line, column, want[i].pointer)
+		}
+	}
+}
+
+func TestDiagnosticPositions(t *testing.T) {
+	const header = `{"name": "demo", "version": "1.0", "language": "cpp", "entry": "demo",` + "\n"
+
+	tests := []struct {
+		name     string
+		manifest string
+		want     []position
+	}{
+		{
+			name: "unknown parameter type",
+			manifest: header + ` "methods": [
+  {"name": "A", "funcName": "A",
+   "paramTypes": [{"name": "x", "type": "strng"}],
+   "retType": {"type": "void"}}
+ ]}`,
+			want: []position{{"", `"strng"`, "/methods/0/paramTypes/0/type"}},
+		},
+		{
+			name: "unknown return type in the second method",
+			manifest: header + ` "methods": [
+  {"name": "A", "funcName": "A", "paramTypes": [], "retType": {"type": "void"}},
+  {"name": "B", "funcName": "B", "paramTypes": [], "retType": {"type": "voi"}}
+ ]}`,
+			want: []position{{"", `"voi"`, "/methods/1/retType/type"}},
+		},
+		{
+			name: "missing key is reported at its object",
+			manifest: header + ` "methods": [
+  {"name": "A", "paramTypes": [], "retType": {"type": "void"}}
+ ]}`,
+			want: []position{{"", `{"name": "A"`, "/methods/0/funcName"}},
+		},
+		{
+			name:     "unknown key",
+			manifest: header + ` "methods": [], "bogus": {"nested": [1, 2]}}`,
+			want:     []position{{"", `{"nested"`, "/bogus"}},
+		},
+		{
+			name: "value of the wrong JSON type",
+			manifest: header + ` "methods": [
+  {"name": "A", "funcName": "A", "paramTypes": 5, "retType": {"type": "void"}}
+ ]}`,
+			want: []position{{"", `5,`, "/methods/0/paramTypes"}},
+		},
+		{
+			name:     "syntax error",
+			manifest: "{\"name\": \"demo\",\n \"version\": }",
+			want:     []position{{"", "}", ""}},
+		},
+		{
+			name: "keys with escapes and brackets in strings do not throw the scanner",
+			manifest: header + ` "description": "a \"quoted\" [bracket] {brace}",
+ "methods": [
+  {"name": "A", "funcName": "A", "description": "x\\y", "paramTypes": [{"name": "x", "type": "strng"}], "retType": {"type": "void"}}
+ ]}`,
+			want: []position{{"", `"strng"`, "/methods/0/paramTypes/0/type"}},
+		},
+		{
+			name: "several problems in source order",
+			manifest: header + ` "methods": [
+  {"name": "A", "funcName": "A", "paramTypes": [{"name": "x", "type": "strng"}], "retType": {"type": "void"}},
+  {"name": "B", "funcName": "B", "paramTypes": [], "retType": {"type": "voi"}}
+ ]}`,
+			want: []position{
+				{"", `"strng"`, "/methods/0/paramTypes/0/type"},
+				{"", `"voi"`, "/methods/1/retType/type"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkPositions(t, tt.manifest, nil, tt.want)
+		})
+	}
+}
+
+func TestDiagnosticPositionsInImports(t *testing.T) {
+	const header = `{"name": "demo", "version": "1.0", "language": "cpp", "entry": "demo",` + "\n"
+	files := map[string]string{
+		"shared.json": `{
+  "enums": [
+    {"name": "Color", "values": [{"name": "Red", "value": 0}]}
+  ],
+  "methods": [
+    {"name": "B", "funcName": "B", "paramTypes": [], "retType": {"type": "void"}},
+    {"name": "C", "funcName": "C", "paramTypes": [{"name": "x", "type": "strng"}], "retType": {"type": "void"}}
+  ],
+  "imports": ["nested/more.json"]
+}`,
+		"nested/more.json": `{"methods": [
+ {"name": "D", "funcName": "D", "paramTypes": [], "retType": {"type": "voi"}}]}`,
+		"extra.json": `{"methods": [], "bogus": true}`,
+	}
+
+	tests := []struct {
+		name     string
+		manifest string
+		want     []position
+	}{
+		{
+			name: "problems traced back to the imported files",
+			manifest: header + ` "imports": ["shared.json"],
+ "methods": [
+  {"name": "A", "funcName": "A", "paramTypes": [{"name": "x", "type": "strng"}], "retType": {"type": "void"}}
+ ]}`,
+			want: []position{
+				{"", `"strng"`, "/methods/0/paramTypes/0/type"},
+				{"shared.json", `"strng"`, "/methods/1/paramTypes/0/type"},
+				{"nested/more.json", `"voi"`, "/methods/0/retType/type"},
+			},
+		},
+		{
+			name:     "missing import is reported where it is named",
+			manifest: header + ` "imports": ["shared.json", "missing.json"], "methods": []}`,
+			want: []position{
+				{"", `"missing.json"`, "/imports/1"},
+				{"shared.json", `"strng"`, "/methods/1/paramTypes/0/type"},
+				{"nested/more.json", `"voi"`, "/methods/0/retType/type"},
+			},
+		},
+		{
+			name:     "unknown key in an imported file",
+			manifest: header + ` "imports": ["extra.json"], "methods": []}`,
+			want:     []position{{"extra.json", `true`, "/bogus"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkPositions(t, tt.manifest, files, tt.want)
+		})
+	}
+}
+
+func TestIndexPositions(t *testing.T) {
+	data := `{"a": [1, {"b/c": "x"}, "y"], "d~e": null, "f": "\"}]"}`
+	tests := []struct {
+		pointer string
+		text    string // where the value starts
+	}{
+		{"", `{"a"`},
+		{"/a", `[1`},
+		{"/a/0", `1,`},
+		{"/a/1", `{"b/c"`},
+		{"/a/1/b~1c", `"x"`},
+		{"/a/2", `"y"`},
+		{"/d~0e", `null`},
+		{"/f", `"\"}]"`},
+		// A pointer to something left out falls back to what holds it
+		{"/a/1/missing", `{"b/c"`},
+	}
+
+	index := indexPositions([]byte(data))
+	for _, tt := range tests {
+		at, ok := index.find(tt.pointer)
+		if !ok {
+			t.Errorf("%q is not indexed", tt.pointer)
+			continue
+		}
+		if want := strings.Index(data, tt.text); at.start != want {
+			t.Errorf("%q starts at %d, want %d", tt.pointer, at.start, want)
+		}
+	}
+}
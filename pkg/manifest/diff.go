@@ -0,0 +1,498 @@
+package manifest
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Impact says what a change between two versions of a manifest does to the
+// plugins built against the older one.
+type Impact string
+
+const (
+	ImpactBreaking   Impact = "breaking"   // they may no longer build, or call the plugin wrongly
+	ImpactCompatible Impact = "compatible" // they keep working; there is more to use
+	ImpactDoc        Impact = "doc-only"   // only the documentation changed
+)
+
+// Change is a difference Diff found between two versions of a manifest.
+type Change struct {
+	Impact  Impact `json:"impact"`
+	Kind    string `json:"kind"` // "manifest", "method", "prototype", "enum", "alias", "struct", "constant", "event" or "class"
+	Name    string `json:"name"`
+	Message string `json:"message"`
+}
+
+func (c *Change) String() string {
+	return fmt.Sprintf("%s: %s %s: %s", c.Impact, c.Kind, c.Name, c.Message)
+}
+
+// Changes is every difference Diff found, the items of the older manifest
+// first and in its order, then those the newer one added.
+type Changes []Change
+
+// Count returns how many of the changes have the given impact.
+func (cs Changes) Count(impact Impact) int {
+	n := 0
+	for _, c := range cs {
+		if c.Impact == impact {
+			n++
+		}
+	}
+	return n
+}
+
+// Bump names the part of a semantic version the changes call for bumping:
+// "major" for a breaking change, "minor" for a compatible one, "patch" when
+// only the documentation changed, and "" when nothing did.
+func (cs Changes) Bump() string {
+	switch {
+	case cs.Count(ImpactBreaking) > 0:
+		return "major"
+	case cs.Count(ImpactCompatible) > 0:
+		return "minor"
+	case len(cs) > 0:
+		return "patch"
+	}
+	return ""
+}
+
+// NextVersion returns the version after version that the changes call for.
+// Before 1.0 nothing is stable, so there a breaking change bumps the minor
+// number and anything else the patch number, the way Cargo reads them.
+func (cs Changes) NextVersion(version string) (Version, error) {
+	current, err := ParseVersion(version)
+	if err != nil {
+		return nil, err
+	}
+	next := make(Version, max(len(current), 3))
+	copy(next, current)
+	if cs.Bump() == "" {
+		return next, nil
+	}
+	part := map[string]int{"major": 0, "minor": 1, "patch": 2}[cs.Bump()]
+	if next[0] == 0 && part < 2 {
+		part++
+	}
+	next[part]++
+	for i := part + 1; i < len(next); i++ {
+		next[i] = 0
+	}
+	return next, nil
+}
+
+// Diff compares two parsed versions of a manifest and classifies every
+// difference by what it does to plugins built against the older one: removing
+// or retyping anything they may use is breaking, adding to it is compatible,
+// and rewording its documentation is doc-only. Types from dependencies belong
+// to the dependency and are left to its own diff.
+func Diff(older, newer *Manifest) Changes {
+	d := &differ{}
+	if older.Name != newer.Name {
+		d.add(ImpactBreaking, "manifest", newer.Name, "renamed from %q, which renames everything generated for it", older.Name)
+	}
+	if older.Description != newer.Description {
+		d.add(ImpactDoc, "manifest", newer.Name, "description changes")
+	}
+
+	diffItems(d, "method", pointers(older.Methods), pointers(newer.Methods), func(m *Method) string { return m.Name }, diffMethod)
+	diffItems(d, "prototype", ownPrototypes(older), ownPrototypes(newer), func(p *Prototype) string { return p.Name }, diffPrototype)
+	diffItems(d, "enum", ownEnums(older), ownEnums(newer), func(e *Enum) string { return e.Name }, diffEnum)
+	diffItems(d, "alias", aliasesOf(older), aliasesOf(newer), func(a *Alias) string { return a.Name }, diffAlias)
+	diffItems(d, "struct", ownStructs(older), ownStructs(newer), func(s *Struct) string { return s.Name }, diffStruct)
+	diffItems(d, "constant", pointers(older.Constants), pointers(newer.Constants), func(c *Constant) string { return c.Name }, diffConstant)
+	diffItems(d, "event", pointers(older.Events), pointers(newer.Events), func(e *Event) string { return e.Name }, diffEvent)
+	diffItems(d, "class", pointers(older.Classes), pointers(newer.Classes), func(c *Class) string { return c.Name }, diffClass)
+	return d.changes
+}
+
+// differ collects changes as the items are compared.
+type differ struct {
+	changes Changes
+}
+
+func (d *differ) add(impact Impact, kind, name, format string, args ...any) {
+	d.changes = append(d.changes, Change{Impact: impact, Kind: kind, Name: name, Message: fmt.Sprintf(format, args...)})
+}
+
+// diffItems pairs up the items of both versions by name, reporting the ones
+// only one of them has and handing each pair to compare.
+func diffItems[T any](d *differ, kind string, older, newer []*T, name func(*T) string, compare func(d *differ, kind string, older, newer *T)) {
+	index := make(map[string]*T, len(newer))
+	for _, item := range newer {
+		index[name(item)] = item
+	}
+	seen := make(map[string]bool, len(older))
+	for _, item := range older {
+		seen[name(item)] = true
+		if now, ok := index[name(item)]; ok {
+			compare(d, kind, item, now)
+		} else {
+			d.add(ImpactBreaking, kind, name(item), "removed")
+		}
+	}
+	for _, item := range newer {
+		if !seen[name(item)] {
+			d.add(ImpactCompatible, kind, name(item), "added")
+		}
+	}
+}
+
+// pointers returns a pointer to each of items, for diffItems.
+func pointers[T any](items []T) []*T {
+	out := make([]*T, len(items))
+	for i := range items {
+		out[i] = &items[i]
+	}
+	return out
+}
+
+// diffDocs reports the changes every kind of item can have in its
+// documentation, deprecation and versions.
+func diffDocs(d *differ, kind, name string, older, newer *itemDocs) {
+	if older.description != newer.description {
+		d.add(ImpactDoc, kind, name, "description changes")
+	}
+	switch {
+	case older.deprecated == newer.deprecated:
+	case older.deprecated == "":
+		d.add(ImpactCompatible, kind, name, "deprecated: %s", newer.deprecated)
+	case newer.deprecated == "":
+		d.add(ImpactCompatible, kind, name, "no longer deprecated")
+	default:
+		d.add(ImpactDoc, kind, name, "deprecation message changes")
+	}
+	if older.since != newer.since || older.removedIn != newer.removedIn {
+		d.add(ImpactDoc, kind, name, "versions change from %s to %s",
+			describeLifetime(older.since, older.removedIn), describeLifetime(newer.since, newer.removedIn))
+	}
+}
+
+func describeLifetime(since, removedIn string) string {
+	switch {
+	case since != "" && removedIn != "":
+		return fmt.Sprintf("since %s, removed in %s", since, removedIn)
+	case since != "":
+		return "since " + since
+	case removedIn != "":
+		return "removed in " + removedIn
+	}
+	return "every version"
+}
+
+// itemDocs is the part of an item diffDocs compares.
+type itemDocs struct {
+	description, deprecated, since, removedIn string
+}
+
+// diffPlatforms reports a change to where an item exists: leaving a platform
+// breaks whoever uses it there, while reaching more of them does not.
+func diffPlatforms(d *differ, kind, name string, older, newer []string) {
+	switch {
+	case !withinPlatforms(older, newer):
+		d.add(ImpactBreaking, kind, name, "platforms narrow from %s to %s", describePlatforms(older), describePlatforms(newer))
+	case !withinPlatforms(newer, older):
+		d.add(ImpactCompatible, kind, name, "platforms widen from %s to %s", describePlatforms(older), describePlatforms(newer))
+	}
+}
+
+func describePlatforms(platforms []string) string {
+	if len(platforms) == 0 {
+		return "all"
+	}
+	return strings.Join(platforms, ", ")
+}
+
+// describeProperty renders the type of a parameter, return value or field
+// for a message, naming the enum, prototype or struct it is.
+func describeProperty(p *Property) string {
+	s := p.Type
+	switch {
+	case p.Enum != nil:
+		s += " " + p.Enum.Name
+	case p.Prototype != nil:
+		s += " " + prototypeName(p.Prototype)
+	case p.Struct != nil && p.Struct.Name != p.Type:
+		s += " " + p.Struct.Name
+	}
+	if p.Optional {
+		s = "optional " + s
+	}
+	if p.Ref {
+		s += "&"
+	}
+	return s
+}
+
+// diffSignature reports changes to the parameters and return value of a
+// method or prototype. Any change to their types is breaking: a plugin built
+// against the older one would pass or expect the wrong thing.
+func diffSignature(d *differ, kind, name string, olderParams, newerParams []ParamType, olderRet, newerRet *RetType) {
+	if len(olderParams) != len(newerParams) {
+		d.add(ImpactBreaking, kind, name, "takes %d parameters instead of %d", len(newerParams), len(olderParams))
+	} else {
+		for i := range olderParams {
+			diffParam(d, kind, name, i, &olderParams[i], &newerParams[i])
+		}
+	}
+	if !sameProperty(olderRet, newerRet) {
+		d.add(ImpactBreaking, kind, name, "returns %s instead of %s", describeProperty(newerRet), describeProperty(olderRet))
+	}
+	if olderRet.Description != newerRet.Description {
+		d.add(ImpactDoc, kind, name, "return value description changes")
+	}
+}
+
+func diffParam(d *differ, kind, name string, i int, older, newer *ParamType) {
+	if !sameProperty(older, newer) {
+		d.add(ImpactBreaking, kind, name, "param[%d] (%s) changes from %s to %s", i, newer.Name, describeProperty(older), describeProperty(newer))
+	}
+	if older.Name != newer.Name {
+		d.add(ImpactCompatible, kind, name, "param[%d] is renamed from %s to %s", i, older.Name, newer.Name)
+	}
+	switch {
+	case older.DefaultValue == nil && newer.DefaultValue == nil:
+	case older.DefaultValue == nil:
+		d.add(ImpactCompatible, kind, name, "parameter %s gains a default", newer.Name)
+	case newer.DefaultValue == nil:
+		d.add(ImpactBreaking, kind, name, "parameter %s loses its default", newer.Name)
+	case !reflect.DeepEqual(older.DefaultValue, newer.DefaultValue):
+		d.add(ImpactCompatible, kind, name, "parameter %s has a different default", newer.Name)
+	}
+	if !sameAlias(older.Alias, newer.Alias) {
+		d.add(ImpactBreaking, kind, name, "parameter %s changes alias", newer.Name)
+	}
+	if older.Description != newer.Description {
+		d.add(ImpactDoc, kind, name, "parameter %s description changes", newer.Name)
+	}
+}
+
+func sameAlias(a, b *Alias) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Name == b.Name
+}
+
+func diffMethod(d *differ, kind string, older, newer *Method) {
+	if older.FuncName != newer.FuncName {
+		d.add(ImpactBreaking, kind, newer.Name, "exported as %s instead of %s", newer.FuncName, older.FuncName)
+	}
+	if older.OverloadName() != newer.OverloadName() {
+		d.add(ImpactBreaking, kind, newer.Name, "overloads %s instead of %s", newer.OverloadName(), older.OverloadName())
+	}
+	diffSignature(d, kind, newer.Name, older.ParamTypes, newer.ParamTypes, &older.RetType, &newer.RetType)
+	diffPlatforms(d, kind, newer.Name, older.Platforms, newer.Platforms)
+	if older.Group != newer.Group {
+		d.add(ImpactCompatible, kind, newer.Name, "moves from group %q to %q", older.Group, newer.Group)
+	}
+	diffDocs(d, kind, newer.Name,
+		&itemDocs{older.Description, older.Deprecated, older.Since, older.RemovedIn},
+		&itemDocs{newer.Description, newer.Deprecated, newer.Since, newer.RemovedIn})
+}
+
+func diffPrototype(d *differ, kind string, older, newer *Prototype) {
+	diffSignature(d, kind, newer.Name, older.ParamTypes, newer.ParamTypes, &older.RetType, &newer.RetType)
+	diffDocs(d, kind, newer.Name,
+		&itemDocs{older.Description, older.Deprecated, older.Since, older.RemovedIn},
+		&itemDocs{newer.Description, newer.Deprecated, newer.Since, newer.RemovedIn})
+}
+
+func diffEnum(d *differ, kind string, older, newer *Enum) {
+	if older.Flags != newer.Flags {
+		d.add(ImpactBreaking, kind, newer.Name, "flags changes from %t to %t", older.Flags, newer.Flags)
+	}
+	values := make(map[string]*Value, len(newer.Values))
+	for i := range newer.Values {
+		values[newer.Values[i].Name] = &newer.Values[i]
+	}
+	seen := make(map[string]bool, len(older.Values))
+	for _, value := range older.Values {
+		seen[value.Name] = true
+		now, ok := values[value.Name]
+		switch {
+		case !ok:
+			d.add(ImpactBreaking, kind, newer.Name, "value %s is removed", value.Name)
+		case now.Value != value.Value:
+			d.add(ImpactBreaking, kind, newer.Name, "value %s is renumbered from %d to %d", value.Name, value.Value, now.Value)
+		case now.Description != value.Description:
+			d.add(ImpactDoc, kind, newer.Name, "value %s description changes", value.Name)
+		}
+	}
+	for _, value := range newer.Values {
+		if !seen[value.Name] {
+			d.add(ImpactCompatible, kind, newer.Name, "value %s is added", value.Name)
+		}
+	}
+	diffDocs(d, kind, newer.Name,
+		&itemDocs{older.Description, older.Deprecated, older.Since, older.RemovedIn},
+		&itemDocs{newer.Description, newer.Deprecated, newer.Since, newer.RemovedIn})
+}
+
+func diffAlias(d *differ, kind string, older, newer *Alias) {
+	diffDocs(d, kind, newer.Name,
+		&itemDocs{older.Description, older.Deprecated, older.Since, older.RemovedIn},
+		&itemDocs{newer.Description, newer.Deprecated, newer.Since, newer.RemovedIn})
+}
+
+// diffStruct treats any change to the fields as breaking, since every
+// generator lays them out in order and the layout is the struct's ABI.
+func diffStruct(d *differ, kind string, older, newer *Struct) {
+	if !sameStruct(older, newer) {
+		d.add(ImpactBreaking, kind, newer.Name, "fields change from (%s) to (%s)", describeFields(older.Fields), describeFields(newer.Fields))
+	} else {
+		for i := range older.Fields {
+			if older.Fields[i].Description != newer.Fields[i].Description {
+				d.add(ImpactDoc, kind, newer.Name, "field %s description changes", newer.Fields[i].Name)
+			}
+		}
+	}
+	diffDocs(d, kind, newer.Name,
+		&itemDocs{older.Description, older.Deprecated, older.Since, older.RemovedIn},
+		&itemDocs{newer.Description, newer.Deprecated, newer.Since, newer.RemovedIn})
+}
+
+func describeFields(fields []Field) string {
+	parts := make([]string, len(fields))
+	for i := range fields {
+		parts[i] = describeProperty(&fields[i]) + " " + fields[i].Name
+	}
+	return strings.Join(parts, ", ")
+}
+
+// diffConstant treats a new value as breaking: languages that compile a
+// constant into its users keep the old one until they are rebuilt.
+func diffConstant(d *differ, kind string, older, newer *Constant) {
+	if older.Type != newer.Type {
+		d.add(ImpactBreaking, kind, newer.Name, "type changes from %s to %s", older.Type, newer.Type)
+	} else if !reflect.DeepEqual(older.Literal, newer.Literal) {
+		d.add(ImpactBreaking, kind, newer.Name, "value changes from %v to %v", older.Value, newer.Value)
+	}
+	diffDocs(d, kind, newer.Name,
+		&itemDocs{older.Description, older.Deprecated, older.Since, older.RemovedIn},
+		&itemDocs{newer.Description, newer.Deprecated, newer.Since, newer.RemovedIn})
+}
+
+func diffEvent(d *differ, kind string, older, newer *Event) {
+	if older.Prototype != newer.Prototype {
+		d.add(ImpactBreaking, kind, newer.Name, "listeners are %s instead of %s", newer.Prototype, older.Prototype)
+	}
+	if older.Subscribe != newer.Subscribe || older.Unsubscribe != newer.Unsubscribe {
+		d.add(ImpactBreaking, kind, newer.Name, "subscribes with %s and %s instead of %s and %s",
+			newer.Subscribe, newer.Unsubscribe, older.Subscribe, older.Unsubscribe)
+	}
+	diffDocs(d, kind, newer.Name,
+		&itemDocs{older.Description, older.Deprecated, older.Since, older.RemovedIn},
+		&itemDocs{newer.Description, newer.Deprecated, newer.Since, newer.RemovedIn})
+}
+
+func diffClass(d *differ, kind string, older, newer *Class) {
+	if older.HandleType != newer.HandleType {
+		d.add(ImpactBreaking, kind, newer.Name, "handle type changes from %q to %q", older.HandleType, newer.HandleType)
+	}
+	if older.InvalidValue != newer.InvalidValue || older.NullPolicy != newer.NullPolicy {
+		d.add(ImpactBreaking, kind, newer.Name, "handle checks change")
+	}
+	for _, ctor := range older.Constructors {
+		if !slices.Contains(newer.Constructors, ctor) {
+			d.add(ImpactBreaking, kind, newer.Name, "constructor %s is removed", ctor)
+		}
+	}
+	for _, ctor := range newer.Constructors {
+		if !slices.Contains(older.Constructors, ctor) {
+			d.add(ImpactCompatible, kind, newer.Name, "constructor %s is added", ctor)
+		}
+	}
+	// With a destructor the class owns its handle; without one it only
+	// borrows it. Either way round, who frees the handle changes.
+	if (older.Destructor == nil) != (newer.Destructor == nil) || (older.Destructor != nil && *older.Destructor != *newer.Destructor) {
+		d.add(ImpactBreaking, kind, newer.Name, "destructor changes")
+	}
+	diffPlatforms(d, kind, newer.Name, older.Platforms, newer.Platforms)
+	if older.Group != newer.Group {
+		d.add(ImpactCompatible, kind, newer.Name, "moves from group %q to %q", older.Group, newer.Group)
+	}
+	diffDocs(d, kind, newer.Name,
+		&itemDocs{older.Description, older.Deprecated, older.Since, older.RemovedIn},
+		&itemDocs{newer.Description, newer.Deprecated, newer.Since, newer.RemovedIn})
+
+	// Bindings are reported as members of their class.
+	member := func(b *Binding) string { return newer.Name + "." + b.Name }
+	diffItems(d, kind, pointers(older.Bindings), pointers(newer.Bindings), member, func(d *differ, kind string, o, n *Binding) {
+		diffBinding(d, kind, member(n), o, n)
+	})
+}
+
+func diffBinding(d *differ, kind, name string, older, newer *Binding) {
+	if older.Method != newer.Method {
+		d.add(ImpactBreaking, kind, name, "calls %s instead of %s", newer.Method, older.Method)
+	}
+	if older.BindSelf != newer.BindSelf {
+		d.add(ImpactBreaking, kind, name, "bindSelf changes from %t to %t", older.BindSelf, newer.BindSelf)
+	}
+	if !sameBinds(older.ParamAliases, newer.ParamAliases) || !sameBinds([]*Bind{older.RetAlias}, []*Bind{newer.RetAlias}) {
+		d.add(ImpactBreaking, kind, name, "parameter or return aliases change")
+	}
+	diffPlatforms(d, kind, name, older.Platforms, newer.Platforms)
+	diffDocs(d, kind, name,
+		&itemDocs{"", older.Deprecated, older.Since, older.RemovedIn},
+		&itemDocs{"", newer.Deprecated, newer.Since, newer.RemovedIn})
+}
+
+func sameBinds(a, b []*Bind) bool {
+	return slices.EqualFunc(a, b, func(x, y *Bind) bool {
+		if x == nil || y == nil {
+			return x == y
+		}
+		return x.Name == y.Name && x.Owner == y.Owner
+	})
+}
+
+// ownPrototypes, ownEnums and ownStructs leave out the definitions Parse
+// brought in from dependencies.
+func ownPrototypes(m *Manifest) []*Prototype {
+	return slices.DeleteFunc(slices.Clone(m.Prototypes), func(p *Prototype) bool { return p.Plugin != "" })
+}
+
+func ownEnums(m *Manifest) []*Enum {
+	return slices.DeleteFunc(slices.Clone(m.Enums), func(e *Enum) bool { return e.Plugin != "" })
+}
+
+func ownStructs(m *Manifest) []*Struct {
+	return slices.DeleteFunc(slices.Clone(m.Structs), func(s *Struct) bool { return s.Plugin != "" })
+}
+
+// aliasesOf collects the aliases the manifest's parameters, return values and
+// fields use, each once, in the order they first appear.
+func aliasesOf(m *Manifest) []*Alias {
+	var aliases []*Alias
+	seen := map[string]bool{}
+	visit := func(props ...*Property) {
+		for _, prop := range props {
+			if prop.Alias != nil && !seen[prop.Alias.Name] {
+				seen[prop.Alias.Name] = true
+				aliases = append(aliases, prop.Alias)
+			}
+		}
+	}
+	signature := func(params []ParamType, ret *RetType) {
+		for i := range params {
+			visit(&params[i])
+		}
+		visit(ret)
+	}
+	for i := range m.Methods {
+		signature(m.Methods[i].ParamTypes, &m.Methods[i].RetType)
+	}
+	for _, prototype := range ownPrototypes(m) {
+		signature(prototype.ParamTypes, &prototype.RetType)
+	}
+	for _, structure := range ownStructs(m) {
+		for i := range structure.Fields {
+			visit(&structure.Fields[i])
+		}
+	}
+	return aliases
+}
@@ -0,0 +1,132 @@
+package manifest
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// checkDiff diffs older against newer and checks the changes found, each
+// written the way Change.String writes it.
+func checkDiff(t *testing.T, older, newer string, want ...string) Changes {
+	t.Helper()
+	changes := Diff(mustParse(t, older, nil), mustParse(t, newer, nil))
+	found := make([]string, len(changes))
+	for i := range changes {
+		found[i] = changes[i].String()
+	}
+	if !slices.Equal(found, want) {
+		t.Errorf("changes are\n\t%s\nwant\n\t%s", strings.Join(found, "\n\t"), strings.Join(want, "\n\t"))
+	}
+	return changes
+}
+
+// kick is a manifest with the one method Kick, taking params and returning
+// ret, with rest added to its keys.
+func kick(params, ret, rest string) string {
+	return testManifest(`"methods": [` + testMethod("Kick", params, ret, rest) + `]`)
+}
+
+const player = `{"name": "player", "type": "int32"}`
+
+func TestDiffMethods(t *testing.T) {
+	none := testManifest(`"methods": []`)
+	checkDiff(t, kick(player, "void", ""), kick(player, "void", ""))
+	checkDiff(t, kick(player, "void", ""), none, "breaking: method Kick: removed")
+	checkDiff(t, none, kick(player, "void", ""), "compatible: method Kick: added")
+	checkDiff(t, kick(player, "void", ""), kick(player, "bool", ""), "breaking: method Kick: returns bool instead of void")
+	checkDiff(t, kick(player, "void", ""),
+		testManifest(`"methods": [{"name": "Kick", "funcName": "KickPlayer", "paramTypes": [`+player+`], "retType": {"type": "void"}}]`),
+		"breaking: method Kick: exported as KickPlayer instead of Kick")
+	checkDiff(t, kick(player, "void", ""), kick(player, "void", `, "platforms": ["windows"]`), "breaking: method Kick: platforms narrow from all to windows")
+	checkDiff(t, kick(player, "void", `, "platforms": ["windows"]`), kick(player, "void", ""), "compatible: method Kick: platforms widen from windows to all")
+	checkDiff(t, kick(player, "void", ""), kick(player, "void", `, "deprecated": "use Ban"`), "compatible: method Kick: deprecated: use Ban")
+	checkDiff(t, kick(player, "void", `, "description": "Kicks a player"`), kick(player, "void", `, "description": "Kicks a player off the server"`), "doc-only: method Kick: description changes")
+	checkDiff(t, kick(player, "void", ""), kick(player, "void", `, "since": "1.0"`), "doc-only: method Kick: versions change from every version to since 1.0")
+}
+
+func TestDiffParameters(t *testing.T) {
+	checkDiff(t, kick(player, "void", ""), kick(`{"name": "player", "type": "int64"}`, "void", ""), "breaking: method Kick: param[0] (player) changes from int32 to int64")
+	checkDiff(t, kick(player, "void", ""), kick(player+`, {"name": "reason", "type": "string"}`, "void", ""), "breaking: method Kick: takes 2 parameters instead of 1")
+	checkDiff(t, kick(player, "void", ""), kick(`{"name": "target", "type": "int32"}`, "void", ""), "compatible: method Kick: param[0] is renamed from player to target")
+
+	defaulted := `{"name": "player", "type": "int32", "default": 0}`
+	checkDiff(t, kick(player, "void", ""), kick(defaulted, "void", ""), "compatible: method Kick: parameter player gains a default")
+	checkDiff(t, kick(defaulted, "void", ""), kick(player, "void", ""), "breaking: method Kick: parameter player loses its default")
+	checkDiff(t, kick(player, "void", ""), kick(`{"name": "player", "type": "int32", "description": "who"}`, "void", ""), "doc-only: method Kick: parameter player description changes")
+}
+
+func TestDiffEnums(t *testing.T) {
+	color := func(values string) string {
+		return testManifest(`"methods": [], "enums": [{"name": "Color", "values": [` + values + `]}]`)
+	}
+	red, blue := `{"name": "Red", "value": 0}`, `{"name": "Blue", "value": 1}`
+	checkDiff(t, color(red), color(red+", "+blue), "compatible: enum Color: value Blue is added")
+	checkDiff(t, color(red+", "+blue), color(red), "breaking: enum Color: value Blue is removed")
+	checkDiff(t, color(red), color(`{"name": "Red", "value": 1}`), "breaking: enum Color: value Red is renumbered from 0 to 1")
+}
+
+func TestDiffManifest(t *testing.T) {
+	checkDiff(t, testManifest(`"methods": []`),
+		`{"name": "other", "version": "1.0", "language": "cpp", "entry": "demo", "methods": []}`,
+		`breaking: manifest other: renamed from "demo", which renames everything generated for it`)
+
+	// The manifest's own changes come first, then the older manifest's items
+	// in its order, then what the newer one added
+	checkDiff(t, kick(player, "void", ""),
+		testManifest(`"description": "reworded", "methods": [], "constants": [{"name": "Max", "type": "int32", "value": 1}]`),
+		"doc-only: manifest demo: description changes",
+		"breaking: method Kick: removed",
+		"compatible: constant Max: added")
+}
+
+func TestChangesBump(t *testing.T) {
+	if bump := Changes(nil).Bump(); bump != "" {
+		t.Errorf("no changes bump %q", bump)
+	}
+	mixed := Changes{{Impact: ImpactDoc}, {Impact: ImpactCompatible}}
+	if bump := mixed.Bump(); bump != "minor" {
+		t.Errorf("compatible and doc-only changes bump %q, want minor", bump)
+	}
+	if bump := append(mixed, Change{Impact: ImpactBreaking}).Bump(); bump != "major" {
+		t.Errorf("a breaking change among others bumps %q, want major", bump)
+	}
+	if bump := mixed[:1].Bump(); bump != "patch" {
+		t.Errorf("a doc-only change bumps %q, want patch", bump)
+	}
+}
+
+func TestChangesNextVersion(t *testing.T) {
+	breaking := Changes{{Impact: ImpactBreaking}}
+	compatible := Changes{{Impact: ImpactCompatible}}
+	doc := Changes{{Impact: ImpactDoc}}
+
+	tests := []struct {
+		changes Changes
+		version string
+		want    string
+	}{
+		{breaking, "1.4.2", "2.0.0"},
+		{compatible, "1.4.2", "1.5.0"},
+		{doc, "1.4.2", "1.4.3"},
+		{nil, "1.4.2", "1.4.2"},
+		{compatible, "1.4", "1.5.0"},
+		// Before 1.0 a breaking change bumps the minor number
+		{breaking, "0.3.1", "0.4.0"},
+		{compatible, "0.3.1", "0.3.2"},
+	}
+
+	for _, tt := range tests {
+		next, err := tt.changes.NextVersion(tt.version)
+		if err != nil {
+			t.Fatalf("NextVersion(%q): %v", tt.version, err)
+		}
+		if next.String() != tt.want {
+			t.Errorf("%s changes: NextVersion(%q) = %s, want %s", tt.changes.Bump(), tt.version, next, tt.want)
+		}
+	}
+
+	if _, err := breaking.NextVersion("one"); err == nil {
+		t.Error("NextVersion accepts a version that is not one")
+	}
+}
@@ -0,0 +1,78 @@
+package manifest
+
+import "fmt"
+
+// valuePointer is the JSON pointer of the enum's i'th value.
+func (e *Enum) valuePointer(i int) string {
+	return fmt.Sprintf("%s/values/%d", e.origin.member("enum"), i)
+}
+
+// checkEnumValues checks an enum's values among themselves. Every target
+// language rejects two values of one name. Two values of one number are only
+// warned about: "First" and "Last" aliases are common and deliberate, but a
+// repeated number is just as often a copy-paste slip. It returns non-nil only
+// when p wants checking to stop.
+func checkEnumValues(enum *Enum, p *problems) error {
+	names := make(map[string]int, len(enum.Values))
+	numbers := make(map[Integer]int, len(enum.Values))
+	for i, value := range enum.Values {
+		if value.Name == "" {
+			if err := p.report(errorAt(enum.valuePointer(i), "enum %q: values[%d] has no name", enum.Name, i)); err != nil {
+				return err
+			}
+		} else if first, seen := names[value.Name]; seen {
+			if err := p.report(errorAt(enum.valuePointer(i)+"/name",
+				"enum %q: value name %q is already used by values[%d]", enum.Name, value.Name, first)); err != nil {
+				return err
+			}
+		} else {
+			names[value.Name] = i
+		}
+
+		if first, seen := numbers[value.Value]; seen {
+			p.report(warningAt(enum.valuePointer(i)+"/value",
+				"enum %q: value %q is %d, the same as %q", enum.Name, value.Name, value.Value, enum.Values[first].Name))
+		} else {
+			numbers[value.Value] = i
+		}
+	}
+	return nil
+}
+
+// enumUse is an enum attached to an integer type. An enum shared by many
+// properties of one type only needs checking against it once.
+type enumUse struct {
+	enum     *Enum
+	typeName string
+}
+
+// checkEnumRange checks that every value of enum fits typeName, the integer type
+// of the property found at context. A value that does not fit turns into
+// target code that fails to compile, or worse, silently wraps.
+func checkEnumRange(enum *Enum, typeName string, context where, p *problems) error {
+	bounds := integerRanges[typeName]
+	for i, value := range enum.Values {
+		fits := false
+		if bounds.unsigned {
+			u, ok := value.Value.Uint64()
+			fits = ok && u <= bounds.maxUint
+		} else {
+			n, ok := value.Value.Int64()
+			fits = ok && n >= bounds.min && n <= bounds.max
+		}
+		if fits {
+			continue
+		}
+		// A dependency's enum is defined in another file, so the problem is
+		// reported where this manifest attaches it instead.
+		at := enum.valuePointer(i) + "/value"
+		if enum.Plugin != "" {
+			at = context.member("enum")
+		}
+		if err := p.report(errorAt(at,
+			"enum %q: value %q (%d) is out of range for %s, the type of %s", enum.Name, value.Name, value.Value, typeName, context)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
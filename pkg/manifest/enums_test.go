@@ -0,0 +1,85 @@
+package manifest
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"testing"
+)
+
+// enumOn is a manifest defining the enum Flags with values, attached to a
+// parameter of each of types.
+func enumOn(values string, types ...string) string {
+	params := ""
+	for i, typeName := range types {
+		if i > 0 {
+			params += ", "
+		}
+		params += fmt.Sprintf(`{"name": "p%d", "type": %q, "enum": "Flags"}`, i, typeName)
+	}
+	return testManifest(`"enums": [{"name": "Flags", "values": [` + values + `]}], "methods": [` + testMethod("A", params, "void", "") + `]`)
+}
+
+func TestEnumRange(t *testing.T) {
+	all := &ParseOptions{AllErrors: true}
+
+	mustParse(t, enumOn(`{"name": "Low", "value": -128}, {"name": "High", "value": 127}`, "int8", "int64"), nil)
+	mustParse(t, enumOn(`{"name": "Top", "value": 18446744073709551615}`, "uint64"), nil)
+
+	found := parseProblems(t, enumOn(`{"name": "Low", "value": 0}, {"name": "High", "value": 256}`, "uint8"), all,
+		"/enums/0/values/1/value")
+	wantContains(t, "diagnostics", found.Error(), `enum "Flags": value "High" (256) is out of range for uint8, the type of method "A" param[0]`)
+
+	found = parseProblems(t, enumOn(`{"name": "None", "value": -1}`, "uint32"), all, "/enums/0/values/0/value")
+	wantContains(t, "diagnostics", found.Error(), `value "None" (-1) is out of range for uint32`)
+
+	// Each type the enum is attached to is checked, once
+	found = parseProblems(t, enumOn(`{"name": "Big", "value": 40000}`, "int32", "int16", "int16", "uint16"), all,
+		"/enums/0/values/0/value")
+	wantContains(t, "diagnostics", found.Error(), "out of range for int16")
+
+	found = parseProblems(t, enumOn(`{"name": "Top", "value": 9223372036854775808}`, "int64"), all, "/enums/0/values/0/value")
+	wantContains(t, "diagnostics", found.Error(), `(9223372036854775808) is out of range for int64`)
+}
+
+func TestEnumDuplicates(t *testing.T) {
+	found := parseProblems(t, enumOn(`{"name": "Red", "value": 0}, {"name": "Red", "value": 1}, {"value": 2}`, "int32"), &ParseOptions{AllErrors: true},
+		"/enums/0/values/1/name", "/enums/0/values/2")
+	wantContains(t, "diagnostics", found.Error(), `enum "Flags": value name "Red" is already used by values[0]`, `enum "Flags": values[2] has no name`)
+
+	// A repeated number is often a deliberate alias, so it is only a warning
+	m := mustParse(t, enumOn(`{"name": "First", "value": 0}, {"name": "Red", "value": 0}`, "int32"), nil)
+	if len(m.Warnings) != 1 || m.Warnings[0].Pointer != "/enums/0/values/1/value" {
+		t.Fatalf("warnings are %v, want one at /enums/0/values/1/value", m.Warnings)
+	}
+	wantContains(t, "warning", m.Warnings.Error(), `enum "Flags": value "Red" is 0, the same as "First"`)
+}
+
+func TestInteger(t *testing.T) {
+	for _, text := range []string{"0", "-1", "9223372036854775807", "-9223372036854775808", "18446744073709551615"} {
+		var i Integer
+		if err := json.Unmarshal([]byte(text), &i); err != nil {
+			t.Errorf("%s does not decode: %v", text, err)
+			continue
+		}
+		if i.String() != text || fmt.Sprintf("%d", i) != text {
+			t.Errorf("%s decodes to %s, printing as %d", text, i, i)
+		}
+	}
+	for _, text := range []string{"0.5", "1e3", "18446744073709551616", "-9223372036854775809", `"1"`} {
+		var i Integer
+		if err := json.Unmarshal([]byte(text), &i); err == nil {
+			t.Errorf("%s decodes to %s", text, i)
+		}
+	}
+
+	if n, ok := NewUint(math.MaxUint64).Int64(); ok {
+		t.Errorf("math.MaxUint64 fits an int64 as %d", n)
+	}
+	if _, ok := NewInt(-1).Uint64(); ok {
+		t.Error("-1 fits a uint64")
+	}
+	if fmt.Sprintf("%#x", NewInt(-1)) != "-0x1" {
+		t.Errorf("-1 prints with %%#x as %#x", NewInt(-1))
+	}
+}
@@ -0,0 +1,116 @@
+package manifest
+
+import "fmt"
+
+// findMethod returns the method called name, by either of its names as
+// FindMethod in the generators accepts, or nil.
+func (m *Manifest) findMethod(name string) *Method {
+	for i := range m.Methods {
+		if m.Methods[i].Name == name || m.Methods[i].FuncName == name {
+			return &m.Methods[i]
+		}
+	}
+	return nil
+}
+
+// prototypeName is how a manifest refers to prototype: by its own name, or
+// qualified with the plugin that defines it.
+func prototypeName(prototype *Prototype) string {
+	if prototype.Plugin != "" {
+		return prototype.Plugin + "." + prototype.Name
+	}
+	return prototype.Name
+}
+
+// checkEvents checks that every event has a name of its own and that its
+// subscribe and unsubscribe methods exist, each taking a listener of the
+// event's prototype and nothing else, filling in Listener. Generated helpers
+// unsubscribe a listener wherever they subscribed it, so the two methods must
+// exist on the same platforms. It returns non-nil only when p wants checking
+// to stop.
+func checkEvents(m *Manifest, p *problems) error {
+	// Generators declare an event's helpers beside the methods and classes.
+	taken := make(map[string]string, len(m.Methods)+len(m.Classes))
+	for _, method := range m.Methods {
+		taken[method.Name] = "method"
+	}
+	for _, class := range m.Classes {
+		taken[class.Name] = "class"
+	}
+
+	names := make(map[string]int, len(m.Events))
+	for i := range m.Events {
+		event := &m.Events[i]
+		at := fmt.Sprintf("/events/%d", i)
+		context := scope("event", i, event.Name)
+		if event.Name == "" {
+			if err := p.report(errorAt(at+"/name", "%s: name is required", context)); err != nil {
+				return err
+			}
+		} else if first, seen := names[event.Name]; seen {
+			if err := p.report(errorAt(at+"/name", "%s: name %q is already used by event[%d]", context, event.Name, first)); err != nil {
+				return err
+			}
+		} else if kind, clash := taken[event.Name]; clash {
+			if err := p.report(errorAt(at+"/name", "%s: name %q is already used by a %s", context, event.Name, kind)); err != nil {
+				return err
+			}
+		} else {
+			names[event.Name] = i
+		}
+		if event.Prototype == "" {
+			if err := p.report(errorAt(at+"/prototype", "%s: prototype is required", context)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// listenerOf returns the listener the method called name takes, or
+		// nil after reporting why it cannot serve the event.
+		listenerOf := func(name, role string) (*Method, *Prototype, error) {
+			if name == "" {
+				return nil, nil, p.report(errorAt(at+"/"+role, "%s: %s is required", context, role))
+			}
+			method := m.findMethod(name)
+			if method == nil {
+				return nil, nil, p.report(errorAt(at+"/"+role, "%s: unknown %s method %q", context, role, name))
+			}
+			if len(method.ParamTypes) != 1 || method.RetType.Type != "void" {
+				return nil, nil, p.report(errorAt(at+"/"+role,
+					"%s: %s method %q must take a listener as its only parameter and return void", context, role, name))
+			}
+			param := &method.ParamTypes[0]
+			if param.Type != "function" || param.Ref || param.Prototype == nil || prototypeName(param.Prototype) != event.Prototype {
+				return nil, nil, p.report(errorAt(at+"/"+role,
+					"%s: %s method %q does not take a listener of prototype %q", context, role, name, event.Prototype))
+			}
+			return method, param.Prototype, nil
+		}
+		subscribe, listener, err := listenerOf(event.Subscribe, "subscribe")
+		if err != nil {
+			return err
+		}
+		unsubscribe, _, err := listenerOf(event.Unsubscribe, "unsubscribe")
+		if err != nil {
+			return err
+		}
+		if subscribe == nil || unsubscribe == nil {
+			continue
+		}
+
+		switch {
+		case subscribe == unsubscribe:
+			if err := p.report(errorAt(at+"/unsubscribe", "%s: subscribes and unsubscribes with the same method %q", context, event.Subscribe)); err != nil {
+				return err
+			}
+		case !withinPlatforms(subscribe.Platforms, unsubscribe.Platforms) || !withinPlatforms(unsubscribe.Platforms, subscribe.Platforms):
+			if err := p.report(errorAt(at+"/unsubscribe",
+				"%s: methods %q and %q do not exist on the same platforms", context, event.Subscribe, event.Unsubscribe)); err != nil {
+				return err
+			}
+		default:
+			event.Listener = listener
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package manifest
+
+import "testing"
+
+// onTickListener is a parameter taking a listener of prototype OnTick.
+const onTickListener = `{"name": "listener", "type": "function", "prototype": "OnTick"}`
+
+// ticks is a manifest with the prototype OnTick, the methods given and the
+// events given.
+func ticks(methods, events string) string {
+	return testManifest(`"prototypes": [{"name": "OnTick", "funcName": "OnTick", "paramTypes": [], "retType": {"type": "void"}}],
+		"methods": [` + methods + `], "events": [` + events + `]`)
+}
+
+// tickMethods are the methods an event OnTick can use, Add and Remove limited
+// to removePlatforms.
+func tickMethods(removePlatforms string) string {
+	return testMethod("AddTick", onTickListener, "void", "") + `, ` +
+		testMethod("RemoveTick", onTickListener, "void", `, "platforms": `+removePlatforms)
+}
+
+func TestEvents(t *testing.T) {
+	m := mustParse(t, ticks(tickMethods("[]"), `{"name": "Tick", "prototype": "OnTick", "subscribe": "AddTick", "unsubscribe": "RemoveTick"}`), nil)
+	if listener := m.Events[0].Listener; listener == nil || listener.Name != "OnTick" {
+		t.Errorf("the event's listener is %+v", listener)
+	}
+}
+
+func TestEventChecks(t *testing.T) {
+	all := &ParseOptions{AllErrors: true}
+	methods := tickMethods("[]") + `,
+		` + testMethod("Count", `{"name": "n", "type": "int32"}`, "void", "") + `,
+		` + testMethod("AddOther", `{"name": "listener", "type": "function", "prototype": {"name": "OnOther", "funcName": "OnOther", "paramTypes": [], "retType": {"type": "void"}}}`, "void", "")
+
+	found := parseProblems(t, ticks(methods, `
+		{"name": "Tick", "prototype": "OnTick", "subscribe": "AddTick", "unsubscribe": "RemoveTick"},
+		{"name": "Tick", "prototype": "OnTick", "subscribe": "AddTik", "unsubscribe": "Count"},
+		{"name": "Count", "prototype": "OnTick", "subscribe": "AddOther", "unsubscribe": "AddTick"},
+		{"name": "Same", "prototype": "OnTick", "subscribe": "AddTick", "unsubscribe": "AddTick"},
+		{"name": "Bare", "subscribe": "AddTick", "unsubscribe": "RemoveTick"}`), all,
+		"/events/1/name", "/events/1/subscribe", "/events/1/unsubscribe",
+		"/events/2/name", "/events/2/subscribe",
+		"/events/3/unsubscribe",
+		"/events/4/prototype")
+	wantContains(t, "diagnostics", found.Error(),
+		`event "Tick": name "Tick" is already used by event[0]`,
+		`unknown subscribe method "AddTik"`,
+		`unsubscribe method "Count" does not take a listener of prototype "OnTick"`,
+		`event "Count": name "Count" is already used by a method`,
+		`subscribe method "AddOther" does not take a listener of prototype "OnTick"`,
+		`subscribes and unsubscribes with the same method "AddTick"`,
+		`event "Bare": prototype is required`)
+
+	found = parseProblems(t, ticks(tickMethods(`["windows"]`), `{"name": "Tick", "prototype": "OnTick", "subscribe": "AddTick", "unsubscribe": "RemoveTick"}`), all,
+		"/events/0/unsubscribe")
+	wantContains(t, "diagnostics", found.Error(), `methods "AddTick" and "RemoveTick" do not exist on the same platforms`)
+}
+
+func TestFilterPlatformEvents(t *testing.T) {
+	data := ticks(testMethod("AddTick", onTickListener, "void", `, "platforms": ["linux"]`)+`, `+testMethod("RemoveTick", onTickListener, "void", `, "platforms": ["linux"]`),
+		`{"name": "Tick", "prototype": "OnTick", "subscribe": "AddTick", "unsubscribe": "RemoveTick"}`)
+
+	m := mustParse(t, data, nil)
+	if err := m.FilterPlatform(PlatformWindows); err != nil {
+		t.Fatalf("FilterPlatform: %v", err)
+	}
+	if len(m.Events) != 0 {
+		t.Error("the event is kept on windows, where its methods are not")
+	}
+
+	m = mustParse(t, data, nil)
+	if err := m.FilterPlatform(PlatformLinux); err != nil {
+		t.Fatalf("FilterPlatform: %v", err)
+	}
+	if len(m.Events) != 1 {
+		t.Error("the event is dropped on linux, where its methods are")
+	}
+}
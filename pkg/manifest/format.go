@@ -0,0 +1,336 @@
+package manifest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// formatKept lists the keys Format writes even when they are empty, beyond
+// those schemaRequired lists: plugify itself expects every method and
+// prototype to spell out its parameters.
+var formatKept = map[reflect.Type][]string{
+	reflect.TypeOf(Method{}):    {"paramTypes"},
+	reflect.TypeOf(Prototype{}): {"paramTypes"},
+}
+
+// FormatFile returns the manifest at path in canonical form; see Format.
+func FormatFile(path string, opts *ParseOptions) ([]byte, error) {
+	opts = EnsureParseOptions(opts)
+	data, err := opts.readFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest file: %w", err)
+	}
+
+	return format(path, data, opts)
+}
+
+// Format returns manifest JSON data in canonical form, so that two manifests
+// saying the same thing read the same:
+//
+//   - keys come in the order of the fields of the types in this package,
+//     indented by two spaces;
+//   - keys holding their default, such as "ref": false or an empty
+//     "paramAliases", are left out;
+//   - an enum, prototype or struct defined inline in more than one place, or
+//     also listed in the manifest's table, moves to the table and is referred
+//     to by name wherever it was used;
+//   - the enums, prototypes and structs tables are sorted by name.
+//
+// Only a manifest Parse accepts is formatted, and strictly: a key Parse would
+// drop is an error rather than lost. Imported files are left as they are.
+func Format(data []byte, opts *ParseOptions) ([]byte, error) {
+	return format("", data, opts)
+}
+
+func format(file string, data []byte, opts *ParseOptions) ([]byte, error) {
+	strict := *EnsureParseOptions(opts)
+	strict.Strict = true
+	if _, err := parse(file, data, &strict); err != nil {
+		return nil, err
+	}
+
+	// Parse merged the imports and resolved every reference; formatting starts
+	// over from what this file says.
+	var m Manifest
+	if err := decode(data, &m); err != nil {
+		return nil, decodeError(err, file, data)
+	}
+	hoistDefinitions(&m)
+
+	var buf bytes.Buffer
+	writeCanonical(&buf, reflect.ValueOf(m), "")
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// definitionSlots visits every place in m where an enum, prototype or struct
+// is written, inline or by name, descending into the inline definitions. The
+// tables are walked last and by index, so that entries visit appends are
+// walked too.
+type definitionSlots struct {
+	enum      func(slot **Enum)
+	prototype func(slot **Prototype)
+	structure func(slot **Struct)
+}
+
+func (s *definitionSlots) walk(m *Manifest) {
+	for i := range m.Methods {
+		s.signature(m.Methods[i].ParamTypes, &m.Methods[i].RetType)
+	}
+	for i := 0; i < len(m.Prototypes); i++ {
+		s.signature(m.Prototypes[i].ParamTypes, &m.Prototypes[i].RetType)
+	}
+	for i := 0; i < len(m.Structs); i++ {
+		s.fields(m.Structs[i].Fields)
+	}
+}
+
+func (s *definitionSlots) signature(params []ParamType, ret *RetType) {
+	for i := range params {
+		s.property(&params[i])
+	}
+	s.property(ret)
+}
+
+func (s *definitionSlots) fields(fields []Field) {
+	for i := range fields {
+		s.property(&fields[i])
+	}
+}
+
+func (s *definitionSlots) property(prop *Property) {
+	if prop.Enum != nil {
+		s.enum(&prop.Enum)
+	}
+	if prop.Prototype != nil {
+		inline := !prop.Prototype.ref
+		definition := prop.Prototype
+		s.prototype(&prop.Prototype)
+		if inline {
+			s.signature(definition.ParamTypes, &definition.RetType)
+		}
+	}
+	if prop.Struct != nil {
+		inline := !prop.Struct.ref
+		definition := prop.Struct
+		s.structure(&prop.Struct)
+		if inline {
+			s.fields(definition.Fields)
+		}
+	}
+}
+
+// hoistDefinitions moves the inline definitions Format hoists into the
+// manifest's tables, leaving their names behind, then sorts the tables. It
+// returns the definitions it moved, as "enum Name" and the like, in the order
+// it came to them.
+func hoistDefinitions(m *Manifest) []string {
+	// Count where each name is written: in the table, inline and by name.
+	uses := map[string]int{}
+	for _, enum := range m.Enums {
+		uses["enum "+enum.Name]++
+	}
+	for _, prototype := range m.Prototypes {
+		uses["prototype "+prototype.Name]++
+	}
+	for _, structure := range m.Structs {
+		uses["struct "+structure.Name]++
+	}
+	counter := definitionSlots{
+		enum:      func(slot **Enum) { uses["enum "+(*slot).Name]++ },
+		prototype: func(slot **Prototype) { uses["prototype "+(*slot).Name]++ },
+		structure: func(slot **Struct) { uses["struct "+(*slot).Name]++ },
+	}
+	counter.walk(m)
+
+	// Swap every inline definition of a name written more than once for the
+	// name, adding the definition to the table the first time round.
+	var hoisted []string
+	tabled := map[string]bool{}
+	for _, enum := range m.Enums {
+		tabled["enum "+enum.Name] = true
+	}
+	for _, prototype := range m.Prototypes {
+		tabled["prototype "+prototype.Name] = true
+	}
+	for _, structure := range m.Structs {
+		tabled["struct "+structure.Name] = true
+	}
+	hoist := func(key string, ref bool, add func()) bool {
+		if ref || uses[key] < 2 {
+			return false
+		}
+		if !slices.Contains(hoisted, key) {
+			hoisted = append(hoisted, key)
+		}
+		if !tabled[key] {
+			tabled[key] = true
+			add()
+		}
+		return true
+	}
+	hoister := definitionSlots{
+		enum: func(slot **Enum) {
+			if enum := *slot; hoist("enum "+enum.Name, enum.ref, func() { m.Enums = append(m.Enums, enum) }) {
+				*slot = &Enum{Name: enum.Name, ref: true}
+			}
+		},
+		prototype: func(slot **Prototype) {
+			if prototype := *slot; hoist("prototype "+prototype.Name, prototype.ref, func() { m.Prototypes = append(m.Prototypes, prototype) }) {
+				*slot = &Prototype{Name: prototype.Name, ref: true}
+			}
+		},
+		structure: func(slot **Struct) {
+			if structure := *slot; hoist("struct "+structure.Name, structure.ref, func() { m.Structs = append(m.Structs, structure) }) {
+				*slot = &Struct{Name: structure.Name, ref: true}
+			}
+		},
+	}
+	hoister.walk(m)
+
+	sort.SliceStable(m.Enums, func(i, j int) bool { return m.Enums[i].Name < m.Enums[j].Name })
+	sort.SliceStable(m.Prototypes, func(i, j int) bool { return m.Prototypes[i].Name < m.Prototypes[j].Name })
+	sort.SliceStable(m.Structs, func(i, j int) bool { return m.Structs[i].Name < m.Structs[j].Name })
+	return hoisted
+}
+
+var (
+	jsonNumberType = reflect.TypeOf(json.Number(""))
+	marshalerType  = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// writeCanonical writes v as Format lays JSON out, the way json.MarshalIndent
+// would but without escaping HTML, without the keys holding their default,
+// and with an enum, prototype or struct the manifest wrote by name written by
+// name again.
+func writeCanonical(buf *bytes.Buffer, v reflect.Value, indent string) {
+	if v.Type() == jsonNumberType {
+		buf.WriteString(v.String())
+		return
+	}
+	if v.Type().Implements(marshalerType) {
+		data, _ := v.Interface().(json.Marshaler).MarshalJSON()
+		buf.Write(data)
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Interface, reflect.Pointer:
+		if v.IsNil() {
+			buf.WriteString("null")
+			return
+		}
+		switch definition := v.Interface().(type) {
+		case *Enum:
+			if definition.ref {
+				writeString(buf, definition.Name)
+				return
+			}
+		case *Prototype:
+			if definition.ref {
+				writeString(buf, definition.Name)
+				return
+			}
+		case *Struct:
+			if definition.ref {
+				writeString(buf, definition.Name)
+				return
+			}
+		}
+		writeCanonical(buf, v.Elem(), indent)
+	case reflect.Struct:
+		t := v.Type()
+		kept := append(slices.Clone(schemaRequired[t]), formatKept[t]...)
+		var keys []string
+		var values []reflect.Value
+		for i := 0; i < t.NumField(); i++ {
+			key, ok := jsonKey(t.Field(i))
+			if !ok || (v.Field(i).IsZero() || v.Field(i).Kind() == reflect.Slice && v.Field(i).Len() == 0) && !slices.Contains(kept, key) {
+				continue
+			}
+			keys = append(keys, key)
+			values = append(values, v.Field(i))
+		}
+		if len(keys) == 0 {
+			buf.WriteString("{}")
+			return
+		}
+		buf.WriteString("{\n")
+		for i, key := range keys {
+			buf.WriteString(indent + "  ")
+			writeString(buf, key)
+			buf.WriteString(": ")
+			writeCanonical(buf, values[i], indent+"  ")
+			if i < len(keys)-1 {
+				buf.WriteByte(',')
+			}
+			buf.WriteByte('\n')
+		}
+		buf.WriteString(indent + "}")
+	case reflect.Slice:
+		if v.Len() == 0 {
+			buf.WriteString("[]")
+			return
+		}
+		buf.WriteString("[\n")
+		for i := 0; i < v.Len(); i++ {
+			buf.WriteString(indent + "  ")
+			writeCanonical(buf, v.Index(i), indent+"  ")
+			if i < v.Len()-1 {
+				buf.WriteByte(',')
+			}
+			buf.WriteByte('\n')
+		}
+		buf.WriteString(indent + "]")
+	case reflect.Map:
+		// Only defaults and constant values hold maps, decoded as map[string]any.
+		keys := make([]string, 0, v.Len())
+		for _, key := range v.MapKeys() {
+			keys = append(keys, key.String())
+		}
+		if len(keys) == 0 {
+			buf.WriteString("{}")
+			return
+		}
+		sort.Strings(keys)
+		buf.WriteString("{\n")
+		for i, key := range keys {
+			buf.WriteString(indent + "  ")
+			writeString(buf, key)
+			buf.WriteString(": ")
+			writeCanonical(buf, v.MapIndex(reflect.ValueOf(key)), indent+"  ")
+			if i < len(keys)-1 {
+				buf.WriteByte(',')
+			}
+			buf.WriteByte('\n')
+		}
+		buf.WriteString(indent + "}")
+	case reflect.String:
+		writeString(buf, v.String())
+	case reflect.Bool:
+		buf.WriteString(strconv.FormatBool(v.Bool()))
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		buf.WriteString(strconv.FormatInt(v.Int(), 10))
+	case reflect.Uint32, reflect.Uint64:
+		buf.WriteString(strconv.FormatUint(v.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		buf.WriteString(strconv.FormatFloat(v.Float(), 'g', -1, 64))
+	default:
+		panic(fmt.Sprintf("manifest: cannot format %s", v.Type()))
+	}
+}
+
+// writeString writes s as a JSON string, leaving <, > and & as they are.
+func writeString(buf *bytes.Buffer, s string) {
+	var quoted bytes.Buffer
+	encoder := json.NewEncoder(&quoted)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(s)
+	buf.WriteString(strings.TrimSuffix(quoted.String(), "\n"))
+}
@@ -0,0 +1,91 @@
+package manifest
+
+import (
+	"strings"
+	"testing"
+)
+
+// checkFormat formats data, checks that the result holds each of want and
+// that formatting it again changes nothing, and returns it.
+func checkFormat(t *testing.T, data string, want ...string) string {
+	t.Helper()
+	formatted, err := Format([]byte(data), nil)
+	if err != nil {
+		t.Fatalf("Format: %v", err)
+	}
+	wantContains(t, "formatted manifest", string(formatted), want...)
+
+	again, err := Format(formatted, nil)
+	if err != nil {
+		t.Fatalf("Format of the formatted manifest: %v", err)
+	}
+	if string(again) != string(formatted) {
+		t.Errorf("formatting again gives:\n%s\nafter:\n%s", again, formatted)
+	}
+	return string(formatted)
+}
+
+func TestFormatKeyOrder(t *testing.T) {
+	formatted := checkFormat(t,
+		testManifest(`"methods": [{"retType": {"type": "void"}, "paramTypes": [{"type": "int32", "name": "x", "ref": false}], "funcName": "A", "name": "A"}]`),
+		"{\n      \"name\": \"A\",\n      \"funcName\": \"A\",\n      \"paramTypes\": [",
+		"{\n          \"name\": \"x\",\n          \"type\": \"int32\"\n        }")
+	if strings.Contains(formatted, `"ref"`) {
+		t.Errorf("a key holding its default is kept:\n%s", formatted)
+	}
+}
+
+func TestFormatMovesRepeatedDefinitions(t *testing.T) {
+	color := `{"name": "Color", "values": [{"name": "Red", "value": 0}]}`
+	checkFormat(t,
+		testManifest(`"methods": [`+testMethod("A", `{"name": "c", "type": "int32", "enum": `+color+`}`, "void", "")+`,
+			{"name": "B", "funcName": "B", "paramTypes": [], "retType": {"type": "int32", "enum": `+color+`}}]`),
+		`"enum": "Color"`,
+		"\"enums\": [\n    {\n      \"name\": \"Color\",")
+
+	onTick := `{"name": "cb", "type": "function", "prototype": {"name": "OnTick", "funcName": "OnTick", "paramTypes": [], "retType": {"type": "void"}}}`
+	checkFormat(t,
+		testManifest(`"methods": [`+testMethod("A", onTick, "void", "")+`, `+testMethod("B", onTick, "void", "")+`]`),
+		`"prototype": "OnTick"`, `"prototypes": [`)
+}
+
+func TestFormatKeepsSingleDefinitionsInline(t *testing.T) {
+	formatted := checkFormat(t,
+		testManifest(`"methods": [`+testMethod("A", `{"name": "c", "type": "int32", "enum": {"name": "Color", "values": [{"name": "Red", "value": 0}]}}`, "void", "")+`]`),
+		"\"enum\": {\n            \"name\": \"Color\",")
+	if strings.Contains(formatted, `"enums"`) {
+		t.Errorf("an enum used once moved to the table:\n%s", formatted)
+	}
+}
+
+func TestFormatSortsTables(t *testing.T) {
+	checkFormat(t,
+		testManifest(`"methods": [], "enums": [
+			{"name": "Zeta", "values": [{"name": "Z", "value": 0}]},
+			{"name": "Alpha", "values": [{"name": "A", "value": 0}]}]`),
+		"\"name\": \"Alpha\",\n      \"values\": [\n        {\n          \"name\": \"A\",\n          \"value\": 0\n        }\n      ]\n    },\n    {\n      \"name\": \"Zeta\"")
+}
+
+func TestFormatKeepsLiteralsExact(t *testing.T) {
+	checkFormat(t,
+		testManifest(`"methods": [`+testMethod("A", `{"name": "big", "type": "uint64", "default": 18446744073709551615}, {"name": "f", "type": "double", "default": 0.1}`, "void", "")+`],
+			"constants": [{"name": "Max", "type": "uint64", "value": 18446744073709551615}, {"name": "Pi", "type": "double", "value": 3.141592653589793238}]`),
+		`"default": 18446744073709551615`,
+		`"default": 0.1`,
+		`"value": 18446744073709551615`,
+		`"value": 3.141592653589793238`)
+
+	checkFormat(t, testManifest(`"description": "a <b> & c", "methods": []`), `"description": "a <b> & c"`)
+}
+
+func TestFormatRejects(t *testing.T) {
+	_, err := Format([]byte(testManifest(`"methods": [], "bogus": 1`)), nil)
+	wantError(t, err, "bogus")
+
+	_, err = Format([]byte(testManifest(`"methods": [`+testMethod("A", `{"name": "x", "type": "strng"}`, "void", "")+`]`)), nil)
+	wantError(t, err, "strng")
+
+	if _, err = Format([]byte(`{"name": `), nil); err == nil {
+		t.Error("malformed JSON is formatted")
+	}
+}
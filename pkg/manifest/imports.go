@@ -0,0 +1,241 @@
+package manifest
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// importFile is what a file named in "imports" may contain: the manifest's
+// tables of definitions, and further imports, but nothing that describes the
+// plugin itself.
+type importFile struct {
+	Schema     string       `json:"$schema,omitempty"`
+	Imports    []string     `json:"imports,omitempty"`
+	Methods    []Method     `json:"methods,omitempty"`
+	Classes    []Class      `json:"classes,omitempty"`
+	Prototypes []*Prototype `json:"prototypes,omitempty"`
+	Enums      []*Enum      `json:"enums,omitempty"`
+	Structs    []*Struct    `json:"structs,omitempty"`
+	Constants  []Constant   `json:"constants,omitempty"`
+	Events     []Event      `json:"events,omitempty"`
+}
+
+// source is one file that went into a manifest.
+type source struct {
+	file      string
+	data      []byte
+	positions positionIndex // built the first time something in the file is reported
+}
+
+// importedRange is where the entries one imported file added to a table of
+// the manifest sit, so that a pointer into the merged table can be turned back
+// into a pointer into that file.
+type importedRange struct {
+	start, end int // indices into the merged table
+	source     int // index into sourceMap.sources
+}
+
+// sourceMap remembers which file each entry of a merged manifest came from.
+// Everything is checked as one manifest, with pointers into the merged tables,
+// and only when a problem is reported is it traced back to its file.
+type sourceMap struct {
+	sources []*source                  // the manifest itself comes first
+	tables  map[string][]importedRange // by table key, e.g. "methods"
+	visited map[string]bool            // files already imported, by absolute path
+}
+
+func newSourceMap(file string, data []byte) *sourceMap {
+	s := &sourceMap{
+		sources: []*source{{file: file, data: data}},
+		tables:  map[string][]importedRange{},
+		visited: map[string]bool{},
+	}
+	if file != "" {
+		if abs, err := filepath.Abs(file); err == nil {
+			s.visited[abs] = true
+		}
+	}
+	return s
+}
+
+// loadImports merges every file m imports, and every file those import, into
+// m. Paths are relative to the file that names them, or, for a manifest
+// handed to Parse with its own ReadFile, to wherever that reads from. A file imported more than
+// once, through whichever route, is merged only the first time, so two files
+// may share a common import and import cycles are harmless. The merged entries
+// go through resolve like any others, so a definition repeated across files
+// must agree with itself. It returns non-nil only when p wants checking to stop.
+func (s *sourceMap) loadImports(m *Manifest, opts *ParseOptions, p *problems) error {
+	return s.importAll(m, m.Imports, 0, opts, p)
+}
+
+func (s *sourceMap) importAll(m *Manifest, imports []string, from int, opts *ParseOptions, p *problems) error {
+	importer := s.sources[from]
+	for i, name := range imports {
+		fail := func(format string, args ...any) error {
+			d := errorAt("/imports/"+strconv.Itoa(i), format, args...)
+			d.File = importer.file
+			return p.report(d)
+		}
+
+		if importer.file == "" && opts.ReadFile == nil {
+			// Parse has no file to resolve the path against.
+			if err := fail("import %q: imports can only be resolved when parsing a file", name); err != nil {
+				return err
+			}
+			continue
+		}
+		path := name
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(filepath.Dir(importer.file), path)
+		}
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			if err := fail("import %q: %v", name, err); err != nil {
+				return err
+			}
+			continue
+		}
+		if s.visited[abs] {
+			continue
+		}
+		s.visited[abs] = true
+
+		data, err := opts.readFile(path)
+		if err != nil {
+			if err := fail("import %q: %v", name, unwrapPathError(err)); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := s.merge(m, path, data, opts, p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// merge appends one imported file's definitions to m, then follows its imports.
+func (s *sourceMap) merge(m *Manifest, file string, data []byte, opts *ParseOptions, p *problems) error {
+	index := len(s.sources)
+	s.sources = append(s.sources, &source{file: file, data: data})
+
+	var imported importFile
+	if err := decode(data, &imported); err != nil {
+		for _, d := range decodeError(err, file, data) {
+			if err := p.report(d); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if opts.ValidateSchema || opts.Strict {
+		// The manifest schema describes the manifest itself, so an imported
+		// file only has its keys checked, against what an import may hold.
+		within := &problems{all: p.all}
+		checkFields(data, reflect.TypeOf(importFile{}), within)
+		for _, d := range within.found {
+			d.File = file
+			if err := p.report(d); err != nil {
+				return err
+			}
+		}
+	}
+
+	s.record("methods", len(m.Methods), len(imported.Methods), index)
+	m.Methods = append(m.Methods, imported.Methods...)
+	s.record("classes", len(m.Classes), len(imported.Classes), index)
+	m.Classes = append(m.Classes, imported.Classes...)
+	s.record("prototypes", len(m.Prototypes), len(imported.Prototypes), index)
+	m.Prototypes = append(m.Prototypes, imported.Prototypes...)
+	s.record("enums", len(m.Enums), len(imported.Enums), index)
+	m.Enums = append(m.Enums, imported.Enums...)
+	s.record("structs", len(m.Structs), len(imported.Structs), index)
+	m.Structs = append(m.Structs, imported.Structs...)
+	s.record("constants", len(m.Constants), len(imported.Constants), index)
+	m.Constants = append(m.Constants, imported.Constants...)
+	s.record("events", len(m.Events), len(imported.Events), index)
+	m.Events = append(m.Events, imported.Events...)
+
+	return s.importAll(m, imported.Imports, index, opts, p)
+}
+
+func (s *sourceMap) record(table string, start, n, source int) {
+	if n > 0 {
+		s.tables[table] = append(s.tables[table], importedRange{start: start, end: start + n, source: source})
+	}
+}
+
+// trace finds the file a pointer into the merged manifest belongs to, and the
+// pointer within that file.
+func (s *sourceMap) trace(pointer string) (*source, string) {
+	parts := strings.SplitN(pointer, "/", 4) // "", table, index, rest
+	if len(parts) >= 3 {
+		if n, err := strconv.Atoi(parts[2]); err == nil {
+			for _, r := range s.tables[parts[1]] {
+				if n >= r.start && n < r.end {
+					parts[2] = strconv.Itoa(n - r.start)
+					return s.sources[r.source], strings.Join(parts, "/")
+				}
+			}
+		}
+	}
+	return s.sources[0], pointer
+}
+
+func (s *sourceMap) byFile(file string) *source {
+	for _, src := range s.sources {
+		if src.file == file {
+			return src
+		}
+	}
+	return nil
+}
+
+// locate positions every diagnostic within the file it belongs to. Checking
+// knows where a problem is only by pointer, and the file, line and column are
+// worked out from that. A diagnostic already positioned is left as it is.
+func (s *sourceMap) locate(found Diagnostics) Diagnostics {
+	out := make(Diagnostics, len(found))
+	for i, d := range found {
+		located := *d
+		if located.Line == 0 {
+			var src *source
+			if located.File != "" {
+				src = s.byFile(located.File)
+			} else {
+				src, located.Pointer = s.trace(located.Pointer)
+			}
+			if src == nil {
+				// Some other manifest's, a dependency's say, which it has
+				// positioned as far as it could already.
+				out[i] = &located
+				continue
+			}
+			located.File = src.file
+			if src.positions == nil {
+				src.positions = indexPositions(src.data)
+			}
+			if at, ok := src.positions.find(located.Pointer); ok {
+				located.Line, located.Column = lineColumn(src.data, at.start)
+			}
+		}
+		out[i] = &located
+	}
+	return out
+}
+
+// unwrapPathError drops the operation and path from a file error, which the
+// diagnostic already names.
+func unwrapPathError(err error) error {
+	var pathErr *os.PathError
+	if errors.As(err, &pathErr) {
+		return pathErr.Err
+	}
+	return err
+}
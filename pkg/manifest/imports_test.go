@@ -0,0 +1,75 @@
+package manifest
+
+import (
+	"io/fs"
+	"slices"
+	"testing"
+)
+
+// readFrom is a ParseOptions.ReadFile serving files, by path.
+func readFrom(files map[string]string) func(string) ([]byte, error) {
+	return func(name string) ([]byte, error) {
+		if content, ok := files[name]; ok {
+			return []byte(content), nil
+		}
+		return nil, fs.ErrNotExist
+	}
+}
+
+const colorRed = `{"name": "Color", "values": [{"name": "Red", "value": 0}]}`
+
+func TestImportCycles(t *testing.T) {
+	// Each file is merged once however often it is reached
+	files := map[string]string{
+		"a.json":        `{"imports": ["b.json", "nested/c.json"], "enums": [` + colorRed + `]}`,
+		"b.json":        `{"imports": ["a.json"], "methods": [` + testMethod("B", "", "void", "") + `]}`,
+		"nested/c.json": `{"imports": ["../a.json", "d.json"], "methods": [` + testMethod("C", "", "void", "") + `]}`,
+		"nested/d.json": `{"methods": [` + testMethod("D", `{"name": "c", "type": "int32", "enum": "Color"}`, "void", "") + `]}`,
+		// Paths are relative to the importing file, so this is never reached
+		"nested/a.json": `{"methods": [` + testMethod("E", "", "void", "") + `]}`,
+	}
+	m := mustParse(t, testManifest(`"imports": ["a.json", "b.json"], "methods": [`+testMethod("A", "", "void", "")+`]`),
+		&ParseOptions{ReadFile: readFrom(files)})
+
+	var names []string
+	for _, method := range m.Methods {
+		names = append(names, method.Name)
+	}
+	if !slices.Equal(names, []string{"A", "B", "C", "D"}) {
+		t.Errorf("merged methods are %v, want [A B C D]", names)
+	}
+	if len(m.Enums) != 1 || m.Methods[3].ParamTypes[0].Enum != m.Enums[0] {
+		t.Errorf("the imported enum is not shared: %v", m.Enums)
+	}
+}
+
+func TestImportConflicts(t *testing.T) {
+	files := map[string]string{
+		"same.json":  `{"enums": [` + colorRed + `]}`,
+		"other.json": `{"enums": [{"name": "Color", "values": [{"name": "Red", "value": 1}]}], "methods": [` + testMethod("A", "", "void", "") + `]}`,
+	}
+	opts := &ParseOptions{AllErrors: true, ReadFile: readFrom(files)}
+
+	// The same definition twice is one definition
+	mustParse(t, testManifest(`"imports": ["same.json"], "enums": [`+colorRed+`], "methods": []`), opts)
+
+	found := parseProblems(t, testManifest(`"imports": ["other.json"], "enums": [`+colorRed+`], "methods": [`+testMethod("A", "", "int32", "")+`]`), opts,
+		"/enums/0", "/methods/0/name")
+	for _, d := range found {
+		if d.File != "other.json" {
+			t.Errorf("%v is not reported in other.json", d)
+		}
+	}
+	wantContains(t, "diagnostics", found.Error(),
+		`conflicting definitions for enum "Color"`,
+		`method "A": name "A" is already used by method[0]`)
+}
+
+func TestImportFailures(t *testing.T) {
+	found := parseProblems(t, testManifest(`"imports": ["missing.json"], "methods": []`), &ParseOptions{ReadFile: readFrom(nil)}, "/imports/0")
+	wantContains(t, "diagnostics", found.Error(), `import "missing.json"`)
+
+	// Without a file or ReadFile there is nothing to resolve a path against
+	found = parseProblems(t, testManifest(`"imports": ["a.json"], "methods": []`), nil, "/imports/0")
+	wantContains(t, "diagnostics", found.Error(), `import "a.json": imports can only be resolved when parsing a file`)
+}
@@ -0,0 +1,81 @@
+package manifest
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Integer is the number of an enum value. An enum may be backed by any integer
+// type from int8 to uint64, and neither int64 nor uint64 holds every value of
+// the other, so Integer keeps whichever one the manifest wrote. Flag values
+// above 1<<63 in a uint64 enum come through unchanged.
+//
+// It formats with the fmt integer verbs as the number itself, so generators
+// can print it with %d like any other integer.
+type Integer struct {
+	bits     uint64 // the value, in two's complement when negative
+	negative bool
+}
+
+// NewInt returns the Integer for i.
+func NewInt(i int64) Integer {
+	return Integer{bits: uint64(i), negative: i < 0}
+}
+
+// NewUint returns the Integer for u.
+func NewUint(u uint64) Integer {
+	return Integer{bits: u}
+}
+
+// Int64 returns the value as an int64, and whether it fits one.
+func (i Integer) Int64() (int64, bool) {
+	return int64(i.bits), i.negative || i.bits <= math.MaxInt64
+}
+
+// Uint64 returns the value as a uint64, and whether it fits one.
+func (i Integer) Uint64() (uint64, bool) {
+	return i.bits, !i.negative
+}
+
+// IsNegative reports whether the value is below zero.
+func (i Integer) IsNegative() bool {
+	return i.negative
+}
+
+func (i Integer) String() string {
+	if i.negative {
+		return strconv.FormatInt(int64(i.bits), 10)
+	}
+	return strconv.FormatUint(i.bits, 10)
+}
+
+// Format hands the verb on to the int64 or uint64 the value is.
+func (i Integer) Format(f fmt.State, verb rune) {
+	if i.negative {
+		fmt.Fprintf(f, fmt.FormatString(f, verb), int64(i.bits))
+		return
+	}
+	fmt.Fprintf(f, fmt.FormatString(f, verb), i.bits)
+}
+
+func (i Integer) MarshalJSON() ([]byte, error) {
+	return []byte(i.String()), nil
+}
+
+// UnmarshalJSON accepts any integer from math.MinInt64 to math.MaxUint64.
+func (i *Integer) UnmarshalJSON(data []byte) error {
+	text := string(data)
+	if text == "null" {
+		return nil // as for the built-in types, null leaves the value alone
+	}
+	if signed, err := strconv.ParseInt(text, 10, 64); err == nil {
+		*i = NewInt(signed)
+		return nil
+	}
+	if unsigned, err := strconv.ParseUint(text, 10, 64); err == nil {
+		*i = NewUint(unsigned)
+		return nil
+	}
+	return fmt.Errorf("enum value %s is not an integer between %d and %d", text, int64(math.MinInt64), uint64(math.MaxUint64))
+}
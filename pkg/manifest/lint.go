@@ -0,0 +1,423 @@
+package manifest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// LintOff, given as a rule's severity in a LintConfig, turns the rule off.
+const LintOff Severity = "off"
+
+// LintRule is a check of style or quality rather than validity: a manifest
+// that breaks one still generates.
+type LintRule struct {
+	ID          string
+	Severity    Severity // what the rule reports as, unless a LintConfig says otherwise
+	Description string
+
+	check func(m *Manifest, report lintReport)
+}
+
+// lintReport records a finding at a pointer into the manifest, unless a
+// "nolint" there or around it suppresses the rule.
+type lintReport func(pointer, format string, args ...any)
+
+var lintRules = []LintRule{
+	{"missing-description", SeverityWarning, "a method or one of its parameters has no description", lintDescriptions},
+	{"name-case-mismatch", SeverityWarning, "a method's name and funcName are written in different cases", lintNameCase},
+	{"single-method-group", SeverityWarning, "a group holds a single method and nothing else", lintSingleMethodGroups},
+	{"destructor-without-owner", SeverityWarning, "a class has a destructor but nothing that makes it own a handle", lintDestructors},
+	{"duplicate-binding", SeverityWarning, "two bindings in a class have the same name", lintDuplicateBindings},
+	{"deprecated-without-replacement", SeverityWarning, "a deprecation message does not name what to use instead", lintDeprecations},
+}
+
+// LintRules returns every rule Lint knows, in the order it runs them.
+func LintRules() []LintRule {
+	return slices.Clone(lintRules)
+}
+
+func knownLintRule(id string) bool {
+	return slices.ContainsFunc(lintRules, func(rule LintRule) bool { return rule.ID == id })
+}
+
+// LintConfig adjusts the rules Lint runs.
+type LintConfig struct {
+	// Rules sets the severity of the rules it names by ID: "error",
+	// "warning" or "off". A rule left out keeps its own.
+	Rules map[string]Severity `json:"rules"`
+}
+
+// LoadLintConfig reads a LintConfig from a JSON file such as
+//
+//	{"rules": {"missing-description": "off", "duplicate-binding": "error"}}
+func LoadLintConfig(path string) (*LintConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lint config: %w", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	var config LintConfig
+	if err := decoder.Decode(&config); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for id, severity := range config.Rules {
+		if !knownLintRule(id) {
+			return nil, fmt.Errorf("%s: unknown lint rule %q", path, id)
+		}
+		switch severity {
+		case SeverityError, SeverityWarning, LintOff:
+		default:
+			return nil, fmt.Errorf("%s: rule %q: severity must be %q, %q or %q, not %q", path, id, SeverityError, SeverityWarning, LintOff, severity)
+		}
+	}
+	return &config, nil
+}
+
+// LintFile lints the manifest at path; see Lint.
+func LintFile(path string, config *LintConfig, opts *ParseOptions) (Diagnostics, error) {
+	opts = EnsureParseOptions(opts)
+	data, err := opts.readFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest file: %w", err)
+	}
+
+	return lint(path, data, config, opts)
+}
+
+// Lint parses manifest JSON data and runs every rule over it that config, which
+// may be nil, leaves on. A manifest that does not parse is an error; otherwise
+// the findings come back in the order they appear in the files, each naming
+// its rule.
+//
+// A finding is left out when the item it is about, or one the item sits in,
+// lists its rule under "nolint":
+//
+//	{"name": "Internal", "funcName": "Internal", "nolint": ["missing-description"], ...}
+func Lint(data []byte, config *LintConfig, opts *ParseOptions) (Diagnostics, error) {
+	return lint("", data, config, opts)
+}
+
+func lint(file string, data []byte, config *LintConfig, opts *ParseOptions) (Diagnostics, error) {
+	m, sources, err := parseSources(file, data, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	suppressed, found := nolintPointers(m)
+	for _, rule := range lintRules {
+		severity := rule.Severity
+		if config != nil {
+			if configured, ok := config.Rules[rule.ID]; ok {
+				severity = configured
+			}
+		}
+		if severity == LintOff {
+			continue
+		}
+		rule.check(m, func(pointer, format string, args ...any) {
+			for at := pointer; ; at = at[:strings.LastIndexByte(at, '/')] {
+				if slices.Contains(suppressed[at], rule.ID) {
+					return
+				}
+				if at == "" {
+					break
+				}
+			}
+			d := errorAt(pointer, format, args...)
+			d.Severity = severity
+			d.Rule = rule.ID
+			found = append(found, d)
+		})
+	}
+
+	found = sources.locate(found)
+	sort.SliceStable(found, func(i, j int) bool {
+		a, b := found[i], found[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return found, nil
+}
+
+// nolintPointers maps the pointer of every item with a "nolint" to the rules
+// it lists. A rule Lint does not know is reported, as it would otherwise sit
+// there suppressing nothing.
+func nolintPointers(m *Manifest) (map[string][]string, Diagnostics) {
+	suppressed := map[string][]string{}
+	var unknown Diagnostics
+	add := func(pointer string, rules []string) {
+		for i, id := range rules {
+			if !knownLintRule(id) {
+				unknown = append(unknown, warningAt(fmt.Sprintf("%s/nolint/%d", pointer, i), "nolint names unknown lint rule %q", id))
+			}
+		}
+		if len(rules) > 0 {
+			suppressed[pointer] = append(suppressed[pointer], rules...)
+		}
+	}
+	signature := func(at where, params []ParamType, ret *RetType) {
+		for j := range params {
+			add(at.param(j).pointer(), params[j].NoLint)
+		}
+		add(at.returnType().pointer(), ret.NoLint)
+	}
+
+	add("", m.NoLint)
+	for i := range m.Methods {
+		method := &m.Methods[i]
+		add(fmt.Sprintf("/methods/%d", i), method.NoLint)
+		signature(scope("method", i, method.Name), method.ParamTypes, &method.RetType)
+	}
+	for i := range m.Classes {
+		add(fmt.Sprintf("/classes/%d", i), m.Classes[i].NoLint)
+		for j := range m.Classes[i].Bindings {
+			add(fmt.Sprintf("/classes/%d/bindings/%d", i, j), m.Classes[i].Bindings[j].NoLint)
+		}
+	}
+	for i := range m.Constants {
+		add(fmt.Sprintf("/constants/%d", i), m.Constants[i].NoLint)
+	}
+	for i := range m.Events {
+		add(fmt.Sprintf("/events/%d", i), m.Events[i].NoLint)
+	}
+	// Resolve sorted these tables, but each definition remembers where it was
+	// written, in the table or inline.
+	for _, enum := range ownEnums(m) {
+		add(enum.origin.member("enum"), enum.NoLint)
+	}
+	for _, prototype := range ownPrototypes(m) {
+		add(prototype.origin.member("prototype"), prototype.NoLint)
+		signature(prototype.origin, prototype.ParamTypes, &prototype.RetType)
+	}
+	for _, structure := range ownStructs(m) {
+		add(structure.origin.member("struct"), structure.NoLint)
+		for j := range structure.Fields {
+			add(structure.origin.structField(j).pointer(), structure.Fields[j].NoLint)
+		}
+	}
+	return suppressed, unknown
+}
+
+func lintDescriptions(m *Manifest, report lintReport) {
+	for i := range m.Methods {
+		method := &m.Methods[i]
+		if method.Description == "" {
+			report(fmt.Sprintf("/methods/%d", i), "method %q has no description", method.Name)
+		}
+		for j := range method.ParamTypes {
+			if method.ParamTypes[j].Description == "" {
+				report(fmt.Sprintf("/methods/%d/paramTypes/%d", i, j), "method %q: parameter %q has no description", method.Name, method.ParamTypes[j].Name)
+			}
+		}
+	}
+}
+
+func lintNameCase(m *Manifest, report lintReport) {
+	for i := range m.Methods {
+		method := &m.Methods[i]
+		name, funcName := nameCaseOf(method.Name), nameCaseOf(method.FuncName)
+		if name != 0 && funcName != 0 && name&funcName == 0 {
+			report(fmt.Sprintf("/methods/%d/funcName", i), "method %q: name is %s but funcName %q is %s",
+				method.Name, name, method.FuncName, funcName)
+		}
+	}
+}
+
+// nameCase is the set of naming conventions a name could be written in. A
+// single word fits more than one: "get" could be camelCase or snake_case.
+type nameCase int
+
+const (
+	camelCase nameCase = 1 << iota
+	pascalCase
+	snakeCase
+	screamingSnakeCase
+)
+
+func (c nameCase) String() string {
+	switch c {
+	case camelCase:
+		return "camelCase"
+	case pascalCase:
+		return "PascalCase"
+	case snakeCase:
+		return "snake_case"
+	case screamingSnakeCase:
+		return "SCREAMING_SNAKE_CASE"
+	case camelCase | snakeCase:
+		return "lowercase"
+	default:
+		return "uppercase"
+	}
+}
+
+// nameCaseOf returns the conventions name fits, or 0 for a name that mixes
+// them, such as Get_value, or is empty.
+func nameCaseOf(name string) nameCase {
+	name = strings.Trim(name, "_")
+	if name == "" {
+		return 0
+	}
+	underscored := strings.Contains(name, "_")
+	upper := strings.ToLower(name) != name
+	lower := strings.ToUpper(name) != name
+	first, _ := utf8.DecodeRuneInString(name)
+	switch {
+	case underscored && upper && lower:
+		return 0
+	case underscored && upper:
+		return screamingSnakeCase
+	case underscored:
+		return snakeCase
+	case !upper:
+		return camelCase | snakeCase
+	case !lower:
+		return pascalCase | screamingSnakeCase
+	case unicode.IsUpper(first):
+		return pascalCase
+	default:
+		return camelCase
+	}
+}
+
+func lintSingleMethodGroups(m *Manifest, report lintReport) {
+	// Groups are matched regardless of case, as the generators do.
+	members := map[string]int{}
+	for i := range m.Methods {
+		members[strings.ToLower(m.Methods[i].Group)]++
+	}
+	for i := range m.Classes {
+		members[strings.ToLower(m.Classes[i].Group)]++
+	}
+	for i := range m.Methods {
+		method := &m.Methods[i]
+		if method.Group != "" && members[strings.ToLower(method.Group)] == 1 {
+			report(fmt.Sprintf("/methods/%d/group", i), "method %q is alone in group %q", method.Name, method.Group)
+		}
+	}
+}
+
+func lintDestructors(m *Manifest, report lintReport) {
+	// A binding returning the class as its owner hands it a handle to destroy,
+	// just as a constructor does.
+	owned := map[string]bool{}
+	for i := range m.Classes {
+		for _, binding := range m.Classes[i].Bindings {
+			if binding.RetAlias != nil && binding.RetAlias.Owner {
+				owned[binding.RetAlias.Name] = true
+			}
+		}
+	}
+	for i := range m.Classes {
+		class := &m.Classes[i]
+		if class.Destructor != nil && len(class.Constructors) == 0 && !owned[class.Name] {
+			report(fmt.Sprintf("/classes/%d/destructor", i),
+				"class %q has destructor %q, but no constructor and no binding returning an owned %s, so it never owns a handle to destroy",
+				class.Name, *class.Destructor, class.Name)
+		}
+	}
+}
+
+func lintDuplicateBindings(m *Manifest, report lintReport) {
+	for i := range m.Classes {
+		class := &m.Classes[i]
+		first := map[string]int{}
+		for j, binding := range class.Bindings {
+			if k, taken := first[binding.Name]; taken {
+				report(fmt.Sprintf("/classes/%d/bindings/%d/name", i, j), "class %q binding %q: the name is taken by bindings[%d] already",
+					class.Name, binding.Name, k)
+				continue
+			}
+			first[binding.Name] = j
+		}
+	}
+}
+
+// identifierPattern matches a name in a deprecation message, qualified or not:
+// Kick, Player.Kick.
+var identifierPattern = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*`)
+
+func lintDeprecations(m *Manifest, report lintReport) {
+	// Everything a message could point to instead.
+	names := map[string]bool{}
+	for i := range m.Methods {
+		names[m.Methods[i].Name] = true
+		names[m.Methods[i].FuncName] = true
+	}
+	for i := range m.Classes {
+		names[m.Classes[i].Name] = true
+		for _, binding := range m.Classes[i].Bindings {
+			names[binding.Name] = true
+			names[m.Classes[i].Name+"."+binding.Name] = true
+		}
+	}
+	for _, enum := range m.Enums {
+		names[enum.Name] = true
+	}
+	for _, prototype := range m.Prototypes {
+		names[prototype.Name] = true
+	}
+	for _, structure := range m.Structs {
+		names[structure.Name] = true
+	}
+	for i := range m.Constants {
+		names[m.Constants[i].Name] = true
+	}
+	for i := range m.Events {
+		names[m.Events[i].Name] = true
+	}
+
+	check := func(deprecated, self, at, context string) {
+		if deprecated == "" {
+			return
+		}
+		for _, word := range identifierPattern.FindAllString(deprecated, -1) {
+			if word != self && names[word] {
+				return
+			}
+		}
+		report(at+"/deprecated", "%s is deprecated without naming a replacement", context)
+	}
+
+	for i := range m.Methods {
+		method := &m.Methods[i]
+		check(method.Deprecated, method.Name, fmt.Sprintf("/methods/%d", i), fmt.Sprintf("method %q", method.Name))
+	}
+	for i := range m.Classes {
+		class := &m.Classes[i]
+		check(class.Deprecated, class.Name, fmt.Sprintf("/classes/%d", i), fmt.Sprintf("class %q", class.Name))
+		for j, binding := range class.Bindings {
+			check(binding.Deprecated, binding.Name, fmt.Sprintf("/classes/%d/bindings/%d", i, j), fmt.Sprintf("class %q binding %q", class.Name, binding.Name))
+		}
+	}
+	for _, enum := range ownEnums(m) {
+		check(enum.Deprecated, enum.Name, enum.origin.member("enum"), fmt.Sprintf("enum %q", enum.Name))
+	}
+	for _, prototype := range ownPrototypes(m) {
+		check(prototype.Deprecated, prototype.Name, prototype.origin.member("prototype"), fmt.Sprintf("prototype %q", prototype.Name))
+	}
+	for _, structure := range ownStructs(m) {
+		check(structure.Deprecated, structure.Name, structure.origin.member("struct"), fmt.Sprintf("struct %q", structure.Name))
+	}
+	for i := range m.Constants {
+		check(m.Constants[i].Deprecated, m.Constants[i].Name, fmt.Sprintf("/constants/%d", i), fmt.Sprintf("constant %q", m.Constants[i].Name))
+	}
+	for i := range m.Events {
+		check(m.Events[i].Deprecated, m.Events[i].Name, fmt.Sprintf("/events/%d", i), fmt.Sprintf("event %q", m.Events[i].Name))
+	}
+}
@@ -0,0 +1,160 @@
+package manifest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// documented is a method with nothing for the missing-description rule to
+// report, with rest added to its keys.
+func documented(name, rest string) string {
+	return fmt.Sprintf(`{"name": %q, "funcName": %q, "description": "d", "paramTypes": [], "retType": {"type": "void"}%s}`, name, name, rest)
+}
+
+// documentedPlayer is a class with nothing for the missing-description rule
+// to report, with rest added to its keys.
+func documentedPlayer(rest string) string {
+	return `{"name": "Player", "description": "d", "handleType": "int32", "invalidValue": "-1", ` + rest + `}`
+}
+
+// checkLint lints data under config and compares what it finds against want,
+// each written as "severity rule at pointer", or "severity at pointer" when no
+// rule reported it.
+func checkLint(t *testing.T, data string, config *LintConfig, want ...string) {
+	t.Helper()
+	diagnostics, err := Lint([]byte(data), config, nil)
+	if err != nil {
+		t.Fatalf("Lint: %v", err)
+	}
+	found := make([]string, len(diagnostics))
+	for i, d := range diagnostics {
+		found[i] = strings.TrimSpace(fmt.Sprintf("%s %s", d.Severity, d.Rule)) + " at " + d.Pointer
+		if d.Line == 0 {
+			t.Errorf("finding at %s has no line", d.Pointer)
+		}
+	}
+	if !slices.Equal(found, want) {
+		t.Errorf("Lint found\n\t%s\nwant\n\t%s", strings.Join(found, "\n\t"), strings.Join(want, "\n\t"))
+	}
+}
+
+func TestLintClean(t *testing.T) {
+	checkLint(t, testManifest(`"methods": [`+documented("Kick", "")+`]`), nil)
+}
+
+func TestLintMissingDescription(t *testing.T) {
+	checkLint(t, testManifest(`"methods": [`+testMethod("Kick", `{"name": "player", "type": "int32"}`, "void", "")+`]`), nil,
+		"warning missing-description at /methods/0",
+		"warning missing-description at /methods/0/paramTypes/0")
+}
+
+func TestLintNameCase(t *testing.T) {
+	checkLint(t, testManifest(`"methods": [{"name": "GetValue", "funcName": "get_value", "description": "d", "paramTypes": [], "retType": {"type": "void"}}]`), nil,
+		"warning name-case-mismatch at /methods/0/funcName")
+
+	// A single lowercase word reads as either case
+	checkLint(t, testManifest(`"methods": [`+documented("get", "")+`]`), nil)
+}
+
+func TestLintSingleMethodGroups(t *testing.T) {
+	checkLint(t, testManifest(`"methods": [`+documented("Kick", `, "group": "admin"`)+`, `+documented("Ban", `, "group": "Players"`)+`, `+documented("Mute", `, "group": "players"`)+`]`), nil,
+		"warning single-method-group at /methods/0/group")
+}
+
+func TestLintDestructors(t *testing.T) {
+	destroy := `{"name": "Destroy", "funcName": "Destroy", "description": "d", "paramTypes": [{"name": "p", "type": "int32", "description": "d"}], "retType": {"type": "void"}}`
+	checkLint(t, testManifest(`"methods": [`+destroy+`], "classes": [`+documentedPlayer(`"destructor": "Destroy", "bindings": []`)+`]`), nil,
+		"warning destructor-without-owner at /classes/0/destructor")
+
+	// A binding returning an owned handle gives the destructor something to free
+	checkLint(t, testManifest(`"methods": [`+destroy+`,
+		{"name": "Find", "funcName": "Find", "description": "d", "paramTypes": [], "retType": {"type": "int32"}}],
+		"classes": [`+documentedPlayer(`"destructor": "Destroy", "bindings": [{"name": "Find", "method": "Find", "retAlias": {"name": "Player", "owner": true}}]`)+`]`), nil)
+}
+
+func TestLintDuplicateBindings(t *testing.T) {
+	checkLint(t, testManifest(`"methods": [{"name": "Kick", "funcName": "Kick", "description": "d", "paramTypes": [{"name": "p", "type": "int32", "description": "d"}], "retType": {"type": "void"}}],
+		"classes": [`+documentedPlayer(`"bindings": [{"name": "Kick", "method": "Kick", "bindSelf": true}, {"name": "Kick", "method": "Kick", "bindSelf": true}]`)+`]`), nil,
+		"warning duplicate-binding at /classes/0/bindings/1/name")
+}
+
+func TestLintDeprecations(t *testing.T) {
+	checkLint(t, testManifest(`"methods": [`+documented("Kick", `, "deprecated": "do not call this"`)+`]`), nil,
+		"warning deprecated-without-replacement at /methods/0/deprecated")
+
+	// Naming something in the manifest is a replacement
+	checkLint(t, testManifest(`"methods": [`+documented("Kick", `, "deprecated": "use Ban instead"`)+`, `+documented("Ban", "")+`]`), nil)
+}
+
+func TestLintNolint(t *testing.T) {
+	undocumented := `"paramTypes": [{"name": "player", "type": "int32"}], "retType": {"type": "void"}`
+
+	checkLint(t, testManifest(`"methods": [`+documented("Kick", `, "deprecated": "do not call this", "nolint": ["deprecated-without-replacement"]`)+`]`), nil)
+
+	// An item's nolint covers what the item holds, and the manifest's covers
+	// everything
+	checkLint(t, testManifest(`"methods": [{"name": "Kick", "funcName": "Kick", "nolint": ["missing-description"], `+undocumented+`}]`), nil)
+	checkLint(t, testManifest(`"nolint": ["missing-description"], "methods": [{"name": "Kick", "funcName": "Kick", `+undocumented+`}]`), nil)
+
+	checkLint(t, testManifest(`"methods": [{"name": "Kick", "funcName": "Kick", "deprecated": "gone", "nolint": ["missing-description"], "paramTypes": [], "retType": {"type": "void"}}]`), nil,
+		"warning deprecated-without-replacement at /methods/0/deprecated")
+
+	checkLint(t, testManifest(`"methods": [`+documented("Kick", `, "nolint": ["missing-descripton"]`)+`]`), nil,
+		"warning at /methods/0/nolint/0")
+}
+
+func TestLintConfigSeverities(t *testing.T) {
+	checkLint(t, testManifest(`"methods": [`+documented("Kick", `, "deprecated": "gone"`)+`]`),
+		&LintConfig{Rules: map[string]Severity{"deprecated-without-replacement": SeverityError}},
+		"error deprecated-without-replacement at /methods/0/deprecated")
+
+	checkLint(t, testManifest(`"methods": [{"name": "Kick", "funcName": "Kick", "deprecated": "gone", "paramTypes": [], "retType": {"type": "void"}}]`),
+		&LintConfig{Rules: map[string]Severity{"missing-description": LintOff}},
+		"warning deprecated-without-replacement at /methods/0/deprecated")
+}
+
+func TestLintRulesAreDistinct(t *testing.T) {
+	seen := map[string]bool{}
+	for _, rule := range LintRules() {
+		if seen[rule.ID] {
+			t.Errorf("rule %s is listed twice", rule.ID)
+		}
+		seen[rule.ID] = true
+		if rule.Severity != SeverityWarning && rule.Severity != SeverityError {
+			t.Errorf("rule %s has severity %q", rule.ID, rule.Severity)
+		}
+	}
+}
+
+// loadLintConfig writes config to a file and loads it.
+func loadLintConfig(t *testing.T, config string) (*LintConfig, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "lint.json")
+	if err := os.WriteFile(path, []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return LoadLintConfig(path)
+}
+
+func TestLoadLintConfig(t *testing.T) {
+	config, err := loadLintConfig(t, `{"rules": {"missing-description": "off", "duplicate-binding": "error"}}`)
+	if err != nil {
+		t.Fatalf("LoadLintConfig: %v", err)
+	}
+	if config.Rules["duplicate-binding"] != SeverityError || config.Rules["missing-description"] != LintOff {
+		t.Errorf("rules are %v", config.Rules)
+	}
+
+	_, err = loadLintConfig(t, `{"rules": {"missing-descripton": "off"}}`)
+	wantError(t, err, `unknown lint rule "missing-descripton"`)
+
+	_, err = loadLintConfig(t, `{"rules": {"missing-description": "loud"}}`)
+	wantError(t, err, "severity must be")
+
+	_, err = loadLintConfig(t, `{"rulez": {}}`)
+	wantError(t, err, `unknown field "rulez"`)
+}
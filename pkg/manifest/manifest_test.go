@@ -0,0 +1,71 @@
+package manifest
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// testManifest wraps rest in the keys every manifest needs.
+func testManifest(rest string) string {
+	return `{"name": "demo", "version": "1.0", "language": "cpp", "entry": "demo", ` + rest + `}`
+}
+
+// testMethod is a method called name taking params, the inside of a JSON
+// array, and returning ret, with rest added to its keys.
+func testMethod(name, params, ret, rest string) string {
+	return fmt.Sprintf(`{"name": %q, "funcName": %q, "paramTypes": [%s], "retType": {"type": %q}%s}`, name, name, params, ret, rest)
+}
+
+// mustParse parses data, failing the test if it does not parse.
+func mustParse(t *testing.T, data string, opts *ParseOptions) *Manifest {
+	t.Helper()
+	m, err := Parse([]byte(data), opts)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	return m
+}
+
+// parseProblems parses data, which should not parse, and checks the pointers
+// of the diagnostics it fails with against want. It returns the diagnostics
+// for the test to look further into.
+func parseProblems(t *testing.T, data string, opts *ParseOptions, want ...string) Diagnostics {
+	t.Helper()
+	_, err := Parse([]byte(data), opts)
+	var found Diagnostics
+	if !errors.As(err, &found) {
+		t.Fatalf("Parse returned %v, want diagnostics", err)
+	}
+	pointers := make([]string, len(found))
+	for i, d := range found {
+		pointers[i] = d.Pointer
+	}
+	if !slices.Equal(pointers, want) {
+		t.Errorf("Parse reported problems at\n\t%s\nwant\n\t%s\n%v", strings.Join(pointers, "\n\t"), strings.Join(want, "\n\t"), found)
+	}
+	return found
+}
+
+// wantContains checks that text, what the test made of something, holds each
+// of want.
+func wantContains(t *testing.T, what, text string, want ...string) {
+	t.Helper()
+	for _, w := range want {
+		if !strings.Contains(text, w) {
+			t.Errorf("%s lacks %q:\n%s", what, w, text)
+		}
+	}
+}
+
+// wantError checks that err is an error mentioning want.
+func wantError(t *testing.T, err error, want string) {
+	t.Helper()
+	if err == nil {
+		t.Errorf("no error, want one mentioning %q", want)
+	} else if !strings.Contains(err.Error(), want) {
+		t.Errorf("error %q does not mention %q", err, want)
+	}
+}
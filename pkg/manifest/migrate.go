@@ -0,0 +1,168 @@
+package manifest
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// migrations lists, for each schema version, the steps that bring a manifest
+// written for it up to the next version, in the order they run. Each step
+// returns what it changed, one line per change. A version missing here changed
+// nothing a manifest has to follow.
+var migrations = map[int][]func(m *Manifest) []string{
+	// Version 0 is every manifest from before "$schema" named one of ours.
+	0: {
+		migrateEnumRefs,
+		migrateRepeatedDefinitions,
+		migrateFuncNames,
+	},
+}
+
+// Migration is what Migrate made of a manifest.
+type Migration struct {
+	From    int      // the schema version the manifest was written for
+	To      int      // the schema version it now follows, SchemaVersion
+	Changes []string // what changed, one line each; empty when nothing did
+	Data    []byte   // the migrated manifest, in canonical form if anything changed
+}
+
+// MigrateFile migrates the manifest at path; see Migrate.
+func MigrateFile(path string, opts *ParseOptions) (*Migration, error) {
+	opts = EnsureParseOptions(opts)
+	data, err := opts.readFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest file: %w", err)
+	}
+
+	return migrate(path, data, opts)
+}
+
+// Migrate rewrites manifest JSON data written for an older schema version, as
+// its "$schema" tells, into the shape SchemaVersion expects, running every
+// migration registered between the two in turn. The result records SchemaID in
+// "$schema" and is written as Format writes, and must parse; a manifest
+// already at SchemaVersion comes back as it was.
+//
+// Keys Parse would drop are an error rather than lost, and imported files are
+// left as they are.
+func Migrate(data []byte, opts *ParseOptions) (*Migration, error) {
+	return migrate("", data, opts)
+}
+
+func migrate(file string, data []byte, opts *ParseOptions) (*Migration, error) {
+	opts = EnsureParseOptions(opts)
+
+	var m Manifest
+	if err := decode(data, &m); err != nil {
+		return nil, decodeError(err, file, data)
+	}
+	p := &problems{all: opts.AllErrors}
+	checkFields(data, reflect.TypeOf(Manifest{}), p)
+	from := schemaVersionOf(m.Schema)
+	if from > SchemaVersion {
+		p.report(errorAt("/$schema", "manifest is written for schema version %d, newer than the %d this generator knows", from, SchemaVersion))
+	}
+	if p.found.Count(SeverityError) > 0 {
+		return nil, newSourceMap(file, data).locate(p.found)
+	}
+	result := &Migration{From: from, To: SchemaVersion, Data: data}
+	if from == SchemaVersion {
+		return result, nil
+	}
+
+	for version := from; version < SchemaVersion; version++ {
+		for _, step := range migrations[version] {
+			result.Changes = append(result.Changes, step(&m)...)
+		}
+	}
+	m.Schema = SchemaID
+	result.Changes = append(result.Changes, fmt.Sprintf("$schema set to %q", SchemaID))
+
+	var buf bytes.Buffer
+	writeCanonical(&buf, reflect.ValueOf(m), "")
+	buf.WriteByte('\n')
+	result.Data = buf.Bytes()
+
+	// A migration only respells what the manifest says, so whatever is still
+	// wrong with it was wrong before and needs fixing by hand.
+	if _, err := parse(file, result.Data, opts); err != nil {
+		return nil, fmt.Errorf("the migrated manifest does not parse:\n%v", err)
+	}
+	return result, nil
+}
+
+// schemaVersionOf returns the schema version a manifest's "$schema" names. A
+// manifest naming anything but one of ours, plugify's own schema included,
+// predates the versioning and is at version 0.
+func schemaVersionOf(schema string) int {
+	var version int
+	if _, err := fmt.Sscanf(schema, schemaIDFormat, &version); err != nil || fmt.Sprintf(schemaIDFormat, version) != schema {
+		return 0
+	}
+	return version
+}
+
+// migrateEnumRefs rewrites the value-less enum objects manifests once wrote to
+// mean "the enum of this name, defined elsewhere" as that name.
+func migrateEnumRefs(m *Manifest) []string {
+	var names []string
+	count := map[string]int{}
+	slots := definitionSlots{
+		enum: func(slot **Enum) {
+			enum := *slot
+			if enum.ref || len(enum.Values) != 0 || enum.Name == "" {
+				return
+			}
+			*slot = &Enum{Name: enum.Name, ref: true}
+			if count[enum.Name] == 0 {
+				names = append(names, enum.Name)
+			}
+			count[enum.Name]++
+		},
+		prototype: func(**Prototype) {},
+		structure: func(**Struct) {},
+	}
+	slots.walk(m)
+
+	changes := make([]string, len(names))
+	for i, name := range names {
+		changes[i] = fmt.Sprintf("enum %q: %s replaced by its name", name, plural(count[name], "value-less object"))
+	}
+	return changes
+}
+
+// migrateRepeatedDefinitions moves an enum, prototype or struct defined inline
+// in several places to the manifest's tables, as Format does. Older manifests
+// repeated a callback's prototype at every method taking it.
+func migrateRepeatedDefinitions(m *Manifest) []string {
+	var changes []string
+	for _, key := range hoistDefinitions(m) {
+		kind, name, _ := strings.Cut(key, " ")
+		changes = append(changes, fmt.Sprintf("%s %q: inline definitions moved to '%ss' and replaced by its name", kind, name, kind))
+	}
+	return changes
+}
+
+// migrateFuncNames fills in the funcName older manifests left out where it was
+// the method's name.
+func migrateFuncNames(m *Manifest) []string {
+	var changes []string
+	for i := range m.Methods {
+		method := &m.Methods[i]
+		if method.FuncName == "" && method.Name != "" {
+			method.FuncName = method.Name
+			changes = append(changes, fmt.Sprintf("method %q: funcName set to %q", method.Name, method.FuncName))
+		}
+	}
+	return changes
+}
+
+// plural renders n of thing, adding an s when n is not 1.
+func plural(n int, thing string) string {
+	if n == 1 {
+		return "1 " + thing
+	}
+	return fmt.Sprintf("%d %ss", n, thing)
+}
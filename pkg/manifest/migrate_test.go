@@ -0,0 +1,82 @@
+package manifest
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// schemaSet is the change every migration from version 0 ends with.
+var schemaSet = fmt.Sprintf("$schema set to %q", SchemaID)
+
+// checkMigrate migrates data from version 0, checks the changes reported
+// and that the result holds each of want, and checks that migrating the
+// result again changes nothing.
+func checkMigrate(t *testing.T, data string, changes []string, want ...string) {
+	t.Helper()
+	migration, err := Migrate([]byte(data), nil)
+	if err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	if migration.From != 0 || migration.To != SchemaVersion {
+		t.Errorf("migrated from %d to %d, want 0 to %d", migration.From, migration.To, SchemaVersion)
+	}
+	if !slices.Equal(migration.Changes, changes) {
+		t.Errorf("changes are\n\t%s\nwant\n\t%s", strings.Join(migration.Changes, "\n\t"), strings.Join(changes, "\n\t"))
+	}
+	wantContains(t, "migrated manifest", string(migration.Data), want...)
+
+	again, err := Migrate(migration.Data, nil)
+	if err != nil {
+		t.Fatalf("Migrate of the migrated manifest: %v", err)
+	}
+	if again.From != SchemaVersion || len(again.Changes) != 0 || string(again.Data) != string(migration.Data) {
+		t.Errorf("migrating again changes %v", again.Changes)
+	}
+}
+
+func TestMigrateEnumNames(t *testing.T) {
+	checkMigrate(t,
+		testManifest(`"enums": [{"name": "Color", "values": [{"name": "Red", "value": 0}]}], "methods": [
+			{"name": "A", "funcName": "A", "paramTypes": [{"name": "c", "type": "int32", "enum": {"name": "Color"}}], "retType": {"type": "int32", "enum": {"name": "Color"}}}]`),
+		[]string{`enum "Color": 2 value-less objects replaced by its name`, schemaSet},
+		`"enum": "Color"`)
+}
+
+func TestMigrateRepeatedDefinitions(t *testing.T) {
+	onTick := `{"name": "cb", "type": "function", "prototype": {"name": "OnTick", "funcName": "OnTick", "paramTypes": [], "retType": {"type": "void"}}}`
+	checkMigrate(t,
+		testManifest(`"methods": [`+testMethod("A", onTick, "void", "")+`, `+testMethod("B", onTick, "void", "")+`]`),
+		[]string{`prototype "OnTick": inline definitions moved to 'prototypes' and replaced by its name`, schemaSet},
+		`"prototype": "OnTick"`, `"prototypes": [`)
+}
+
+func TestMigrateFuncNames(t *testing.T) {
+	checkMigrate(t,
+		testManifest(`"methods": [
+			{"name": "A", "paramTypes": [], "retType": {"type": "void"}},
+			{"name": "B", "funcName": "B_impl", "paramTypes": [], "retType": {"type": "void"}}]`),
+		[]string{`method "A": funcName set to "A"`, schemaSet},
+		`"funcName": "A"`, `"funcName": "B_impl"`)
+}
+
+func TestMigrateSchema(t *testing.T) {
+	checkMigrate(t, testManifest(`"methods": []`), []string{schemaSet}, `"$schema": "`+SchemaID+`"`)
+
+	// plugify's own schema is version 0
+	checkMigrate(t,
+		`{"$schema": "https://raw.githubusercontent.com/untrustedmodders/plugify/main/schemas/plugin.schema.json", `+testManifest(`"methods": []`)[1:],
+		[]string{schemaSet})
+}
+
+func TestMigrateRejects(t *testing.T) {
+	_, err := Migrate([]byte(fmt.Sprintf(`{"$schema": %q, `, fmt.Sprintf(schemaIDFormat, SchemaVersion+1))+testManifest(`"methods": []`)[1:]), nil)
+	wantError(t, err, "newer than")
+
+	_, err = Migrate([]byte(testManifest(`"methods": [], "bogus": 1`)), nil)
+	wantError(t, err, "bogus")
+
+	_, err = Migrate([]byte(testManifest(`"methods": [{"name": "A", "paramTypes": [{"name": "x", "type": "strng"}], "retType": {"type": "void"}}]`)), nil)
+	wantError(t, err, "does not parse")
+}
@@ -0,0 +1,200 @@
+package manifest
+
+import (
+	"fmt"
+	"strings"
+)
+
+// IsOverloaded reports whether method shares its name with another where a
+// language has overloading. A set left with one member, the rest filtered out
+// for a platform, is no longer overloaded.
+func (m *Manifest) IsOverloaded(method *Method) bool {
+	for i := range m.Methods {
+		if &m.Methods[i] != method && m.Methods[i].OverloadName() == method.OverloadName() {
+			return true
+		}
+	}
+	return false
+}
+
+// methodPointer is the JSON pointer of field in the manifest's i'th method.
+func methodPointer(i int, field string) string {
+	return fmt.Sprintf("/methods/%d/%s", i, field)
+}
+
+// checkOverloads checks that every method has a name of its own, and that
+// each overloadOf names a method that is not an overload itself. Languages
+// with overloading pick among a set by the arguments alone, so no two of a set
+// may accept the same argument types, counting the ones a default lets a call
+// leave out. It returns non-nil only when p wants checking to stop.
+func checkOverloads(m *Manifest, p *problems) error {
+	names := make(map[string]int, len(m.Methods))
+	for i, method := range m.Methods {
+		if method.Name == "" {
+			continue // validate reports this
+		}
+		if first, seen := names[method.Name]; seen {
+			if err := p.report(errorAt(methodPointer(i, "name"),
+				"%s: name %q is already used by method[%d]", scope("method", i, method.Name), method.Name, first)); err != nil {
+				return err
+			}
+			continue
+		}
+		names[method.Name] = i
+	}
+
+	sets := map[string][]int{}
+	for i, method := range m.Methods {
+		if method.OverloadOf == "" {
+			continue
+		}
+		context := scope("method", i, method.Name)
+		target, found := names[method.OverloadOf]
+		switch {
+		case !found:
+			if err := p.report(errorAt(methodPointer(i, "overloadOf"),
+				"%s: overloadOf %q is not a method of this manifest", context, method.OverloadOf)); err != nil {
+				return err
+			}
+			continue
+		case target == i:
+			if err := p.report(errorAt(methodPointer(i, "overloadOf"), "%s: a method cannot overload itself", context)); err != nil {
+				return err
+			}
+			continue
+		case m.Methods[target].OverloadOf != "":
+			if err := p.report(errorAt(methodPointer(i, "overloadOf"),
+				"%s: %q is itself an overload of %q; name that method instead", context, method.OverloadOf, m.Methods[target].OverloadOf)); err != nil {
+				return err
+			}
+			continue
+		}
+		if len(sets[method.OverloadOf]) == 0 {
+			sets[method.OverloadOf] = []int{target}
+		}
+		sets[method.OverloadOf] = append(sets[method.OverloadOf], i)
+	}
+
+	for i, method := range m.Methods {
+		set := sets[method.OverloadOf]
+		for _, other := range set {
+			if other == i {
+				break // each pair is checked once, by its later method
+			}
+			view, clash := overloadsClash(&m.Methods[other], &method)
+			if !clash {
+				continue
+			}
+			var err error
+			if view.lang == "" {
+				err = p.report(errorAt(methodPointer(i, "overloadOf"),
+					"%s: a call could not tell it apart from %q, which takes the same arguments", scope("method", i, method.Name), m.Methods[other].Name))
+			} else {
+				err = p.report(errorAt(methodPointer(i, "overloadOf"),
+					"%s: a call could not tell it apart from %q in %s, where both take the same arguments", scope("method", i, method.Name), m.Methods[other].Name, view.lang))
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// overloadView is how one group of languages with overloading sees parameter
+// types: types maps each value type to the one it becomes there, and a type
+// it leaves out stays its own.
+type overloadView struct {
+	lang  string
+	types map[string]string
+}
+
+// overloadViews lists every way the languages with overloading tell
+// parameters apart. C++, C# and D keep each type its own; TypeScript and
+// Python merge many into one, and take a list of any length for a fixed-size
+// array.
+var overloadViews = []overloadView{
+	{},
+	{lang: "TypeScript", types: map[string]string{
+		"char8": "number", "char16": "number",
+		"int8": "number", "int16": "number", "int32": "number", "int64": "number",
+		"uint8": "number", "uint16": "number", "uint32": "number",
+		"float": "number", "double": "number",
+		"uint64": "bigint", "ptr64": "bigint",
+	}},
+	{lang: "Python", types: map[string]string{
+		"char8": "str", "char16": "str", "string": "str",
+		"int8": "int", "int16": "int", "int32": "int", "int64": "int",
+		"uint8": "int", "uint16": "int", "uint32": "int", "uint64": "int", "ptr64": "int",
+		"float": "float", "double": "float",
+	}},
+}
+
+// overloadsClash reports whether some list of arguments is accepted by both a
+// and b, and in which view.
+func overloadsClash(a, b *Method) (overloadView, bool) {
+	for _, view := range overloadViews {
+		keysA, keysB := view.keys(a.ParamTypes), view.keys(b.ParamTypes)
+		for n := max(requiredParams(a.ParamTypes), requiredParams(b.ParamTypes)); n <= min(len(keysA), len(keysB)); n++ {
+			if strings.Join(keysA[:n], ",") == strings.Join(keysB[:n], ",") {
+				return view, true
+			}
+		}
+	}
+	return overloadView{}, false
+}
+
+// keys describes each parameter by what tells its arguments apart in the view.
+// Passing by reference does not: a call reads the same either way.
+func (view overloadView) keys(params []ParamType) []string {
+	keys := make([]string, len(params))
+	for i := range params {
+		param := &params[i]
+		switch {
+		case param.Enum != nil:
+			keys[i] = param.Type + ":" + param.Enum.Name
+		case param.Struct != nil:
+			keys[i] = param.Type + ":" + param.Struct.Name
+		case param.Prototype != nil:
+			keys[i] = param.Type + ":" + param.Prototype.Name
+		default:
+			keys[i] = view.typeKey(param.Type)
+		}
+	}
+	return keys
+}
+
+// typeKey is the type a parameter of type name has in the view.
+func (view overloadView) typeKey(name string) string {
+	if view.types == nil {
+		return name
+	}
+	node, err := ParseType(name)
+	if err != nil {
+		return name // checkType reports this
+	}
+	merge := func(n *TypeNode) {
+		if merged, ok := view.types[n.Name]; ok {
+			n.Name = merged
+		}
+	}
+	merge(node.Value())
+	if node.Key != nil {
+		merge(node.Key)
+	}
+	if node.Kind == KindFixedArray {
+		node.Kind, node.Len = KindArray, 0
+	}
+	return node.String()
+}
+
+// requiredParams counts the parameters a call has to pass, those before the
+// first with a default.
+func requiredParams(params []ParamType) int {
+	for i := range params {
+		if params[i].Default != nil {
+			return i
+		}
+	}
+	return len(params)
+}
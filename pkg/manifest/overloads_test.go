@@ -0,0 +1,70 @@
+package manifest
+
+import "testing"
+
+// overloads is a manifest with Kick taking params, and KickBy, an overload of
+// it, taking other.
+func overloads(params, other string) string {
+	return testManifest(`"methods": [` + testMethod("Kick", params, "void", "") + `, ` + testMethod("KickBy", other, "void", `, "overloadOf": "Kick"`) + `]`)
+}
+
+func TestOverloadTargets(t *testing.T) {
+	all := &ParseOptions{AllErrors: true}
+
+	found := parseProblems(t, testManifest(`"methods": [`+testMethod("Kick", "", "void", "")+`, `+testMethod("Kick", "", "void", "")+`]`), all,
+		"/methods/1/name")
+	wantContains(t, "diagnostics", found.Error(), `name "Kick" is already used by method[0]`)
+
+	found = parseProblems(t, testManifest(`"methods": [
+		`+testMethod("Kick", "", "void", "")+`,
+		`+testMethod("KickAll", "", "void", `, "overloadOf": "Kik"`)+`,
+		`+testMethod("KickSelf", "", "void", `, "overloadOf": "KickSelf"`)+`,
+		`+testMethod("KickBy", `{"name": "x", "type": "int32"}`, "void", `, "overloadOf": "Kick"`)+`,
+		`+testMethod("KickTwice", `{"name": "x", "type": "string"}`, "void", `, "overloadOf": "KickBy"`)+`]`), all,
+		"/methods/1/overloadOf", "/methods/2/overloadOf", "/methods/4/overloadOf")
+	wantContains(t, "diagnostics", found.Error(),
+		`overloadOf "Kik" is not a method of this manifest`,
+		"a method cannot overload itself",
+		`"KickBy" is itself an overload of "Kick"; name that method instead`)
+}
+
+func TestOverloadClashViews(t *testing.T) {
+	param := func(typeName string) string { return `{"name": "x", "type": "` + typeName + `"}` }
+	clash := func(params, other, want string) {
+		t.Helper()
+		found := parseProblems(t, overloads(params, other), nil, "/methods/1/overloadOf")
+		wantContains(t, "diagnostics", found.Error(), want)
+	}
+
+	mustParse(t, overloads(param("int32"), param("string")), nil)
+	mustParse(t, overloads(param("int32"), param("int32")+", "+param("int32")), nil)
+
+	clash(param("int32"), `{"name": "y", "type": "int32", "ref": true}`,
+		`method "KickBy": a call could not tell it apart from "Kick", which takes the same arguments`)
+	clash(param("int32"), param("double"), `could not tell it apart from "Kick" in TypeScript, where both take the same arguments`)
+	clash(param("int64"), param("uint64"), `could not tell it apart from "Kick" in Python`)
+	clash(param("int32[4]"), param("int32[8]"), "in TypeScript")
+	clash(param("map<int32,string>"), param("map<int64,string>"), "in TypeScript")
+
+	// A default lets a call leave the parameter out, which leaves Kick's list
+	clash(param("int32"), param("int32")+`, {"name": "force", "type": "bool", "default": false}`, "which takes the same arguments")
+
+	// Enums and prototypes tell parameters apart by name in every view
+	enums := testManifest(`"enums": [` + colorRed + `, {"name": "Size", "values": [{"name": "Small", "value": 0}]}],
+		"methods": [` + testMethod("Kick", `{"name": "x", "type": "int32", "enum": "Color"}`, "void", "") + `,
+		` + testMethod("KickBy", `{"name": "x", "type": "int32", "enum": "Size"}`, "void", `, "overloadOf": "Kick"`) + `]`)
+	mustParse(t, enums, nil)
+}
+
+func TestIsOverloaded(t *testing.T) {
+	m := mustParse(t, testManifest(`"methods": [
+		`+testMethod("Kick", "", "void", "")+`,
+		`+testMethod("KickBy", `{"name": "x", "type": "int32"}`, "void", `, "overloadOf": "Kick"`)+`,
+		`+testMethod("Ban", "", "void", "")+`]`), nil)
+	if !m.IsOverloaded(&m.Methods[0]) || !m.IsOverloaded(&m.Methods[1]) {
+		t.Error("Kick and KickBy are not overloaded")
+	}
+	if m.IsOverloaded(&m.Methods[2]) {
+		t.Error("Ban is overloaded")
+	}
+}
@@ -1,80 +1,187 @@
 package manifest
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
+	"reflect"
 	"unicode"
 )
 
+// ParseOptions contains options for manifest parsing
+type ParseOptions struct {
+	// AllErrors keeps checking after the first problem, so that every problem
+	// in the manifest is reported at once
+	AllErrors bool
+	// ValidateSchema checks the manifest against Schema before resolving it,
+	// which among other things rejects keys the schema does not know
+	ValidateSchema bool
+	// Strict rejects keys that have no field to decode into, instead of
+	// dropping them the way encoding/json does
+	Strict bool
+	// SearchPath lists the directories searched for the manifests of the
+	// plugins named in "dependencies", for qualified references such as
+	// "otherplugin.EnumName"
+	SearchPath []string
+	// ReadFile reads imported files and dependency manifests. It defaults to
+	// os.ReadFile; the WASM build supplies files handed over from JavaScript.
+	// A file that is not there should be reported with an error matching
+	// fs.ErrNotExist, so the search path moves on to its next directory
+	ReadFile func(name string) ([]byte, error)
+
+	dependencies *dependencyCache // shared with the dependencies a manifest loads
+}
+
+func (o *ParseOptions) readFile(name string) ([]byte, error) {
+	if o.ReadFile != nil {
+		return o.ReadFile(name)
+	}
+	return os.ReadFile(name)
+}
+
+// EnsureParseOptions returns valid options, using defaults if nil
+func EnsureParseOptions(opts *ParseOptions) *ParseOptions {
+	if opts == nil {
+		return &ParseOptions{}
+	}
+	return opts
+}
+
 // ParseFile parses a .pplugin manifest file
-func ParseFile(path string) (*Manifest, error) {
-	data, err := os.ReadFile(path)
+func ParseFile(path string, opts *ParseOptions) (*Manifest, error) {
+	opts = EnsureParseOptions(opts)
+	data, err := opts.readFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read manifest file: %w", err)
 	}
 
-	return Parse(data)
+	return parse(path, data, opts)
+}
+
+// Parse parses manifest JSON data. Any problem with the manifest itself is
+// returned as Diagnostics positioned within data. Warnings do not fail the
+// parse; they are left in the manifest's Warnings.
+func Parse(data []byte, opts *ParseOptions) (*Manifest, error) {
+	return parse("", data, opts)
 }
 
-// Parse parses manifest JSON data
-func Parse(data []byte) (*Manifest, error) {
+func parse(file string, data []byte, opts *ParseOptions) (*Manifest, error) {
+	m, _, err := parseSources(file, data, opts)
+	return m, err
+}
+
+// parseSources is parse, also returning the files that went into the manifest
+// so that further diagnostics about it can be positioned.
+func parseSources(file string, data []byte, opts *ParseOptions) (*Manifest, *sourceMap, error) {
+	opts = EnsureParseOptions(opts)
+
 	var m Manifest
-	if err := json.Unmarshal(data, &m); err != nil {
-		return nil, fmt.Errorf("failed to parse manifest JSON: %w", err)
+	if err := decode(data, &m); err != nil {
+		return nil, nil, decodeError(err, file, data)
 	}
 
 	// Resolve before validating: this links by-name prototype/enum references to
 	// their definitions, which the generators then rely on being present.
-	if err := resolve(&m); err != nil {
-		return nil, fmt.Errorf("manifest resolution failed: %w", err)
+	p := &problems{all: opts.AllErrors}
+	var err error
+	switch {
+	case opts.ValidateSchema:
+		// The schema rejects unknown keys as well, so Strict adds nothing but
+		// a second report of each.
+		err = validateSchema(data, p)
+	case opts.Strict:
+		err = checkFields(data, reflect.TypeOf(Manifest{}), p)
 	}
-
-	if err := validate(&m); err != nil {
-		return nil, fmt.Errorf("manifest validation failed: %w", err)
+	sources := newSourceMap(file, data)
+	if err == nil {
+		err = sources.loadImports(&m, opts, p)
 	}
+	if err == nil {
+		err = resolve(&m, newDependencyLoader(&m, opts, p), p)
+	}
+	if err == nil {
+		validate(&m, p)
+	}
+	if p.found.Count(SeverityError) > 0 {
+		return nil, nil, sources.locate(p.found)
+	}
+	if len(p.found) > 0 {
+		m.Warnings = sources.locate(p.found)
+	}
+	buildTypeNodes(&m)
 
-	return &m, nil
+	return &m, sources, nil
 }
 
-// validate performs basic validation on the manifest
-func validate(m *Manifest) error {
+// validate performs basic validation on the manifest. It returns non-nil only
+// when p wants checking to stop.
+func validate(m *Manifest, p *problems) error {
 	if m.Name == "" {
-		return fmt.Errorf("manifest name is required")
+		if err := p.report(errorAt("/name", "manifest name is required")); err != nil {
+			return err
+		}
 	}
 	if m.Version == "" {
-		return fmt.Errorf("manifest version is required")
+		if err := p.report(errorAt("/version", "manifest version is required")); err != nil {
+			return err
+		}
 	}
 	if m.Language == "" {
-		return fmt.Errorf("manifest language is required")
+		if err := p.report(errorAt("/language", "manifest language is required")); err != nil {
+			return err
+		}
 	}
 
 	// Validate methods
 	for i, method := range m.Methods {
 		if method.Name == "" {
-			return fmt.Errorf("method[%d]: name is required", i)
+			if err := p.report(errorAt(fmt.Sprintf("/methods/%d/name", i), "method[%d]: name is required", i)); err != nil {
+				return err
+			}
 		}
 		if method.FuncName == "" {
-			return fmt.Errorf("method[%d]: funcName is required", i)
+			if err := p.report(errorAt(fmt.Sprintf("/methods/%d/funcName", i), "method[%d]: funcName is required", i)); err != nil {
+				return err
+			}
 		}
 
 		// Validate parameters
 		for j, param := range method.ParamTypes {
 			if param.Name == "" {
-				return fmt.Errorf("method[%d].param[%d]: name is required", i, j)
+				if err := p.report(errorAt(fmt.Sprintf("/methods/%d/paramTypes/%d/name", i, j), "method[%d].param[%d]: name is required", i, j)); err != nil {
+					return err
+				}
 			}
 			if param.Type == "" {
-				return fmt.Errorf("method[%d].param[%d]: type is required", i, j)
+				if err := p.report(errorAt(fmt.Sprintf("/methods/%d/paramTypes/%d/type", i, j), "method[%d].param[%d]: type is required", i, j)); err != nil {
+					return err
+				}
 			}
 		}
 
 		// Validate return type
 		if method.RetType.Type == "" {
-			return fmt.Errorf("method[%d]: retType.type is required", i)
+			if err := p.report(errorAt(fmt.Sprintf("/methods/%d/retType/type", i), "method[%d]: retType.type is required", i)); err != nil {
+				return err
+			}
 		}
 	}
 
-	return nil
+	if err := checkOverloads(m, p); err != nil {
+		return err
+	}
+	if err := checkPlatforms(m, p); err != nil {
+		return err
+	}
+	if err := checkConstants(m, p); err != nil {
+		return err
+	}
+	if err := checkEvents(m, p); err != nil {
+		return err
+	}
+	if err := checkVersions(m, p); err != nil {
+		return err
+	}
+	return checkTypes(m, p)
 }
 
 func Capitalize(s string) string {
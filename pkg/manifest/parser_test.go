@@ -0,0 +1,35 @@
+package manifest
+
+import "testing"
+
+// unnamed is a manifest with three problems: a missing version, a method
+// with no funcName and a parameter with no type.
+var unnamed = `{"name": "demo", "language": "cpp", "entry": "demo", "methods": [
+	{"name": "A", "paramTypes": [], "retType": {"type": "void"}},
+	{"name": "B", "funcName": "B", "paramTypes": [{"name": "x"}], "retType": {"type": "void"}}]}`
+
+func TestParseStopsAtFirstProblem(t *testing.T) {
+	parseProblems(t, unnamed, nil, "/version")
+}
+
+func TestParseAllErrors(t *testing.T) {
+	found := parseProblems(t, unnamed, &ParseOptions{AllErrors: true},
+		"/version", "/methods/0/funcName", "/methods/1/paramTypes/0/type")
+	if n := found.Count(SeverityError); n != 3 {
+		t.Errorf("%d of the problems are errors, want 3", n)
+	}
+	wantContains(t, "diagnostics", found.Error(),
+		"error: manifest version is required",
+		"error: method[0]: funcName is required",
+		"error: method[1].param[0]: type is required")
+}
+
+func TestParseAllErrorsThroughResolve(t *testing.T) {
+	// Resolving carries on past an unknown enum, so the problems after it are
+	// found too
+	parseProblems(t, testManifest(`"methods": [
+		`+testMethod("A", `{"name": "c", "type": "int32", "enum": "Missing"}`, "void", "")+`,
+		`+testMethod("B", `{"name": "x", "type": "strng"}`, "void", "")+`]`),
+		&ParseOptions{AllErrors: true},
+		"/methods/0/paramTypes/0/enum", "/methods/1/paramTypes/0/type")
+}
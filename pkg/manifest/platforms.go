@@ -0,0 +1,178 @@
+package manifest
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Platforms a method, class or binding can be limited to
+const (
+	PlatformWindows = "windows"
+	PlatformLinux   = "linux"
+	PlatformMacOS   = "macos"
+)
+
+// KnownPlatforms lists every platform name a manifest may use, in the order
+// generators write conditionals for them.
+var KnownPlatforms = []string{PlatformWindows, PlatformLinux, PlatformMacOS}
+
+// OnPlatform reports whether an item limited to platforms exists on platform.
+// An item that lists none exists everywhere.
+func OnPlatform(platforms []string, platform string) bool {
+	return len(platforms) == 0 || slices.Contains(platforms, platform)
+}
+
+// withinPlatforms reports whether everywhere inner exists, outer does too.
+func withinPlatforms(inner, outer []string) bool {
+	if len(outer) == 0 {
+		return true
+	}
+	if len(inner) == 0 {
+		return false
+	}
+	for _, platform := range inner {
+		if !slices.Contains(outer, platform) {
+			return false
+		}
+	}
+	return true
+}
+
+// FilterPlatform drops every method, class and binding that does not exist on
+// platform, and the platform lists of those that remain, which then need no
+// conditional around them. An event goes with its methods.
+func (m *Manifest) FilterPlatform(platform string) error {
+	if !slices.Contains(KnownPlatforms, platform) {
+		return fmt.Errorf("unknown platform %q (expected one of %s)", platform, strings.Join(KnownPlatforms, ", "))
+	}
+	if len(m.Platforms) > 0 && !slices.Contains(m.Platforms, platform) {
+		return fmt.Errorf("plugin %s does not support platform %q", m.Name, platform)
+	}
+
+	events := m.Events[:0]
+	for _, event := range m.Events {
+		if method := m.findMethod(event.Subscribe); method == nil || OnPlatform(method.Platforms, platform) {
+			events = append(events, event)
+		}
+	}
+	m.Events = events
+
+	methods := m.Methods[:0]
+	for _, method := range m.Methods {
+		if OnPlatform(method.Platforms, platform) {
+			method.Platforms = nil
+			methods = append(methods, method)
+		}
+	}
+	m.Methods = methods
+
+	classes := m.Classes[:0]
+	for _, class := range m.Classes {
+		if !OnPlatform(class.Platforms, platform) {
+			continue
+		}
+		bindings := class.Bindings[:0]
+		for _, binding := range class.Bindings {
+			if OnPlatform(binding.Platforms, platform) {
+				binding.Platforms = nil
+				bindings = append(bindings, binding)
+			}
+		}
+		class.Bindings = bindings
+		class.Platforms = nil
+		classes = append(classes, class)
+	}
+	m.Classes = classes
+	return nil
+}
+
+// checkPlatforms checks that items are limited to platforms known to the
+// generators and supported by the plugin, and that a class or binding exists
+// nowhere the methods it calls do not. It returns non-nil only when p wants
+// checking to stop.
+func checkPlatforms(m *Manifest, p *problems) error {
+	methods := make(map[string]*Method, 2*len(m.Methods))
+	for i := range m.Methods {
+		method := &m.Methods[i]
+		// Match FindMethod in the generators, which accepts either name.
+		methods[method.FuncName] = method
+		methods[method.Name] = method
+	}
+
+	// checkNames reports the names in platforms that no generator knows, or
+	// that the plugin does not support.
+	checkNames := func(platforms []string, at, context string) error {
+		for k, platform := range platforms {
+			switch {
+			case !slices.Contains(KnownPlatforms, platform):
+				if err := p.report(errorAt(fmt.Sprintf("%s/platforms/%d", at, k),
+					"%s: unknown platform %q (expected one of %s)", context, platform, strings.Join(KnownPlatforms, ", "))); err != nil {
+					return err
+				}
+			case len(m.Platforms) > 0 && !slices.Contains(m.Platforms, platform):
+				if err := p.report(errorAt(fmt.Sprintf("%s/platforms/%d", at, k),
+					"%s: platform %q is not among those the plugin supports", context, platform)); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	// checkCalls reports a method that is missing from some platform where
+	// the item calling it exists.
+	checkCalls := func(name string, platforms []string, at, context, role string) error {
+		method, found := methods[name]
+		if !found || withinPlatforms(platforms, method.Platforms) {
+			return nil // checkClasses reports a method that is missing altogether
+		}
+		return p.report(errorAt(at+"/platforms", "%s: %s method %q is missing from some of its platforms", context, role, name))
+	}
+
+	for i := range m.Methods {
+		method := &m.Methods[i]
+		if err := checkNames(method.Platforms, fmt.Sprintf("/methods/%d", i), scope("method", i, method.Name).String()); err != nil {
+			return err
+		}
+	}
+
+	for i := range m.Classes {
+		class := &m.Classes[i]
+		at := fmt.Sprintf("/classes/%d", i)
+		context := fmt.Sprintf("class %q", class.Name)
+		if err := checkNames(class.Platforms, at, context); err != nil {
+			return err
+		}
+		for _, name := range class.Constructors {
+			if err := checkCalls(name, class.Platforms, at, context, "constructor"); err != nil {
+				return err
+			}
+		}
+		if class.Destructor != nil {
+			if err := checkCalls(*class.Destructor, class.Platforms, at, context, "destructor"); err != nil {
+				return err
+			}
+		}
+
+		for j := range class.Bindings {
+			binding := &class.Bindings[j]
+			bindingAt := fmt.Sprintf("%s/bindings/%d", at, j)
+			bindingContext := fmt.Sprintf("%s binding %q", context, binding.Name)
+			if err := checkNames(binding.Platforms, bindingAt, bindingContext); err != nil {
+				return err
+			}
+			platforms := binding.Platforms
+			if len(platforms) == 0 {
+				platforms = class.Platforms
+			} else if !withinPlatforms(platforms, class.Platforms) {
+				if err := p.report(errorAt(bindingAt+"/platforms", "%s: lists a platform its class does not exist on", bindingContext)); err != nil {
+					return err
+				}
+			}
+			if err := checkCalls(binding.Method, platforms, bindingAt, bindingContext, "bound"); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package manifest
+
+import (
+	"slices"
+	"testing"
+)
+
+// playerClass is a manifest supporting platforms, with the methods Create and
+// Kick, each limited as given, and a class Player over them limited to
+// classPlatforms, whose binding Kick is limited to bindingPlatforms.
+func playerClass(platforms, create, kick, classPlatforms, bindingPlatforms string) string {
+	return testManifest(`"platforms": ` + platforms + `,
+		"methods": [` + testMethod("Create", "", "int32", `, "platforms": `+create) + `,
+		` + testMethod("Kick", `{"name": "p", "type": "int32"}`, "void", `, "platforms": `+kick) + `],
+		"classes": [{"name": "Player", "handleType": "int32", "invalidValue": "-1", "platforms": ` + classPlatforms + `, "constructors": ["Create"],
+			"bindings": [{"name": "Kick", "method": "Kick", "bindSelf": true, "platforms": ` + bindingPlatforms + `}]}]`)
+}
+
+func TestPlatformChecks(t *testing.T) {
+	all := &ParseOptions{AllErrors: true}
+	const none = "[]"
+
+	mustParse(t, playerClass(none, none, none, none, none), nil)
+	mustParse(t, playerClass(`["windows", "linux"]`, none, `["linux"]`, none, `["linux"]`), nil)
+
+	found := parseProblems(t, playerClass(`["windows", "linux"]`, `["windows", "linux", "macos"]`, `["linux", "bsd"]`, none, none), all,
+		"/methods/0/platforms/2", "/methods/1/platforms/1", "/classes/0/platforms", "/classes/0/bindings/0/platforms")
+	wantContains(t, "diagnostics", found.Error(),
+		`method "Create": platform "macos" is not among those the plugin supports`,
+		`class "Player": constructor method "Create" is missing from some of its platforms`,
+		`method "Kick": unknown platform "bsd" (expected one of windows, linux, macos)`,
+		`class "Player" binding "Kick": bound method "Kick" is missing from some of its platforms`)
+
+	found = parseProblems(t, playerClass(none, `["windows"]`, none, `["windows", "linux"]`, `["linux", "macos"]`), all,
+		"/classes/0/platforms", "/classes/0/bindings/0/platforms")
+	wantContains(t, "diagnostics", found.Error(),
+		`class "Player": constructor method "Create" is missing from some of its platforms`,
+		`class "Player" binding "Kick": lists a platform its class does not exist on`)
+}
+
+func TestFilterPlatform(t *testing.T) {
+	m := mustParse(t, playerClass(`["windows", "linux"]`, "[]", `["linux"]`, "[]", `["linux"]`), nil)
+	wantError(t, m.FilterPlatform("bsd"), `unknown platform "bsd"`)
+	wantError(t, m.FilterPlatform("macos"), `plugin demo does not support platform "macos"`)
+
+	if err := m.FilterPlatform("windows"); err != nil {
+		t.Fatalf("FilterPlatform: %v", err)
+	}
+	if len(m.Methods) != 1 || m.Methods[0].Name != "Create" || len(m.Classes[0].Bindings) != 0 {
+		t.Errorf("on windows the manifest has %d methods and %d bindings, want Create alone", len(m.Methods), len(m.Classes[0].Bindings))
+	}
+
+	m = mustParse(t, playerClass(`["windows", "linux"]`, "[]", `["linux"]`, `["windows", "linux"]`, `["linux"]`), nil)
+	if err := m.FilterPlatform("linux"); err != nil {
+		t.Fatalf("FilterPlatform: %v", err)
+	}
+	if len(m.Methods) != 2 || m.Methods[1].Platforms != nil || m.Classes[0].Platforms != nil || m.Classes[0].Bindings[0].Platforms != nil {
+		t.Error("what is left on linux still lists its platforms")
+	}
+
+	m = mustParse(t, playerClass(`["windows", "linux"]`, "[]", `["linux"]`, `["linux"]`, "[]"), nil)
+	if err := m.FilterPlatform("windows"); err != nil {
+		t.Fatalf("FilterPlatform: %v", err)
+	}
+	if len(m.Classes) != 0 {
+		t.Error("a linux class is kept on windows")
+	}
+}
+
+func TestOnPlatform(t *testing.T) {
+	if !OnPlatform(nil, PlatformMacOS) || !OnPlatform([]string{PlatformLinux, PlatformMacOS}, PlatformMacOS) {
+		t.Error("an item is missing from a platform it exists on")
+	}
+	if OnPlatform([]string{PlatformWindows}, PlatformLinux) {
+		t.Error("a windows item exists on linux")
+	}
+	if !slices.Equal(KnownPlatforms, []string{"windows", "linux", "macos"}) {
+		t.Errorf("the known platforms are %v", KnownPlatforms)
+	}
+}
@@ -5,45 +5,83 @@ import (
 	"sort"
 )
 
-// resolve links every by-name prototype/enum reference to its definition and
-// hoists inline definitions into the manifest's shared tables, so that after it
-// runs every Property.Enum and Property.Prototype points at a fully populated
-// definition and each distinct type appears exactly once in Enums/Prototypes.
+// resolve links every by-name prototype/enum/struct reference to its definition
+// and hoists inline definitions into the manifest's shared tables, so that after
+// it runs every Property.Enum, Property.Prototype and Property.Struct points at
+// a fully populated definition and each distinct type appears exactly once in
+// Enums/Prototypes/Structs.
 //
 // This mirrors Manifest::Resolve in plugify core; the two must accept the same
 // manifests, so the duplicate-definition and cycle rules are kept in step.
-func resolve(m *Manifest) error {
+//
+// A qualified reference, "otherplugin.Name", is linked to the definition in
+// that dependency's manifest, which deps finds. Such definitions stay out of
+// the manifest's own tables.
+//
+// Problems are reported to p. resolve returns non-nil only when p wants
+// checking to stop, so with every error wanted it walks the whole manifest.
+func resolve(m *Manifest, deps *dependencyLoader, p *problems) error {
 	t := typeTable{
 		prototypes: map[string]*Prototype{},
 		enums:      map[string]*Enum{},
+		structs:    map[string]*Struct{},
+		deps:       deps,
+		problems:   p,
 	}
 
 	// Declared definitions go in first, so that a clash between two of them is
 	// reported against the manifest's own tables rather than against whichever
 	// inline definition happened to be walked first.
 	declared := make([]*Prototype, 0, len(m.Prototypes))
-	for i := range m.Prototypes {
-		if _, err := t.registerPrototype(&m.Prototypes[i], scope("manifest", "")); err != nil {
+	for i, prototype := range m.Prototypes {
+		context := scope("prototype", i, "")
+		if prototype != nil {
+			context.name = prototype.Name
+		}
+		if _, err := t.registerPrototype(&m.Prototypes[i], context); err != nil {
 			return err
 		}
 		declared = append(declared, m.Prototypes[i])
 	}
-	for i := range m.Enums {
-		if _, err := t.registerEnum(&m.Enums[i], scope("manifest", "")); err != nil {
+	for i, enum := range m.Enums {
+		context := scope("enum", i, "")
+		if enum != nil {
+			context.name = enum.Name
+		}
+		if _, err := t.registerEnum(&m.Enums[i], context); err != nil {
 			return err
 		}
 	}
+	declaredStructs := make([]*Struct, 0, len(m.Structs))
+	for i, structure := range m.Structs {
+		context := scope("struct", i, "")
+		if structure != nil {
+			context.name = structure.Name
+		}
+		introduced, err := t.registerStruct(&m.Structs[i], context)
+		if err != nil {
+			return err
+		}
+		if introduced {
+			declaredStructs = append(declaredStructs, m.Structs[i])
+		}
+	}
 
 	// Pass one: hoist inline definitions into the tables. collect descends into
 	// each definition it introduces, so walking the methods and the declared
 	// prototypes reaches everything.
 	for i := range m.Methods {
-		if err := t.collectMethod(&m.Methods[i], scope("method", m.Methods[i].Name)); err != nil {
+		if err := t.collectMethod(&m.Methods[i], scope("method", i, m.Methods[i].Name)); err != nil {
 			return err
 		}
 	}
 	for _, prototype := range declared {
-		if err := t.collectPrototype(prototype, scope("prototype", prototype.Name)); err != nil {
+		if err := t.collectPrototype(prototype, prototype.origin); err != nil {
+			return err
+		}
+	}
+	for _, structure := range declaredStructs {
+		if err := t.collectStruct(structure, structure.origin); err != nil {
 			return err
 		}
 	}
@@ -51,12 +89,19 @@ func resolve(m *Manifest) error {
 	// Pass two: swap each by-name reference for the definition it names. Every
 	// definition is in the tables by now, so references may point forwards.
 	for i := range m.Methods {
-		if err := t.linkMethod(&m.Methods[i], scope("method", m.Methods[i].Name)); err != nil {
+		if err := t.linkMethod(&m.Methods[i], scope("method", i, m.Methods[i].Name)); err != nil {
 			return err
 		}
 	}
-	for _, prototype := range t.prototypes {
-		if err := t.linkPrototype(prototype, scope("prototype", prototype.Name)); err != nil {
+	for _, name := range sortedKeys(t.prototypes) {
+		prototype := t.prototypes[name]
+		if err := t.linkPrototype(prototype, prototype.origin); err != nil {
+			return err
+		}
+	}
+	for _, name := range sortedKeys(t.structs) {
+		structure := t.structs[name]
+		if err := t.linkStruct(structure, structure.origin); err != nil {
 			return err
 		}
 	}
@@ -64,9 +109,17 @@ func resolve(m *Manifest) error {
 	if err := t.detectCycles(); err != nil {
 		return err
 	}
+	if err := t.detectStructCycles(); err != nil {
+		return err
+	}
+
+	if err := checkClasses(m, p); err != nil {
+		return err
+	}
 
 	m.Prototypes = t.sortedPrototypes()
 	m.Enums = t.sortedEnums()
+	m.Structs = t.sortedStructs()
 	return nil
 }
 
@@ -76,43 +129,89 @@ func resolve(m *Manifest) error {
 // joined on the path that actually reports a failure.
 type where struct {
 	parent *where // the property this one sits inside, if any
-	kind   string // "method" or "prototype", when there is no parent
-	name   string // the method or prototype it belongs to
-	index  int    // parameter position, or idxReturn / idxScope
+	kind   string // "method", "prototype", "enum" or "struct", when there is no parent
+	name   string // the method, prototype or struct it belongs to
+	index  int    // position in the manifest's table when there is no parent; otherwise parameter or field position, or idxReturn
+	field  bool   // a field of the parent's struct rather than a parameter of its prototype
 }
 
-const (
-	idxScope  = -1
-	idxReturn = -2
-)
+const idxReturn = -1
 
-func scope(kind, name string) where {
-	return where{kind: kind, name: name, index: idxScope}
+func scope(kind string, index int, name string) where {
+	return where{kind: kind, name: name, index: index}
 }
 
-func (w where) param(i int) where { return where{parent: &w, index: i} }
-func (w where) returnType() where { return where{parent: &w, index: idxReturn} }
+func (w where) param(i int) where       { return where{parent: &w, index: i} }
+func (w where) returnType() where       { return where{parent: &w, index: idxReturn} }
+func (w where) structField(i int) where { return where{parent: &w, index: i, field: true} }
 
 func (w where) String() string {
 	if w.parent == nil {
 		if w.name == "" {
-			return w.kind
+			return fmt.Sprintf("%s[%d]", w.kind, w.index)
 		}
 		return fmt.Sprintf("%s %q", w.kind, w.name)
 	}
 	prefix := w.parent.String()
+	if w.field {
+		return fmt.Sprintf("%s field[%d]", prefix, w.index)
+	}
 	if w.index == idxReturn {
 		return prefix + " return type"
 	}
 	return fmt.Sprintf("%s param[%d]", prefix, w.index)
 }
 
-// typeTable gathers every prototype and enum in a manifest into one table keyed
+// pointer is the JSON pointer of the place w names, e.g.
+// /methods/3/paramTypes/0/prototype/retType for the return type of the inline
+// prototype taken by the first parameter of the fourth method, or
+// /structs/1/fields/2 for the third field of the second struct.
+func (w where) pointer() string {
+	if w.parent == nil {
+		return fmt.Sprintf("/%ss/%d", w.kind, w.index)
+	}
+	prefix := w.parent.pointer()
+	if w.parent.parent != nil {
+		// A property inside a property is a parameter of the latter's
+		// prototype, or a field of its struct.
+		if w.field {
+			prefix += "/struct"
+		} else {
+			prefix += "/prototype"
+		}
+	}
+	if w.field {
+		return fmt.Sprintf("%s/fields/%d", prefix, w.index)
+	}
+	if w.index == idxReturn {
+		return prefix + "/retType"
+	}
+	return fmt.Sprintf("%s/paramTypes/%d", prefix, w.index)
+}
+
+// member is the pointer of a field of the property w names. A top-level scope
+// is itself the definition, so it has no such field to point into.
+func (w where) member(field string) string {
+	if w.parent == nil {
+		return w.pointer()
+	}
+	return w.pointer() + "/" + field
+}
+
+// fail describes a problem with the property w names, or with one of its fields.
+func (w where) fail(field, format string, args ...any) *Diagnostic {
+	return errorAt(w.member(field), "%s: %s", w, fmt.Sprintf(format, args...))
+}
+
+// typeTable gathers every prototype, enum and struct in a manifest into one table keyed
 // by name, so a definition declared up front and the same definition written
 // inline collapse onto a single shared value.
 type typeTable struct {
 	prototypes map[string]*Prototype
 	enums      map[string]*Enum
+	structs    map[string]*Struct
+	deps       *dependencyLoader
+	problems   *problems
 }
 
 // registerPrototype reports whether this definition is the first seen under its
@@ -124,16 +223,20 @@ func (t *typeTable) registerPrototype(slot **Prototype, context where) (bool, er
 		return false, nil
 	}
 	if prototype.Name == "" {
-		return false, fmt.Errorf("%s: prototype definition must have a name", context)
+		return false, t.problems.report(context.fail("prototype", "prototype definition must have a name"))
+	}
+	if _, _, qualified := splitQualified(prototype.Name); qualified {
+		return false, t.problems.report(context.fail("prototype", "prototype %q: only a reference can name another plugin", prototype.Name))
 	}
 
 	existing, found := t.prototypes[prototype.Name]
 	if !found {
 		t.prototypes[prototype.Name] = prototype
+		prototype.origin = context
 		return true, nil
 	}
 	if existing != prototype && !samePrototype(existing, prototype) {
-		return false, fmt.Errorf("%s: conflicting definitions for prototype %q", context, prototype.Name)
+		return false, t.problems.report(context.fail("prototype", "conflicting definitions for prototype %q", prototype.Name))
 	}
 	*slot = existing
 	return false, nil
@@ -148,21 +251,50 @@ func (t *typeTable) registerEnum(slot **Enum, context where) (bool, error) {
 	// "the enum of this name, defined elsewhere". That spelling is gone, so say
 	// what to write instead rather than letting it collide with the real one.
 	if len(enum.Values) == 0 {
-		return false, fmt.Errorf(
-			"%s: enum %q has no values; write it as \"enum\": %q to refer to a definition in the manifest's 'enums' table",
-			context, enum.Name, enum.Name)
+		return false, t.problems.report(context.fail("enum",
+			"enum %q has no values; write it as \"enum\": %q to refer to a definition in the manifest's 'enums' table, or run plugify-gen migrate",
+			enum.Name, enum.Name))
 	}
 	if enum.Name == "" {
-		return false, fmt.Errorf("%s: enum definition must have a name", context)
+		return false, t.problems.report(context.fail("enum", "enum definition must have a name"))
+	}
+	if _, _, qualified := splitQualified(enum.Name); qualified {
+		return false, t.problems.report(context.fail("enum", "enum %q: only a reference can name another plugin", enum.Name))
 	}
 
 	existing, found := t.enums[enum.Name]
 	if !found {
 		t.enums[enum.Name] = enum
-		return true, nil
+		enum.origin = context
+		return true, checkEnumValues(enum, t.problems)
 	}
 	if existing != enum && !sameEnum(existing, enum) {
-		return false, fmt.Errorf("%s: conflicting definitions for enum %q", context, enum.Name)
+		return false, t.problems.report(context.fail("enum", "conflicting definitions for enum %q", enum.Name))
+	}
+	*slot = existing
+	return false, nil
+}
+
+func (t *typeTable) registerStruct(slot **Struct, context where) (bool, error) {
+	structure := *slot
+	if structure == nil || structure.ref {
+		return false, nil
+	}
+	if structure.Name == "" {
+		return false, t.problems.report(context.fail("struct", "struct definition must have a name"))
+	}
+	if _, _, qualified := splitQualified(structure.Name); qualified {
+		return false, t.problems.report(context.fail("struct", "struct %q: only a reference can name another plugin", structure.Name))
+	}
+
+	existing, found := t.structs[structure.Name]
+	if !found {
+		t.structs[structure.Name] = structure
+		structure.origin = context
+		return true, nil
+	}
+	if existing != structure && !sameStruct(existing, structure) {
+		return false, t.problems.report(context.fail("struct", "conflicting definitions for struct %q", structure.Name))
 	}
 	*slot = existing
 	return false, nil
@@ -176,11 +308,29 @@ func (t *typeTable) collectProperty(prop *Property, context where) error {
 	if _, err := t.registerEnum(&prop.Enum, context); err != nil {
 		return err
 	}
+	introducedStruct, err := t.registerStruct(&prop.Struct, context)
+	if err != nil {
+		return err
+	}
 
 	// Only descend into a definition this call introduced; one that collapsed
 	// onto an earlier definition has already been walked.
 	if introduced {
-		return t.collectPrototype(prop.Prototype, context)
+		if err := t.collectPrototype(prop.Prototype, context); err != nil {
+			return err
+		}
+	}
+	if introducedStruct {
+		return t.collectStruct(prop.Struct, context)
+	}
+	return nil
+}
+
+func (t *typeTable) collectStruct(structure *Struct, context where) error {
+	for i := range structure.Fields {
+		if err := t.collectProperty(&structure.Fields[i], context.structField(i)); err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -205,18 +355,61 @@ func (t *typeTable) collectMethod(method *Method, context where) error {
 
 func (t *typeTable) linkProperty(prop *Property, context where) error {
 	if prop.Prototype != nil && prop.Prototype.ref {
-		definition, found := t.prototypes[prop.Prototype.Name]
-		if !found {
-			return fmt.Errorf("%s: unknown prototype %q", context, prop.Prototype.Name)
+		if plugin, name, qualified := splitQualified(prop.Prototype.Name); qualified {
+			definition, err := t.deps.prototype(plugin, name)
+			if err != nil {
+				if err := t.problems.report(context.fail("prototype", "unknown prototype %q: %v", prop.Prototype.Name, err)); err != nil {
+					return err
+				}
+			} else {
+				prop.Prototype = definition
+			}
+		} else if definition, found := t.prototypes[prop.Prototype.Name]; found {
+			prop.Prototype = definition
+		} else if err := t.problems.report(context.fail("prototype", "unknown prototype %q", prop.Prototype.Name)); err != nil {
+			return err
 		}
-		prop.Prototype = definition
 	}
 	if prop.Enum != nil && prop.Enum.ref {
-		definition, found := t.enums[prop.Enum.Name]
-		if !found {
-			return fmt.Errorf("%s: unknown enum %q", context, prop.Enum.Name)
+		if plugin, name, qualified := splitQualified(prop.Enum.Name); qualified {
+			definition, err := t.deps.enum(plugin, name)
+			if err != nil {
+				if err := t.problems.report(context.fail("enum", "unknown enum %q: %v", prop.Enum.Name, err)); err != nil {
+					return err
+				}
+			} else {
+				prop.Enum = definition
+			}
+		} else if definition, found := t.enums[prop.Enum.Name]; found {
+			prop.Enum = definition
+		} else if err := t.problems.report(context.fail("enum", "unknown enum %q", prop.Enum.Name)); err != nil {
+			return err
+		}
+	}
+	if prop.Struct != nil && prop.Struct.ref {
+		if plugin, name, qualified := splitQualified(prop.Struct.Name); qualified {
+			definition, err := t.deps.structure(plugin, name)
+			if err != nil {
+				if err := t.problems.report(context.fail("struct", "unknown struct %q: %v", prop.Struct.Name, err)); err != nil {
+					return err
+				}
+			} else {
+				prop.Struct = definition
+			}
+		} else if definition, found := t.structs[prop.Struct.Name]; found {
+			prop.Struct = definition
+		} else if err := t.problems.report(context.fail("struct", "unknown struct %q", prop.Struct.Name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *typeTable) linkStruct(structure *Struct, context where) error {
+	for i := range structure.Fields {
+		if err := t.linkProperty(&structure.Fields[i], context.structField(i)); err != nil {
+			return err
 		}
-		prop.Enum = definition
 	}
 	return nil
 }
@@ -255,7 +448,7 @@ func (t *typeTable) detectCycles() error {
 		case done:
 			return nil
 		case onStack:
-			return fmt.Errorf("prototype %q is part of a reference cycle", prototype.Name)
+			return t.problems.report(errorAt(prototype.origin.member("prototype"), "prototype %q is part of a reference cycle", prototype.Name))
 		}
 		marks[prototype] = onStack
 
@@ -286,6 +479,44 @@ func (t *typeTable) detectCycles() error {
 	return nil
 }
 
+// detectStructCycles rejects a struct that holds itself, through its own fields
+// or those of a struct it holds. Fields are held by value, so such a struct
+// would have no finite size.
+func (t *typeTable) detectStructCycles() error {
+	const (
+		onStack = 1
+		done    = 2
+	)
+	marks := map[*Struct]int{}
+
+	var visit func(structure *Struct) error
+	visit = func(structure *Struct) error {
+		switch marks[structure] {
+		case done:
+			return nil
+		case onStack:
+			return t.problems.report(errorAt(structure.origin.member("struct"), "struct %q contains itself", structure.Name))
+		}
+		marks[structure] = onStack
+		for i := range structure.Fields {
+			if field := structure.Fields[i].Struct; field != nil && !field.ref {
+				if err := visit(field); err != nil {
+					return err
+				}
+			}
+		}
+		marks[structure] = done
+		return nil
+	}
+
+	for _, name := range sortedKeys(t.structs) {
+		if err := visit(t.structs[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (t *typeTable) sortedPrototypes() []*Prototype {
 	if len(t.prototypes) == 0 {
 		return nil
@@ -308,6 +539,17 @@ func (t *typeTable) sortedEnums() []*Enum {
 	return out
 }
 
+func (t *typeTable) sortedStructs() []*Struct {
+	if len(t.structs) == 0 {
+		return nil
+	}
+	out := make([]*Struct, 0, len(t.structs))
+	for _, name := range sortedKeys(t.structs) {
+		out = append(out, t.structs[name])
+	}
+	return out
+}
+
 func sortedKeys[V any](m map[string]V) []string {
 	keys := make([]string, 0, len(m))
 	for k := range m {
@@ -319,12 +561,12 @@ func sortedKeys[V any](m map[string]V) []string {
 
 // Two definitions written under the same name are only allowed if they say the
 // same thing, so that repeating an inline enum across several methods keeps
-// working. Nested prototypes and enums compare by name rather than by value:
+// working. Nested prototypes, enums and structs compare by name rather than by value:
 // within a manifest a name denotes exactly one type, which also keeps this
 // terminating for recursive types.
 
 func sameProperty(a, b *Property) bool {
-	if a.Type != b.Type || a.Ref != b.Ref {
+	if a.Type != b.Type || a.Ref != b.Ref || a.Optional != b.Optional {
 		return false
 	}
 	aProto, bProto := "", ""
@@ -341,11 +583,18 @@ func sameProperty(a, b *Property) bool {
 	if b.Enum != nil {
 		bEnum = b.Enum.Name
 	}
-	return aProto == bProto && aEnum == bEnum
+	aStruct, bStruct := "", ""
+	if a.Struct != nil {
+		aStruct = a.Struct.Name
+	}
+	if b.Struct != nil {
+		bStruct = b.Struct.Name
+	}
+	return aProto == bProto && aEnum == bEnum && aStruct == bStruct
 }
 
 func sameEnum(a, b *Enum) bool {
-	if len(a.Values) != len(b.Values) {
+	if a.Flags != b.Flags || len(a.Values) != len(b.Values) {
 		return false
 	}
 	for i := range a.Values {
@@ -370,3 +619,15 @@ func samePrototype(a, b *Prototype) bool {
 	}
 	return true
 }
+
+func sameStruct(a, b *Struct) bool {
+	if len(a.Fields) != len(b.Fields) {
+		return false
+	}
+	for i := range a.Fields {
+		if a.Fields[i].Name != b.Fields[i].Name || !sameProperty(&a.Fields[i], &b.Fields[i]) {
+			return false
+		}
+	}
+	return true
+}
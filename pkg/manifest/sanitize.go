@@ -1,12 +1,35 @@
 package manifest
 
 import (
+	"fmt"
 	"strings"
 )
 
 // SanitizeNameFunc is a function that sanitizes a name for language-specific reserved keywords
 type SanitizeNameFunc func(name string) string
 
+// CheckSanitizedNames fails if sanitizeName would give two methods the same
+// name, which a language would see as a redefinition. Generators call it
+// before Sanitize, which renames them regardless.
+func (m *Manifest) CheckSanitizedNames(sanitizeName SanitizeNameFunc) error {
+	if sanitizeName == nil {
+		return nil
+	}
+	sanitized := make(map[string]string, len(m.Methods))
+	for i := range m.Methods {
+		original := m.Methods[i].Name
+		if original == "" {
+			continue
+		}
+		name := sanitizeName(original)
+		if other, seen := sanitized[name]; seen && other != original {
+			return fmt.Errorf("methods %q and %q are both named %q in this language", other, original, name)
+		}
+		sanitized[name] = original
+	}
+	return nil
+}
+
 // Sanitize sanitizes all names in the manifest
 // It auto-generates parameter names if empty and applies language-specific sanitization
 func (m *Manifest) Sanitize(sanitizeName SanitizeNameFunc) {
@@ -19,6 +42,11 @@ func (m *Manifest) Sanitize(sanitizeName SanitizeNameFunc) {
 			method.Name = sanitizeName(method.Name)
 		}
 
+		// Sanitize the overloaded name the same way
+		if method.OverloadOf != "" && sanitizeName != nil {
+			method.OverloadOf = sanitizeName(method.OverloadOf)
+		}
+
 		// Sanitize function name
 		if method.FuncName != "" && sanitizeName != nil {
 			method.FuncName = sanitizeName(method.FuncName)
@@ -89,6 +117,23 @@ func (m *Manifest) Sanitize(sanitizeName SanitizeNameFunc) {
 			}
 		}
 	}
+
+	// Sanitize constants
+	for i := range m.Constants {
+		if m.Constants[i].Name != "" && sanitizeName != nil {
+			m.Constants[i].Name = sanitizeName(m.Constants[i].Name)
+		}
+	}
+
+	// Sanitize events, and the methods they name the way the methods were
+	if sanitizeName != nil {
+		for i := range m.Events {
+			event := &m.Events[i]
+			event.Name = sanitizeName(event.Name)
+			event.Subscribe = sanitizeName(event.Subscribe)
+			event.Unsubscribe = sanitizeName(event.Unsubscribe)
+		}
+	}
 }
 
 // sanitizeParamTypes sanitizes parameter names and types
@@ -110,6 +155,11 @@ func sanitizeParamTypes(params []ParamType, sanitizeName SanitizeNameFunc) {
 		if param.Prototype != nil {
 			sanitizePrototype(param.Prototype, sanitizeName)
 		}
+
+		// Sanitize struct if present
+		if param.Struct != nil {
+			sanitizeStruct(param.Struct, sanitizeName)
+		}
 	}
 }
 
@@ -122,6 +172,10 @@ func sanitizeTypeInfo(typeInfo *RetType, sanitizeName SanitizeNameFunc) {
 	if typeInfo.Prototype != nil {
 		sanitizePrototype(typeInfo.Prototype, sanitizeName)
 	}
+
+	if typeInfo.Struct != nil {
+		sanitizeStruct(typeInfo.Struct, sanitizeName)
+	}
 }
 
 // sanitizeEnum sanitizes enum names and values
@@ -150,3 +204,13 @@ func sanitizePrototype(proto *Prototype, sanitizeName SanitizeNameFunc) {
 	// Sanitize prototype return type
 	sanitizeTypeInfo(&proto.RetType, sanitizeName)
 }
+
+// sanitizeStruct sanitizes struct names and fields
+func sanitizeStruct(structure *Struct, sanitizeName SanitizeNameFunc) {
+	if structure.Name != "" && sanitizeName != nil {
+		structure.Name = sanitizeName(structure.Name)
+	}
+
+	// Fields are sanitized like parameters, nested structs included
+	sanitizeParamTypes(structure.Fields, sanitizeName)
+}
@@ -0,0 +1,30 @@
+package manifest
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckSanitizedNames(t *testing.T) {
+	m := mustParse(t, testManifest(`"methods": [`+testMethod("end", "", "void", "")+`, `+testMethod("end_", "", "void", "")+`, `+testMethod("Begin", "", "void", "")+`]`), nil)
+	suffix := func(name string) string {
+		if name == "end" {
+			return "end_"
+		}
+		return name
+	}
+
+	wantError(t, m.CheckSanitizedNames(suffix), `methods "end" and "end_" are both named "end_" in this language`)
+	if err := m.CheckSanitizedNames(strings.ToUpper); err != nil {
+		t.Errorf("names that stay distinct collide: %v", err)
+	}
+	if err := m.CheckSanitizedNames(nil); err != nil {
+		t.Errorf("no sanitizer collides: %v", err)
+	}
+
+	// Sanitize renames regardless of the check
+	m.Sanitize(suffix)
+	if m.Methods[0].Name != "end_" || m.Methods[0].Group != "core" {
+		t.Errorf("the sanitized method is %q in group %q", m.Methods[0].Name, m.Methods[0].Group)
+	}
+}
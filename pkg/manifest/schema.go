@@ -0,0 +1,358 @@
+package manifest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// SchemaVersion is bumped whenever the shape of a manifest changes in a way
+// that the schema has to follow.
+const SchemaVersion = 1
+
+// SchemaID identifies the schema generated from this package's types.
+var SchemaID = fmt.Sprintf(schemaIDFormat, SchemaVersion)
+
+// schemaIDFormat is SchemaID with the version left to fill in.
+const schemaIDFormat = "https://gen.plugify.net/schemas/v%d/pplugin.schema.json"
+
+// schemaRequired lists the keys a definition must have. Struct tags cannot say
+// this: many optional keys are written without omitempty, and Property is both
+// a parameter (which needs a name) and a return type (which does not).
+var schemaRequired = map[reflect.Type][]string{
+	reflect.TypeOf(Manifest{}):   {"name", "version", "language"},
+	reflect.TypeOf(Dependency{}): {"name"},
+	reflect.TypeOf(Method{}):     {"name", "funcName", "retType"},
+	reflect.TypeOf(Property{}):   {"type"},
+	reflect.TypeOf(Alias{}):      {"name"},
+	reflect.TypeOf(Enum{}):       {"name", "values"},
+	reflect.TypeOf(Value{}):      {"name", "value"},
+	reflect.TypeOf(Prototype{}):  {"name", "retType"},
+	reflect.TypeOf(Struct{}):     {"name", "fields"},
+	reflect.TypeOf(Constant{}):   {"name", "type", "value"},
+	reflect.TypeOf(Event{}):      {"name", "prototype", "subscribe", "unsubscribe"},
+	reflect.TypeOf(Class{}):      {"name", "bindings"},
+	reflect.TypeOf(Binding{}):    {"name", "method"},
+	reflect.TypeOf(Bind{}):       {"name"},
+}
+
+// byName lists the definitions that may also be written as the name of an
+// entry in the manifest's top-level tables, as their UnmarshalJSON allows.
+var byName = map[reflect.Type]bool{
+	reflect.TypeOf(Enum{}):      true,
+	reflect.TypeOf(Prototype{}): true,
+	reflect.TypeOf(Struct{}):    true,
+}
+
+// Schema returns the JSON Schema for .pplugin manifests. It is derived from the
+// types in this package, so it cannot drift from what Parse accepts.
+func Schema() map[string]any {
+	defs := map[string]any{}
+	manifestType := reflect.TypeOf(Manifest{})
+	schemaFor(manifestType, defs)
+
+	// The manifest itself is the root rather than one of the definitions.
+	schema := defs[manifestType.Name()].(map[string]any)
+	delete(defs, manifestType.Name())
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["$id"] = SchemaID
+	schema["title"] = "Plugify plugin manifest"
+	schema["$defs"] = defs
+	return schema
+}
+
+// SchemaJSON returns Schema as indented JSON.
+func SchemaJSON() ([]byte, error) {
+	return json.MarshalIndent(Schema(), "", "  ")
+}
+
+// schemaFor describes t, adding a definition to defs for each struct reached.
+func schemaFor(t reflect.Type, defs map[string]any) map[string]any {
+	if t == reflect.TypeOf(Integer{}) {
+		return map[string]any{"type": "integer"}
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		return schemaFor(t.Elem(), defs)
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int64, reflect.Int32, reflect.Uint64, reflect.Uint32:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice:
+		return map[string]any{"type": "array", "items": schemaFor(t.Elem(), defs)}
+	case reflect.Interface:
+		return map[string]any{}
+	case reflect.Struct:
+	default:
+		panic(fmt.Sprintf("manifest: no schema for %s", t))
+	}
+
+	name := t.Name()
+	ref := map[string]any{"$ref": "#/$defs/" + name}
+	if _, seen := defs[name]; !seen {
+		def := map[string]any{
+			"type":                 "object",
+			"additionalProperties": false,
+		}
+		defs[name] = def // before descending, so recursive types terminate
+
+		properties := map[string]any{}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			key, ok := jsonKey(field)
+			if !ok {
+				continue
+			}
+			property := schemaFor(field.Type, defs)
+			if field.Type.Kind() == reflect.Pointer {
+				// Unmarshal takes null for a pointer as leaving it nil.
+				property = nullable(property)
+			}
+			properties[key] = property
+		}
+		def["properties"] = properties
+		if required := schemaRequired[t]; len(required) > 0 {
+			def["required"] = required
+		}
+	}
+
+	if byName[t] {
+		return map[string]any{"anyOf": []any{map[string]any{"type": "string"}, ref}}
+	}
+	return ref
+}
+
+// nullable widens schema to allow null as well.
+func nullable(schema map[string]any) map[string]any {
+	null := map[string]any{"type": "null"}
+	switch {
+	case len(schema) == 0:
+		return schema // anything, null included
+	case schema["type"] != nil:
+		widened := maps.Clone(schema)
+		widened["type"] = []any{schema["type"], "null"}
+		return widened
+	case schema["anyOf"] != nil:
+		return map[string]any{"anyOf": append([]any{null}, schema["anyOf"].([]any)...)}
+	default:
+		return map[string]any{"anyOf": []any{null, schema}}
+	}
+}
+
+// jsonKey returns the key encoding/json uses for field, if it uses one.
+func jsonKey(field reflect.StructField) (string, bool) {
+	if !field.IsExported() {
+		return "", false
+	}
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	name, _, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = field.Name
+	}
+	return name, true
+}
+
+// validateSchema checks data against Schema, reporting every mismatch to p. It
+// catches what json.Unmarshal lets through, chiefly keys it does not know,
+// which it drops without a word. It returns non-nil only when p wants checking
+// to stop.
+func validateSchema(data []byte, p *problems) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var document any
+	if err := decoder.Decode(&document); err != nil {
+		return p.report(errorAt("", "invalid manifest JSON: %v", err))
+	}
+
+	schema := Schema()
+	v := schemaValidator{defs: schema["$defs"].(map[string]any), problems: p}
+	return v.check(document, schema, "")
+}
+
+type schemaValidator struct {
+	defs     map[string]any
+	problems *problems
+}
+
+func (v *schemaValidator) fail(pointer, format string, args ...any) error {
+	return v.problems.report(errorAt(pointer, "%s: %s", subjectOf(pointer), fmt.Sprintf(format, args...)))
+}
+
+// subjectOf names the value at pointer at the start of a message.
+func subjectOf(pointer string) string {
+	if pointer == "" {
+		return "manifest"
+	}
+	return pointer
+}
+
+// check supports the parts of JSON Schema that Schema emits and no more.
+func (v *schemaValidator) check(value any, schema map[string]any, pointer string) error {
+	if ref, ok := schema["$ref"].(string); ok {
+		return v.check(value, v.defs[strings.TrimPrefix(ref, "#/$defs/")].(map[string]any), pointer)
+	}
+
+	if anyOf, ok := schema["anyOf"].([]any); ok {
+		// Try each alternative quietly, and only complain if none fits.
+		for _, alternative := range anyOf {
+			trial := schemaValidator{defs: v.defs, problems: &problems{}}
+			if trial.check(value, alternative.(map[string]any), pointer); len(trial.problems.found) == 0 {
+				return nil
+			}
+		}
+		// The object alternative says more about what went wrong than the
+		// name alternative does, so an object is held to it.
+		_, isObject := value.(map[string]any)
+		byName := slices.ContainsFunc(anyOf, func(alternative any) bool { return alternative.(map[string]any)["type"] == "string" })
+		if isObject || !byName {
+			return v.check(value, anyOf[len(anyOf)-1].(map[string]any), pointer)
+		}
+		return v.fail(pointer, "expected a name or a definition, got %s", jsonKind(value))
+	}
+
+	want, _ := schema["type"].(string)
+	if types, ok := schema["type"].([]any); ok {
+		// Only nullable emits a list, of a type and null.
+		if value == nil {
+			return nil
+		}
+		want = types[0].(string)
+	}
+	switch want {
+	case "null":
+		if value != nil {
+			return v.fail(pointer, "expected null, got %s", jsonKind(value))
+		}
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			return v.fail(pointer, "expected an object, got %s", jsonKind(value))
+		}
+		properties, _ := schema["properties"].(map[string]any)
+		if required, ok := schema["required"].([]string); ok {
+			for _, key := range required {
+				if _, present := object[key]; !present {
+					if err := v.fail(pointer, "missing required key %q", key); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		for _, key := range sortedKeys(object) {
+			propertySchema, known := properties[key]
+			if !known {
+				// Named after the object it sits in, as its own pointer would just
+				// repeat the key.
+				if err := v.problems.report(errorAt(pointer+"/"+escapePointer(key), "%s: unknown key %q%s",
+					subjectOf(pointer), key, suggestKey(key, sortedKeys(properties)))); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := v.check(object[key], propertySchema.(map[string]any), pointer+"/"+escapePointer(key)); err != nil {
+				return err
+			}
+		}
+	case "array":
+		array, ok := value.([]any)
+		if !ok {
+			return v.fail(pointer, "expected an array, got %s", jsonKind(value))
+		}
+		items, _ := schema["items"].(map[string]any)
+		for i, item := range array {
+			if err := v.check(item, items, pointer+"/"+strconv.Itoa(i)); err != nil {
+				return err
+			}
+		}
+	case "string":
+		if _, ok := value.(string); !ok {
+			return v.fail(pointer, "expected a string, got %s", jsonKind(value))
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return v.fail(pointer, "expected a boolean, got %s", jsonKind(value))
+		}
+	case "integer":
+		if number, ok := value.(json.Number); !ok || !isInteger(number) {
+			return v.fail(pointer, "expected an integer, got %s", jsonKind(value))
+		}
+	case "number":
+		if _, ok := value.(json.Number); !ok {
+			return v.fail(pointer, "expected a number, got %s", jsonKind(value))
+		}
+	}
+	return nil
+}
+
+func isInteger(number json.Number) bool {
+	if _, err := strconv.ParseInt(string(number), 10, 64); err == nil {
+		return true
+	}
+	_, err := strconv.ParseUint(string(number), 10, 64)
+	return err == nil
+}
+
+// suggestKey points out the known key a misspelt one was probably meant to be.
+// candidates should be sorted, so that ties always go the same way.
+func suggestKey(key string, candidates []string) string {
+	best, bestDistance := "", 3 // anything further off is more likely a different key
+	for _, candidate := range candidates {
+		if d := editDistance(strings.ToLower(key), strings.ToLower(candidate)); d < bestDistance {
+			best, bestDistance = candidate, d
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (did you mean %q?)", best)
+}
+
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+func jsonKind(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "a boolean"
+	case json.Number:
+		return "a number"
+	case string:
+		return "a string"
+	case []any:
+		return "an array"
+	case map[string]any:
+		return "an object"
+	}
+	return fmt.Sprintf("%T", value)
+}
@@ -0,0 +1,69 @@
+package manifest
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func TestSchemaShape(t *testing.T) {
+	schema := Schema()
+	if schema["$id"] != SchemaID {
+		t.Errorf("$id is %v, want %s", schema["$id"], SchemaID)
+	}
+	if required := schema["required"].([]string); !slices.Equal(required, []string{"name", "version", "language"}) {
+		t.Errorf("the manifest requires %v", required)
+	}
+
+	defs := schema["$defs"].(map[string]any)
+	if _, ok := defs["Manifest"]; ok {
+		t.Error("the manifest is among the definitions as well as the root")
+	}
+	method := defs["Method"].(map[string]any)
+	if method["additionalProperties"] != false {
+		t.Error("a method may have keys the schema does not list")
+	}
+
+	// An enum may be named as well as defined, and a pointer may be null
+	enum := defs["Property"].(map[string]any)["properties"].(map[string]any)["enum"].(map[string]any)
+	alternatives := enum["anyOf"].([]any)
+	if len(alternatives) != 3 || alternatives[0].(map[string]any)["type"] != "null" || alternatives[1].(map[string]any)["type"] != "string" {
+		t.Errorf("a property's enum is %v", enum)
+	}
+
+	if _, err := SchemaJSON(); err != nil {
+		t.Errorf("SchemaJSON: %v", err)
+	}
+	if _, err := json.Marshal(schema); err != nil {
+		t.Errorf("the schema does not marshal: %v", err)
+	}
+}
+
+func TestValidateSchemaAccepts(t *testing.T) {
+	color := `{"name": "Color", "values": [{"name": "Red", "value": 0}]}`
+	mustParse(t, testManifest(`"enums": [`+color+`], "methods": [
+		`+testMethod("A", `{"name": "c", "type": "int32", "enum": "Color"}, {"name": "d", "type": "int32", "enum": `+color+`}`, "void", "")+`,
+		`+testMethod("B", `{"name": "x", "type": "double", "default": 0.5, "alias": null}`, "void", "")+`]`),
+		&ParseOptions{ValidateSchema: true})
+}
+
+func TestValidateSchemaRejects(t *testing.T) {
+	opts := &ParseOptions{ValidateSchema: true, AllErrors: true}
+
+	found := parseProblems(t, testManifest(`"methods": [], "bogus": 1`), opts, "/bogus")
+	wantContains(t, "diagnostics", found.Error(), `manifest: unknown key "bogus"`)
+
+	found = parseProblems(t, testManifest(`"methods": [`+testMethod("A", `{"name": "x", "type": "int32", "ref": false, "size": 4}`, "void", "")+`]`), opts,
+		"/methods/0/paramTypes/0/size")
+	wantContains(t, "diagnostics", found.Error(), `/methods/0/paramTypes/0: unknown key "size"`)
+
+	// A missing key is reported by the schema and by the checks after it
+	found = parseProblems(t, `{"name": "demo", "language": "cpp", "methods": [{"name": "A", "funcName": "A"}]}`, opts,
+		"", "/methods/0", "/version", "/methods/0/retType/type")
+	wantContains(t, "diagnostics", found.Error(), `manifest: missing required key "version"`, `/methods/0: missing required key "retType"`)
+
+	// encoding/json leaves a string alone when it meets null
+	found = parseProblems(t, testManifest(`"methods": [{"name": "A", "funcName": "A", "description": null, "paramTypes": [], "retType": {"type": "void"}}]`), opts,
+		"/methods/0/description")
+	wantContains(t, "diagnostics", found.Error(), "/methods/0/description: expected a string, got null")
+}
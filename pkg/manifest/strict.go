@@ -0,0 +1,108 @@
+package manifest
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// checkFields reports every key in data that json.Unmarshal would have nowhere
+// to put. Unmarshal drops such keys without a word, so a misspelt "defualt"
+// silently loses the default it was meant to carry. The walk follows the
+// decoding types themselves, so an Enum or Prototype written by name is passed
+// over just as their UnmarshalJSON passes over it. root is the type data
+// decodes into. It returns non-nil only when p wants checking to stop.
+func checkFields(data []byte, root reflect.Type, p *problems) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var document any
+	if err := decoder.Decode(&document); err != nil {
+		return p.report(errorAt("", "invalid manifest JSON: %v", err))
+	}
+
+	c := fieldChecker{problems: p, fields: map[reflect.Type]map[string]reflect.Type{}}
+	return c.check(document, root, "")
+}
+
+type fieldChecker struct {
+	problems *problems
+	fields   map[reflect.Type]map[string]reflect.Type // JSON key to field type, per struct
+}
+
+func (c *fieldChecker) check(value any, t reflect.Type, pointer string) error {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return c.check(value, t.Elem(), pointer)
+	case reflect.Slice:
+		items, _ := value.([]any)
+		for i, item := range items {
+			if err := c.check(item, t.Elem(), pointer+"/"+strconv.Itoa(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Struct:
+	default:
+		return nil
+	}
+
+	// Anything that is not an object is left for Unmarshal to complain about,
+	// or, for a by-name reference, to accept.
+	object, ok := value.(map[string]any)
+	if !ok {
+		return nil
+	}
+
+	fields := c.fieldsOf(t)
+	for _, key := range sortedKeys(object) {
+		at := pointer + "/" + escapePointer(key)
+		fieldType, known := fields[key]
+		if !known {
+			if err := c.unknown(key, at, fields); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := c.check(object[key], fieldType, at); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unknown reports a key with no field of its own. Unmarshal matches keys
+// case-insensitively, so a key differing only in case does decode, but it is
+// still reported here: strict means spelled the way the manifest format does.
+func (c *fieldChecker) unknown(key, at string, fields map[string]reflect.Type) error {
+	subject := subjectOf(strings.TrimSuffix(at, "/"+escapePointer(key)))
+	for candidate := range fields {
+		if strings.EqualFold(candidate, key) {
+			return c.problems.report(errorAt(at, "%s: key %q should be spelled %q", subject, key, candidate))
+		}
+	}
+
+	candidates := make([]string, 0, len(fields))
+	for candidate := range fields {
+		candidates = append(candidates, candidate)
+	}
+	sort.Strings(candidates)
+	return c.problems.report(errorAt(at, "%s: unknown key %q%s", subject, key, suggestKey(key, candidates)))
+}
+
+func (c *fieldChecker) fieldsOf(t reflect.Type) map[string]reflect.Type {
+	if fields, ok := c.fields[t]; ok {
+		return fields
+	}
+	fields := map[string]reflect.Type{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if key, ok := jsonKey(field); ok {
+			fields[key] = field.Type
+		}
+	}
+	c.fields[t] = fields
+	return fields
+}
@@ -0,0 +1,44 @@
+package manifest
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStrictSuggestsKeys(t *testing.T) {
+	strict := &ParseOptions{Strict: true}
+
+	found := parseProblems(t, testManifest(`"methods": [`+testMethod("A", `{"name": "x", "type": "int32", "defualt": 1}`, "void", "")+`]`), strict,
+		"/methods/0/paramTypes/0/defualt")
+	wantContains(t, "diagnostics", found.Error(), `/methods/0/paramTypes/0: unknown key "defualt" (did you mean "default"?)`)
+
+	// Unmarshal would take this key, but it is not how the format spells it
+	found = parseProblems(t, testManifest(`"methods": [{"name": "A", "FuncName": "A", "paramTypes": [], "retType": {"type": "void"}}]`), strict,
+		"/methods/0/FuncName")
+	wantContains(t, "diagnostics", found.Error(), `/methods/0: key "FuncName" should be spelled "funcName"`)
+
+	found = parseProblems(t, testManifest(`"methods": [], "enums": [{"name": "Color", "values": [{"name": "Red", "value": 0, "colour": "red"}]}]`), strict,
+		"/enums/0/values/0/colour")
+	if strings.Contains(found.Error(), "did you mean") {
+		t.Errorf("a key unlike any other gets a suggestion: %v", found)
+	}
+}
+
+func TestStrictAllErrors(t *testing.T) {
+	parseProblems(t, testManifest(`"methods": [`+testMethod("A", `{"name": "x", "type": "int32", "defualt": 1}`, "void", `, "descripton": "d"`)+`], "bogus": 1`),
+		&ParseOptions{Strict: true, AllErrors: true},
+		"/bogus", "/methods/0/descripton", "/methods/0/paramTypes/0/defualt")
+}
+
+func TestStrictPassesOverNames(t *testing.T) {
+	data := testManifest(`"enums": [{"name": "Color", "values": [{"name": "Red", "value": 0}]}],
+		"methods": [` + testMethod("A", `{"name": "c", "type": "int32", "enum": "Color"}`, "void", "") + `]`)
+	mustParse(t, data, &ParseOptions{Strict: true})
+}
+
+func TestUnknownKeysWithoutStrict(t *testing.T) {
+	m := mustParse(t, testManifest(`"methods": [`+testMethod("A", `{"name": "x", "type": "int32", "defualt": 1}`, "void", "")+`]`), nil)
+	if m.Methods[0].ParamTypes[0].Default != nil {
+		t.Error("a misspelt default is taken")
+	}
+}
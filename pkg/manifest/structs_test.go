@@ -0,0 +1,68 @@
+package manifest
+
+import "testing"
+
+// withStructs is a manifest defining structs, with a method taking the struct
+// named first by value.
+func withStructs(first, structs string) string {
+	return testManifest(`"structs": [` + structs + `],
+		"methods": [` + testMethod("A", `{"name": "s", "type": "struct", "struct": "`+first+`"}`, "void", "") + `]`)
+}
+
+func TestStructCycles(t *testing.T) {
+	found := parseProblems(t, withStructs("Node", `{"name": "Node", "fields": [{"name": "next", "type": "struct", "struct": "Node"}]}`), nil,
+		"/structs/0")
+	wantContains(t, "diagnostics", found.Error(), `struct "Node" contains itself`)
+
+	parseProblems(t, withStructs("A", `
+		{"name": "A", "fields": [{"name": "b", "type": "struct", "struct": "B"}]},
+		{"name": "B", "fields": [{"name": "c", "type": "struct", "struct": "C"}]},
+		{"name": "C", "fields": [{"name": "a", "type": "struct", "struct": "A"}]}`), nil,
+		"/structs/0")
+
+	// Holding one struct twice, directly and through another, is no cycle
+	m := mustParse(t, withStructs("Line", `
+		{"name": "Point", "fields": [{"name": "x", "type": "float"}, {"name": "y", "type": "float"}]},
+		{"name": "Segment", "fields": [{"name": "from", "type": "struct", "struct": "Point"}, {"name": "to", "type": "struct", "struct": "Point"}]},
+		{"name": "Line", "fields": [{"name": "start", "type": "struct", "struct": "Point"}, {"name": "segment", "type": "struct", "struct": "Segment"}]}`), nil)
+	if line := m.Methods[0].ParamTypes[0].Struct; line == nil || line.Fields[1].Struct.Fields[0].Struct != line.Fields[0].Struct {
+		t.Error("Point is not shared between Line and Segment")
+	}
+}
+
+func TestStructFields(t *testing.T) {
+	all := &ParseOptions{AllErrors: true}
+
+	found := parseProblems(t, withStructs("S", `{"name": "S", "fields": [
+		{"name": "a", "type": "string"},
+		{"name": "a", "type": "int32", "ref": true},
+		{"type": "int32", "default": 1},
+		{"name": "d", "type": "int32", "optional": true},
+		{"name": "e", "type": "int32[]"}]}`), all,
+		"/structs/0/fields/0/type",
+		"/structs/0/fields/1/name",
+		"/structs/0/fields/1/ref",
+		"/structs/0/fields/2/name",
+		"/structs/0/fields/2/default",
+		"/structs/0/fields/3/optional",
+		"/structs/0/fields/4/type")
+	wantContains(t, "diagnostics", found.Error(),
+		"a struct field cannot be string",
+		`field name "a" is already used by field[0]`,
+		"a struct field cannot be ref",
+		"a struct field must have a name",
+		"a struct field cannot have a default",
+		"a struct field cannot be optional",
+		"a struct field cannot be int32[]")
+
+	found = parseProblems(t, withStructs("S", `{"name": "S", "fields": []}`), all, "/structs/0")
+	wantContains(t, "diagnostics", found.Error(), `struct "S" has no fields`)
+}
+
+func TestStructUses(t *testing.T) {
+	found := parseProblems(t, testManifest(`"structs": [{"name": "S", "fields": [{"name": "x", "type": "int32"}]}],
+		"methods": [`+testMethod("A", `{"name": "s", "type": "struct"}, {"name": "n", "type": "int32", "struct": "S"}`, "void", "")+`]`),
+		&ParseOptions{AllErrors: true},
+		"/methods/0/paramTypes/0/type", "/methods/0/paramTypes/1/struct")
+	wantContains(t, "diagnostics", found.Error(), "type struct needs a struct", `struct "S" is attached to int32; its type must be struct`)
+}
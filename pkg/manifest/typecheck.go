@@ -0,0 +1,282 @@
+package manifest
+
+import "fmt"
+
+// valueTypes are the type names plugify core understands, each mapped to
+// whether it may also be the element type of an array ("int32[]").
+var valueTypes = map[string]bool{
+	"void":     false,
+	"bool":     true,
+	"char8":    true,
+	"char16":   true,
+	"int8":     true,
+	"int16":    true,
+	"int32":    true,
+	"int64":    true,
+	"uint8":    true,
+	"uint16":   true,
+	"uint32":   true,
+	"uint64":   true,
+	"ptr64":    true,
+	"float":    true,
+	"double":   true,
+	"function": false,
+	"string":   true,
+	"any":      true,
+	"vec2":     true,
+	"vec3":     true,
+	"vec4":     true,
+	"mat4x4":   true,
+	"struct":   false,
+}
+
+// fieldTypes are the types a struct field may have: those with a fixed size and
+// no ownership of their own, which every language lays out the same way.
+var fieldTypes = map[string]struct{}{
+	"bool": {}, "char8": {}, "char16": {},
+	"int8": {}, "int16": {}, "int32": {}, "int64": {},
+	"uint8": {}, "uint16": {}, "uint32": {}, "uint64": {},
+	"ptr64": {}, "float": {}, "double": {},
+	"vec2": {}, "vec3": {}, "vec4": {}, "mat4x4": {},
+	"struct": {},
+}
+
+// optionalTypes are the types that may be optional. Plugify has no notion of
+// an absent value, so none crosses as the type's empty value and each of these
+// has one that is not otherwise meaningful: zero, an empty string, a null
+// function.
+var optionalTypes = map[string]struct{}{
+	"int8": {}, "int16": {}, "int32": {}, "int64": {},
+	"uint8": {}, "uint16": {}, "uint32": {}, "uint64": {},
+	"ptr64": {}, "string": {}, "function": {},
+}
+
+// integerTypes are the types an enum may be attached to.
+var integerTypes = map[string]struct{}{
+	"int8": {}, "int16": {}, "int32": {}, "int64": {},
+	"uint8": {}, "uint16": {}, "uint32": {}, "uint64": {},
+}
+
+// mapKeyTypes are the types a map may be keyed by: those compared by value.
+var mapKeyTypes = map[string]struct{}{
+	"char8": {}, "char16": {},
+	"int8": {}, "int16": {}, "int32": {}, "int64": {},
+	"uint8": {}, "uint16": {}, "uint32": {}, "uint64": {},
+	"string": {},
+}
+
+// IsValueType reports whether name is a type plugify core understands, either
+// on its own or as an array, fixed-size array or map.
+func IsValueType(name string) bool {
+	return checkTypeName(name) == nil
+}
+
+// checkTypeName explains why name is not a type plugify core understands.
+func checkTypeName(name string) error {
+	node, err := ParseType(name)
+	if err != nil {
+		return fmt.Errorf("malformed type %q: %v", name, err)
+	}
+	value := node.Value().Name
+	if _, known := valueTypes[value]; !known {
+		return fmt.Errorf("unknown type %q", name)
+	}
+	switch node.Kind {
+	case KindArray:
+		if !valueTypes[value] {
+			return fmt.Errorf("unknown type %q", name)
+		}
+	case KindFixedArray:
+		// Stored inline, so the elements need a fixed size of their own
+		if _, ok := fieldTypes[value]; !ok || value == "struct" {
+			return fmt.Errorf("%s cannot be the element of a fixed-size array; it must be a number, a character, bool, ptr64, a vector or a matrix", value)
+		}
+	case KindMap:
+		if _, ok := mapKeyTypes[node.Key.Name]; !ok {
+			if _, known := valueTypes[node.Key.Name]; !known {
+				return fmt.Errorf("unknown type %q", name)
+			}
+			return fmt.Errorf("%s cannot be a map key; it must be an integer, a character or string", node.Key.Name)
+		}
+		if !valueTypes[value] {
+			return fmt.Errorf("%s cannot be a map value", value)
+		}
+	}
+	return nil
+}
+
+// checkTypes checks the type of every parameter and return value in the
+// manifest. Each generator passes a type name it does not recognise through
+// unchanged, assuming it to be some custom type, so without this a typo like
+// "strng" turned into target code that does not compile. It returns non-nil
+// only when p wants checking to stop.
+func checkTypes(m *Manifest, p *problems) error {
+	uses := map[enumUse]bool{}
+	for i := range m.Methods {
+		method := &m.Methods[i]
+		if err := checkSignature(method.ParamTypes, &method.RetType, scope("method", i, method.Name), uses, p); err != nil {
+			return err
+		}
+	}
+	// resolve gathered every prototype and struct here, inline ones included.
+	for _, prototype := range m.Prototypes {
+		if err := checkNotOptional(prototype.ParamTypes, &prototype.RetType, prototype.origin, p); err != nil {
+			return err
+		}
+		if err := checkSignature(prototype.ParamTypes, &prototype.RetType, prototype.origin, uses, p); err != nil {
+			return err
+		}
+	}
+	for _, structure := range m.Structs {
+		if err := checkStruct(structure, uses, p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkStruct checks the fields of a struct. A field holds its value in place,
+// so only types of a fixed size with nothing to own can be one.
+func checkStruct(structure *Struct, uses map[enumUse]bool, p *problems) error {
+	if len(structure.Fields) == 0 {
+		// C and C++ disagree on the size of an empty struct.
+		return p.report(errorAt(structure.origin.member("struct"), "struct %q has no fields", structure.Name))
+	}
+	names := make(map[string]int, len(structure.Fields))
+	for i := range structure.Fields {
+		field := &structure.Fields[i]
+		context := structure.origin.structField(i)
+		if field.Name == "" {
+			if err := p.report(context.fail("name", "a struct field must have a name")); err != nil {
+				return err
+			}
+		} else if first, seen := names[field.Name]; seen {
+			if err := p.report(context.fail("name", "field name %q is already used by field[%d]", field.Name, first)); err != nil {
+				return err
+			}
+		} else {
+			names[field.Name] = i
+		}
+
+		if _, ok := fieldTypes[field.Type]; !ok && IsValueType(field.Type) {
+			if err := p.report(context.fail("type", "a struct field cannot be %s; it must be a number, a character, bool, ptr64, a vector, a matrix or another struct", field.Type)); err != nil {
+				return err
+			}
+		}
+		if field.Ref {
+			if err := p.report(context.fail("ref", "a struct field cannot be ref")); err != nil {
+				return err
+			}
+		}
+		if field.Default != nil {
+			if err := p.report(context.fail("default", "a struct field cannot have a default")); err != nil {
+				return err
+			}
+		}
+		if field.Optional {
+			if err := p.report(context.fail("optional", "a struct field cannot be optional")); err != nil {
+				return err
+			}
+		}
+		if err := checkType(field, context, false, uses, p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkNotOptional rejects optional in a prototype. Generators wrap an optional
+// value where a method is called, but a prototype's signature is the function
+// pointer type itself, which has to stay as plugify passes it.
+func checkNotOptional(params []ParamType, ret *RetType, context where, p *problems) error {
+	for i := range params {
+		if params[i].Optional {
+			if err := p.report(context.param(i).fail("optional", "a prototype parameter cannot be optional")); err != nil {
+				return err
+			}
+		}
+	}
+	if ret.Optional {
+		return p.report(context.returnType().fail("optional", "a prototype return type cannot be optional"))
+	}
+	return nil
+}
+
+func checkSignature(params []ParamType, ret *RetType, context where, uses map[enumUse]bool, p *problems) error {
+	for i := range params {
+		if err := checkType(&params[i], context.param(i), false, uses, p); err != nil {
+			return err
+		}
+	}
+	if err := checkType(ret, context.returnType(), true, uses, p); err != nil {
+		return err
+	}
+	return checkDefaults(params, context, p)
+}
+
+// checkType checks one property. uses records the enum and type pairs checked
+// so far, so that each is only checked once.
+func checkType(prop *Property, context where, isReturn bool, uses map[enumUse]bool, p *problems) error {
+	if prop.Type == "" {
+		return nil // validate reports this for methods; there is nothing to check
+	}
+	if err := checkTypeName(prop.Type); err != nil {
+		return p.report(context.fail("type", "%v", err))
+	}
+
+	base := prop.BaseType()
+	switch {
+	case prop.Type == "function" && prop.Prototype == nil:
+		if err := p.report(context.fail("type", "type function needs a prototype")); err != nil {
+			return err
+		}
+	case prop.Type == "struct" && prop.Struct == nil:
+		if err := p.report(context.fail("type", "type struct needs a struct")); err != nil {
+			return err
+		}
+	case prop.Type != "struct" && prop.Struct != nil:
+		if err := p.report(context.fail("struct", "struct %q is attached to %s; its type must be struct", prop.Struct.Name, prop.Type)); err != nil {
+			return err
+		}
+	case prop.Type == "void" && !isReturn:
+		if err := p.report(context.fail("type", "a parameter cannot be void")); err != nil {
+			return err
+		}
+	case prop.Type == "void" && prop.Ref:
+		if err := p.report(context.fail("ref", "a void return type cannot be ref")); err != nil {
+			return err
+		}
+	}
+
+	if prop.Optional {
+		if _, ok := optionalTypes[prop.Type]; !ok {
+			if err := p.report(context.fail("optional", "%s cannot be optional; only integers, ptr64, string and function have an empty value to stand for none", prop.Type)); err != nil {
+				return err
+			}
+		}
+		if prop.Ref {
+			if err := p.report(context.fail("optional", "a ref parameter cannot be optional")); err != nil {
+				return err
+			}
+		}
+		if prop.Default != nil {
+			if err := p.report(context.fail("optional", "an optional parameter cannot have a default")); err != nil {
+				return err
+			}
+		}
+	}
+
+	if prop.Enum != nil {
+		if _, ok := integerTypes[base]; !ok {
+			if err := p.report(context.fail("enum", "enum %q is attached to %s, which is not an integer type", prop.Enum.Name, prop.Type)); err != nil {
+				return err
+			}
+		} else if use := (enumUse{prop.Enum, base}); !uses[use] {
+			uses[use] = true
+			if err := checkEnumRange(prop.Enum, base, context, p); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package manifest
+
+import "testing"
+
+func TestIsValueType(t *testing.T) {
+	for _, name := range []string{"void", "int32", "uint64", "string", "any", "mat4x4", "function", "bool[]", "string[]", "vec3[]"} {
+		if !IsValueType(name) {
+			t.Errorf("%s is not a value type", name)
+		}
+	}
+	for _, name := range []string{"", "strng", "int", "Int32", "void[]", "function[]", "int32[][]", "[]int32"} {
+		if IsValueType(name) {
+			t.Errorf("%s is a value type", name)
+		}
+	}
+}
+
+func TestCheckTypeNames(t *testing.T) {
+	all := &ParseOptions{AllErrors: true}
+
+	found := parseProblems(t, testManifest(`"methods": [`+testMethod("A", `{"name": "x", "type": "strng"}, {"name": "y", "type": "int32[]"}`, "voi", "")+`]`), all,
+		"/methods/0/paramTypes/0/type", "/methods/0/retType/type")
+	wantContains(t, "diagnostics", found.Error(), `unknown type "strng"`, `unknown type "voi"`)
+
+	// Inline prototypes are checked where they are written
+	found = parseProblems(t, testManifest(`"methods": [`+testMethod("A", `{"name": "cb", "type": "function", "prototype": {"name": "OnTick", "funcName": "OnTick", "paramTypes": [{"name": "x", "type": "flaot"}], "retType": {"type": "void"}}}`, "void", "")+`]`), all,
+		"/methods/0/paramTypes/0/prototype/paramTypes/0/type")
+	wantContains(t, "diagnostics", found.Error(), `unknown type "flaot"`)
+}
+
+func TestCheckTypeUses(t *testing.T) {
+	all := &ParseOptions{AllErrors: true}
+
+	found := parseProblems(t, testManifest(`"methods": [`+testMethod("A", `{"name": "x", "type": "void"}, {"name": "cb", "type": "function"}`, "void", "")+`]`), all,
+		"/methods/0/paramTypes/0/type", "/methods/0/paramTypes/1/type")
+	wantContains(t, "diagnostics", found.Error(), "a parameter cannot be void", "type function needs a prototype")
+
+	found = parseProblems(t, testManifest(`"methods": [{"name": "A", "funcName": "A", "paramTypes": [], "retType": {"type": "void", "ref": true}}]`), all,
+		"/methods/0/retType/ref")
+	wantContains(t, "diagnostics", found.Error(), "a void return type cannot be ref")
+
+	found = parseProblems(t, testManifest(`"enums": [{"name": "Color", "values": [{"name": "Red", "value": 0}]}],
+		"methods": [`+testMethod("A", `{"name": "c", "type": "string", "enum": "Color"}`, "void", "")+`]`), all,
+		"/methods/0/paramTypes/0/enum")
+	wantContains(t, "diagnostics", found.Error(), `enum "Color" is attached to string, which is not an integer type`)
+}
@@ -0,0 +1,209 @@
+package manifest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// TypeKind says what a TypeNode stands for.
+type TypeKind int
+
+const (
+	KindPrimitive  TypeKind = iota // a value type plugify core knows, named by Name
+	KindArray                      // a vector of Elem
+	KindFixedArray                 // Len values of Elem, stored inline
+	KindMap                        // Elem values looked up by Key
+	KindEnum                       // Enum, carried as the integer type Name
+	KindAlias                      // Alias, a name declared for Elem
+	KindPrototype                  // a function with Prototype's signature
+	KindStruct                     // Struct, passed by value
+	KindClass                      // the class a binding wraps the handle Elem in
+)
+
+// TypeNode is a type as generators see it: the type name taken apart, with
+// the enum, alias, prototype or struct attached to it put in place. Parse
+// builds one for every parameter, return value and struct field, so
+// generators never take a type name apart themselves.
+type TypeNode struct {
+	Kind      TypeKind
+	Name      string    // the value type of a primitive or an enum
+	Elem      *TypeNode // the element of an array, the value of a map, what an alias or class stands for
+	Key       *TypeNode // the key of a map
+	Len       int       // the length of a fixed-size array
+	Enum      *Enum
+	Alias     *Alias
+	Prototype *Prototype
+	Struct    *Struct
+	Class     *Bind
+}
+
+// NewTypeNode builds the type node of prop. An enum attached to a container
+// applies to its elements, or to its values for a map.
+func NewTypeNode(prop *Property) *TypeNode {
+	node, err := ParseType(prop.Type)
+	if err != nil {
+		// checkType reports it; until then the whole name stands for a value
+		node = &TypeNode{Kind: KindPrimitive, Name: prop.Type}
+	}
+
+	value := node.Value()
+	switch {
+	case prop.Enum != nil:
+		value.Kind, value.Enum = KindEnum, prop.Enum
+	case prop.Prototype != nil:
+		value.Kind, value.Prototype = KindPrototype, prop.Prototype
+	case prop.Struct != nil:
+		value.Kind, value.Struct = KindStruct, prop.Struct
+	}
+
+	if prop.Alias != nil {
+		node = &TypeNode{Kind: KindAlias, Alias: prop.Alias, Elem: node}
+	}
+	return node
+}
+
+// ParseType takes a type name such as "int32", "int32[]", "float[3]" or
+// "map<string,any>" apart into a node of primitives. Plugify does not nest
+// containers, so every value in it is named by a single word. It checks only
+// the syntax; whether the value types are known and fit where they are used
+// is up to checkType.
+func ParseType(name string) (*TypeNode, error) {
+	if inner, ok := strings.CutPrefix(name, "map<"); ok {
+		inner, ok = strings.CutSuffix(inner, ">")
+		key, value, found := strings.Cut(inner, ",")
+		if !ok || !found {
+			return nil, fmt.Errorf("a map type is written map<key,value>")
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if !isTypeWord(key) || !isTypeWord(value) {
+			return nil, fmt.Errorf("the key and value of a map must each be a single type")
+		}
+		return &TypeNode{Kind: KindMap, Key: primitive(key), Elem: primitive(value)}, nil
+	}
+
+	if open := strings.IndexByte(name, '['); open >= 0 {
+		elem, size := name[:open], name[open+1:]
+		size, ok := strings.CutSuffix(size, "]")
+		if !ok || !isTypeWord(elem) {
+			return nil, fmt.Errorf("an array type is written type[] or type[length]")
+		}
+		if size == "" {
+			return &TypeNode{Kind: KindArray, Elem: primitive(elem)}, nil
+		}
+		length, err := strconv.Atoi(size)
+		if err != nil || length < 1 {
+			return nil, fmt.Errorf("the length of a fixed-size array must be a positive integer, not %q", size)
+		}
+		return &TypeNode{Kind: KindFixedArray, Elem: primitive(elem), Len: length}, nil
+	}
+
+	if !isTypeWord(name) {
+		return nil, fmt.Errorf("%q is not a type name", name)
+	}
+	return primitive(name), nil
+}
+
+// primitive is the node of the value type name.
+func primitive(name string) *TypeNode {
+	return &TypeNode{Kind: KindPrimitive, Name: name}
+}
+
+// isTypeWord reports whether s could name a value type.
+func isTypeWord(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// String writes n back out as the type name ParseType reads. Enums, aliases,
+// prototypes, structs and classes go by the value type they are carried as.
+func (n *TypeNode) String() string {
+	switch n.Kind {
+	case KindArray:
+		return n.Elem.String() + "[]"
+	case KindFixedArray:
+		return fmt.Sprintf("%s[%d]", n.Elem, n.Len)
+	case KindMap:
+		return fmt.Sprintf("map<%s,%s>", n.Key, n.Elem)
+	case KindAlias, KindClass:
+		return n.Elem.String()
+	}
+	return n.Name
+}
+
+// BindTypeNode is the type of a binding parameter or return value that bind
+// wraps in a class, handle being the type the method itself uses.
+func BindTypeNode(bind *Bind, handle *TypeNode) *TypeNode {
+	return &TypeNode{Kind: KindClass, Class: bind, Elem: handle}
+}
+
+// Value returns the node for a single value of n: the element of an array,
+// the value of a map, or n itself. Aliases and classes are looked through.
+func (n *TypeNode) Value() *TypeNode {
+	switch n.Kind {
+	case KindArray, KindFixedArray, KindMap, KindAlias, KindClass:
+		return n.Elem.Value()
+	}
+	return n
+}
+
+// Unaliased returns n with any alias looked through.
+func (n *TypeNode) Unaliased() *TypeNode {
+	if n.Kind == KindAlias {
+		return n.Elem.Unaliased()
+	}
+	return n
+}
+
+// IsContainer reports whether n is, or is an alias of, an array, a
+// fixed-size array or a map.
+func (n *TypeNode) IsContainer() bool {
+	switch n.Unaliased().Kind {
+	case KindArray, KindFixedArray, KindMap:
+		return true
+	}
+	return false
+}
+
+// IsArray reports whether n is, or is an alias of, an array of any length.
+func (n *TypeNode) IsArray() bool {
+	return n.Unaliased().Kind == KindArray
+}
+
+// TypeNode returns the type node Parse built for t, or builds one for a
+// property that did not come from Parse.
+func (t *Property) TypeNode() *TypeNode {
+	if t.node != nil {
+		return t.node
+	}
+	return NewTypeNode(t)
+}
+
+// buildTypeNodes gives every parameter, return value and struct field of m
+// its type node, once the references in them are resolved and checked.
+func buildTypeNodes(m *Manifest) {
+	signature := func(params []ParamType, ret *RetType) {
+		for i := range params {
+			params[i].node = NewTypeNode(&params[i])
+		}
+		ret.node = NewTypeNode(ret)
+	}
+	for i := range m.Methods {
+		signature(m.Methods[i].ParamTypes, &m.Methods[i].RetType)
+	}
+	for _, prototype := range m.Prototypes {
+		signature(prototype.ParamTypes, &prototype.RetType)
+	}
+	for _, structure := range m.Structs {
+		for i := range structure.Fields {
+			structure.Fields[i].node = NewTypeNode(&structure.Fields[i])
+		}
+	}
+}
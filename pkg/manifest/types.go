@@ -1,6 +1,26 @@
 package manifest
 
-import "encoding/json"
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+)
+
+// decode unmarshals data the way Parse does throughout: numbers are kept as
+// json.Number, so a parameter default too large for a float64 arrives intact.
+func decode(data []byte, v any) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+	// Unmarshal rejects anything after the value, so this does as well, and
+	// leaves describing what it found there to Unmarshal.
+	if _, err := decoder.Token(); err != io.EOF {
+		return json.Unmarshal(data, new(json.RawMessage))
+	}
+	return nil
+}
 
 // Manifest represents a .pplugin file structure
 type Manifest struct {
@@ -15,10 +35,19 @@ type Manifest struct {
 	Platforms    []string     `json:"platforms"`
 	Language     string       `json:"language"`
 	Dependencies []Dependency `json:"dependencies"`
+	Imports      []string     `json:"imports,omitempty"` // files to merge definitions from, relative to this one
 	Methods      []Method     `json:"methods"`
 	Classes      []Class      `json:"classes,omitempty"`
 	Prototypes   []*Prototype `json:"prototypes,omitempty"`
 	Enums        []*Enum      `json:"enums,omitempty"`
+	Structs      []*Struct    `json:"structs,omitempty"`
+	Constants    []Constant   `json:"constants,omitempty"`
+	Events       []Event      `json:"events,omitempty"`
+	NoLint       []string     `json:"nolint,omitempty"` // lint rules not to report anywhere in the manifest
+
+	// Warnings are the problems Parse found that do not stop the manifest
+	// from being used.
+	Warnings Diagnostics `json:"-"`
 }
 
 // Dependency represents a plugin dependency
@@ -36,6 +65,20 @@ type Method struct {
 	FuncName    string      `json:"funcName"`
 	ParamTypes  []ParamType `json:"paramTypes"`
 	RetType     RetType     `json:"retType"`
+	OverloadOf  string      `json:"overloadOf,omitempty"` // the method whose name this shares where a language has overloading
+	Platforms   []string    `json:"platforms,omitempty"`  // where the method exists; empty for everywhere
+	Since       string      `json:"since,omitempty"`      // plugin version that introduced the method
+	RemovedIn   string      `json:"removedIn,omitempty"`  // plugin version that removed the method
+	NoLint      []string    `json:"nolint,omitempty"`     // lint rules not to report on the method or its parameters
+}
+
+// OverloadName is the name a language with overloading declares the method
+// under: that of the method it overloads, or its own.
+func (m *Method) OverloadName() string {
+	if m.OverloadOf != "" {
+		return m.OverloadOf
+	}
+	return m.Name
 }
 
 // Property represents a parameter/return type
@@ -43,11 +86,20 @@ type Property struct {
 	Name        string     `json:"name"`
 	Type        string     `json:"type"`
 	Ref         bool       `json:"ref,omitempty"`
+	Optional    bool       `json:"optional,omitempty"` // the empty value means none
 	Description string     `json:"description,omitempty"`
 	Default     *any       `json:"default,omitempty"`
 	Alias       *Alias     `json:"alias,omitempty"`
 	Enum        *Enum      `json:"enum,omitempty"`
 	Prototype   *Prototype `json:"prototype,omitempty"`
+	Struct      *Struct    `json:"struct,omitempty"`
+	NoLint      []string   `json:"nolint,omitempty"` // lint rules not to report here
+
+	// DefaultValue is Default checked against Type and converted to match it.
+	// Parse sets it whenever Default is set.
+	DefaultValue *DefaultValue `json:"-"`
+
+	node *TypeNode // see TypeNode
 }
 
 // ParamType represents a function parameter
@@ -61,19 +113,33 @@ type Alias struct {
 	Name        string `json:"name"`
 	Description string `json:"description,omitempty"`
 	Deprecated  string `json:"deprecated,omitempty"`
+	Since       string `json:"since,omitempty"`
+	RemovedIn   string `json:"removedIn,omitempty"`
 }
 
 // Enum represents an enum definition
 type Enum struct {
-	Name        string  `json:"name"`
-	Description string  `json:"description,omitempty"`
-	Deprecated  string  `json:"deprecated,omitempty"`
-	Values      []Value `json:"values"`
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Deprecated  string   `json:"deprecated,omitempty"`
+	Since       string   `json:"since,omitempty"`
+	RemovedIn   string   `json:"removedIn,omitempty"`
+	Flags       bool     `json:"flags,omitempty"` // values are bits, meant to be combined
+	Values      []Value  `json:"values"`
+	NoLint      []string `json:"nolint,omitempty"` // lint rules not to report on the enum
+
+	// Plugin names the dependency that defines this, for a qualified reference
+	// such as "otherplugin.Name"; it is empty for the manifest's own
+	// definitions. Generators refer to the dependency's definition rather than
+	// emitting their own.
+	Plugin string `json:"-"`
 
 	// ref records that the manifest wrote this as a bare name rather than a
 	// definition. resolve fills in the rest and clears it, so generators never
 	// see it set.
 	ref bool
+	// origin is where resolve found the definition, for diagnostics.
+	origin where
 }
 
 // UnmarshalJSON accepts either a full definition or the name of an entry in the
@@ -90,7 +156,7 @@ func (e *Enum) UnmarshalJSON(data []byte) error {
 
 	type plain Enum // shed the method set so this does not recurse
 	var decoded plain
-	if err := json.Unmarshal(data, &decoded); err != nil {
+	if err := decode(data, &decoded); err != nil {
 		return err
 	}
 	*e = Enum(decoded)
@@ -99,23 +165,35 @@ func (e *Enum) UnmarshalJSON(data []byte) error {
 
 // Value represents a single enum value
 type Value struct {
-	Name        string `json:"name"`
-	Value       int    `json:"value"`
-	Description string `json:"description,omitempty"`
+	Name        string  `json:"name"`
+	Value       Integer `json:"value"`
+	Description string  `json:"description,omitempty"`
 }
 
 // Prototype represents a function pointer/delegate type
 type Prototype struct {
 	Name        string      `json:"name"`
+	FuncName    string      `json:"funcName,omitempty"` // plugify core shares its method type with prototypes; generators ignore this
 	Description string      `json:"description,omitempty"`
 	Deprecated  string      `json:"deprecated,omitempty"`
+	Since       string      `json:"since,omitempty"`
+	RemovedIn   string      `json:"removedIn,omitempty"`
 	ParamTypes  []ParamType `json:"paramTypes"`
 	RetType     RetType     `json:"retType"`
+	NoLint      []string    `json:"nolint,omitempty"` // lint rules not to report on the prototype or its parameters
+
+	// Plugin names the dependency that defines this, for a qualified reference
+	// such as "otherplugin.Name"; it is empty for the manifest's own
+	// definitions. Generators refer to the dependency's definition rather than
+	// emitting their own.
+	Plugin string `json:"-"`
 
 	// ref records that the manifest wrote this as a bare name rather than a
 	// definition. resolve fills in the rest and clears it, so generators never
 	// see it set.
 	ref bool
+	// origin is where resolve found the definition, for diagnostics.
+	origin where
 }
 
 // UnmarshalJSON accepts either a full definition or the name of an entry in the
@@ -132,13 +210,99 @@ func (p *Prototype) UnmarshalJSON(data []byte) error {
 
 	type plain Prototype // shed the method set so this does not recurse
 	var decoded plain
-	if err := json.Unmarshal(data, &decoded); err != nil {
+	if err := decode(data, &decoded); err != nil {
 		return err
 	}
 	*p = Prototype(decoded)
 	return nil
 }
 
+// Struct represents a plain value type, passed by value or by reference as
+// the struct type. Generators lay its fields out in order, as a C compiler
+// would, so every language agrees on its size and offsets.
+type Struct struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Deprecated  string   `json:"deprecated,omitempty"`
+	Since       string   `json:"since,omitempty"`
+	RemovedIn   string   `json:"removedIn,omitempty"`
+	Fields      []Field  `json:"fields"`
+	NoLint      []string `json:"nolint,omitempty"` // lint rules not to report on the struct or its fields
+
+	// Plugin names the dependency that defines this, for a qualified reference
+	// such as "otherplugin.Name"; it is empty for the manifest's own
+	// definitions. Generators refer to the dependency's definition rather than
+	// emitting their own.
+	Plugin string `json:"-"`
+
+	// ref records that the manifest wrote this as a bare name rather than a
+	// definition. resolve fills in the rest and clears it, so generators never
+	// see it set.
+	ref bool
+	// origin is where resolve found the definition, for diagnostics.
+	origin where
+}
+
+// Field represents a struct field
+type Field = Property
+
+// Constant is a named value the plugin exposes, such as a limit or an invalid
+// index, so that consumers do not have to hardcode it.
+type Constant struct {
+	Name        string   `json:"name"`
+	Type        string   `json:"type"`
+	Value       any      `json:"value"`
+	Description string   `json:"description,omitempty"`
+	Deprecated  string   `json:"deprecated,omitempty"`
+	Since       string   `json:"since,omitempty"`
+	RemovedIn   string   `json:"removedIn,omitempty"`
+	NoLint      []string `json:"nolint,omitempty"` // lint rules not to report on the constant
+
+	// Literal is Value checked against Type and converted to match it. Parse
+	// sets it for every constant.
+	Literal *DefaultValue `json:"-"`
+}
+
+// UnmarshalJSON accepts either a full definition or the name of an entry in the
+// manifest's top-level "structs" table.
+func (s *Struct) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		var name string
+		if err := json.Unmarshal(data, &name); err != nil {
+			return err
+		}
+		*s = Struct{Name: name, ref: true}
+		return nil
+	}
+
+	type plain Struct // shed the method set so this does not recurse
+	var decoded plain
+	if err := decode(data, &decoded); err != nil {
+		return err
+	}
+	*s = Struct(decoded)
+	return nil
+}
+
+// Event is something the plugin raises, such as a client connecting. Listeners
+// are subscribed and unsubscribed through a pair of the plugin's methods, each
+// taking the listener as its only parameter.
+type Event struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Deprecated  string   `json:"deprecated,omitempty"`
+	Prototype   string   `json:"prototype"`   // the prototype every listener has
+	Subscribe   string   `json:"subscribe"`   // the method that adds a listener
+	Unsubscribe string   `json:"unsubscribe"` // the method that removes it again
+	Since       string   `json:"since,omitempty"`
+	RemovedIn   string   `json:"removedIn,omitempty"`
+	NoLint      []string `json:"nolint,omitempty"` // lint rules not to report on the event
+
+	// Listener is the prototype Prototype names. Parse sets it for every
+	// event, as the one its methods take.
+	Listener *Prototype `json:"-"`
+}
+
 // Class represents an RAII wrapper class for handle-based APIs
 type Class struct {
 	Name         string    `json:"name"`
@@ -152,6 +316,10 @@ type Class struct {
 	Constructors []string  `json:"constructors,omitempty"`
 	Destructor   *string   `json:"destructor,omitempty"`
 	Bindings     []Binding `json:"bindings"`
+	Platforms    []string  `json:"platforms,omitempty"` // where the class exists; empty for everywhere
+	Since        string    `json:"since,omitempty"`     // plugin version that introduced the class
+	RemovedIn    string    `json:"removedIn,omitempty"` // plugin version that removed the class
+	NoLint       []string  `json:"nolint,omitempty"`    // lint rules not to report on the class or its bindings
 }
 
 // Binding represents a method in a wrapper class
@@ -162,6 +330,10 @@ type Binding struct {
 	Deprecated   string        `json:"deprecated,omitempty"`
 	ParamAliases []*ParamAlias `json:"paramAliases,omitempty"`
 	RetAlias     *RetAlias     `json:"retAlias,omitempty"`
+	Platforms    []string      `json:"platforms,omitempty"` // where the binding exists; empty for wherever its class does
+	Since        string        `json:"since,omitempty"`     // plugin version that introduced the binding; empty for its class's
+	RemovedIn    string        `json:"removedIn,omitempty"` // plugin version that removed the binding; empty for its class's
+	NoLint       []string      `json:"nolint,omitempty"`    // lint rules not to report on the binding
 }
 
 // Bind represents a value that should be treated as a class type
@@ -178,15 +350,13 @@ type ParamAlias = Bind
 // RetAlias represents a return value that should be treated as a class type
 type RetAlias = Bind
 
-// IsArray returns true if the type is an array (ends with [])
+// IsArray returns true if the type is an array of any length (ends with [])
 func (t *Property) IsArray() bool {
-	return len(t.Type) > 2 && t.Type[len(t.Type)-2:] == "[]"
+	return t.TypeNode().IsArray()
 }
 
-// BaseType returns the type without array suffix
+// BaseType returns the value type: the element type of an array, the value
+// type of a map, or the type itself
 func (t *Property) BaseType() string {
-	if t.IsArray() {
-		return t.Type[:len(t.Type)-2]
-	}
-	return t.Type
+	return t.TypeNode().Value().Name
 }
@@ -0,0 +1,375 @@
+package manifest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a plugin version such as 1.2.0, compared number by number. A
+// missing trailing number counts as zero, so 1.2 and 1.2.0 are the same.
+type Version []int
+
+// ParseVersion parses a version of dot-separated numbers, optionally written
+// with a leading "v".
+func ParseVersion(s string) (Version, error) {
+	parts := strings.Split(strings.TrimPrefix(s, "v"), ".")
+	version := make(Version, len(parts))
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 || strings.HasPrefix(part, "+") {
+			return nil, fmt.Errorf("%q is not a version such as 1.2.0", s)
+		}
+		version[i] = n
+	}
+	return version, nil
+}
+
+// Compare returns -1, 0 or +1 as v is older than, the same as or newer than w.
+func (v Version) Compare(w Version) int {
+	for i := 0; i < max(len(v), len(w)); i++ {
+		a, b := 0, 0
+		if i < len(v) {
+			a = v[i]
+		}
+		if i < len(w) {
+			b = w[i]
+		}
+		switch {
+		case a < b:
+			return -1
+		case a > b:
+			return +1
+		}
+	}
+	return 0
+}
+
+func (v Version) String() string {
+	parts := make([]string, len(v))
+	for i, n := range v {
+		parts[i] = strconv.Itoa(n)
+	}
+	return strings.Join(parts, ".")
+}
+
+// lifetime is the range of plugin versions an item exists in: from since up
+// to, but not including, removedIn. A nil bound leaves that end open.
+type lifetime struct {
+	since, removedIn Version
+}
+
+// lifetimeOf parses an item's since and removedIn. A bound that does not
+// parse is left open; checkVersions reports it.
+func lifetimeOf(since, removedIn string) lifetime {
+	var l lifetime
+	if since != "" {
+		l.since, _ = ParseVersion(since)
+	}
+	if removedIn != "" {
+		l.removedIn, _ = ParseVersion(removedIn)
+	}
+	return l
+}
+
+// has reports whether an item with this lifetime exists in version.
+func (l lifetime) has(version Version) bool {
+	return (l.since == nil || l.since.Compare(version) <= 0) &&
+		(l.removedIn == nil || version.Compare(l.removedIn) < 0)
+}
+
+// within reports whether every version l covers, outer covers too.
+func (l lifetime) within(outer lifetime) bool {
+	if outer.since != nil && (l.since == nil || l.since.Compare(outer.since) < 0) {
+		return false
+	}
+	if outer.removedIn != nil && (l.removedIn == nil || l.removedIn.Compare(outer.removedIn) > 0) {
+		return false
+	}
+	return true
+}
+
+// Available reports whether an item introduced in since and removed in
+// removedIn exists in version. Either may be empty, leaving that end open.
+func Available(since, removedIn string, version Version) bool {
+	return lifetimeOf(since, removedIn).has(version)
+}
+
+// FilterVersion drops every item that does not exist in the plugin version
+// target, either because it came later or because it was removed by then, so
+// that bindings can be generated for an older API level.
+func (m *Manifest) FilterVersion(target string) error {
+	version, err := ParseVersion(target)
+	if err != nil {
+		return fmt.Errorf("invalid target version: %w", err)
+	}
+
+	methods := m.Methods[:0]
+	for _, method := range m.Methods {
+		if Available(method.Since, method.RemovedIn, version) {
+			methods = append(methods, method)
+		}
+	}
+	m.Methods = methods
+
+	classes := m.Classes[:0]
+	for _, class := range m.Classes {
+		if !Available(class.Since, class.RemovedIn, version) {
+			continue
+		}
+		bindings := class.Bindings[:0]
+		for _, binding := range class.Bindings {
+			if Available(binding.Since, binding.RemovedIn, version) {
+				bindings = append(bindings, binding)
+			}
+		}
+		class.Bindings = bindings
+		classes = append(classes, class)
+	}
+	m.Classes = classes
+
+	constants := m.Constants[:0]
+	for _, constant := range m.Constants {
+		if Available(constant.Since, constant.RemovedIn, version) {
+			constants = append(constants, constant)
+		}
+	}
+	m.Constants = constants
+
+	events := m.Events[:0]
+	for _, event := range m.Events {
+		if Available(event.Since, event.RemovedIn, version) {
+			events = append(events, event)
+		}
+	}
+	m.Events = events
+
+	// checkVersions made sure nothing that is left uses one of these.
+	enums := m.Enums[:0]
+	for _, enum := range m.Enums {
+		if Available(enum.Since, enum.RemovedIn, version) {
+			enums = append(enums, enum)
+		}
+	}
+	m.Enums = enums
+	prototypes := m.Prototypes[:0]
+	for _, prototype := range m.Prototypes {
+		if Available(prototype.Since, prototype.RemovedIn, version) {
+			prototypes = append(prototypes, prototype)
+		}
+	}
+	m.Prototypes = prototypes
+	structs := m.Structs[:0]
+	for _, structure := range m.Structs {
+		if Available(structure.Since, structure.RemovedIn, version) {
+			structs = append(structs, structure)
+		}
+	}
+	m.Structs = structs
+	return nil
+}
+
+// checkVersions checks that since and removedIn are versions, that an item is
+// removed only after it came, and not after the plugin's own version. An item
+// may not outlive what it uses: a method's enums, prototypes, structs and
+// aliases, a class's constructors and destructor, a binding's class and
+// method, and an event's methods must exist in every version it does. It
+// returns non-nil only when p wants checking to stop.
+func checkVersions(m *Manifest, p *problems) error {
+	// A plugin whose own version is not one of these is not held to it.
+	current, _ := ParseVersion(m.Version)
+
+	// checkItem checks one item's own since and removedIn.
+	checkItem := func(since, removedIn, at, context string) error {
+		var parsed lifetime
+		if since != "" {
+			version, err := ParseVersion(since)
+			if err != nil {
+				if err := p.report(errorAt(at+"/since", "%s: since %v", context, err)); err != nil {
+					return err
+				}
+			} else if current != nil && version.Compare(current) > 0 {
+				if err := p.report(errorAt(at+"/since",
+					"%s: since %s is later than the plugin's version %s", context, since, m.Version)); err != nil {
+					return err
+				}
+			}
+			parsed.since = version
+		}
+		if removedIn != "" {
+			version, err := ParseVersion(removedIn)
+			if err != nil {
+				if err := p.report(errorAt(at+"/removedIn", "%s: removedIn %v", context, err)); err != nil {
+					return err
+				}
+			} else if parsed.since != nil && version.Compare(parsed.since) <= 0 {
+				if err := p.report(errorAt(at+"/removedIn",
+					"%s: removedIn %s is not later than since %s", context, removedIn, since)); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	// checkProperty reports the types a property uses that are missing from
+	// some version of the item it belongs to.
+	checkProperty := func(prop *Property, user lifetime, context where) error {
+		if prop.Enum != nil && prop.Enum.Plugin == "" && !user.within(lifetimeOf(prop.Enum.Since, prop.Enum.RemovedIn)) {
+			if err := p.report(context.fail("enum", "enum %q is missing from some versions this exists in", prop.Enum.Name)); err != nil {
+				return err
+			}
+		}
+		if prop.Prototype != nil && prop.Prototype.Plugin == "" && !user.within(lifetimeOf(prop.Prototype.Since, prop.Prototype.RemovedIn)) {
+			if err := p.report(context.fail("prototype", "prototype %q is missing from some versions this exists in", prop.Prototype.Name)); err != nil {
+				return err
+			}
+		}
+		if prop.Struct != nil && prop.Struct.Plugin == "" && !user.within(lifetimeOf(prop.Struct.Since, prop.Struct.RemovedIn)) {
+			if err := p.report(context.fail("struct", "struct %q is missing from some versions this exists in", prop.Struct.Name)); err != nil {
+				return err
+			}
+		}
+		if prop.Alias != nil {
+			// An alias is written out at each property that has one.
+			if err := checkItem(prop.Alias.Since, prop.Alias.RemovedIn, context.member("alias"), context.String()+" alias"); err != nil {
+				return err
+			}
+		}
+		if prop.Alias != nil && !user.within(lifetimeOf(prop.Alias.Since, prop.Alias.RemovedIn)) {
+			if err := p.report(context.fail("alias", "alias %q is missing from some versions this exists in", prop.Alias.Name)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	checkSignature := func(params []ParamType, ret *RetType, user lifetime, context where) error {
+		for j := range params {
+			if err := checkProperty(&params[j], user, context.param(j)); err != nil {
+				return err
+			}
+		}
+		return checkProperty(ret, user, context.returnType())
+	}
+
+	methods := make(map[string]*Method, 2*len(m.Methods))
+	for i := range m.Methods {
+		method := &m.Methods[i]
+		context := scope("method", i, method.Name)
+		if err := checkItem(method.Since, method.RemovedIn, fmt.Sprintf("/methods/%d", i), context.String()); err != nil {
+			return err
+		}
+		if err := checkSignature(method.ParamTypes, &method.RetType, lifetimeOf(method.Since, method.RemovedIn), context); err != nil {
+			return err
+		}
+		// Match FindMethod in the generators, which accepts either name.
+		methods[method.FuncName] = method
+		methods[method.Name] = method
+	}
+
+	// resolve gathered every enum, prototype and struct here, inline ones
+	// included.
+	for _, enum := range m.Enums {
+		if err := checkItem(enum.Since, enum.RemovedIn, enum.origin.member("enum"), fmt.Sprintf("enum %q", enum.Name)); err != nil {
+			return err
+		}
+	}
+	for _, prototype := range m.Prototypes {
+		if err := checkItem(prototype.Since, prototype.RemovedIn, prototype.origin.member("prototype"), fmt.Sprintf("prototype %q", prototype.Name)); err != nil {
+			return err
+		}
+		if err := checkSignature(prototype.ParamTypes, &prototype.RetType, lifetimeOf(prototype.Since, prototype.RemovedIn), prototype.origin); err != nil {
+			return err
+		}
+	}
+	for _, structure := range m.Structs {
+		if err := checkItem(structure.Since, structure.RemovedIn, structure.origin.member("struct"), fmt.Sprintf("struct %q", structure.Name)); err != nil {
+			return err
+		}
+		user := lifetimeOf(structure.Since, structure.RemovedIn)
+		for j := range structure.Fields {
+			if err := checkProperty(&structure.Fields[j], user, structure.origin.structField(j)); err != nil {
+				return err
+			}
+		}
+	}
+
+	for i := range m.Constants {
+		constant := &m.Constants[i]
+		if err := checkItem(constant.Since, constant.RemovedIn, fmt.Sprintf("/constants/%d", i), scope("constant", i, constant.Name).String()); err != nil {
+			return err
+		}
+	}
+
+	// checkCalls reports a method that is missing from some version of the
+	// item calling it.
+	checkCalls := func(name string, user lifetime, at, context, role string) error {
+		method, found := methods[name]
+		if !found || user.within(lifetimeOf(method.Since, method.RemovedIn)) {
+			return nil // checkClasses reports a method that is missing altogether
+		}
+		return p.report(errorAt(at, "%s: %s method %q is missing from some versions this exists in", context, role, name))
+	}
+
+	for i := range m.Classes {
+		class := &m.Classes[i]
+		at := fmt.Sprintf("/classes/%d", i)
+		context := fmt.Sprintf("class %q", class.Name)
+		if err := checkItem(class.Since, class.RemovedIn, at, context); err != nil {
+			return err
+		}
+		classLifetime := lifetimeOf(class.Since, class.RemovedIn)
+		for _, name := range class.Constructors {
+			if err := checkCalls(name, classLifetime, at, context, "constructor"); err != nil {
+				return err
+			}
+		}
+		if class.Destructor != nil {
+			if err := checkCalls(*class.Destructor, classLifetime, at, context, "destructor"); err != nil {
+				return err
+			}
+		}
+
+		for j := range class.Bindings {
+			binding := &class.Bindings[j]
+			bindingAt := fmt.Sprintf("%s/bindings/%d", at, j)
+			bindingContext := fmt.Sprintf("%s binding %q", context, binding.Name)
+			if err := checkItem(binding.Since, binding.RemovedIn, bindingAt, bindingContext); err != nil {
+				return err
+			}
+			// A bound left open takes its class's.
+			user := lifetimeOf(binding.Since, binding.RemovedIn)
+			if user.since == nil {
+				user.since = classLifetime.since
+			}
+			if user.removedIn == nil {
+				user.removedIn = classLifetime.removedIn
+			}
+			if !user.within(classLifetime) {
+				if err := p.report(errorAt(bindingAt, "%s: exists in versions its class does not", bindingContext)); err != nil {
+					return err
+				}
+			}
+			if err := checkCalls(binding.Method, user, bindingAt, bindingContext, "bound"); err != nil {
+				return err
+			}
+		}
+	}
+
+	for i := range m.Events {
+		event := &m.Events[i]
+		at := fmt.Sprintf("/events/%d", i)
+		context := scope("event", i, event.Name).String()
+		if err := checkItem(event.Since, event.RemovedIn, at, context); err != nil {
+			return err
+		}
+		user := lifetimeOf(event.Since, event.RemovedIn)
+		if err := checkCalls(event.Subscribe, user, at, context, "subscribe"); err != nil {
+			return err
+		}
+		if err := checkCalls(event.Unsubscribe, user, at, context, "unsubscribe"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
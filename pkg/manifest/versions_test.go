@@ -0,0 +1,118 @@
+package manifest
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestParseVersion(t *testing.T) {
+	for _, text := range []string{"1", "1.2", "v1.2.0", "0.10.3"} {
+		if _, err := ParseVersion(text); err != nil {
+			t.Errorf("ParseVersion(%q): %v", text, err)
+		}
+	}
+	for _, text := range []string{"", "1.", "1.x", "-1.0", "+1.0", "1.2-beta"} {
+		if version, err := ParseVersion(text); err == nil {
+			t.Errorf("ParseVersion(%q) = %v", text, version)
+		}
+	}
+
+	compare := func(a, b string) int {
+		v, _ := ParseVersion(a)
+		w, _ := ParseVersion(b)
+		return v.Compare(w)
+	}
+	if compare("1.2", "1.2.0") != 0 || compare("1.9", "1.10") != -1 || compare("2", "1.99.99") != +1 {
+		t.Error("versions do not compare number by number")
+	}
+}
+
+// versioned is a manifest at version 2.0 with an enum Color and a method Kick
+// using it, each existing in the versions given by its since and removedIn.
+func versioned(color, kick string) string {
+	return `{"name": "demo", "version": "2.0", "language": "cpp", "entry": "demo",
+		"enums": [{"name": "Color", "values": [{"name": "Red", "value": 0}]` + color + `}],
+		"methods": [` + testMethod("Kick", `{"name": "c", "type": "int32", "enum": "Color"}`, "void", kick) + `]}`
+}
+
+func TestVersionChecks(t *testing.T) {
+	all := &ParseOptions{AllErrors: true}
+
+	mustParse(t, versioned(`, "since": "1.0"`, `, "since": "1.2", "removedIn": "2.0"`), nil)
+
+	found := parseProblems(t, versioned(`, "since": "3.0"`, `, "since": "1.x", "removedIn": "0.9"`), all,
+		"/methods/0/since", "/methods/0/paramTypes/0/enum", "/enums/0/since")
+	wantContains(t, "diagnostics", found.Error(),
+		`method "Kick": since "1.x" is not a version such as 1.2.0`,
+		`enum "Color": since 3.0 is later than the plugin's version 2.0`)
+
+	found = parseProblems(t, versioned("", `, "since": "1.5", "removedIn": "1.5"`), all, "/methods/0/removedIn")
+	wantContains(t, "diagnostics", found.Error(), "removedIn 1.5 is not later than since 1.5")
+
+	// Kick may not outlive the enum it uses, at either end
+	found = parseProblems(t, versioned(`, "since": "1.0", "removedIn": "1.8"`, `, "since": "0.5"`), all,
+		"/methods/0/paramTypes/0/enum")
+	wantContains(t, "diagnostics", found.Error(), `enum "Color" is missing from some versions this exists in`)
+}
+
+func TestClassVersionChecks(t *testing.T) {
+	class := func(class, binding string) string {
+		return testManifest(`"methods": [
+			` + testMethod("Create", "", "int32", `, "since": "0.5"`) + `,
+			` + testMethod("Kick", `{"name": "p", "type": "int32"}`, "void", `, "removedIn": "0.9"`) + `],
+			"classes": [{"name": "Player", "handleType": "int32", "invalidValue": "-1", "constructors": ["Create"]` + class + `,
+				"bindings": [{"name": "Kick", "method": "Kick", "bindSelf": true` + binding + `}]}]`)
+	}
+	all := &ParseOptions{AllErrors: true}
+
+	mustParse(t, class(`, "since": "0.5"`, `, "removedIn": "0.9"`), nil)
+
+	found := parseProblems(t, class("", ""), all, "/classes/0", "/classes/0/bindings/0")
+	wantContains(t, "diagnostics", found.Error(),
+		`class "Player": constructor method "Create" is missing from some versions this exists in`,
+		`class "Player" binding "Kick": bound method "Kick" is missing from some versions this exists in`)
+
+	found = parseProblems(t, class(`, "since": "0.5", "removedIn": "0.9"`, `, "since": "0.6", "removedIn": "1.0"`), all,
+		"/classes/0/bindings/0", "/classes/0/bindings/0")
+	wantContains(t, "diagnostics", found.Error(), `class "Player" binding "Kick": exists in versions its class does not`)
+}
+
+func TestFilterVersion(t *testing.T) {
+	data := `{"name": "demo", "version": "2.0", "language": "cpp", "entry": "demo",
+		"enums": [{"name": "Old", "values": [{"name": "A", "value": 0}], "removedIn": "1.5"}],
+		"methods": [
+			` + testMethod("Legacy", `{"name": "o", "type": "int32", "enum": "Old"}`, "void", `, "removedIn": "1.5"`) + `,
+			` + testMethod("Kick", "", "void", "") + `,
+			` + testMethod("Ban", "", "void", `, "since": "1.5"`) + `],
+		"constants": [{"name": "Max", "type": "int32", "value": 1, "since": "2.0"}]}`
+
+	names := func(m *Manifest) string {
+		var names []string
+		for _, method := range m.Methods {
+			names = append(names, method.Name)
+		}
+		for _, enum := range m.Enums {
+			names = append(names, enum.Name)
+		}
+		for _, constant := range m.Constants {
+			names = append(names, constant.Name)
+		}
+		return fmt.Sprint(names)
+	}
+
+	for target, want := range map[string]string{
+		"1.0":  "[Legacy Kick Old]",
+		"1.5":  "[Kick Ban]",
+		"v2.0": "[Kick Ban Max]",
+	} {
+		m := mustParse(t, data, nil)
+		if err := m.FilterVersion(target); err != nil {
+			t.Fatalf("FilterVersion(%q): %v", target, err)
+		}
+		if got := names(m); got != want {
+			t.Errorf("FilterVersion(%q) leaves %s, want %s", target, got, want)
+		}
+	}
+
+	wantError(t, mustParse(t, data, nil).FilterVersion("latest"), "invalid target version")
+}
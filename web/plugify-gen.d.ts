@@ -8,6 +8,29 @@
  * 2. Types will be automatically picked up by TypeScript
  */
 
+/**
+ * A problem found in a manifest, located by source position and JSON pointer
+ */
+export interface Diagnostic {
+  /** Manifest file name (empty when the manifest was passed as a string) */
+  file: string
+
+  /** 1-based line of the offending value (0 when unknown) */
+  line: number
+
+  /** 1-based column of the offending value (0 when unknown) */
+  column: number
+
+  /** JSON pointer to the offending value, e.g. `/methods/3/paramTypes/0/enum` */
+  pointer: string
+
+  /** How serious the problem is */
+  severity: 'error' | 'warning'
+
+  /** Human-readable description */
+  message: string
+}
+
 /**
  * Result from converting a manifest to language bindings
  */
@@ -20,6 +43,35 @@ export interface ConvertResult {
 
   /** Error message (only present when success is false) */
   error?: string
+
+  /** Structured manifest diagnostics: the errors when the manifest failed to parse, otherwise any warnings */
+  diagnostics?: Diagnostic[]
+}
+
+/**
+ * Options for converting a manifest
+ */
+export interface ConvertOptions {
+  /** Generate class wrappers (default: true) */
+  generateClasses?: boolean
+
+  /** Generate call scopes (default: true) */
+  generateScopes?: boolean
+
+  /** Report every manifest error instead of stopping at the first (default: true) */
+  allErrors?: boolean
+
+  /** Validate the manifest against the JSON Schema, rejecting unknown keys (default: false) */
+  validateSchema?: boolean
+
+  /** Reject manifest keys that are unknown or spelled with the wrong case (default: false) */
+  strict?: boolean
+
+  /** Other files the manifest may read, by path: files it imports, and the manifests of its dependencies */
+  files?: Record<string, string>
+
+  /** Directories within files to look for dependency manifests in (default: none) */
+  searchPath?: string[]
 }
 
 /**
@@ -42,6 +94,7 @@ declare global {
      *
      * @param manifestContent - The content of the .pplugin manifest file
      * @param language - Target language (cpp, cxx, v8, python, lua, dotnet, golang, dlang, rust)
+     * @param options - Optional generation and parsing options
      * @returns Conversion result with generated files or error message
      *
      * @example
@@ -56,7 +109,7 @@ declare global {
      * }
      * ```
      */
-    convertManifest(manifestContent: string, language: SupportedLanguage): ConvertResult
+    convertManifest(manifestContent: string, language: SupportedLanguage, options?: ConvertOptions): ConvertResult
 
     /**
      * Get list of supported target languages
@@ -83,6 +136,13 @@ declare global {
      * ```
      */
     getVersion(): string
+
+    /**
+     * Get the JSON Schema for .pplugin manifests
+     *
+     * @returns The schema as a JSON string
+     */
+    getSchema(): string
   }
 }
 